@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gymondo_dz/pkg/api"
+	"gymondo_dz/pkg/cache"
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/database"
+	"gymondo_dz/pkg/logging"
+	"gymondo_dz/pkg/repositories"
+
+	"gorm.io/gorm"
+)
+
+// commonFlags are accepted by every command.
+type commonFlags struct {
+	config  string
+	sqlite  string
+	output  string
+	verbose bool
+}
+
+func (f *commonFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.config, "config", "", "service config file")
+	fs.StringVar(&f.sqlite, "sqlite", "", "SQLite database file")
+	fs.StringVar(&f.output, "o", "table", "output format: table or json")
+	fs.BoolVar(&f.verbose, "v", false, "verbose logging")
+}
+
+// app holds what commands work with.
+type app struct {
+	db            *gorm.DB
+	products      repositories.ProductRepository
+	subscriptions repositories.SubscriptionRepository
+	out           io.Writer
+	json          bool
+
+	// productCache is the cache shared with the service, if it uses the
+	// redis backend; product changes invalidate it as the service's do.
+	productCache *repositories.CachedProductRepository
+	closeCache   func() error
+}
+
+// open connects to the configured database. A SQLite file given with
+// --sqlite is a local database and is migrated on the spot; the service's
+// database must already be at the schema version this build expects, so
+// operators never migrate production by accident (use `gymondo migrate`
+// for that).
+func open(ctx context.Context, flags *commonFlags, out io.Writer) (*app, error) {
+	if flags.output != "table" && flags.output != "json" {
+		return nil, fmt.Errorf("unknown output format %q, use table or json", flags.output)
+	}
+
+	var args []string
+	if flags.config != "" {
+		args = []string{"--config", flags.config}
+	}
+	cfg, err := config.Load(args)
+	if err != nil {
+		return nil, err
+	}
+
+	logCfg := cfg.Log
+	logCfg.Format = "text"
+	if !flags.verbose {
+		logCfg.Level = "warn"
+	} else {
+		logCfg.Level = "debug"
+	}
+	logger := logging.New(logCfg, os.Stderr)
+	slog.SetDefault(logger)
+	gormLogger := logging.NewGormLogger(logger, cfg.Log.SlowQueryThreshold)
+
+	if flags.sqlite != "" {
+		cfg.Database.Driver = "sqlite"
+		cfg.Database.SQLite.Path = flags.sqlite
+	}
+	db, err := database.Open(cfg.Database, gormLogger)
+	if err != nil {
+		return nil, err
+	}
+	if flags.sqlite != "" {
+		err = database.Migrate(ctx, db)
+	} else {
+		err = database.CheckSchemaVersion(ctx, db)
+	}
+	if err != nil {
+		_ = database.Close(db)
+		return nil, fmt.Errorf("database schema: %w", err)
+	}
+
+	a := &app{
+		db:            db,
+		products:      repositories.NewProductRepository(db),
+		subscriptions: repositories.NewSubscriptionRepository(db),
+		out:           out,
+		json:          flags.output == "json",
+	}
+	if cfg.Cache.Enabled && cfg.Cache.Backend == "redis" && flags.sqlite == "" {
+		store, closeStore := cache.NewStore(cfg.Cache)
+		a.productCache = repositories.NewCachedProductRepository(a.products, store, cfg.Cache.TTL)
+		a.products, a.closeCache = a.productCache, closeStore
+	}
+	return a, nil
+}
+
+func (a *app) close() {
+	if a.closeCache != nil {
+		_ = a.closeCache()
+	}
+	_ = database.Close(a.db)
+}
+
+// invalidateProducts drops the service's shared product cache after
+// products were changed around the repository, as seeding does.
+func (a *app) invalidateProducts(ctx context.Context) {
+	if a.productCache == nil {
+		return
+	}
+	if err := a.productCache.Invalidate(ctx); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate the product cache; changes show once cache.ttl has passed", "error", err)
+	}
+}
+
+// print writes v as JSON, in the API's response envelope, or as a table
+// of header and rows.
+func (a *app) print(v any, meta *api.Meta, header []string, rows [][]string) error {
+	if a.json {
+		enc := json.NewEncoder(a.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(api.SuccessResponse(v, meta))
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	if meta != nil && meta.Total != nil {
+		fmt.Fprintf(w, "\npage %d, %d per page, %d in total\n", meta.Page, meta.Limit, *meta.Total)
+	}
+	if meta != nil && meta.NextCursor != "" {
+		fmt.Fprintf(w, "\nnext page: --cursor %s\n", meta.NextCursor)
+	}
+	return w.Flush()
+}
+
+// printFields writes v as JSON, or as one "name: value" line per field.
+func (a *app) printFields(v any, fields [][2]string) error {
+	if a.json {
+		return a.print(v, nil, nil, nil)
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	for _, f := range fields {
+		fmt.Fprintf(w, "%s:\t%s\n", f[0], f[1])
+	}
+	return w.Flush()
+}
+
+// printMessage writes a short confirmation, or v as JSON.
+func (a *app) printMessage(v any, format string, args ...any) error {
+	if a.json {
+		return a.print(v, nil, nil, nil)
+	}
+	_, err := fmt.Fprintf(a.out, format+"\n", args...)
+	return err
+}
+
+func meta(p repositories.Pagination) *api.Meta {
+	return &api.Meta{
+		Total:      p.Total,
+		Page:       p.Page,
+		Limit:      p.Limit,
+		NextCursor: p.NextCursor,
+		PrevCursor: p.PrevCursor,
+	}
+}
+
+// pageFlags registers the pagination flags shared by the list commands.
+func pageFlags(fs *flag.FlagSet, page *repositories.PageRequest) {
+	page.Page, page.Limit = 1, 20
+	fs.IntVar(&page.Page, "page", 1, "page number")
+	fs.IntVar(&page.Limit, "limit", 20, "results per page")
+	fs.StringVar(&page.Cursor, "cursor", "", "continue after this cursor instead of paging")
+}
@@ -0,0 +1,163 @@
+// Command gymctl is the operator CLI of the subscription service. It works
+// on the service's database directly, through the same repositories the
+// API uses, so every state change follows the same rules.
+//
+//	gymctl subscriptions list --status active -o json
+//	gymctl subscriptions pause 465dc700-666c-4b7a-80e2-d9e2967f4442
+//	gymctl products create --sqlite gymondo.db --name Monthly --price 9.99 --duration month
+//
+// The database is taken from the service configuration (config file,
+// environment, --config), or is a local SQLite file given with --sqlite.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"gymondo_dz/pkg/apperrors"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "gymctl:", describe(err))
+		os.Exit(1)
+	}
+}
+
+// command is one gymctl subcommand, e.g. "subscriptions pause".
+type command struct {
+	name    string
+	args    string // positional arguments, for the usage line
+	summary string
+	flags   *flag.FlagSet
+	common  *commonFlags
+	run     func(ctx context.Context, a *app, args []string) error
+}
+
+func newCommand(name, args, summary string) *command {
+	c := &command{
+		name:    name,
+		args:    args,
+		summary: summary,
+		flags:   flag.NewFlagSet("gymctl "+name, flag.ContinueOnError),
+		common:  &commonFlags{},
+	}
+	c.flags.SetOutput(io.Discard)
+	c.common.register(c.flags)
+	return c
+}
+
+func commands() []*command {
+	return []*command{
+		listSubscriptions(),
+		showSubscription(),
+		changeSubscription("pause"),
+		changeSubscription("unpause"),
+		changeSubscription("cancel"),
+		listProducts(),
+		showProduct(),
+		createProduct(),
+		updateProduct(),
+		seed(),
+		expire(),
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	all := commands()
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return usage(stdout, all)
+	}
+
+	c, rest := find(all, args)
+	if c == nil {
+		return fmt.Errorf("unknown command %q; run gymctl help", strings.Join(args[:min(2, len(args))], " "))
+	}
+	positional, err := parseInterspersed(c.flags, rest)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.name, err)
+	}
+
+	a, err := open(ctx, c.common, stdout)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	return c.run(ctx, a, positional)
+}
+
+// find matches the command named by the leading words of args.
+func find(all []*command, args []string) (*command, []string) {
+	for _, c := range all {
+		words := strings.Fields(c.name)
+		if len(args) >= len(words) && slices.Equal(args[:len(words)], words) {
+			return c, args[len(words):]
+		}
+	}
+	return nil, nil
+}
+
+// parseInterspersed parses flags appearing anywhere among the positional
+// arguments, which the flag package alone stops at.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional, args = append(positional, args[0]), args[1:]
+	}
+}
+
+func usage(w io.Writer, all []*command) error {
+	fmt.Fprintln(w, "usage: gymctl <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range all {
+		fmt.Fprintf(w, "  %-40s %s\n", strings.TrimSpace(c.name+" "+c.args), c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "flags accepted by every command:")
+	fmt.Fprintln(w, "  --config PATH   service config file (env CONFIG_FILE)")
+	fmt.Fprintln(w, "  --sqlite PATH   use this SQLite file instead of the configured database")
+	fmt.Fprintln(w, "  -o FORMAT       output format: table (default) or json")
+	fmt.Fprintln(w, "  -v              log SQL statements and other details to stderr")
+	return nil
+}
+
+// describe renders err for the terminal, including per-field problems.
+func describe(err error) string {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || len(appErr.Fields) == 0 {
+		return err.Error()
+	}
+	problems := make([]string, len(appErr.Fields))
+	for i, f := range appErr.Fields {
+		problems[i] = f.Field + " " + f.Message
+	}
+	return appErr.Detail + ": " + strings.Join(problems, "; ")
+}
+
+// requireArgs checks the number of positional arguments.
+func requireArgs(c *command, args []string, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("usage: gymctl %s %s", c.name, c.args)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, nil, 0o600))
+	t.Setenv("CONFIG_FILE", configFile)
+	db := filepath.Join(dir, "gymondo.db")
+
+	gymctl := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		err := run(context.Background(), append(args, "--sqlite", db), &out)
+		return out.String(), err
+	}
+	data := func(out string) []map[string]any {
+		var resp struct{ Data []map[string]any }
+		require.NoError(t, json.Unmarshal([]byte(out), &resp), out)
+		return resp.Data
+	}
+
+	out, err := gymctl("seed")
+	require.NoError(t, err)
+	assert.Equal(t, "seeded demo: 3 products, 4 subscriptions\n", out)
+
+	out, err = gymctl("seed", "e2e", "--mode", "upsert")
+	require.NoError(t, err)
+	assert.Equal(t, "seeded e2e: 2 products, 4 subscriptions\n", out)
+	_, err = gymctl("seed", "e2e", "--reference", "yesterday")
+	assert.ErrorContains(t, err, "invalid --reference")
+	_, err = gymctl("seed", "staging")
+	assert.ErrorContains(t, err, `unknown fixture profile "staging"`)
+
+	out, err = gymctl("products", "create", "--name", "Weekly", "--price", "4.99", "--duration", "month")
+	require.NoError(t, err)
+	assert.Contains(t, out, "(Weekly)")
+
+	out, err = gymctl("products", "list", "--search", "Weekly", "-o", "json")
+	require.NoError(t, err)
+	products := data(out)
+	require.Len(t, products, 1)
+
+	out, err = gymctl("products", "update", products[0]["id"].(string), "--price", "5.99")
+	require.NoError(t, err)
+	assert.Contains(t, out, "5.99 EUR")
+
+	out, err = gymctl("subscriptions", "list", "--status", "active", "-o", "json")
+	require.NoError(t, err)
+	subscriptions := data(out)
+	require.NotEmpty(t, subscriptions)
+
+	id := subscriptions[0]["id"].(string)
+	out, err = gymctl("subscriptions", "pause", id)
+	require.NoError(t, err)
+	assert.Contains(t, out, "is now paused (version 2)")
+
+	_, err = gymctl("subscriptions", "unpause", id, "--version", "1")
+	assert.EqualError(t, err, "subscription was modified by another request")
+
+	_, err = gymctl("products", "create", "--price", "-1", "--duration", "year")
+	assert.Error(t, err)
+	assert.Equal(t, "invalid product: name must be 1 to 100 characters; price must not be negative", describe(err))
+
+	_, err = gymctl("subscriptions", "frobnicate")
+	assert.EqualError(t, err, `unknown command "subscriptions frobnicate"; run gymctl help`)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gymondo_dz/pkg/fixtures"
+)
+
+func seed() *command {
+	c := newCommand("seed", "[PROFILE|FILE]", "load a fixture profile: "+strings.Join(fixtures.Names(), ", ")+" (default demo) or a .yaml or .json file")
+	mode := c.flags.String("mode", string(fixtures.ModeInsert), "insert only adds missing rows, upsert also resets existing ones")
+	reference := c.flags.String("reference", "", "RFC 3339 time the profile's dates are relative to (default today 00:00 UTC)")
+
+	c.run = func(ctx context.Context, a *app, args []string) error {
+		name := "demo"
+		if len(args) > 0 {
+			if err := requireArgs(c, args, 1); err != nil {
+				return err
+			}
+			name = args[0]
+		}
+		ref := fixtures.DefaultReference(time.Now())
+		if *reference != "" {
+			t, err := time.Parse(time.RFC3339, *reference)
+			if err != nil {
+				return fmt.Errorf("invalid --reference: %w", err)
+			}
+			ref = t
+		}
+
+		profile, err := fixtures.Load(name)
+		if err != nil {
+			return err
+		}
+		result, err := fixtures.Apply(ctx, a.db, profile, fixtures.Mode(*mode), ref)
+		if err != nil {
+			return err
+		}
+		a.invalidateProducts(ctx)
+		return a.printMessage(result, "seeded %s: %d products, %d subscriptions", profile.Name, result.Products, result.Subscriptions)
+	}
+	return c
+}
+
+func expire() *command {
+	c := newCommand("expire", "", "expire every subscription whose end date has passed")
+	c.run = func(ctx context.Context, a *app, args []string) error {
+		if err := requireArgs(c, args, 0); err != nil {
+			return err
+		}
+		n, err := a.subscriptions.ExpireSubscriptions(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		return a.printMessage(map[string]int{"expired": n}, "expired %d subscriptions", n)
+	}
+	return c
+}
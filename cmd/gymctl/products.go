@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+)
+
+var durationNames = map[string]models.SubscriptionDuration{
+	"month":    models.DurationMonth,
+	"year":     models.DurationYear,
+	"lifetime": models.DurationLifetime,
+}
+
+// durationFlag accepts month, year or lifetime, or a number of days.
+type durationFlag struct {
+	value *models.SubscriptionDuration
+}
+
+func (d *durationFlag) String() string {
+	if d.value == nil {
+		return ""
+	}
+	return durationName(*d.value)
+}
+
+func (d *durationFlag) Set(s string) error {
+	if v, ok := durationNames[s]; ok {
+		*d.value = v
+		return nil
+	}
+	days, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%q is not month, year, lifetime or a number of days", s)
+	}
+	*d.value = models.SubscriptionDuration(days)
+	return nil
+}
+
+func durationName(d models.SubscriptionDuration) string {
+	for name, v := range durationNames {
+		if v == d {
+			return name
+		}
+	}
+	return strconv.Itoa(int(d)) + " days"
+}
+
+func formatPrice(amount float64, currency string) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64) + " " + currency
+}
+
+func listProducts() *command {
+	c := newCommand("products list", "", "list or search products")
+	var filter repositories.ProductFilter
+	var page repositories.PageRequest
+	c.flags.StringVar(&filter.Query, "search", "", "full-text search in name and description")
+	c.flags.Var(&durationFlag{value: &filter.Duration}, "duration", "only products of this duration")
+	c.flags.StringVar(&filter.Currency, "currency", "", "only products priced in this currency")
+	c.flags.StringVar(&filter.SortBy, "sort", "", "sort by created_at, price or name")
+	c.flags.BoolVar(&filter.SortDesc, "desc", false, "sort in descending order")
+	pageFlags(c.flags, &page)
+
+	c.run = func(ctx context.Context, a *app, args []string) error {
+		if err := requireArgs(c, args, 0); err != nil {
+			return err
+		}
+		page.IncludeTotal = true
+
+		products, pagination, err := a.products.GetProducts(ctx, filter, page)
+		if err != nil {
+			return err
+		}
+
+		rows := make([][]string, len(products))
+		for i, p := range products {
+			rows[i] = []string{p.ID.String(), p.Name, durationName(p.Duration),
+				formatPrice(p.Price, p.Currency), strconv.FormatFloat(p.TaxRate, 'f', -1, 64), formatPrice(p.TotalPrice, p.Currency)}
+		}
+		return a.print(products, meta(pagination), []string{"ID", "NAME", "DURATION", "PRICE", "TAX RATE", "TOTAL"}, rows)
+	}
+	return c
+}
+
+func showProduct() *command {
+	c := newCommand("products show", "ID", "show a product")
+	c.run = func(ctx context.Context, a *app, args []string) error {
+		if err := requireArgs(c, args, 1); err != nil {
+			return err
+		}
+		p, err := a.products.GetProduct(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return a.printFields(p, productFields(p))
+	}
+	return c
+}
+
+func createProduct() *command {
+	c := newCommand("products create", "", "create a product")
+	product := &models.Product{TaxRate: 0.10, Currency: "EUR"}
+	c.flags.StringVar(&product.Name, "name", "", "product name (required)")
+	c.flags.StringVar(&product.Description, "description", "", "product description")
+	c.flags.Float64Var(&product.Price, "price", 0, "net price")
+	c.flags.Float64Var(&product.TaxRate, "tax-rate", product.TaxRate, "tax rate, e.g. 0.19")
+	c.flags.StringVar(&product.Currency, "currency", product.Currency, "ISO 4217 currency code")
+	c.flags.Var(&durationFlag{value: &product.Duration}, "duration", "month, year, lifetime or days (required)")
+
+	c.run = func(ctx context.Context, a *app, args []string) error {
+		if err := requireArgs(c, args, 0); err != nil {
+			return err
+		}
+		p, err := a.products.CreateProduct(ctx, product)
+		if err != nil {
+			return err
+		}
+		return a.printMessage(p, "created product %s (%s)", p.ID, p.Name)
+	}
+	return c
+}
+
+func updateProduct() *command {
+	c := newCommand("products update", "ID", "change some fields of a product")
+	var name, description, currency string
+	var price, taxRate float64
+	var duration models.SubscriptionDuration
+	c.flags.StringVar(&name, "name", "", "new name")
+	c.flags.StringVar(&description, "description", "", "new description")
+	c.flags.Float64Var(&price, "price", 0, "new net price")
+	c.flags.Float64Var(&taxRate, "tax-rate", 0, "new tax rate")
+	c.flags.StringVar(&currency, "currency", "", "new currency")
+	c.flags.Var(&durationFlag{value: &duration}, "duration", "new duration")
+
+	c.run = func(ctx context.Context, a *app, args []string) error {
+		if err := requireArgs(c, args, 1); err != nil {
+			return err
+		}
+
+		// only flags given on the command line change anything
+		var changes repositories.ProductChanges
+		c.flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "name":
+				changes.Name = &name
+			case "description":
+				changes.Description = &description
+			case "price":
+				changes.Price = &price
+			case "tax-rate":
+				changes.TaxRate = &taxRate
+			case "currency":
+				changes.Currency = &currency
+			case "duration":
+				changes.Duration = &duration
+			}
+		})
+		if changes == (repositories.ProductChanges{}) {
+			return fmt.Errorf("nothing to change; pass at least one of --name, --description, --price, --tax-rate, --currency, --duration")
+		}
+
+		p, err := a.products.UpdateProduct(ctx, args[0], changes)
+		if err != nil {
+			return err
+		}
+		return a.printFields(p, productFields(p))
+	}
+	return c
+}
+
+func productFields(p *models.Product) [][2]string {
+	return [][2]string{
+		{"ID", p.ID.String()},
+		{"Name", p.Name},
+		{"Description", p.Description},
+		{"Duration", durationName(p.Duration)},
+		{"Price", formatPrice(p.Price, p.Currency)},
+		{"Tax rate", strconv.FormatFloat(p.TaxRate, 'f', -1, 64)},
+		{"Total price", formatPrice(p.TotalPrice, p.Currency)},
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+)
+
+func listSubscriptions() *command {
+	c := newCommand("subscriptions list", "", "list subscriptions, optionally filtered")
+	var filter repositories.SubscriptionFilter
+	var status string
+	var page repositories.PageRequest
+	c.flags.StringVar(&filter.UserID, "user", "", "only subscriptions of this user ID")
+	c.flags.StringVar(&filter.ProductID, "product", "", "only subscriptions to this product ID")
+	c.flags.StringVar(&status, "status", "", "only subscriptions in this status: active, paused, cancelled or expired")
+	pageFlags(c.flags, &page)
+
+	c.run = func(ctx context.Context, a *app, args []string) error {
+		if err := requireArgs(c, args, 0); err != nil {
+			return err
+		}
+		filter.Status = models.SubscriptionStatus(status)
+		if status != "" && !filter.Status.IsValid() {
+			return fmt.Errorf("unknown status %q", status)
+		}
+		page.IncludeTotal = true
+
+		subscriptions, pagination, err := a.subscriptions.ListSubscriptions(ctx, filter, page)
+		if err != nil {
+			return err
+		}
+
+		rows := make([][]string, len(subscriptions))
+		for i, s := range subscriptions {
+			rows[i] = []string{s.ID.String(), s.UserID.String(), productName(s.Product), string(s.Status),
+				formatTime(&s.StartDate), formatTime(&s.EndDate), strconv.Itoa(s.Version)}
+		}
+		return a.print(subscriptions, meta(pagination),
+			[]string{"ID", "USER", "PRODUCT", "STATUS", "START", "END", "VERSION"}, rows)
+	}
+	return c
+}
+
+func showSubscription() *command {
+	c := newCommand("subscriptions show", "ID", "show a subscription with its product")
+	c.run = func(ctx context.Context, a *app, args []string) error {
+		if err := requireArgs(c, args, 1); err != nil {
+			return err
+		}
+		s, err := a.subscriptions.GetSubscription(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return a.printFields(s, subscriptionFields(s))
+	}
+	return c
+}
+
+// changeSubscription builds the pause, unpause and cancel commands. They
+// act on the version given with --version, or else on the current one.
+func changeSubscription(action string) *command {
+	c := newCommand("subscriptions "+action, "ID", action+" a subscription")
+	version := c.flags.Int("version", 0, "expected version, to fail if the subscription changed meanwhile")
+
+	c.run = func(ctx context.Context, a *app, args []string) error {
+		if err := requireArgs(c, args, 1); err != nil {
+			return err
+		}
+		id := args[0]
+		expected := *version
+		if expected == 0 {
+			current, err := a.subscriptions.GetSubscription(ctx, id)
+			if err != nil {
+				return err
+			}
+			expected = current.Version
+		}
+
+		change := map[string]func(context.Context, string, int) (*models.Subscription, error){
+			"pause":   a.subscriptions.PauseSubscription,
+			"unpause": a.subscriptions.UnpauseSubscription,
+			"cancel":  a.subscriptions.CancelSubscription,
+		}[action]
+		s, err := change(ctx, id, expected)
+		if err != nil {
+			return err
+		}
+		return a.printMessage(s, "subscription %s is now %s (version %d)", s.ID, s.Status, s.Version)
+	}
+	return c
+}
+
+func subscriptionFields(s *models.Subscription) [][2]string {
+	fields := [][2]string{
+		{"ID", s.ID.String()},
+		{"User", s.UserID.String()},
+		{"Status", string(s.Status)},
+		{"Start", formatTime(&s.StartDate)},
+		{"End", formatTime(&s.EndDate)},
+		{"Paused at", formatTime(s.PausedAt)},
+		{"Cancelled at", formatTime(s.CancelledAt)},
+		{"Version", strconv.Itoa(s.Version)},
+		{"Product", s.ProductID.String()},
+	}
+	if p := s.Product; p != nil {
+		fields = append(fields,
+			[2]string{"  Name", p.Name},
+			[2]string{"  Duration", durationName(p.Duration)},
+			[2]string{"  Price", formatPrice(p.Price, p.Currency)},
+			[2]string{"  Total price", formatPrice(p.TotalPrice, p.Currency)},
+		)
+	}
+	return fields
+}
+
+func productName(p *models.Product) string {
+	if p == nil {
+		return "-"
+	}
+	return p.Name
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -1,19 +1,45 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"gymondo_dz/pkg/buildinfo"
+	"gymondo_dz/pkg/cache"
+	"gymondo_dz/pkg/config"
 	"gymondo_dz/pkg/database"
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/fixtures"
+	"gymondo_dz/pkg/graph"
+	"gymondo_dz/pkg/grpcapi"
 	"gymondo_dz/pkg/handlers"
+	"gymondo_dz/pkg/health"
+	"gymondo_dz/pkg/logging"
+	"gymondo_dz/pkg/metrics"
+	"gymondo_dz/pkg/middleware"
+	"gymondo_dz/pkg/outbox"
+	"gymondo_dz/pkg/ratelimit"
 	"gymondo_dz/pkg/repositories"
-	"log"
+	"gymondo_dz/pkg/resilience"
+	"gymondo_dz/pkg/server"
+	"gymondo_dz/pkg/stream"
+	"gymondo_dz/pkg/tracing"
+	"gymondo_dz/pkg/webhooks"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "gymondo_dz/docs" // docs is generated by Swag CLI, you have to import it.
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"google.golang.org/grpc"
 )
 
 // @title Gymondo Subscription API
@@ -25,56 +51,304 @@ import (
 // @host localhost:8080
 // @BasePath /
 // @schemes http
+// @securityDefinitions.apikey AdminKey
+// @in header
+// @name Authorization
+// @description An admin API key sent as "Bearer <key>", required by the /admin endpoints
 func main() {
-	err := godotenv.Load()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fatal("Migration failed", err)
+		}
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		fatal("Failed to load configuration", err)
+	}
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fatal("Failed to print configuration", err)
+		}
+		return
+	}
+
+	// the std log package and anything else using the default logger
+	// write through the same handler
+	logger := logging.New(cfg.Log, os.Stderr)
+	slog.SetDefault(logger)
+	if cfg.Log.Level != "debug" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	info := buildinfo.Get()
+	logger.Info("Starting", "version", info.Version, "commit", info.Commit, "build_time", info.BuildTime)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+
+	db, err := database.Open(cfg.Database, logging.NewGormLogger(logger, cfg.Log.SlowQueryThreshold))
 	if err != nil {
-		log.Fatalf("Error loading .env file")
+		fatal("Failed to connect to database", err)
+	}
+	if err := db.Use(tracing.NewGormPlugin(nil)); err != nil {
+		fatal("Failed to instrument database", err)
+	}
+
+	if cfg.Database.AutoMigrate {
+		if err := database.Migrate(context.Background(), db); err != nil {
+			fatal("Failed to migrate database", err)
+		}
+	}
+	seeded := false
+	if seed := cfg.Database.Seed; seed.Profile != "" {
+		profile, err := fixtures.Load(seed.Profile)
+		if err != nil {
+			fatal("Failed to load seed profile", err)
+		}
+		result, err := fixtures.Apply(context.Background(), db, profile, fixtures.Mode(seed.Mode), fixtures.DefaultReference(time.Now()))
+		if err != nil {
+			fatal("Failed to seed database", err)
+		}
+		logger.Info("Seeded database", "profile", profile.Name, "mode", seed.Mode,
+			"products", result.Products, "subscriptions", result.Subscriptions)
+		seeded = true
+	}
+	if err := database.UseReplicas(db, cfg.Database); err != nil {
+		fatal("Failed to connect to read replicas", err)
+	}
+
+	// every repository call goes through one breaker, so a database that
+	// keeps failing is given a rest and requests fail fast with 503
+	dbExec := resilience.New("database", cfg.Database.Resilience)
+
+	checker := health.NewChecker(cfg.Health.CheckTimeout,
+		health.Check{Name: "database", Run: func(ctx context.Context) error { return database.Ping(ctx, db) }},
+		health.Check{Name: "migrations", Run: func(ctx context.Context) error { return database.CheckSchemaVersion(ctx, db) }},
+		health.Check{Name: "circuit_breaker", Run: dbExec.Check},
+	)
+	var build *buildinfo.Info
+	if cfg.Health.ExposeBuildInfo {
+		build = &info
+	}
+
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("Failed to set trusted proxies", err)
+	}
+	router.Use(
+		otelgin.Middleware(cfg.Tracing.ServiceName),
+		middleware.RequestID(),
+		middleware.Logger(logger),
+		middleware.Recovery(),
+	)
+
+	var observers []repositories.SubscriptionObserver
+	// gRPC calls are measured and limited like HTTP requests
+	var grpcInterceptors []grpc.UnaryServerInterceptor
+	if cfg.Metrics.Enabled {
+		m := metrics.New()
+		if err := m.InstrumentDB(db); err != nil {
+			fatal("Failed to instrument database", err)
+		}
+		if err := m.InstrumentBreaker(dbExec); err != nil {
+			fatal("Failed to instrument circuit breaker", err)
+		}
+		observers = append(observers, m)
+		router.Use(m.Middleware())
+		grpcInterceptors = append(grpcInterceptors, m.UnaryServerInterceptor(grpcapi.Status))
+		router.GET(cfg.Metrics.Path, gin.WrapH(m.Handler()))
 	}
 
-	db, err := database.NewPostgresConnection()
+	srv := server.New(cfg.Server, router)
+	// closers run in reverse, so spans from the final queries are still flushed
+	srv.OnShutdown("tracing", func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return shutdownTracing(ctx)
+	})
+	srv.OnShutdown("database", func() error { return database.Close(db) })
+
+	// subscription changes always store their events in the outbox; the
+	// relay publishes them once a publisher is configured or webhooks are
+	// enabled, which queue a delivery per subscribed endpoint
+	var publishers events.Fanout
+	publisher, err := events.NewPublisher(cfg.Events)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to create event publisher", err)
+	}
+	if publisher != nil {
+		publishers = append(publishers, publisher)
 	}
+	if cfg.Webhooks.Enabled {
+		publishers = append(publishers, webhooks.NewEnqueuer(db))
+		srv.Go("webhook-dispatcher", webhooks.NewDispatcher(db, cfg.Webhooks).Run)
+	}
+	if len(publishers) > 0 {
+		srv.OnShutdown("events", publishers.Close)
+		srv.Go("outbox-relay", outbox.NewRelay(db, publishers, cfg.Events).Run)
+	}
+
+	// committed changes are pushed to the open event streams, which end
+	// as soon as draining starts so that they do not hold up shutdown
+	hub := stream.NewHub(cfg.Stream.Buffer)
+	observers = append(observers, hub)
+	srv.OnDrain(hub.Close)
 
-	if err := database.InitializeDB(db, false); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+	// cache hits are served even while the breaker is open
+	productRepo := repositories.NewResilientProductRepository(repositories.NewProductRepository(db), dbExec)
+	if cfg.Cache.Enabled {
+		store, closeStore := cache.NewStore(cfg.Cache)
+		srv.OnShutdown("cache", closeStore)
+		cached := repositories.NewCachedProductRepository(productRepo, store, cfg.Cache.TTL)
+		// a shared cache may still hold the products the seed replaced
+		if seeded {
+			if err := cached.Invalidate(context.Background()); err != nil {
+				logger.Warn("Failed to invalidate product cache after seeding", "error", err)
+			}
+		}
+		productRepo = cached
 	}
+	subscriptionRepo := repositories.NewResilientSubscriptionRepository(repositories.NewSubscriptionRepository(db, observers...), dbExec)
+	translationRepo := repositories.NewResilientTranslationRepository(repositories.NewTranslationRepository(db), dbExec)
+	webhookRepo := repositories.NewResilientWebhookRepository(repositories.NewWebhookRepository(db), dbExec)
+	eventRepo := repositories.NewResilientEventRepository(repositories.NewEventRepository(db), dbExec)
 
-	productRepo := repositories.NewProductRepository(db)
-	subscriptionRepo := repositories.NewSubscriptionRepository(db)
+	productHandler := handlers.NewProductHandler(productRepo, translationRepo)
+	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionRepo, productRepo, translationRepo)
+	translationHandler := handlers.NewTranslationHandler(translationRepo)
+	webhookHandler := handlers.NewWebhookHandler(webhookRepo)
+	streamHandler := handlers.NewStreamHandler(subscriptionRepo, eventRepo, hub, cfg.Stream)
+	healthHandler := handlers.NewHealthHandler(srv.State(), checker, build)
 
-	productHandler := handlers.NewProductHandler(productRepo)
-	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionRepo, productRepo)
+	if len(cfg.Database.Replicas) > 0 {
+		router.Use(middleware.ReadYourWrites(cache.NewLRU(readYourWritesClients), cfg.Database.ReadYourWrites,
+			ratelimit.Identify(cfg.RateLimit.APIKeyHeader)))
+	}
+	router.Use(middleware.Timeout(cfg.Database.RequestTimeout), middleware.ErrorHandler())
+
+	// reads and writes are limited separately, writes more strictly
+	read, write := noLimit, noLimit
+	if cfg.RateLimit.Enabled {
+		store := ratelimit.NewMemoryStore()
+		srv.Go("ratelimit-sweeper", store.Run)
+		limiter := ratelimit.New(store, ratelimit.Identify(cfg.RateLimit.APIKeyHeader))
+		readPolicy := ratelimit.Policy{Name: "read", Rate: cfg.RateLimit.Read.Rate, Burst: cfg.RateLimit.Read.Burst}
+		writePolicy := ratelimit.Policy{Name: "write", Rate: cfg.RateLimit.Write.Rate, Burst: cfg.RateLimit.Write.Burst}
+		read, write = limiter.Limit(readPolicy), limiter.Limit(writePolicy)
+		grpcInterceptors = append(grpcInterceptors, limiter.LimitCalls(
+			ratelimit.IdentifyCall(cfg.RateLimit.APIKeyHeader), grpcapi.RateLimits(readPolicy, writePolicy)))
+	}
 
-	router := gin.Default()
+	// internal services call the same repositories over gRPC; calls drain
+	// alongside HTTP requests
+	if cfg.GRPC.Enabled {
+		ln, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPC.Port))
+		if err != nil {
+			fatal("Failed to listen for gRPC", err)
+		}
+		grpcServer := grpcapi.NewServer(cfg.GRPC, cfg.Database.RequestTimeout, logger,
+			productRepo, subscriptionRepo, translationRepo, grpcInterceptors...)
+		srv.OnDrain(grpcServer.Drain)
+		srv.Go("grpc-server", func(ctx context.Context) { grpcServer.Serve(ctx, ln) })
+	}
 
 	productRoutes := router.Group("/products")
 	{
-		productRoutes.GET("", productHandler.GetProducts)
-		productRoutes.GET("/:id", productHandler.GetProduct)
+		productRoutes.GET("", read, productHandler.GetProducts)
+		productRoutes.GET("/:id", read, productHandler.GetProduct)
 	}
 
 	subscriptionRoutes := router.Group("/subscriptions")
 	{
-		subscriptionRoutes.POST("/:product_id", subscriptionHandler.CreateSubscription)
-		subscriptionRoutes.GET("/:id", subscriptionHandler.GetSubscription)
-		subscriptionRoutes.PATCH("/:id/pause", subscriptionHandler.PauseSubscription)
-		subscriptionRoutes.PATCH("/:id/unpause", subscriptionHandler.UnpauseSubscription)
-		subscriptionRoutes.DELETE("/:id", subscriptionHandler.CancelSubscription)
+		subscriptionRoutes.GET("", read, subscriptionHandler.ListSubscriptions)
+		subscriptionRoutes.POST("/:product_id", write, subscriptionHandler.CreateSubscription)
+		subscriptionRoutes.GET("/:id", read, subscriptionHandler.GetSubscription)
+		subscriptionRoutes.GET("/:id/events", read, streamHandler.SubscriptionEvents)
+		subscriptionRoutes.PATCH("/:id/pause", write, subscriptionHandler.PauseSubscription)
+		subscriptionRoutes.PATCH("/:id/unpause", write, subscriptionHandler.UnpauseSubscription)
+		subscriptionRoutes.DELETE("/:id", write, subscriptionHandler.CancelSubscription)
 	}
 
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
+	// translations, webhooks and the event firehose are for operators only
+	if len(cfg.Admin.APIKeys) == 0 {
+		logger.Warn("No admin API keys configured, the /admin endpoints refuse every request")
+	}
+	adminRoutes := router.Group("/admin", middleware.AdminAuth(cfg.Admin.APIKeys))
+	{
+		adminRoutes.GET("/products/:id/translations", read, translationHandler.ListTranslations)
+		adminRoutes.PUT("/products/:id/translations/:locale", write, translationHandler.PutTranslation)
+		adminRoutes.DELETE("/products/:id/translations/:locale", write, translationHandler.DeleteTranslation)
+		adminRoutes.POST("/webhooks", write, webhookHandler.CreateEndpoint)
+		adminRoutes.GET("/webhooks", read, webhookHandler.ListEndpoints)
+		adminRoutes.GET("/webhooks/:id", read, webhookHandler.GetEndpoint)
+		adminRoutes.PATCH("/webhooks/:id", write, webhookHandler.UpdateEndpoint)
+		adminRoutes.DELETE("/webhooks/:id", write, webhookHandler.DeleteEndpoint)
+		adminRoutes.GET("/webhooks/:id/deliveries", read, webhookHandler.ListDeliveries)
+		adminRoutes.GET("/webhook-deliveries/:id", read, webhookHandler.GetDelivery)
+		adminRoutes.POST("/webhook-deliveries/:id/redeliver", write, webhookHandler.Redeliver)
+		adminRoutes.GET("/subscription-events", read, streamHandler.AllSubscriptionEvents)
+	}
+
+	// GraphQL requests are limited as reads or writes by their operation,
+	// which means reading the body first, so its size is bounded before
+	if cfg.GraphQL.Enabled {
+		graphqlHandler := gin.WrapH(graph.NewHandler(cfg.GraphQL, productRepo, subscriptionRepo, translationRepo))
+		maxBody := middleware.MaxBodySize(int64(cfg.GraphQL.MaxBodyBytes))
+		router.GET("/graphql", byOperation(read, write), graphqlHandler)
+		router.POST("/graphql", maxBody, byOperation(read, write), graphqlHandler)
+		if cfg.GraphQL.Playground {
+			router.GET("/graphiql", gin.WrapH(graph.Playground("/graphql")))
+		}
+	}
+
+	router.GET("/health", healthHandler.Livez)
+	router.GET("/livez", healthHandler.Livez)
+	router.GET("/readyz", healthHandler.Readyz)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := srv.Run(ctx); err != nil {
+		fatal("Server error", err)
 	}
-	log.Printf("Starting server on %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+}
+
+func noLimit(c *gin.Context) { c.Next() }
+
+// byOperation limits GraphQL queries as reads and mutations as writes,
+// whichever method they are sent with.
+func byOperation(read, write gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mutation, err := graph.IsMutation(c.Request)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				err = middleware.ErrPayloadTooLarge
+			}
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		if mutation {
+			write(c)
+			return
+		}
+		read(c)
 	}
 }
+
+// readYourWritesClients bounds how many recent writers an instance
+// remembers; the least recent are forgotten first.
+const readYourWritesClients = 10000
+
+// fatal logs err through the default logger and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
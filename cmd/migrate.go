@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/database"
+	"gymondo_dz/pkg/logging"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+)
+
+const migrateUsage = "usage: gymondo migrate up|down|status|to VERSION [flags]"
+
+// runMigrate implements the migrate subcommand. The remaining arguments
+// are the usual configuration flags, which select the database.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	action, args := args[0], args[1:]
+
+	target := -1
+	if action == "to" {
+		if len(args) == 0 {
+			return errors.New(migrateUsage)
+		}
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid version %q", args[0])
+		}
+		target, args = v, args[1:]
+	}
+
+	switch action {
+	case "up", "down", "status", "to":
+	default:
+		return fmt.Errorf("unknown migrate command %q; %s", action, migrateUsage)
+	}
+
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+	logger := logging.New(cfg.Log, os.Stderr)
+	slog.SetDefault(logger)
+
+	db, err := database.Open(cfg.Database, logging.NewGormLogger(logger, cfg.Log.SlowQueryThreshold))
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch action {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		return m.Down(ctx)
+	case "to":
+		return m.To(ctx, target)
+	default:
+		return printMigrationStatus(ctx, os.Stdout, m)
+	}
+}
+
+func printMigrationStatus(ctx context.Context, out io.Writer, m *database.Migrator) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "schema version %d, latest %d\n", version, m.Latest())
+	for _, migration := range m.Migrations() {
+		status := "pending"
+		if migration.Version <= version {
+			status = "applied"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", migration, status)
+	}
+	return w.Flush()
+}
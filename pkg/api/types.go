@@ -1,5 +1,10 @@
 package api
 
+import "gymondo_dz/pkg/apperrors"
+
+// MIMEProblemJSON is the media type of RFC 7807 problem details.
+const MIMEProblemJSON = "application/problem+json"
+
 type (
 	Response struct {
 		Data  interface{} `json:"data,omitempty"`
@@ -8,14 +13,31 @@ type (
 	}
 
 	Meta struct {
-		Total int64 `json:"total,omitempty"`
-		Page  int   `json:"page,omitempty"`
-		Limit int   `json:"limit,omitempty"`
+		Total      *int64 `json:"total,omitempty"`
+		Page       int    `json:"page,omitempty"`
+		Limit      int    `json:"limit,omitempty"`
+		NextCursor string `json:"next_cursor,omitempty"`
+		PrevCursor string `json:"prev_cursor,omitempty"`
 	}
 
 	Error struct {
-		Message string `json:"message"`
-		Code    string `json:"code"`
+		Message   string                 `json:"message"`
+		Code      string                 `json:"code"`
+		Fields    []apperrors.FieldError `json:"fields,omitempty"`
+		RequestID string                 `json:"request_id,omitempty"`
+	}
+
+	// Problem is an RFC 7807 problem details object, extended with the
+	// error code, per-field errors and the request ID.
+	Problem struct {
+		Type      string                 `json:"type"`
+		Title     string                 `json:"title"`
+		Status    int                    `json:"status"`
+		Detail    string                 `json:"detail,omitempty"`
+		Instance  string                 `json:"instance,omitempty"`
+		Code      string                 `json:"code"`
+		Errors    []apperrors.FieldError `json:"errors,omitempty"`
+		RequestID string                 `json:"request_id,omitempty"`
 	}
 )
 
@@ -31,3 +53,8 @@ func ErrorResponse(message, code string) Response {
 		Error: &Error{Message: message, Code: code},
 	}
 }
+
+// ProblemType returns the URI identifying a problem type for code.
+func ProblemType(code string) string {
+	return "https://api.gymondo.com/problems/" + code
+}
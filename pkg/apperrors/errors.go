@@ -0,0 +1,150 @@
+package apperrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Error codes shared by the REST envelope and problem+json responses.
+const (
+	CodeNotFound               = "not_found"
+	CodeInvalidID              = "invalid_id"
+	CodeInvalidState           = "invalid_state"
+	CodeConcurrentModification = "concurrent_modification"
+	CodeValidation             = "validation_error"
+	CodePreconditionRequired   = "precondition_required"
+	CodeBadRequest             = "bad_request"
+	CodeUnauthorized           = "unauthorized"
+	CodePayloadTooLarge        = "payload_too_large"
+	CodeInternal               = "internal_error"
+	CodeTimeout                = "timeout"
+	CodeCanceled               = "request_canceled"
+	CodeRateLimited            = "rate_limited"
+	CodeUnavailable            = "service_unavailable"
+)
+
+// StatusClientClosedRequest is the non-standard status (from nginx) used
+// when the client went away before the response was ready.
+const StatusClientClosedRequest = 499
+
+// ErrInternal is what every unclassified error is rendered as, so that
+// driver or SQL messages never leak to clients.
+var ErrInternal = New(CodeInternal, http.StatusInternalServerError, "internal server error")
+
+// ErrTimeout and ErrCanceled classify work aborted through its context.
+var (
+	ErrTimeout  = New(CodeTimeout, http.StatusGatewayTimeout, "request timed out")
+	ErrCanceled = New(CodeCanceled, StatusClientClosedRequest, "request was canceled")
+)
+
+// ErrUnavailable is returned while a dependency is known to be down.
+var ErrUnavailable = New(CodeUnavailable, http.StatusServiceUnavailable, "service temporarily unavailable")
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error that carries everything needed to render it over HTTP.
+type Error struct {
+	Code   string
+	Status int
+	Title  string
+	Detail string
+	Fields []FieldError
+	Err    error // underlying cause, never rendered
+	// RetryAfter, if set, tells clients when to try again through the
+	// Retry-After header.
+	RetryAfter time.Duration
+
+	kind *Error // sentinel this error was derived from, used by errors.Is
+}
+
+func New(code string, status int, detail string) *Error {
+	return &Error{
+		Code:   code,
+		Status: status,
+		Title:  http.StatusText(status),
+		Detail: detail,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel e was derived from, so that
+// errors.Is(err.WithDetail(...), ErrX) keeps working.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e == t || e.kind == t
+}
+
+func (e *Error) derive() *Error {
+	clone := *e
+	clone.Fields = append([]FieldError(nil), e.Fields...)
+	if e.kind != nil {
+		clone.kind = e.kind
+	} else {
+		clone.kind = e
+	}
+	return &clone
+}
+
+// WithDetail returns a copy of e with a more specific detail message.
+func (e *Error) WithDetail(format string, args ...interface{}) *Error {
+	clone := e.derive()
+	clone.Detail = fmt.Sprintf(format, args...)
+	return clone
+}
+
+// WithFields returns a copy of e carrying per-field errors.
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	clone := e.derive()
+	clone.Fields = append(clone.Fields, fields...)
+	return clone
+}
+
+// WithRetryAfter returns a copy of e asking clients to retry after d.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	clone := e.derive()
+	clone.RetryAfter = d
+	return clone
+}
+
+// Wrap returns a copy of e that records err as its cause.
+func (e *Error) Wrap(err error) *Error {
+	clone := e.derive()
+	clone.Err = err
+	return clone
+}
+
+// From extracts the *Error from err's chain. Context cancellation and
+// deadlines map to ErrCanceled and ErrTimeout; anything else falls back
+// to ErrInternal wrapping err.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout.Wrap(err)
+	case errors.Is(err, context.Canceled):
+		return ErrCanceled.Wrap(err)
+	}
+	return ErrInternal.Wrap(err)
+}
@@ -0,0 +1,63 @@
+package apperrors_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/apperrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	errNotFound := apperrors.New(apperrors.CodeNotFound, http.StatusNotFound, "thing not found")
+	errOther := apperrors.New(apperrors.CodeNotFound, http.StatusNotFound, "other not found")
+
+	t.Run("Derived errors match their sentinel only", func(t *testing.T) {
+		derived := errNotFound.WithDetail("thing %d not found", 42)
+
+		assert.ErrorIs(t, derived, errNotFound)
+		assert.NotErrorIs(t, derived, errOther)
+		assert.Equal(t, "thing 42 not found", derived.Detail)
+		assert.Equal(t, "thing not found", errNotFound.Detail)
+	})
+
+	t.Run("Wrapped causes stay reachable", func(t *testing.T) {
+		cause := errors.New("boom")
+		wrapped := fmt.Errorf("repo: %w", errNotFound.Wrap(cause))
+
+		assert.ErrorIs(t, wrapped, errNotFound)
+		assert.ErrorIs(t, wrapped, cause)
+		assert.Equal(t, errNotFound.Code, apperrors.From(wrapped).Code)
+	})
+
+	t.Run("Unknown errors become internal errors", func(t *testing.T) {
+		appErr := apperrors.From(errors.New("pq: connection refused"))
+
+		assert.ErrorIs(t, appErr, apperrors.ErrInternal)
+		assert.Equal(t, http.StatusInternalServerError, appErr.Status)
+		assert.Equal(t, "internal server error", appErr.Detail)
+	})
+
+	t.Run("Retry hints are kept per derived error", func(t *testing.T) {
+		derived := apperrors.ErrUnavailable.WithRetryAfter(3 * time.Second)
+
+		assert.ErrorIs(t, derived, apperrors.ErrUnavailable)
+		assert.Equal(t, 3*time.Second, apperrors.From(fmt.Errorf("repo: %w", derived)).RetryAfter)
+		assert.Zero(t, apperrors.ErrUnavailable.RetryAfter)
+	})
+
+	t.Run("Context errors map to timeout and cancellation", func(t *testing.T) {
+		timeout := apperrors.From(fmt.Errorf("query: %w", context.DeadlineExceeded))
+		assert.ErrorIs(t, timeout, apperrors.ErrTimeout)
+		assert.Equal(t, http.StatusGatewayTimeout, timeout.Status)
+
+		canceled := apperrors.From(context.Canceled)
+		assert.ErrorIs(t, canceled, apperrors.ErrCanceled)
+		assert.Equal(t, apperrors.StatusClientClosedRequest, canceled.Status)
+	})
+}
@@ -0,0 +1,42 @@
+// Package buildinfo exposes version information embedded at link time:
+//
+//	go build -ldflags "-X gymondo_dz/pkg/buildinfo.Version=v1.2.0 \
+//	  -X gymondo_dz/pkg/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X gymondo_dz/pkg/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Commit and BuildTime fall back to the VCS stamp Go records in the binary.
+package buildinfo
+
+import "runtime/debug"
+
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = bi.GoVersion
+	for _, s := range bi.Settings {
+		switch {
+		case s.Key == "vcs.revision" && info.Commit == "":
+			info.Commit = s.Value
+		case s.Key == "vcs.time" && info.BuildTime == "":
+			info.BuildTime = s.Value
+		}
+	}
+	return info
+}
@@ -0,0 +1,121 @@
+// Package cache provides the byte-oriented stores repositories cache
+// through: LRU for a single instance, or RedisStore when instances must
+// share the cache.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"gymondo_dz/pkg/config"
+)
+
+// Store holds encoded values. Get reports whether key was present; a ttl
+// of zero or less keeps the value until it is evicted or deleted.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStore returns the Store selected by cfg.Backend and a function that
+// releases it.
+func NewStore(cfg config.CacheConfig) (Store, func() error) {
+	if cfg.Backend == "redis" {
+		client := NewRedisClient(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
+		return NewRedisStore(client, cfg.Redis.KeyPrefix), client.Close
+	}
+	return NewLRU(cfg.Size), func() error { return nil }
+}
+
+// LRU is an in-process Store holding at most size entries, evicting the
+// least recently used one when full.
+type LRU struct {
+	// Now defaults to time.Now; tests replace it to expire entries.
+	Now func() time.Time
+
+	size    int
+	mu      sync.Mutex
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time // zero if the entry never expires
+}
+
+func NewLRU(size int) *LRU {
+	return &LRU{
+		Now:     time.Now,
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*entry)
+	if !e.expires.IsZero() && !c.Now().Before(e.expires) {
+		c.remove(el)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.Now().Add(ttl)
+	}
+
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+func (c *LRU) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	return nil
+}
+
+// Len returns the number of entries held, including expired ones not yet
+// evicted.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
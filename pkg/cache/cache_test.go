@@ -0,0 +1,112 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	lru := cache.NewLRU(2)
+	lru.Now = func() time.Time { return now }
+
+	get := func(key string) string {
+		value, ok, err := lru.Get(ctx, key)
+		require.NoError(t, err)
+		if !ok {
+			return "<miss>"
+		}
+		return string(value)
+	}
+
+	require.NoError(t, lru.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, lru.Set(ctx, "b", []byte("2"), time.Minute))
+	assert.Equal(t, "1", get("a"))
+
+	// b is now the least recently used entry
+	require.NoError(t, lru.Set(ctx, "c", []byte("3"), 0))
+	assert.Equal(t, 2, lru.Len())
+	assert.Equal(t, "<miss>", get("b"))
+	assert.Equal(t, "1", get("a"))
+	assert.Equal(t, "3", get("c"))
+
+	require.NoError(t, lru.Set(ctx, "c", []byte("4"), time.Minute))
+	assert.Equal(t, "4", get("c"))
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, "<miss>", get("c"), "expired")
+	assert.Equal(t, "1", get("a"), "no TTL")
+	assert.Equal(t, 1, lru.Len())
+
+	require.NoError(t, lru.Delete(ctx, "a"))
+	require.NoError(t, lru.Delete(ctx, "missing"))
+	assert.Equal(t, "<miss>", get("a"))
+	assert.Equal(t, 0, lru.Len())
+}
+
+func TestRedisStore(t *testing.T) {
+	ctx := context.Background()
+	values := map[string]string{}
+	var commands [][]any
+	store := cache.NewRedisStore(cache.DoerFunc(func(_ context.Context, args ...any) (any, error) {
+		commands = append(commands, args)
+		key := args[1].(string)
+		switch args[0] {
+		case "GET":
+			if v, ok := values[key]; ok {
+				return v, nil
+			}
+			return nil, nil
+		case "SET":
+			values[key] = string(args[2].([]byte))
+			return "OK", nil
+		case "DEL":
+			delete(values, key)
+			return int64(1), nil
+		}
+		return nil, errors.New("unknown command")
+	}), "gymondo:")
+
+	_, ok, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, store.Set(ctx, "b", []byte("2"), 1500*time.Millisecond))
+	value, ok, err := store.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("2"), value)
+
+	require.NoError(t, store.Delete(ctx, "a"))
+	_, ok, err = store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.Equal(t, [][]any{
+		{"GET", "gymondo:a"},
+		{"SET", "gymondo:a", []byte("1")},
+		{"SET", "gymondo:b", []byte("2"), "PX", int64(1500)},
+		{"GET", "gymondo:b"},
+		{"DEL", "gymondo:a"},
+		{"GET", "gymondo:a"},
+	}, commands)
+}
+
+func TestRedisStoreReportsFailures(t *testing.T) {
+	store := cache.NewRedisStore(cache.DoerFunc(func(context.Context, ...any) (any, error) {
+		return nil, errors.New("connection refused")
+	}), "")
+
+	_, _, err := store.Get(context.Background(), "a")
+	assert.ErrorContains(t, err, "connection refused")
+	assert.ErrorContains(t, store.Set(context.Background(), "a", nil, 0), "connection refused")
+}
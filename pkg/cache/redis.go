@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Doer runs one Redis command and returns its reply, nil for a missing
+// key. Any Redis-compatible client can provide it; NewRedisClient adapts
+// go-redis.
+type Doer interface {
+	Do(ctx context.Context, args ...any) (any, error)
+}
+
+type DoerFunc func(ctx context.Context, args ...any) (any, error)
+
+func (f DoerFunc) Do(ctx context.Context, args ...any) (any, error) {
+	return f(ctx, args...)
+}
+
+// RedisStore shares cached values between instances through Redis, which
+// evicts them by its own maxmemory policy.
+type RedisStore struct {
+	client Doer
+	prefix string
+}
+
+// NewRedisStore keeps values under keys starting with prefix.
+func NewRedisStore(client Doer, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := s.client.Do(ctx, "GET", s.prefix+key)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get cached value: %w", err)
+	}
+	switch v := reply.(type) {
+	case nil:
+		return nil, false, nil
+	case string:
+		return []byte(v), true, nil
+	case []byte:
+		return v, true, nil
+	default:
+		return nil, false, fmt.Errorf("unexpected reply %v", reply)
+	}
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []any{"SET", s.prefix + key, value}
+	if ttl > 0 {
+		args = append(args, "PX", max(ttl.Milliseconds(), 1))
+	}
+	if _, err := s.client.Do(ctx, args...); err != nil {
+		return fmt.Errorf("failed to cache value: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	if _, err := s.client.Do(ctx, "DEL", s.prefix+key); err != nil {
+		return fmt.Errorf("failed to delete cached value: %w", err)
+	}
+	return nil
+}
+
+// RedisClient is a go-redis client serving as the Doer of a RedisStore.
+type RedisClient struct {
+	*redis.Client
+}
+
+// NewRedisClient connects lazily to the Redis server at addr.
+func NewRedisClient(addr, password string, db int) *RedisClient {
+	return &RedisClient{redis.NewClient(&redis.Options{Addr: addr, Password: password, DB: db})}
+}
+
+func (c *RedisClient) Do(ctx context.Context, args ...any) (any, error) {
+	reply, err := c.Client.Do(ctx, args...).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return reply, err
+}
@@ -0,0 +1,1139 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultConfigFile = "config.yaml"
+	redacted          = "******"
+)
+
+type Config struct {
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Health    HealthConfig    `yaml:"health"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Log       LogConfig       `yaml:"log"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Cache     CacheConfig     `yaml:"cache"`
+	Events    EventsConfig    `yaml:"events"`
+	Webhooks  WebhooksConfig  `yaml:"webhooks"`
+	Stream    StreamConfig    `yaml:"stream"`
+	GRPC      GRPCConfig      `yaml:"grpc"`
+	GraphQL   GraphQLConfig   `yaml:"graphql"`
+	Admin     AdminConfig     `yaml:"admin"`
+
+	// PrintConfig is set by --print-config; it is never read from a file or env.
+	PrintConfig bool `yaml:"-"`
+}
+
+type ServerConfig struct {
+	Port              int           `yaml:"port"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownDelay keeps serving after readiness turns false so load
+	// balancers can stop routing traffic before connections are drained.
+	ShutdownDelay   time.Duration `yaml:"shutdown_delay"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// TrustedProxies lists the proxy addresses or CIDRs whose
+	// X-Forwarded-For header is believed when resolving the client IP.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	// Driver selects the storage backend: postgres, or sqlite for a
+	// single-binary deployment keeping its data in SQLite.Path. The
+	// connection settings below apply to postgres only.
+	Driver   string       `yaml:"driver"`
+	SQLite   SQLiteConfig `yaml:"sqlite"`
+	Host     string       `yaml:"host"`
+	Port     int          `yaml:"port"`
+	User     string       `yaml:"user"`
+	Password string       `yaml:"password"`
+	Name     string       `yaml:"name"`
+	SSLMode  string       `yaml:"sslmode"`
+	TimeZone string       `yaml:"timezone"`
+	// RequestTimeout bounds the database work of a single HTTP request;
+	// zero disables the deadline.
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// AutoMigrate applies pending migrations on startup. When disabled,
+	// run `gymondo migrate up` before deploying; instances stay unready
+	// until the schema is current.
+	AutoMigrate bool `yaml:"auto_migrate"`
+	// Seed loads a fixture profile on startup.
+	Seed SeedConfig `yaml:"seed"`
+	// Pool sizes the connection pool to the primary and to each replica.
+	Pool PoolConfig `yaml:"pool"`
+	// StatementCacheCapacity bounds the prepared statements the Postgres
+	// driver keeps per connection; 0 prepares nothing, as PgBouncer in
+	// transaction mode requires.
+	StatementCacheCapacity int `yaml:"statement_cache_capacity"`
+	// Replicas are read-only copies of the Postgres primary, as host or
+	// host:port, reached with the primary's credentials. Queries outside
+	// transactions are spread over them; writes and transactions go to
+	// the primary.
+	Replicas []string `yaml:"replicas"`
+	// ReadYourWrites sends a client's reads to the primary for this long
+	// after it changed something, so replication lag never hides its own
+	// writes from it; zero disables.
+	ReadYourWrites time.Duration    `yaml:"read_your_writes"`
+	Resilience     ResilienceConfig `yaml:"resilience"`
+}
+
+// PoolConfig sizes a connection pool. MaxOpenConns 0 means unlimited.
+// Connections are replaced after ConnMaxLifetime, so the pool follows
+// failovers and DNS changes, and closed after ConnMaxIdleTime unused;
+// zero keeps them.
+type PoolConfig struct {
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+// SeedConfig names the fixture profile applied on every startup, a
+// built-in one (demo, load-test, e2e) or a .yaml or .json file; empty
+// applies none. Mode insert only adds missing rows, upsert also resets
+// existing fixture rows to their profile values.
+type SeedConfig struct {
+	Profile string `yaml:"profile"`
+	Mode    string `yaml:"mode"`
+}
+
+// SQLiteConfig configures the sqlite driver. Writers wait up to
+// BusyTimeout for the database lock instead of failing with
+// SQLITE_BUSY; JournalMode wal lets readers proceed while one writer
+// commits, and should only be changed for file systems without shared
+// memory support, such as network shares.
+type SQLiteConfig struct {
+	Path        string        `yaml:"path"`
+	BusyTimeout time.Duration `yaml:"busy_timeout"`
+	JournalMode string        `yaml:"journal_mode"`
+}
+
+// ResilienceConfig tunes the circuit breaker and retries around database
+// access. The breaker opens after BreakerFailures consecutive failures
+// and lets a probe through after BreakerOpenTimeout. RetryAttempts counts
+// the first try, so 1 disables retries; delays between attempts grow
+// exponentially from RetryBaseDelay up to RetryMaxDelay, with jitter.
+type ResilienceConfig struct {
+	BreakerFailures    int           `yaml:"breaker_failures"`
+	BreakerOpenTimeout time.Duration `yaml:"breaker_open_timeout"`
+	RetryAttempts      int           `yaml:"retry_attempts"`
+	RetryBaseDelay     time.Duration `yaml:"retry_base_delay"`
+	RetryMaxDelay      time.Duration `yaml:"retry_max_delay"`
+}
+
+type HealthConfig struct {
+	CheckTimeout    time.Duration `yaml:"check_timeout"`
+	ExposeBuildInfo bool          `yaml:"expose_build_info"`
+}
+
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"`
+}
+
+// TracingConfig selects where spans are exported: "none" only propagates
+// trace context, "stdout" prints spans and "otlp" sends them over OTLP/HTTP.
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter"`
+	ServiceName  string  `yaml:"service_name"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint"`
+	OTLPInsecure bool    `yaml:"otlp_insecure"`
+	SampleRatio  float64 `yaml:"sample_ratio"`
+}
+
+// LogConfig controls the structured logger. Statements slower than
+// SlowQueryThreshold are logged at warn level; zero disables the check.
+type LogConfig struct {
+	Level              string        `yaml:"level"`
+	Format             string        `yaml:"format"`
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+}
+
+// RateLimitConfig holds the token-bucket policies applied per client:
+// Read covers product and subscription lookups, Write the subscription
+// and translation mutations. Clients are identified by the API key header
+// when sent, else by user, else by IP.
+type RateLimitConfig struct {
+	Enabled      bool            `yaml:"enabled"`
+	APIKeyHeader string          `yaml:"api_key_header"`
+	Read         RateLimitPolicy `yaml:"read"`
+	Write        RateLimitPolicy `yaml:"write"`
+}
+
+// CacheConfig selects the product cache. Backend "memory" keeps up to
+// Size entries in each instance; "redis" shares them between instances
+// and gymctl through Redis, which evicts by its own maxmemory policy.
+// Entries expire after TTL at the latest; product writes through the API
+// invalidate them as soon as they commit.
+type CacheConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Backend string        `yaml:"backend"`
+	TTL     time.Duration `yaml:"ttl"`
+	Size    int           `yaml:"size"`
+	Redis   RedisConfig   `yaml:"redis"`
+}
+
+// RedisConfig addresses a Redis server, or anything speaking its
+// protocol such as Valkey. Keys start with KeyPrefix, so instances of
+// other services can share the server.
+type RedisConfig struct {
+	Addr      string `yaml:"addr"`
+	Password  string `yaml:"password"`
+	DB        int    `yaml:"db"`
+	KeyPrefix string `yaml:"key_prefix"`
+}
+
+// EventsConfig selects where subscription events are published: "none"
+// keeps them in the outbox, "stdout" and "file" write one JSON event per
+// line, "kafka" produces them to Topic keyed by subscription. The relay
+// polls the outbox every PollInterval for up to BatchSize events, gives
+// each PublishTimeout to publish, and retries a failed event after
+// RetryBaseDelay, doubling up to RetryMaxDelay; events of that
+// subscription wait meanwhile, so each subscription's events arrive in
+// order. Published events are deleted after Retention.
+type EventsConfig struct {
+	Publisher      string        `yaml:"publisher"`
+	File           string        `yaml:"file"`
+	Kafka          KafkaConfig   `yaml:"kafka"`
+	PollInterval   time.Duration `yaml:"poll_interval"`
+	BatchSize      int           `yaml:"batch_size"`
+	PublishTimeout time.Duration `yaml:"publish_timeout"`
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay"`
+	RetryMaxDelay  time.Duration `yaml:"retry_max_delay"`
+	Retention      time.Duration `yaml:"retention"`
+}
+
+// KafkaConfig addresses a Kafka cluster, or anything speaking its
+// protocol such as Redpanda.
+type KafkaConfig struct {
+	Brokers []string `yaml:"brokers"`
+	Topic   string   `yaml:"topic"`
+}
+
+// WebhooksConfig controls the delivery of subscription events to the
+// registered webhook endpoints. The dispatcher polls every PollInterval
+// for up to BatchSize due deliveries and sends up to Concurrency of them
+// at once, each within Timeout. A failed delivery is retried after
+// RetryBaseDelay, doubling up to RetryMaxDelay, and given up after
+// MaxAttempts. An endpoint whose deliveries have failed without a single
+// success for DisableAfter is disabled. Finished deliveries are deleted
+// after Retention.
+//
+// Deliveries are only sent to public addresses, checked when connecting
+// so that DNS cannot point a registered host elsewhere later.
+// AllowPrivateNetworks lifts that for development against local
+// receivers.
+type WebhooksConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Timeout        time.Duration `yaml:"timeout"`
+	MaxAttempts    int           `yaml:"max_attempts"`
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay"`
+	RetryMaxDelay  time.Duration `yaml:"retry_max_delay"`
+	DisableAfter   time.Duration `yaml:"disable_after"`
+	PollInterval   time.Duration `yaml:"poll_interval"`
+	BatchSize      int           `yaml:"batch_size"`
+	Concurrency    int           `yaml:"concurrency"`
+	Retention      time.Duration `yaml:"retention"`
+
+	AllowPrivateNetworks bool `yaml:"allow_private_networks"`
+}
+
+// StreamConfig tunes the Server-Sent Events streams of subscription
+// changes. An idle stream sends a keep-alive comment every Heartbeat, and
+// clients are asked to wait Retry before reconnecting. A client that
+// falls more than Buffer events behind is disconnected and resumes with
+// Last-Event-ID, which replays up to ReplayLimit stored events.
+type StreamConfig struct {
+	Heartbeat   time.Duration `yaml:"heartbeat"`
+	Retry       time.Duration `yaml:"retry"`
+	Buffer      int           `yaml:"buffer"`
+	ReplayLimit int           `yaml:"replay_limit"`
+}
+
+// GRPCConfig controls the gRPC API served next to the REST API on its
+// own port. Reflection lets tools such as grpcurl discover the services.
+type GRPCConfig struct {
+	Enabled    bool `yaml:"enabled"`
+	Port       int  `yaml:"port"`
+	Reflection bool `yaml:"reflection"`
+}
+
+// GraphQLConfig controls the /graphql endpoint. Queries whose estimated
+// cost exceeds ComplexityLimit are rejected before they run; a list counts
+// its fields once per item it may return. Request bodies larger than
+// MaxBodyBytes are refused before they are parsed. Playground serves
+// GraphiQL at /graphiql, which is meant for development.
+type GraphQLConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	Playground      bool `yaml:"playground"`
+	ComplexityLimit int  `yaml:"complexity_limit"`
+	MaxBodyBytes    int  `yaml:"max_body_bytes"`
+}
+
+// minAdminAPIKeyLength keeps admin keys out of reach of guessing.
+const minAdminAPIKeyLength = 32
+
+// AdminConfig guards the /admin endpoints. Requests must send one of
+// APIKeys as a bearer token; with none configured, every admin request is
+// refused.
+type AdminConfig struct {
+	APIKeys []string `yaml:"api_keys"`
+}
+
+// RateLimitPolicy refills Rate tokens per second up to Burst.
+type RateLimitPolicy struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+var (
+	logLevels  = []string{"debug", "info", "warn", "error"}
+	logFormats = []string{"json", "text"}
+)
+
+var tracingExporters = []string{"none", "stdout", "otlp"}
+
+var eventPublishers = []string{"none", "stdout", "file", "kafka"}
+
+var cacheBackends = []string{"memory", "redis"}
+
+var (
+	databaseDrivers    = []string{"postgres", "sqlite"}
+	seedModes          = []string{"insert", "upsert"}
+	sqliteJournalModes = []string{"wal", "delete", "truncate"}
+)
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:              8080,
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver: "postgres",
+			SQLite: SQLiteConfig{
+				Path:        "gymondo.db",
+				BusyTimeout: 5 * time.Second,
+				JournalMode: "wal",
+			},
+			Host:           "localhost",
+			Port:           5432,
+			User:           "postgres",
+			Name:           "gymondo",
+			SSLMode:        "disable",
+			TimeZone:       "UTC",
+			RequestTimeout: 5 * time.Second,
+			AutoMigrate:    true,
+			Seed: SeedConfig{
+				Mode: "insert",
+			},
+			Pool: PoolConfig{
+				MaxOpenConns:    25,
+				MaxIdleConns:    10,
+				ConnMaxLifetime: 30 * time.Minute,
+				ConnMaxIdleTime: 5 * time.Minute,
+			},
+			StatementCacheCapacity: 512,
+			ReadYourWrites:         5 * time.Second,
+			Resilience: ResilienceConfig{
+				BreakerFailures:    5,
+				BreakerOpenTimeout: 10 * time.Second,
+				RetryAttempts:      3,
+				RetryBaseDelay:     50 * time.Millisecond,
+				RetryMaxDelay:      time.Second,
+			},
+		},
+		Health: HealthConfig{
+			CheckTimeout: 2 * time.Second,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Tracing: TracingConfig{
+			Exporter:     "none",
+			ServiceName:  "gymondo",
+			OTLPEndpoint: "localhost:4318",
+			SampleRatio:  1,
+		},
+		Log: LogConfig{
+			Level:              "info",
+			Format:             "json",
+			SlowQueryThreshold: 200 * time.Millisecond,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:      true,
+			APIKeyHeader: "X-API-Key",
+			Read:         RateLimitPolicy{Rate: 20, Burst: 40},
+			Write:        RateLimitPolicy{Rate: 1, Burst: 5},
+		},
+		Cache: CacheConfig{
+			Enabled: true,
+			Backend: "memory",
+			TTL:     5 * time.Minute,
+			Size:    1000,
+			Redis:   RedisConfig{Addr: "localhost:6379", KeyPrefix: "gymondo:"},
+		},
+		Events: EventsConfig{
+			Publisher:      "none",
+			File:           "events.jsonl",
+			Kafka:          KafkaConfig{Topic: "gymondo.subscriptions"},
+			PollInterval:   time.Second,
+			BatchSize:      100,
+			PublishTimeout: 10 * time.Second,
+			RetryBaseDelay: time.Second,
+			RetryMaxDelay:  5 * time.Minute,
+			Retention:      7 * 24 * time.Hour,
+		},
+		Webhooks: WebhooksConfig{
+			Enabled:        false,
+			Timeout:        10 * time.Second,
+			MaxAttempts:    12,
+			RetryBaseDelay: 30 * time.Second,
+			RetryMaxDelay:  6 * time.Hour,
+			DisableAfter:   24 * time.Hour,
+			PollInterval:   time.Second,
+			BatchSize:      50,
+			Concurrency:    10,
+			Retention:      30 * 24 * time.Hour,
+		},
+		Stream: StreamConfig{
+			Heartbeat:   15 * time.Second,
+			Retry:       3 * time.Second,
+			Buffer:      64,
+			ReplayLimit: 1000,
+		},
+		GRPC: GRPCConfig{
+			Enabled:    false,
+			Port:       9090,
+			Reflection: false,
+		},
+		GraphQL: GraphQLConfig{
+			Enabled:         true,
+			Playground:      false,
+			ComplexityLimit: 3000,
+			MaxBodyBytes:    1 << 20,
+		},
+	}
+}
+
+func (c EventsConfig) problems() []string {
+	var problems []string
+	switch c.Publisher {
+	case "none":
+		return nil
+	case "file":
+		if c.File == "" {
+			problems = append(problems, "events.file is required for the file publisher")
+		}
+	case "kafka":
+		if len(c.Kafka.Brokers) == 0 {
+			problems = append(problems, "events.kafka.brokers are required for the kafka publisher")
+		}
+		for _, b := range c.Kafka.Brokers {
+			if _, _, err := net.SplitHostPort(b); err != nil {
+				problems = append(problems, fmt.Sprintf("events.kafka.brokers: %q is not host:port", b))
+			}
+		}
+		if c.Kafka.Topic == "" {
+			problems = append(problems, "events.kafka.topic is required for the kafka publisher")
+		}
+	case "stdout":
+	default:
+		return []string{"events.publisher must be one of: " + strings.Join(eventPublishers, ", ")}
+	}
+	if c.PollInterval <= 0 {
+		problems = append(problems, "events.poll_interval must be positive")
+	}
+	if c.BatchSize < 1 {
+		problems = append(problems, "events.batch_size must be at least 1")
+	}
+	if c.PublishTimeout <= 0 {
+		problems = append(problems, "events.publish_timeout must be positive")
+	}
+	if c.RetryBaseDelay <= 0 || c.RetryMaxDelay < c.RetryBaseDelay {
+		problems = append(problems, "events retry delays must satisfy 0 < retry_base_delay <= retry_max_delay")
+	}
+	if c.Retention <= 0 {
+		problems = append(problems, "events.retention must be positive")
+	}
+	return problems
+}
+
+func (c WebhooksConfig) problems() []string {
+	if !c.Enabled {
+		return nil
+	}
+	var problems []string
+	if c.Timeout <= 0 {
+		problems = append(problems, "webhooks.timeout must be positive")
+	}
+	if c.MaxAttempts < 1 {
+		problems = append(problems, "webhooks.max_attempts must be at least 1")
+	}
+	if c.RetryBaseDelay <= 0 || c.RetryMaxDelay < c.RetryBaseDelay {
+		problems = append(problems, "webhooks retry delays must satisfy 0 < retry_base_delay <= retry_max_delay")
+	}
+	if c.DisableAfter <= 0 {
+		problems = append(problems, "webhooks.disable_after must be positive")
+	}
+	if c.PollInterval <= 0 {
+		problems = append(problems, "webhooks.poll_interval must be positive")
+	}
+	if c.BatchSize < 1 {
+		problems = append(problems, "webhooks.batch_size must be at least 1")
+	}
+	if c.Concurrency < 1 {
+		problems = append(problems, "webhooks.concurrency must be at least 1")
+	}
+	if c.Retention <= 0 {
+		problems = append(problems, "webhooks.retention must be positive")
+	}
+	return problems
+}
+
+func (c StreamConfig) problems() []string {
+	var problems []string
+	if c.Heartbeat <= 0 {
+		problems = append(problems, "stream.heartbeat must be positive")
+	}
+	if c.Retry <= 0 {
+		problems = append(problems, "stream.retry must be positive")
+	}
+	if c.Buffer < 1 {
+		problems = append(problems, "stream.buffer must be at least 1")
+	}
+	if c.ReplayLimit < 1 {
+		problems = append(problems, "stream.replay_limit must be at least 1")
+	}
+	return problems
+}
+
+func (c DatabaseConfig) postgresProblems() []string {
+	var problems []string
+	if c.Host == "" {
+		problems = append(problems, "database.host is required")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		problems = append(problems, "database.port must be between 1 and 65535")
+	}
+	if c.User == "" {
+		problems = append(problems, "database.user is required")
+	}
+	if c.Name == "" {
+		problems = append(problems, "database.name is required")
+	}
+	if !slices.Contains(sslModes, c.SSLMode) {
+		problems = append(problems, "database.sslmode must be one of: "+strings.Join(sslModes, ", "))
+	}
+	if _, err := time.LoadLocation(c.TimeZone); err != nil {
+		problems = append(problems, "database.timezone must be a valid IANA time zone")
+	}
+	if c.StatementCacheCapacity < 0 {
+		problems = append(problems, "database.statement_cache_capacity must not be negative")
+	}
+	for _, replica := range c.Replicas {
+		if host, _, err := splitHostPort(replica, c.Port); err != nil || host == "" {
+			problems = append(problems, fmt.Sprintf("database.replicas: %q is not a host or host:port", replica))
+		}
+	}
+	return problems
+}
+
+func (c PoolConfig) problems() []string {
+	var problems []string
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 {
+		problems = append(problems, "database.pool connection limits must not be negative")
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		problems = append(problems, "database.pool.max_idle_conns must not exceed max_open_conns")
+	}
+	if c.ConnMaxLifetime < 0 || c.ConnMaxIdleTime < 0 {
+		problems = append(problems, "database.pool connection lifetimes must not be negative")
+	}
+	return problems
+}
+
+func (c SQLiteConfig) problems() []string {
+	var problems []string
+	if c.Path == "" {
+		problems = append(problems, "database.sqlite.path is required")
+	}
+	if c.BusyTimeout < 0 {
+		problems = append(problems, "database.sqlite.busy_timeout must not be negative")
+	}
+	if !slices.Contains(sqliteJournalModes, c.JournalMode) {
+		problems = append(problems, "database.sqlite.journal_mode must be one of: "+strings.Join(sqliteJournalModes, ", "))
+	}
+	return problems
+}
+
+// DSN returns the Postgres connection string.
+func (c DatabaseConfig) DSN() string {
+	return c.dsn(c.Host, c.Port)
+}
+
+// ReplicaDSNs returns the connection strings of the replicas.
+func (c DatabaseConfig) ReplicaDSNs() []string {
+	dsns := make([]string, len(c.Replicas))
+	for i, replica := range c.Replicas {
+		host, port, _ := splitHostPort(replica, c.Port)
+		dsns[i] = c.dsn(host, port)
+	}
+	return dsns
+}
+
+func (c DatabaseConfig) dsn(host string, port int) string {
+	dsn := fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s statement_cache_capacity=%d",
+		host, c.User, c.Password, c.Name, port, c.SSLMode, c.TimeZone, c.StatementCacheCapacity,
+	)
+	if c.StatementCacheCapacity == 0 {
+		dsn += " default_query_exec_mode=exec"
+	}
+	return dsn
+}
+
+// splitHostPort splits "host" or "host:port", defaulting the port.
+func splitHostPort(s string, defaultPort int) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(s)
+	if err != nil {
+		// no port
+		return s, defaultPort, nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port %q", portStr)
+	}
+	return host, port, nil
+}
+
+// setting binds one configuration value to its environment variable and
+// command-line flag. Secret settings are redacted by --print-config.
+type setting struct {
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	value  flag.Value
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{env: "PORT", flag: "port", usage: "HTTP listen port", value: intValue{&c.Server.Port}},
+		{env: "SERVER_READ_TIMEOUT", flag: "read-timeout", usage: "maximum duration for reading a request", value: durationValue{&c.Server.ReadTimeout}},
+		{env: "SERVER_READ_HEADER_TIMEOUT", flag: "read-header-timeout", usage: "maximum duration for reading request headers", value: durationValue{&c.Server.ReadHeaderTimeout}},
+		{env: "SERVER_WRITE_TIMEOUT", flag: "write-timeout", usage: "maximum duration for writing a response", value: durationValue{&c.Server.WriteTimeout}},
+		{env: "SERVER_IDLE_TIMEOUT", flag: "idle-timeout", usage: "keep-alive idle timeout", value: durationValue{&c.Server.IdleTimeout}},
+		{env: "SERVER_SHUTDOWN_DELAY", flag: "shutdown-delay", usage: "time to keep serving after readiness turns false", value: durationValue{&c.Server.ShutdownDelay}},
+		{env: "SERVER_SHUTDOWN_TIMEOUT", flag: "shutdown-timeout", usage: "deadline for draining requests on shutdown", value: durationValue{&c.Server.ShutdownTimeout}},
+		{env: "SERVER_TRUSTED_PROXIES", flag: "trusted-proxies", usage: "comma-separated proxy IPs or CIDRs trusted for X-Forwarded-For", value: listValue{&c.Server.TrustedProxies}},
+		{env: "DB_DRIVER", flag: "db-driver", usage: "storage backend: postgres or sqlite", value: stringValue{&c.Database.Driver}},
+		{env: "DB_SQLITE_PATH", flag: "db-sqlite-path", usage: "SQLite database file", value: stringValue{&c.Database.SQLite.Path}},
+		{env: "DB_SQLITE_BUSY_TIMEOUT", flag: "db-sqlite-busy-timeout", usage: "how long SQLite writers wait for the database lock", value: durationValue{&c.Database.SQLite.BusyTimeout}},
+		{env: "DB_SQLITE_JOURNAL_MODE", flag: "db-sqlite-journal-mode", usage: "SQLite journal mode: wal, delete or truncate", value: stringValue{&c.Database.SQLite.JournalMode}},
+		{env: "DB_HOST", flag: "db-host", usage: "database host", value: stringValue{&c.Database.Host}},
+		{env: "DB_PORT", flag: "db-port", usage: "database port", value: intValue{&c.Database.Port}},
+		{env: "DB_USER", flag: "db-user", usage: "database user", value: stringValue{&c.Database.User}},
+		{env: "DB_PASSWORD", flag: "db-password", usage: "database password", secret: true, value: stringValue{&c.Database.Password}},
+		{env: "DB_NAME", flag: "db-name", usage: "database name", value: stringValue{&c.Database.Name}},
+		{env: "DB_SSL_MODE", flag: "db-sslmode", usage: "database SSL mode", value: stringValue{&c.Database.SSLMode}},
+		{env: "DB_TIMEZONE", flag: "db-timezone", usage: "database session time zone", value: stringValue{&c.Database.TimeZone}},
+		{env: "DB_REQUEST_TIMEOUT", flag: "db-request-timeout", usage: "deadline for the database work of one request (0 disables)", value: durationValue{&c.Database.RequestTimeout}},
+		{env: "DB_AUTO_MIGRATE", flag: "db-auto-migrate", usage: "apply pending schema migrations on startup", value: boolValue{&c.Database.AutoMigrate}},
+		{env: "SEED_PROFILE", flag: "seed-profile", usage: "fixture profile or file to load on startup (empty loads none)", value: stringValue{&c.Database.Seed.Profile}},
+		{env: "SEED_MODE", flag: "seed-mode", usage: "how the seed profile treats existing rows: insert or upsert", value: stringValue{&c.Database.Seed.Mode}},
+		{env: "DB_MAX_OPEN_CONNS", flag: "db-max-open-conns", usage: "open connections per database pool (0 is unlimited)", value: intValue{&c.Database.Pool.MaxOpenConns}},
+		{env: "DB_MAX_IDLE_CONNS", flag: "db-max-idle-conns", usage: "idle connections kept per database pool", value: intValue{&c.Database.Pool.MaxIdleConns}},
+		{env: "DB_CONN_MAX_LIFETIME", flag: "db-conn-max-lifetime", usage: "age after which a database connection is replaced (0 keeps it)", value: durationValue{&c.Database.Pool.ConnMaxLifetime}},
+		{env: "DB_CONN_MAX_IDLE_TIME", flag: "db-conn-max-idle-time", usage: "idle time after which a database connection is closed (0 keeps it)", value: durationValue{&c.Database.Pool.ConnMaxIdleTime}},
+		{env: "DB_STATEMENT_CACHE_CAPACITY", flag: "db-statement-cache-capacity", usage: "prepared statements cached per Postgres connection (0 disables)", value: intValue{&c.Database.StatementCacheCapacity}},
+		{env: "DB_REPLICAS", flag: "db-replicas", usage: "comma-separated read replica hosts, as host or host:port", value: listValue{&c.Database.Replicas}},
+		{env: "DB_READ_YOUR_WRITES", flag: "db-read-your-writes", usage: "how long a client's reads go to the primary after it wrote (0 disables)", value: durationValue{&c.Database.ReadYourWrites}},
+		{env: "DB_BREAKER_FAILURES", flag: "db-breaker-failures", usage: "consecutive database failures that open the circuit breaker", value: intValue{&c.Database.Resilience.BreakerFailures}},
+		{env: "DB_BREAKER_OPEN_TIMEOUT", flag: "db-breaker-open-timeout", usage: "how long the circuit breaker stays open before probing", value: durationValue{&c.Database.Resilience.BreakerOpenTimeout}},
+		{env: "DB_RETRY_ATTEMPTS", flag: "db-retry-attempts", usage: "attempts per database operation, including the first (1 disables retries)", value: intValue{&c.Database.Resilience.RetryAttempts}},
+		{env: "DB_RETRY_BASE_DELAY", flag: "db-retry-base-delay", usage: "delay before the first retry, doubled on each further one", value: durationValue{&c.Database.Resilience.RetryBaseDelay}},
+		{env: "DB_RETRY_MAX_DELAY", flag: "db-retry-max-delay", usage: "upper bound for the delay between retries", value: durationValue{&c.Database.Resilience.RetryMaxDelay}},
+		{env: "HEALTH_CHECK_TIMEOUT", flag: "health-check-timeout", usage: "timeout for each readiness dependency check", value: durationValue{&c.Health.CheckTimeout}},
+		{env: "HEALTH_EXPOSE_BUILD_INFO", flag: "health-expose-build-info", usage: "include build info in probe responses", value: boolValue{&c.Health.ExposeBuildInfo}},
+		{env: "METRICS_ENABLED", flag: "metrics-enabled", usage: "expose Prometheus metrics", value: boolValue{&c.Metrics.Enabled}},
+		{env: "METRICS_PATH", flag: "metrics-path", usage: "path Prometheus metrics are served on", value: stringValue{&c.Metrics.Path}},
+		{env: "TRACING_EXPORTER", flag: "tracing-exporter", usage: "span exporter: none, stdout or otlp", value: stringValue{&c.Tracing.Exporter}},
+		{env: "TRACING_SERVICE_NAME", flag: "tracing-service-name", usage: "service name reported on spans", value: stringValue{&c.Tracing.ServiceName}},
+		{env: "TRACING_OTLP_ENDPOINT", flag: "tracing-otlp-endpoint", usage: "OTLP/HTTP collector host:port", value: stringValue{&c.Tracing.OTLPEndpoint}},
+		{env: "TRACING_OTLP_INSECURE", flag: "tracing-otlp-insecure", usage: "send OTLP over plain HTTP", value: boolValue{&c.Tracing.OTLPInsecure}},
+		{env: "TRACING_SAMPLE_RATIO", flag: "tracing-sample-ratio", usage: "fraction of new traces to sample", value: floatValue{&c.Tracing.SampleRatio}},
+		{env: "LOG_LEVEL", flag: "log-level", usage: "minimum log level: debug, info, warn or error", value: stringValue{&c.Log.Level}},
+		{env: "LOG_FORMAT", flag: "log-format", usage: "log output format: json or text", value: stringValue{&c.Log.Format}},
+		{env: "LOG_SLOW_QUERY_THRESHOLD", flag: "log-slow-query-threshold", usage: "log SQL statements slower than this at warn level (0 disables)", value: durationValue{&c.Log.SlowQueryThreshold}},
+		{env: "RATE_LIMIT_ENABLED", flag: "rate-limit-enabled", usage: "enforce per-client rate limits", value: boolValue{&c.RateLimit.Enabled}},
+		{env: "RATE_LIMIT_API_KEY_HEADER", flag: "rate-limit-api-key-header", usage: "header identifying API clients for rate limiting", value: stringValue{&c.RateLimit.APIKeyHeader}},
+		{env: "RATE_LIMIT_READ_RATE", flag: "rate-limit-read-rate", usage: "read requests per second per client", value: floatValue{&c.RateLimit.Read.Rate}},
+		{env: "RATE_LIMIT_READ_BURST", flag: "rate-limit-read-burst", usage: "read requests a client may burst", value: intValue{&c.RateLimit.Read.Burst}},
+		{env: "RATE_LIMIT_WRITE_RATE", flag: "rate-limit-write-rate", usage: "write requests per second per client", value: floatValue{&c.RateLimit.Write.Rate}},
+		{env: "RATE_LIMIT_WRITE_BURST", flag: "rate-limit-write-burst", usage: "write requests a client may burst", value: intValue{&c.RateLimit.Write.Burst}},
+		{env: "CACHE_ENABLED", flag: "cache-enabled", usage: "cache products", value: boolValue{&c.Cache.Enabled}},
+		{env: "CACHE_BACKEND", flag: "cache-backend", usage: "where products are cached: memory or redis", value: stringValue{&c.Cache.Backend}},
+		{env: "CACHE_TTL", flag: "cache-ttl", usage: "how long cached products are served", value: durationValue{&c.Cache.TTL}},
+		{env: "CACHE_SIZE", flag: "cache-size", usage: "maximum number of cached products and product pages in memory", value: intValue{&c.Cache.Size}},
+		{env: "CACHE_REDIS_ADDR", flag: "cache-redis-addr", usage: "Redis server of the redis cache backend, as host:port", value: stringValue{&c.Cache.Redis.Addr}},
+		{env: "CACHE_REDIS_PASSWORD", flag: "cache-redis-password", usage: "Redis password", secret: true, value: stringValue{&c.Cache.Redis.Password}},
+		{env: "CACHE_REDIS_DB", flag: "cache-redis-db", usage: "Redis database number", value: intValue{&c.Cache.Redis.DB}},
+		{env: "CACHE_REDIS_KEY_PREFIX", flag: "cache-redis-key-prefix", usage: "prefix of the cache's Redis keys", value: stringValue{&c.Cache.Redis.KeyPrefix}},
+		{env: "EVENTS_PUBLISHER", flag: "events-publisher", usage: "where subscription events go: none, stdout, file or kafka", value: stringValue{&c.Events.Publisher}},
+		{env: "EVENTS_FILE", flag: "events-file", usage: "file the file publisher appends events to", value: stringValue{&c.Events.File}},
+		{env: "EVENTS_KAFKA_BROKERS", flag: "events-kafka-brokers", usage: "comma-separated Kafka bootstrap brokers, as host:port", value: listValue{&c.Events.Kafka.Brokers}},
+		{env: "EVENTS_KAFKA_TOPIC", flag: "events-kafka-topic", usage: "Kafka topic subscription events are produced to", value: stringValue{&c.Events.Kafka.Topic}},
+		{env: "EVENTS_POLL_INTERVAL", flag: "events-poll-interval", usage: "how often the relay looks for unpublished events", value: durationValue{&c.Events.PollInterval}},
+		{env: "EVENTS_BATCH_SIZE", flag: "events-batch-size", usage: "events the relay reads per poll", value: intValue{&c.Events.BatchSize}},
+		{env: "EVENTS_PUBLISH_TIMEOUT", flag: "events-publish-timeout", usage: "how long the relay waits for one event to be published", value: durationValue{&c.Events.PublishTimeout}},
+		{env: "EVENTS_RETRY_BASE_DELAY", flag: "events-retry-base-delay", usage: "delay before republishing a failed event, doubled on each further failure", value: durationValue{&c.Events.RetryBaseDelay}},
+		{env: "EVENTS_RETRY_MAX_DELAY", flag: "events-retry-max-delay", usage: "upper bound for the delay between publishing attempts", value: durationValue{&c.Events.RetryMaxDelay}},
+		{env: "EVENTS_RETENTION", flag: "events-retention", usage: "how long published events stay in the outbox", value: durationValue{&c.Events.Retention}},
+		{env: "WEBHOOKS_ENABLED", flag: "webhooks-enabled", usage: "deliver subscription events to the registered webhook endpoints", value: boolValue{&c.Webhooks.Enabled}},
+		{env: "WEBHOOKS_TIMEOUT", flag: "webhooks-timeout", usage: "how long an endpoint has to answer a delivery", value: durationValue{&c.Webhooks.Timeout}},
+		{env: "WEBHOOKS_MAX_ATTEMPTS", flag: "webhooks-max-attempts", usage: "attempts before a delivery is given up", value: intValue{&c.Webhooks.MaxAttempts}},
+		{env: "WEBHOOKS_RETRY_BASE_DELAY", flag: "webhooks-retry-base-delay", usage: "delay before retrying a failed delivery, doubled on each further failure", value: durationValue{&c.Webhooks.RetryBaseDelay}},
+		{env: "WEBHOOKS_RETRY_MAX_DELAY", flag: "webhooks-retry-max-delay", usage: "upper bound for the delay between delivery attempts", value: durationValue{&c.Webhooks.RetryMaxDelay}},
+		{env: "WEBHOOKS_DISABLE_AFTER", flag: "webhooks-disable-after", usage: "how long an endpoint may fail without a success before it is disabled", value: durationValue{&c.Webhooks.DisableAfter}},
+		{env: "WEBHOOKS_POLL_INTERVAL", flag: "webhooks-poll-interval", usage: "how often the dispatcher looks for due deliveries", value: durationValue{&c.Webhooks.PollInterval}},
+		{env: "WEBHOOKS_BATCH_SIZE", flag: "webhooks-batch-size", usage: "deliveries the dispatcher claims per poll", value: intValue{&c.Webhooks.BatchSize}},
+		{env: "WEBHOOKS_CONCURRENCY", flag: "webhooks-concurrency", usage: "deliveries sent at once", value: intValue{&c.Webhooks.Concurrency}},
+		{env: "WEBHOOKS_RETENTION", flag: "webhooks-retention", usage: "how long finished deliveries stay in the delivery log", value: durationValue{&c.Webhooks.Retention}},
+		{env: "WEBHOOKS_ALLOW_PRIVATE_NETWORKS", flag: "webhooks-allow-private-networks", usage: "let deliveries reach loopback and private addresses (for development)", value: boolValue{&c.Webhooks.AllowPrivateNetworks}},
+		{env: "STREAM_HEARTBEAT", flag: "stream-heartbeat", usage: "how often an idle event stream sends a keep-alive", value: durationValue{&c.Stream.Heartbeat}},
+		{env: "STREAM_RETRY", flag: "stream-retry", usage: "how long event stream clients wait before reconnecting", value: durationValue{&c.Stream.Retry}},
+		{env: "STREAM_BUFFER", flag: "stream-buffer", usage: "events a stream client may fall behind before it is disconnected", value: intValue{&c.Stream.Buffer}},
+		{env: "STREAM_REPLAY_LIMIT", flag: "stream-replay-limit", usage: "stored events replayed to a stream resumed with Last-Event-ID", value: intValue{&c.Stream.ReplayLimit}},
+		{env: "GRPC_ENABLED", flag: "grpc-enabled", usage: "serve the gRPC API", value: boolValue{&c.GRPC.Enabled}},
+		{env: "GRPC_PORT", flag: "grpc-port", usage: "port the gRPC API listens on", value: intValue{&c.GRPC.Port}},
+		{env: "GRPC_REFLECTION", flag: "grpc-reflection", usage: "let clients discover the gRPC services through server reflection", value: boolValue{&c.GRPC.Reflection}},
+		{env: "GRAPHQL_ENABLED", flag: "graphql-enabled", usage: "serve the GraphQL API at /graphql", value: boolValue{&c.GraphQL.Enabled}},
+		{env: "GRAPHQL_PLAYGROUND", flag: "graphql-playground", usage: "serve the GraphiQL playground at /graphiql (for development)", value: boolValue{&c.GraphQL.Playground}},
+		{env: "GRAPHQL_COMPLEXITY_LIMIT", flag: "graphql-complexity-limit", usage: "highest estimated cost of a GraphQL query", value: intValue{&c.GraphQL.ComplexityLimit}},
+		{env: "GRAPHQL_MAX_BODY_BYTES", flag: "graphql-max-body-bytes", usage: "largest GraphQL request body accepted, in bytes", value: intValue{&c.GraphQL.MaxBodyBytes}},
+		{env: "ADMIN_API_KEYS", flag: "admin-api-keys", usage: "comma-separated bearer tokens accepted by the /admin endpoints", secret: true, value: listValue{&c.Admin.APIKeys}},
+	}
+}
+
+// Load builds the effective configuration from, in increasing order of
+// precedence: built-in defaults, the YAML config file, environment
+// variables (including a .env file, if present) and command-line flags.
+// Every environment variable NAME can instead be given as NAME_FILE
+// pointing at a file holding the value, which is how secrets are mounted.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("gymondo", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configFile := fs.String("config", "", "path to the YAML config file (env CONFIG_FILE, default "+DefaultConfigFile+")")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "print the effective configuration with secrets redacted and exit")
+
+	// flags are applied last, so they are parsed into a scratch config and
+	// copied over once the file and environment have been loaded
+	flagged := Default()
+	flagSettings := flagged.settings()
+	for _, s := range flagSettings {
+		fs.Var(s.value, s.flag, s.usage+" (env "+s.env+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("invalid command line: %w", err)
+	}
+
+	// a missing .env is fine, the environment may be set by other means
+	_ = godotenv.Load()
+
+	path, explicit := *configFile, *configFile != ""
+	if !explicit {
+		if env, ok := os.LookupEnv("CONFIG_FILE"); ok && env != "" {
+			path, explicit = env, true
+		} else {
+			path = DefaultConfigFile
+		}
+	}
+	if err := cfg.loadFile(path, explicit); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	settings := cfg.settings()
+	for i, s := range flagSettings {
+		if set[s.flag] {
+			_ = settings[i].value.Set(s.value.String())
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string, required bool) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil
+		}
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	var errs []error
+	for _, s := range c.settings() {
+		value, ok, err := lookupEnv(s.env)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := s.value.Set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lookupEnv reads name, or the file named by name_FILE.
+func lookupEnv(name string) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+	file, fileOK := os.LookupEnv(name + "_FILE")
+	if !fileOK {
+		return value, ok, nil
+	}
+	if ok {
+		return "", false, fmt.Errorf("both %s and %s_FILE are set", name, name)
+	}
+
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(raw), "\r\n"), true, nil
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var problems []string
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		problems = append(problems, "server.port must be between 1 and 65535")
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_delay", c.Server.ShutdownDelay},
+	} {
+		if d.value < 0 {
+			problems = append(problems, d.name+" must not be negative")
+		}
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server.shutdown_timeout must be positive")
+	}
+	switch c.Database.Driver {
+	case "postgres":
+		problems = append(problems, c.Database.postgresProblems()...)
+	case "sqlite":
+		problems = append(problems, c.Database.SQLite.problems()...)
+		if len(c.Database.Replicas) > 0 {
+			problems = append(problems, "database.replicas require the postgres driver")
+		}
+	default:
+		problems = append(problems, "database.driver must be one of: "+strings.Join(databaseDrivers, ", "))
+	}
+	problems = append(problems, c.Database.Pool.problems()...)
+	if !slices.Contains(seedModes, c.Database.Seed.Mode) {
+		problems = append(problems, "database.seed.mode must be one of: "+strings.Join(seedModes, ", "))
+	}
+	if c.Database.ReadYourWrites < 0 {
+		problems = append(problems, "database.read_your_writes must not be negative")
+	}
+	if c.Database.RequestTimeout < 0 {
+		problems = append(problems, "database.request_timeout must not be negative")
+	}
+	res := c.Database.Resilience
+	if res.BreakerFailures < 1 {
+		problems = append(problems, "database.resilience.breaker_failures must be at least 1")
+	}
+	if res.BreakerOpenTimeout <= 0 {
+		problems = append(problems, "database.resilience.breaker_open_timeout must be positive")
+	}
+	if res.RetryAttempts < 1 {
+		problems = append(problems, "database.resilience.retry_attempts must be at least 1")
+	}
+	if res.RetryBaseDelay < 0 || res.RetryMaxDelay < res.RetryBaseDelay {
+		problems = append(problems, "database.resilience retry delays must satisfy 0 <= retry_base_delay <= retry_max_delay")
+	}
+	if c.Health.CheckTimeout <= 0 {
+		problems = append(problems, "health.check_timeout must be positive")
+	}
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		problems = append(problems, "metrics.path must start with /")
+	}
+	if !slices.Contains(tracingExporters, c.Tracing.Exporter) {
+		problems = append(problems, "tracing.exporter must be one of: "+strings.Join(tracingExporters, ", "))
+	}
+	if c.Tracing.Exporter == "otlp" && c.Tracing.OTLPEndpoint == "" {
+		problems = append(problems, "tracing.otlp_endpoint is required for the otlp exporter")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, "tracing.sample_ratio must be between 0 and 1")
+	}
+	if !slices.Contains(logLevels, c.Log.Level) {
+		problems = append(problems, "log.level must be one of: "+strings.Join(logLevels, ", "))
+	}
+	if !slices.Contains(logFormats, c.Log.Format) {
+		problems = append(problems, "log.format must be one of: "+strings.Join(logFormats, ", "))
+	}
+	if c.Log.SlowQueryThreshold < 0 {
+		problems = append(problems, "log.slow_query_threshold must not be negative")
+	}
+	for _, p := range c.Server.TrustedProxies {
+		if net.ParseIP(p) == nil {
+			if _, _, err := net.ParseCIDR(p); err != nil {
+				problems = append(problems, fmt.Sprintf("server.trusted_proxies: %q is not an IP or CIDR", p))
+			}
+		}
+	}
+	if c.RateLimit.Enabled {
+		for _, p := range []struct {
+			name   string
+			policy RateLimitPolicy
+		}{
+			{"rate_limit.read", c.RateLimit.Read},
+			{"rate_limit.write", c.RateLimit.Write},
+		} {
+			if p.policy.Rate <= 0 {
+				problems = append(problems, p.name+".rate must be positive")
+			}
+			if p.policy.Burst < 1 {
+				problems = append(problems, p.name+".burst must be at least 1")
+			}
+		}
+	}
+	if c.Cache.Enabled {
+		if c.Cache.TTL <= 0 {
+			problems = append(problems, "cache.ttl must be positive")
+		}
+		switch c.Cache.Backend {
+		case "memory":
+			if c.Cache.Size < 1 {
+				problems = append(problems, "cache.size must be at least 1")
+			}
+		case "redis":
+			if _, _, err := net.SplitHostPort(c.Cache.Redis.Addr); err != nil {
+				problems = append(problems, fmt.Sprintf("cache.redis.addr: %q is not host:port", c.Cache.Redis.Addr))
+			}
+			if c.Cache.Redis.DB < 0 {
+				problems = append(problems, "cache.redis.db must not be negative")
+			}
+		default:
+			problems = append(problems, "cache.backend must be one of: "+strings.Join(cacheBackends, ", "))
+		}
+	}
+	problems = append(problems, c.Events.problems()...)
+	problems = append(problems, c.Webhooks.problems()...)
+	problems = append(problems, c.Stream.problems()...)
+	if c.GRPC.Enabled {
+		if c.GRPC.Port < 1 || c.GRPC.Port > 65535 {
+			problems = append(problems, "grpc.port must be between 1 and 65535")
+		} else if c.GRPC.Port == c.Server.Port {
+			problems = append(problems, "grpc.port must differ from server.port")
+		}
+	}
+	if c.GraphQL.Enabled && c.GraphQL.ComplexityLimit < 1 {
+		problems = append(problems, "graphql.complexity_limit must be at least 1")
+	}
+	if c.GraphQL.Enabled && c.GraphQL.MaxBodyBytes < 1 {
+		problems = append(problems, "graphql.max_body_bytes must be at least 1")
+	}
+	for _, key := range c.Admin.APIKeys {
+		if len(key) < minAdminAPIKeyLength {
+			problems = append(problems, fmt.Sprintf("admin.api_keys must each be at least %d characters long", minAdminAPIKeyLength))
+			break
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// Redacted returns a copy of c with every secret masked.
+func (c *Config) Redacted() *Config {
+	clone := *c
+	for _, s := range clone.settings() {
+		if s.secret && s.value.String() != "" {
+			_ = s.value.Set(redacted)
+		}
+	}
+	return &clone
+}
+
+// Print writes the effective configuration as YAML with secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+type stringValue struct{ p *string }
+
+func (v stringValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return *v.p
+}
+
+func (v stringValue) Set(s string) error {
+	*v.p = s
+	return nil
+}
+
+type intValue struct{ p *int }
+
+func (v intValue) String() string {
+	if v.p == nil {
+		return "0"
+	}
+	return strconv.Itoa(*v.p)
+}
+
+func (v intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%q is not an integer", s)
+	}
+	*v.p = n
+	return nil
+}
+
+type durationValue struct{ p *time.Duration }
+
+func (v durationValue) String() string {
+	if v.p == nil {
+		return "0s"
+	}
+	return v.p.String()
+}
+
+func (v durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration", s)
+	}
+	*v.p = d
+	return nil
+}
+
+type boolValue struct{ p *bool }
+
+func (v boolValue) String() string {
+	if v.p == nil {
+		return "false"
+	}
+	return strconv.FormatBool(*v.p)
+}
+
+func (v boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a boolean", s)
+	}
+	*v.p = b
+	return nil
+}
+
+// IsBoolFlag lets the flag be passed without a value.
+func (v boolValue) IsBoolFlag() bool { return true }
+
+type floatValue struct{ p *float64 }
+
+func (v floatValue) String() string {
+	if v.p == nil {
+		return "0"
+	}
+	return strconv.FormatFloat(*v.p, 'g', -1, 64)
+}
+
+func (v floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", s)
+	}
+	*v.p = f
+	return nil
+}
+
+type listValue struct{ p *[]string }
+
+func (v listValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return strings.Join(*v.p, ",")
+}
+
+// Set splits a comma-separated list; an empty string clears it.
+func (v listValue) Set(s string) error {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*v.p = items
+	return nil
+}
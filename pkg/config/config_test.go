@@ -0,0 +1,317 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gymondo_dz/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var envVars = []string{
+	"CONFIG_FILE", "PORT", "DB_HOST", "DB_PORT", "DB_USER",
+	"DB_PASSWORD", "DB_NAME", "DB_SSL_MODE", "DB_TIMEZONE", "DB_DRIVER",
+	"ADMIN_API_KEYS",
+}
+
+// clearEnv unsets every variable Load reads; t.Setenv restores them.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range envVars {
+		for _, v := range []string{name, name + "_FILE"} {
+			t.Setenv(v, "")
+			require.NoError(t, os.Unsetenv(v))
+		}
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, config.Default(), cfg)
+}
+
+func TestLoadSQLiteIgnoresPostgresSettings(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_DRIVER", "sqlite")
+
+	cfg, err := config.Load([]string{"--db-host", "", "--db-sslmode", "sometimes", "--db-sqlite-path", "/var/lib/gymondo/data.db"})
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", cfg.Database.Driver)
+	assert.Equal(t, "/var/lib/gymondo/data.db", cfg.Database.SQLite.Path)
+}
+
+func TestReplicaDSNs(t *testing.T) {
+	cfg := config.Default().Database
+	cfg.Replicas = []string{"replica-1", "replica-2:5433"}
+	cfg.StatementCacheCapacity = 0
+
+	dsns := cfg.ReplicaDSNs()
+	require.Len(t, dsns, 2)
+	assert.Contains(t, dsns[0], "host=replica-1 ")
+	assert.Contains(t, dsns[0], "port=5432 ")
+	assert.Contains(t, dsns[1], "host=replica-2 ")
+	assert.Contains(t, dsns[1], "port=5433 ")
+	assert.Contains(t, dsns[1], "default_query_exec_mode=exec")
+	assert.NotContains(t, config.Default().Database.DSN(), "default_query_exec_mode")
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "config.yaml", `
+server:
+  port: 9000
+database:
+  host: file-host
+  user: file-user
+  name: file-db
+`)
+	t.Setenv("DB_HOST", "env-host")
+	t.Setenv("DB_USER", "env-user")
+	t.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.1, 192.168.0.0/16")
+
+	cfg, err := config.Load([]string{"--config", path, "--db-user", "flag-user"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 9000, cfg.Server.Port)
+	assert.Equal(t, "file-db", cfg.Database.Name)
+	assert.Equal(t, "env-host", cfg.Database.Host)
+	assert.Equal(t, "flag-user", cfg.Database.User)
+	assert.Equal(t, 5432, cfg.Database.Port)
+	assert.Equal(t, []string{"10.0.0.1", "192.168.0.0/16"}, cfg.Server.TrustedProxies)
+}
+
+func TestLoadConfigFileFromEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("CONFIG_FILE", writeFile(t, "config.yaml", "server:\n  port: 9100\n"))
+
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 9100, cfg.Server.Port)
+}
+
+func TestLoadSecretFromFile(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, "password", "s3cret\n"))
+
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", cfg.Database.Password)
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		contains []string
+	}{
+		{
+			name:     "Missing explicit config file",
+			args:     []string{"--config", filepath.Join(os.TempDir(), "does-not-exist.yaml")},
+			contains: []string{"failed to read config file"},
+		},
+		{
+			name:     "Unknown flag",
+			args:     []string{"--bogus"},
+			contains: []string{"invalid command line"},
+		},
+		{
+			name:     "Non-numeric env",
+			env:      map[string]string{"DB_PORT": "abc"},
+			contains: []string{"DB_PORT", `"abc" is not an integer`},
+		},
+		{
+			name:     "Value and file both set",
+			env:      map[string]string{"DB_PASSWORD": "a", "DB_PASSWORD_FILE": "/nonexistent"},
+			contains: []string{"both DB_PASSWORD and DB_PASSWORD_FILE are set"},
+		},
+		{
+			name: "Every invalid setting is reported",
+			args: []string{"--port", "0", "--db-host", "", "--db-sslmode", "sometimes", "--db-timezone", "Mars/Olympus"},
+			contains: []string{
+				"server.port must be between 1 and 65535",
+				"database.host is required",
+				"database.sslmode must be one of",
+				"database.timezone must be a valid IANA time zone",
+			},
+		},
+		{
+			name: "Invalid log settings",
+			env:  map[string]string{"LOG_LEVEL": "verbose", "LOG_FORMAT": "xml"},
+			args: []string{"--log-slow-query-threshold", "-1s"},
+			contains: []string{
+				"log.level must be one of: debug, info, warn, error",
+				"log.format must be one of: json, text",
+				"log.slow_query_threshold must not be negative",
+			},
+		},
+		{
+			name: "Invalid proxies and rate limits",
+			args: []string{"--trusted-proxies", "10.0.0.0/8, nope", "--rate-limit-write-rate", "0", "--rate-limit-read-burst", "0"},
+			contains: []string{
+				`server.trusted_proxies: "nope" is not an IP or CIDR`,
+				"rate_limit.read.burst must be at least 1",
+				"rate_limit.write.rate must be positive",
+			},
+		},
+		{
+			name:     "Invalid cache settings",
+			env:      map[string]string{"CACHE_TTL": "0s", "CACHE_SIZE": "0"},
+			contains: []string{"cache.ttl must be positive", "cache.size must be at least 1"},
+		},
+		{
+			name: "Invalid SQLite settings",
+			env:  map[string]string{"DB_DRIVER": "sqlite", "DB_HOST": ""},
+			args: []string{"--db-sqlite-path", "", "--db-sqlite-busy-timeout", "-1s", "--db-sqlite-journal-mode", "memory"},
+			contains: []string{
+				"database.sqlite.path is required",
+				"database.sqlite.busy_timeout must not be negative",
+				"database.sqlite.journal_mode must be one of: wal, delete, truncate",
+			},
+		},
+		{
+			name: "Invalid pool and replica settings",
+			env:  map[string]string{"DB_MAX_OPEN_CONNS": "5", "DB_MAX_IDLE_CONNS": "10", "DB_REPLICAS": "replica-1:5433, replica-2:none"},
+			args: []string{"--db-conn-max-lifetime", "-1m", "--db-statement-cache-capacity", "-1", "--db-read-your-writes", "-1s"},
+			contains: []string{
+				"database.pool.max_idle_conns must not exceed max_open_conns",
+				"database.pool connection lifetimes must not be negative",
+				"database.statement_cache_capacity must not be negative",
+				`database.replicas: "replica-2:none" is not a host or host:port`,
+				"database.read_your_writes must not be negative",
+			},
+		},
+		{
+			name:     "Replicas need Postgres",
+			env:      map[string]string{"DB_DRIVER": "sqlite", "DB_REPLICAS": "replica-1"},
+			contains: []string{"database.replicas require the postgres driver"},
+		},
+		{
+			name:     "Unknown seed mode",
+			env:      map[string]string{"SEED_PROFILE": "demo", "SEED_MODE": "replace"},
+			contains: []string{"database.seed.mode must be one of: insert, upsert"},
+		},
+		{
+			name: "Invalid event settings",
+			env:  map[string]string{"EVENTS_PUBLISHER": "kafka", "EVENTS_KAFKA_BROKERS": "kafka-1:9092, kafka-2"},
+			args: []string{"--events-kafka-topic", "", "--events-batch-size", "0", "--events-retry-max-delay", "1ms"},
+			contains: []string{
+				`events.kafka.brokers: "kafka-2" is not host:port`,
+				"events.kafka.topic is required for the kafka publisher",
+				"events.batch_size must be at least 1",
+				"events retry delays must satisfy 0 < retry_base_delay <= retry_max_delay",
+			},
+		},
+		{
+			name:     "Unknown event publisher",
+			env:      map[string]string{"EVENTS_PUBLISHER": "sns"},
+			contains: []string{"events.publisher must be one of: none, stdout, file, kafka"},
+		},
+		{
+			name: "Invalid webhook settings",
+			env:  map[string]string{"WEBHOOKS_ENABLED": "true", "WEBHOOKS_MAX_ATTEMPTS": "0"},
+			args: []string{"--webhooks-concurrency", "0", "--webhooks-disable-after", "0s"},
+			contains: []string{
+				"webhooks.max_attempts must be at least 1",
+				"webhooks.disable_after must be positive",
+				"webhooks.concurrency must be at least 1",
+			},
+		},
+		{
+			name: "Invalid stream settings",
+			env:  map[string]string{"STREAM_HEARTBEAT": "0s"},
+			args: []string{"--stream-buffer", "0"},
+			contains: []string{
+				"stream.heartbeat must be positive",
+				"stream.buffer must be at least 1",
+			},
+		},
+		{
+			name:     "gRPC port taken by the REST API",
+			env:      map[string]string{"GRPC_ENABLED": "true", "PORT": "9090"},
+			contains: []string{"grpc.port must differ from server.port"},
+		},
+		{
+			name:     "GraphQL without a complexity limit",
+			env:      map[string]string{"GRAPHQL_COMPLEXITY_LIMIT": "0"},
+			contains: []string{"graphql.complexity_limit must be at least 1"},
+		},
+		{
+			name:     "Short admin API key",
+			env:      map[string]string{"ADMIN_API_KEYS": "letmein"},
+			contains: []string{"admin.api_keys must each be at least 32 characters long"},
+		},
+		{
+			name:     "Unknown driver",
+			env:      map[string]string{"DB_DRIVER": "mysql"},
+			contains: []string{"database.driver must be one of: postgres, sqlite"},
+		},
+		{
+			name: "Invalid resilience settings",
+			env:  map[string]string{"DB_BREAKER_FAILURES": "0", "DB_RETRY_ATTEMPTS": "0"},
+			args: []string{"--db-breaker-open-timeout", "0s", "--db-retry-base-delay", "2s", "--db-retry-max-delay", "1s"},
+			contains: []string{
+				"database.resilience.breaker_failures must be at least 1",
+				"database.resilience.breaker_open_timeout must be positive",
+				"database.resilience.retry_attempts must be at least 1",
+				"retry_base_delay <= retry_max_delay",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := config.Load(tt.args)
+			require.Error(t, err)
+			assert.Nil(t, cfg)
+			for _, s := range tt.contains {
+				assert.Contains(t, err.Error(), s)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "config.yaml", "database:\n  hostname: typo\n")
+
+	_, err := config.Load([]string{"--config", path})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hostname")
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_PASSWORD", "s3cret")
+	t.Setenv("ADMIN_API_KEYS", "admin-key-0123456789abcdef0123456789")
+
+	cfg, err := config.Load([]string{"--print-config"})
+	require.NoError(t, err)
+	assert.True(t, cfg.PrintConfig)
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Print(&out))
+	assert.NotContains(t, out.String(), "s3cret")
+	assert.NotContains(t, out.String(), "admin-key-0123456789abcdef0123456789")
+	assert.Contains(t, out.String(), "password: '******'")
+	assert.Equal(t, "s3cret", cfg.Database.Password, "redaction must not modify the loaded config")
+}
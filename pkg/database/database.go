@@ -2,97 +2,105 @@ package database
 
 import (
 	"fmt"
-	"gymondo_dz/pkg/models"
-	"log"
-	"os"
+	"gymondo_dz/pkg/config"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
-func NewPostgresConnection() (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_SSL_MODE"),
-		os.Getenv("DB_TIMEZONE"),
-	)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// NewPostgresConnection opens the connection pool; statements are logged
+// through logger.
+func NewPostgresConnection(cfg config.DatabaseConfig, logger gormlogger.Interface) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{Logger: logger})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	if err := sizePool(db, cfg.Pool); err != nil {
+		return nil, err
+	}
 
-	log.Println("Connected to PostgreSQL database")
+	slog.Info("Connected to PostgreSQL database", "host", cfg.Host, "database", cfg.Name)
 	return db, nil
 }
 
-func AutoMigrate(db *gorm.DB, isTest bool) error {
-	if isTest {
-		// clean slate test
-		db.Exec("DROP TABLE IF EXISTS subscriptions")
-		db.Exec("DROP TABLE IF EXISTS products")
-		// SQLite-specific schema
-		err := db.Exec(`
-            CREATE TABLE IF NOT EXISTS products (
-                id TEXT PRIMARY KEY,
-                name TEXT NOT NULL,
-                description TEXT,
-                price REAL NOT NULL,
-				tax_rate REAL NOT NULL DEFAULT 0.10,
-                duration INTEGER NOT NULL,
-                created_at DATETIME,
-                updated_at DATETIME,
-                deleted_at DATETIME
-            )
-        `).Error
-		if err != nil {
-			return fmt.Errorf("failed to create products table: %w", err)
-		}
-
-		err = db.Exec(`
-    CREATE TABLE IF NOT EXISTS subscriptions (
-        id TEXT PRIMARY KEY,
-        user_id TEXT NOT NULL,
-        product_id TEXT NOT NULL,
-        start_date DATETIME NOT NULL,
-        end_date DATETIME NOT NULL,
-        status TEXT NOT NULL DEFAULT 'active',
-		version INTEGER NOT NULL DEFAULT 1,
-        paused_at DATETIME,
-        cancelled_at DATETIME,
-        created_at DATETIME,
-        updated_at DATETIME,
-        deleted_at DATETIME,
-        FOREIGN KEY (product_id) REFERENCES products(id)
-    )
-`).Error
-
-		return err
+// NewSQLiteConnection opens the SQLite database file named in cfg,
+// creating it if needed; statements are logged through logger.
+//
+// SQLite has no row locks, so every transaction begins IMMEDIATE: it takes
+// the database write lock up front, which serializes read-modify-write
+// transactions the way SELECT ... FOR UPDATE does on Postgres. Waiting
+// writers retry for up to cfg.BusyTimeout before failing.
+func NewSQLiteConnection(cfg config.SQLiteConfig, logger gormlogger.Interface) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(sqliteDSN(cfg)), &gorm.Config{Logger: logger})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// PostgreSQL migrations
-	return db.AutoMigrate(&models.Product{}, &models.Subscription{})
+	slog.Info("Opened SQLite database", "path", cfg.Path, "journal_mode", cfg.JournalMode)
+	return db, nil
 }
 
-func InitializeDB(db *gorm.DB, isTest bool) error {
-	if err := AutoMigrate(db, isTest); err != nil {
-		return fmt.Errorf("failed to auto-migrate: %w", err)
+func sqliteDSN(cfg config.SQLiteConfig) string {
+	params := url.Values{
+		"_busy_timeout": {strconv.FormatInt(cfg.BusyTimeout.Milliseconds(), 10)},
+		"_foreign_keys": {"1"},
+		"_txlock":       {"immediate"},
+	}
+	if cfg.JournalMode != "" {
+		params.Set("_journal_mode", cfg.JournalMode)
+	}
+	if cfg.JournalMode == "wal" {
+		// fsync at checkpoints only; a crash can lose the last commits but
+		// never corrupts the database
+		params.Set("_synchronous", "NORMAL")
 	}
 
-	if !isTest {
-		if err := SeedProducts(db); err != nil {
-			return fmt.Errorf("failed to seed products: %w", err)
-		}
+	sep := "?"
+	if strings.Contains(cfg.Path, "?") {
+		sep = "&"
+	}
+	return cfg.Path + sep + params.Encode()
+}
 
-		if err := SeedSubscriptions(db); err != nil {
-			return fmt.Errorf("failed to seed subscriptions: %w", err)
+// Open connects to the backend selected by cfg.Driver.
+func Open(cfg config.DatabaseConfig, logger gormlogger.Interface) (*gorm.DB, error) {
+	switch cfg.Driver {
+	case "sqlite":
+		db, err := NewSQLiteConnection(cfg.SQLite, logger)
+		if err != nil {
+			return nil, err
 		}
+		return db, sizePool(db, cfg.Pool)
+	case "postgres":
+		return NewPostgresConnection(cfg, logger)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
 	}
+}
 
+func sizePool(db *gorm.DB, cfg config.PoolConfig) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 	return nil
 }
+
+// Close releases the connection pool behind db.
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,68 @@
+package database_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestNewSQLiteConnection(t *testing.T) {
+	db, err := database.NewSQLiteConnection(config.SQLiteConfig{
+		Path:        filepath.Join(t.TempDir(), "gymondo.db"),
+		BusyTimeout: 5 * time.Second,
+		JournalMode: "wal",
+	}, gormlogger.Discard)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+
+	pragmas := map[string]string{"journal_mode": "wal", "busy_timeout": "5000", "foreign_keys": "1"}
+	for name, want := range pragmas {
+		var got string
+		require.NoError(t, db.Raw("PRAGMA "+name).Scan(&got).Error)
+		assert.Equal(t, want, got, name)
+	}
+
+	t.Run("transactions take the write lock up front", func(t *testing.T) {
+		require.NoError(t, db.Exec("CREATE TABLE counter (n INTEGER)").Error)
+		require.NoError(t, db.Exec("INSERT INTO counter VALUES (0)").Error)
+
+		// A read-modify-write racing the open transaction below waits at
+		// BEGIN rather than reading a value about to change, which would
+		// fail its write with "database is locked".
+		tx := db.Begin()
+		require.NoError(t, tx.Error)
+		var n int
+		require.NoError(t, tx.Raw("SELECT n FROM counter").Scan(&n).Error)
+
+		done := make(chan error)
+		go func() {
+			other := db.Begin()
+			var n int
+			if err := other.Raw("SELECT n FROM counter").Scan(&n).Error; err != nil {
+				done <- err
+				return
+			}
+			if err := other.Exec("UPDATE counter SET n = ?", n+1).Error; err != nil {
+				other.Rollback()
+				done <- err
+				return
+			}
+			done <- other.Commit().Error
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, tx.Exec("UPDATE counter SET n = ?", n+1).Error)
+		require.NoError(t, tx.Commit().Error)
+		require.NoError(t, <-done)
+
+		require.NoError(t, db.Raw("SELECT n FROM counter").Scan(&n).Error)
+		assert.Equal(t, 2, n, "neither increment is lost")
+	})
+}
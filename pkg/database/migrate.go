@@ -0,0 +1,297 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"gymondo_dz/pkg/logging"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationLockID keys the Postgres advisory lock held while migrating.
+var migrationLockID = int64(crc32.ChecksumIEEE([]byte("gymondo_dz:schema_migrations")))
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change, with the SQL that applies
+// and reverts it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// String returns the file name stem, e.g. 0001_initial_schema.
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Migrator applies the migrations embedded for the database's dialect
+// (migrations/postgres or migrations/sqlite). Each migration runs in a
+// transaction together with the update of schema_migrations, so a failed
+// migration leaves no trace. On Postgres an advisory lock keeps
+// concurrent migrators (e.g. several instances starting at once) apart;
+// SQLite serializes writers itself.
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Migrate brings the schema of db up to date.
+func Migrate(ctx context.Context, db *gorm.DB) error {
+	m, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	return m.Up(ctx)
+}
+
+func loadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %q", dialect)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s/%s", dir, entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		raw, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files named both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(raw)
+		} else {
+			m.Down = string(raw)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Version == 0 || m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %s needs a version above 0 and both an up and a down file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+// Migrations lists the known migrations, oldest first.
+func (m *Migrator) Migrations() []Migration {
+	return slices.Clone(m.migrations)
+}
+
+// Latest is the version the schema has once every migration is applied.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version reports the current schema version, 0 if nothing is applied.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	db := m.db.WithContext(ctx)
+	if err := createMigrationsTable(db); err != nil {
+		return 0, err
+	}
+	return currentVersion(db)
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down reverts the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	current, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return errors.New("no migration to revert")
+	}
+	i := m.index(current)
+	if i < 0 {
+		return fmt.Errorf("schema version %d is unknown to this build", current)
+	}
+	previous := 0
+	if i > 0 {
+		previous = m.migrations[i-1].Version
+	}
+	return m.To(ctx, previous)
+}
+
+// To applies or reverts migrations, one transaction each, until the
+// schema is at version; 0 reverts everything.
+func (m *Migrator) To(ctx context.Context, version int) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.locked(ctx, func(conn *gorm.DB) error {
+		if err := createMigrationsTable(conn); err != nil {
+			return err
+		}
+		for {
+			done, err := m.step(conn, version)
+			if err != nil || done {
+				return err
+			}
+		}
+	})
+}
+
+// step applies or reverts the one migration that moves the schema
+// towards target. The version is read inside the transaction, so the
+// step is skipped if someone else got there first.
+func (m *Migrator) step(conn *gorm.DB, target int) (done bool, err error) {
+	err = conn.Transaction(func(tx *gorm.DB) error {
+		current, err := currentVersion(tx)
+		if err != nil {
+			return err
+		}
+		if current == target {
+			done = true
+			return nil
+		}
+
+		var migration Migration
+		var sql string
+		next := 0
+		if current < target {
+			i := slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version > current })
+			migration, sql, next = m.migrations[i], m.migrations[i].Up, m.migrations[i].Version
+		} else {
+			i := m.index(current)
+			if i < 0 {
+				return fmt.Errorf("schema version %d is unknown to this build", current)
+			}
+			migration, sql = m.migrations[i], m.migrations[i].Down
+			if i > 0 {
+				next = m.migrations[i-1].Version
+			}
+		}
+
+		if err := tx.Exec(sql).Error; err != nil {
+			return fmt.Errorf("migration %s failed: %w", migration, err)
+		}
+		if err := setVersion(tx, next); err != nil {
+			return err
+		}
+		ctx := tx.Statement.Context
+		logging.FromContext(ctx).InfoContext(ctx, "Migrated database schema",
+			"from", current, "to", next, "migration", migration.String())
+		return nil
+	})
+	return done, err
+}
+
+func (m *Migrator) index(version int) int {
+	return slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version == version })
+}
+
+// locked runs fn on a single connection, holding the migration lock on
+// Postgres for the duration.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if m.dialect != "postgres" {
+			return fn(conn)
+		}
+
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer func() {
+			// unlock even if ctx is done, or the lock lives as long as the connection
+			unlock := conn.WithContext(context.WithoutCancel(ctx))
+			if err := unlock.Exec("SELECT pg_advisory_unlock(?)", migrationLockID).Error; err != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "Failed to release migration lock", "error", err)
+			}
+		}()
+		return fn(conn)
+	})
+}
+
+// createMigrationsTable creates schema_migrations unless it exists. Tables
+// created by earlier builds carry a dirty column, which is dropped: every
+// migration is transactional, so a schema is never left half-migrated.
+func createMigrationsTable(db *gorm.DB) error {
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY
+	)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	if db.Migrator().HasColumn(&schemaMigration{}, "dirty") {
+		if err := db.Exec("ALTER TABLE schema_migrations DROP COLUMN dirty").Error; err != nil {
+			return fmt.Errorf("failed to drop schema_migrations.dirty: %w", err)
+		}
+	}
+	return nil
+}
+
+func currentVersion(db *gorm.DB) (int, error) {
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return 0, err
+	}
+	switch len(rows) {
+	case 0:
+		return 0, nil
+	case 1:
+		return rows[0].Version, nil
+	default:
+		return 0, fmt.Errorf("schema_migrations holds %d rows, expected at most one", len(rows))
+	}
+}
+
+// setVersion records version as the only row; 0 leaves the table empty.
+func setVersion(tx *gorm.DB, version int) error {
+	if err := tx.Where("1 = 1").Delete(&schemaMigration{}).Error; err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+	return tx.Create(&schemaMigration{Version: version}).Error
+}
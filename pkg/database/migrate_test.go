@@ -0,0 +1,118 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"gymondo_dz/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrationsMatchSchemaVersion(t *testing.T) {
+	pg, err := gorm.Open(postgres.Open("host=localhost"), &gorm.Config{DisableAutomaticPing: true})
+	require.NoError(t, err)
+	lite, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	var names [][]string
+	for _, db := range []*gorm.DB{pg, lite} {
+		m, err := database.NewMigrator(db)
+		require.NoError(t, err)
+		assert.Equal(t, database.SchemaVersion, m.Latest(), db.Dialector.Name())
+
+		var dialect []string
+		for _, migration := range m.Migrations() {
+			dialect = append(dialect, migration.String())
+		}
+		names = append(names, dialect)
+	}
+	assert.Equal(t, names[0], names[1], "every dialect has the same migrations")
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	m, err := database.NewMigrator(db)
+	require.NoError(t, err)
+
+	version := func() int {
+		t.Helper()
+		v, err := m.Version(ctx)
+		require.NoError(t, err)
+		return v
+	}
+	hasIndex := func() bool {
+		return db.Migrator().HasIndex("subscriptions", "idx_subscriptions_user_id")
+	}
+
+	assert.Equal(t, 0, version())
+	assert.EqualError(t, m.Down(ctx), "no migration to revert")
+
+	require.NoError(t, m.To(ctx, 1))
+	assert.Equal(t, 1, version())
+	assert.True(t, db.Migrator().HasTable("subscriptions"))
+	assert.False(t, hasIndex())
+
+	require.NoError(t, m.Up(ctx))
+	require.NoError(t, m.Up(ctx), "nothing left to apply")
+	assert.Equal(t, database.SchemaVersion, version())
+	assert.True(t, hasIndex())
+	assert.True(t, db.Migrator().HasTable("outbox_events"))
+	assert.True(t, db.Migrator().HasTable("webhook_attempts"))
+	assert.False(t, db.Migrator().HasColumn("webhook_attempts", "response_body"))
+	assert.True(t, db.Migrator().HasColumn("webhook_endpoints", "product_ids"))
+	assert.NoError(t, database.CheckSchemaVersion(ctx, db))
+
+	require.NoError(t, m.Down(ctx))
+	assert.Equal(t, 5, version())
+	assert.False(t, db.Migrator().HasColumn("webhook_endpoints", "product_ids"))
+
+	require.NoError(t, m.Down(ctx))
+	assert.Equal(t, 4, version())
+	assert.True(t, db.Migrator().HasColumn("webhook_attempts", "response_body"))
+
+	require.NoError(t, m.Down(ctx))
+	assert.Equal(t, 3, version())
+	assert.False(t, db.Migrator().HasTable("webhook_endpoints"))
+	assert.True(t, db.Migrator().HasTable("outbox_events"))
+
+	require.NoError(t, m.Down(ctx))
+	assert.Equal(t, 2, version())
+	assert.False(t, db.Migrator().HasTable("outbox_events"))
+	assert.True(t, hasIndex())
+
+	require.NoError(t, m.Down(ctx))
+	assert.Equal(t, 1, version())
+	assert.False(t, hasIndex())
+
+	require.NoError(t, m.To(ctx, 0))
+	assert.Equal(t, 0, version())
+	assert.False(t, db.Migrator().HasTable("products"))
+
+	assert.EqualError(t, m.To(ctx, 99), "unknown migration version 99")
+
+	t.Run("The dirty column of earlier builds is dropped", func(t *testing.T) {
+		require.NoError(t, db.Exec("ALTER TABLE schema_migrations ADD COLUMN dirty BOOLEAN NOT NULL DEFAULT FALSE").Error)
+		assert.Equal(t, 0, version())
+		assert.False(t, db.Migrator().HasColumn("schema_migrations", "dirty"))
+	})
+
+	t.Run("Failed migrations leave no trace", func(t *testing.T) {
+		require.NoError(t, m.To(ctx, 1))
+		require.NoError(t, db.Exec("CREATE INDEX idx_subscriptions_user_id ON subscriptions (user_id)").Error)
+		assert.ErrorContains(t, m.Up(ctx), "migration 0002_subscription_lookup_indexes failed")
+		assert.Equal(t, 1, version())
+		require.NoError(t, db.Exec("DROP INDEX idx_subscriptions_user_id").Error)
+	})
+
+	t.Run("Versions newer than the build are refused", func(t *testing.T) {
+		require.NoError(t, db.Exec("UPDATE schema_migrations SET version = ?", 99).Error)
+		assert.EqualError(t, m.Up(ctx), "schema version 99 is unknown to this build")
+	})
+}
@@ -0,0 +1,49 @@
+-- The schema GORM AutoMigrate used to create. IF NOT EXISTS lets this
+-- adopt databases created before migrations were versioned.
+CREATE TABLE IF NOT EXISTS products (
+    id          uuid PRIMARY KEY,
+    name        varchar(100) NOT NULL,
+    description varchar(255),
+    price       decimal(10,2) NOT NULL,
+    tax_rate    decimal(5,2) DEFAULT 0.10,
+    currency    varchar(3) NOT NULL DEFAULT 'EUR',
+    duration    bigint NOT NULL,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
+
+-- backs the product full-text search
+CREATE INDEX IF NOT EXISTS idx_products_search ON products
+    USING GIN (to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(description, '')));
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id           uuid PRIMARY KEY,
+    user_id      uuid NOT NULL,
+    product_id   uuid NOT NULL,
+    start_date   timestamptz NOT NULL,
+    end_date     timestamptz NOT NULL,
+    status       varchar(20) NOT NULL DEFAULT 'active',
+    paused_at    timestamptz,
+    cancelled_at timestamptz,
+    created_at   timestamptz,
+    updated_at   timestamptz,
+    deleted_at   timestamptz,
+    version      bigint DEFAULT 1,
+    CONSTRAINT fk_subscriptions_product FOREIGN KEY (product_id)
+        REFERENCES products (id) ON UPDATE CASCADE ON DELETE RESTRICT
+);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_paused_at ON subscriptions (paused_at);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_cancelled_at ON subscriptions (cancelled_at);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_deleted_at ON subscriptions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS product_translations (
+    product_id  uuid NOT NULL,
+    locale      varchar(35) NOT NULL,
+    name        varchar(100) NOT NULL,
+    description varchar(255),
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    PRIMARY KEY (product_id, locale)
+);
@@ -0,0 +1,21 @@
+-- Subscription events, written in the transaction of the change they
+-- describe and relayed to the broker in position order. sequence is the
+-- subscription version the change produced, so (aggregate_id, sequence)
+-- orders the events of one subscription.
+CREATE TABLE outbox_events (
+    position        bigserial PRIMARY KEY,
+    id              uuid NOT NULL UNIQUE,
+    aggregate_id    uuid NOT NULL,
+    sequence        bigint NOT NULL,
+    type            varchar(64) NOT NULL,
+    payload         text NOT NULL,
+    created_at      timestamptz NOT NULL,
+    attempts        integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL,
+    last_error      text,
+    published_at    timestamptz,
+    CONSTRAINT uq_outbox_events_aggregate_sequence UNIQUE (aggregate_id, sequence)
+);
+-- the relay only ever scans unpublished events
+CREATE INDEX idx_outbox_events_pending ON outbox_events (position) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_published_at ON outbox_events (published_at);
@@ -0,0 +1,54 @@
+-- Partner webhook endpoints. An empty event_types array subscribes to
+-- every event type. failing_since is when the current run of failed
+-- deliveries began; a success clears it.
+CREATE TABLE webhook_endpoints (
+    id              uuid PRIMARY KEY,
+    url             varchar(2048) NOT NULL,
+    description     varchar(255),
+    secret          varchar(64) NOT NULL,
+    event_types     text NOT NULL DEFAULT '[]',
+    enabled         boolean NOT NULL DEFAULT true,
+    disabled_at     timestamptz,
+    disabled_reason varchar(255),
+    failing_since   timestamptz,
+    created_at      timestamptz NOT NULL,
+    updated_at      timestamptz NOT NULL
+);
+
+-- One delivery per endpoint and event; retries and redeliveries reuse it.
+CREATE TABLE webhook_deliveries (
+    id                 uuid PRIMARY KEY,
+    endpoint_id        uuid NOT NULL,
+    event_id           uuid NOT NULL,
+    event_type         varchar(64) NOT NULL,
+    payload            text NOT NULL,
+    status             varchar(20) NOT NULL DEFAULT 'pending',
+    attempts           integer NOT NULL DEFAULT 0,
+    next_attempt_at    timestamptz NOT NULL,
+    last_attempt_at    timestamptz,
+    last_response_code integer,
+    last_error         text,
+    delivered_at       timestamptz,
+    created_at         timestamptz NOT NULL,
+    updated_at         timestamptz NOT NULL,
+    CONSTRAINT uq_webhook_deliveries_endpoint_event UNIQUE (endpoint_id, event_id),
+    CONSTRAINT fk_webhook_deliveries_endpoint FOREIGN KEY (endpoint_id)
+        REFERENCES webhook_endpoints (id) ON DELETE CASCADE
+);
+-- the dispatcher only ever scans pending deliveries
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_endpoint ON webhook_deliveries (endpoint_id, created_at);
+
+-- The delivery log: every attempt with what the endpoint answered.
+CREATE TABLE webhook_attempts (
+    id            bigserial PRIMARY KEY,
+    delivery_id   uuid NOT NULL,
+    attempted_at  timestamptz NOT NULL,
+    response_code integer,
+    response_body text,
+    error         text,
+    duration_ms   bigint NOT NULL,
+    CONSTRAINT fk_webhook_attempts_delivery FOREIGN KEY (delivery_id)
+        REFERENCES webhook_deliveries (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_webhook_attempts_delivery ON webhook_attempts (delivery_id);
@@ -0,0 +1,3 @@
+-- The delivery log keeps only the status code of an answer; the body
+-- could be anything the endpoint's network reaches.
+ALTER TABLE webhook_attempts DROP COLUMN response_body;
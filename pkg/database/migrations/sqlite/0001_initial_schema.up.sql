@@ -0,0 +1,45 @@
+-- Mirrors the Postgres schema; product search falls back to LIKE, so
+-- there is no search index.
+CREATE TABLE products (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    description TEXT,
+    price       REAL NOT NULL,
+    tax_rate    REAL DEFAULT 0.10,
+    currency    TEXT NOT NULL DEFAULT 'EUR',
+    duration    INTEGER NOT NULL,
+    created_at  DATETIME,
+    updated_at  DATETIME,
+    deleted_at  DATETIME
+);
+CREATE INDEX idx_products_deleted_at ON products (deleted_at);
+
+CREATE TABLE subscriptions (
+    id           TEXT PRIMARY KEY,
+    user_id      TEXT NOT NULL,
+    product_id   TEXT NOT NULL,
+    start_date   DATETIME NOT NULL,
+    end_date     DATETIME NOT NULL,
+    status       TEXT NOT NULL DEFAULT 'active',
+    paused_at    DATETIME,
+    cancelled_at DATETIME,
+    created_at   DATETIME,
+    updated_at   DATETIME,
+    deleted_at   DATETIME,
+    version      INTEGER DEFAULT 1,
+    CONSTRAINT fk_subscriptions_product FOREIGN KEY (product_id)
+        REFERENCES products (id) ON UPDATE CASCADE ON DELETE RESTRICT
+);
+CREATE INDEX idx_subscriptions_paused_at ON subscriptions (paused_at);
+CREATE INDEX idx_subscriptions_cancelled_at ON subscriptions (cancelled_at);
+CREATE INDEX idx_subscriptions_deleted_at ON subscriptions (deleted_at);
+
+CREATE TABLE product_translations (
+    product_id  TEXT NOT NULL,
+    locale      TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    description TEXT,
+    created_at  DATETIME,
+    updated_at  DATETIME,
+    PRIMARY KEY (product_id, locale)
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_subscriptions_product_id_status;
+DROP INDEX IF EXISTS idx_subscriptions_user_id;
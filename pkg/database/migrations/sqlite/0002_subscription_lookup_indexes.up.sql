@@ -0,0 +1,4 @@
+-- subscription listings filter by user, product and status; the active
+-- subscriptions gauge counts by product and status
+CREATE INDEX idx_subscriptions_user_id ON subscriptions (user_id);
+CREATE INDEX idx_subscriptions_product_id_status ON subscriptions (product_id, status);
@@ -0,0 +1,16 @@
+CREATE TABLE outbox_events (
+    position        INTEGER PRIMARY KEY AUTOINCREMENT,
+    id              TEXT NOT NULL UNIQUE,
+    aggregate_id    TEXT NOT NULL,
+    sequence        INTEGER NOT NULL,
+    type            TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    created_at      DATETIME NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error      TEXT,
+    published_at    DATETIME,
+    CONSTRAINT uq_outbox_events_aggregate_sequence UNIQUE (aggregate_id, sequence)
+);
+CREATE INDEX idx_outbox_events_pending ON outbox_events (position) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_published_at ON outbox_events (published_at);
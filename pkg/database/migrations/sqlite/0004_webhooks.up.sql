@@ -0,0 +1,48 @@
+CREATE TABLE webhook_endpoints (
+    id              TEXT PRIMARY KEY,
+    url             TEXT NOT NULL,
+    description     TEXT,
+    secret          TEXT NOT NULL,
+    event_types     TEXT NOT NULL DEFAULT '[]',
+    enabled         BOOLEAN NOT NULL DEFAULT 1,
+    disabled_at     DATETIME,
+    disabled_reason TEXT,
+    failing_since   DATETIME,
+    created_at      DATETIME NOT NULL,
+    updated_at      DATETIME NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+    id                 TEXT PRIMARY KEY,
+    endpoint_id        TEXT NOT NULL,
+    event_id           TEXT NOT NULL,
+    event_type         TEXT NOT NULL,
+    payload            TEXT NOT NULL,
+    status             TEXT NOT NULL DEFAULT 'pending',
+    attempts           INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at    DATETIME NOT NULL,
+    last_attempt_at    DATETIME,
+    last_response_code INTEGER,
+    last_error         TEXT,
+    delivered_at       DATETIME,
+    created_at         DATETIME NOT NULL,
+    updated_at         DATETIME NOT NULL,
+    CONSTRAINT uq_webhook_deliveries_endpoint_event UNIQUE (endpoint_id, event_id),
+    CONSTRAINT fk_webhook_deliveries_endpoint FOREIGN KEY (endpoint_id)
+        REFERENCES webhook_endpoints (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_endpoint ON webhook_deliveries (endpoint_id, created_at);
+
+CREATE TABLE webhook_attempts (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    delivery_id   TEXT NOT NULL,
+    attempted_at  DATETIME NOT NULL,
+    response_code INTEGER,
+    response_body TEXT,
+    error         TEXT,
+    duration_ms   INTEGER NOT NULL,
+    CONSTRAINT fk_webhook_attempts_delivery FOREIGN KEY (delivery_id)
+        REFERENCES webhook_deliveries (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_webhook_attempts_delivery ON webhook_attempts (delivery_id);
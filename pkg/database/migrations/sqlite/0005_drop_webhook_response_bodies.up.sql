@@ -0,0 +1 @@
+ALTER TABLE webhook_attempts DROP COLUMN response_body;
@@ -0,0 +1 @@
+ALTER TABLE webhook_endpoints DROP COLUMN product_ids;
@@ -0,0 +1,4 @@
+-- Endpoints only receive the events about subscriptions to the products
+-- they list. Endpoints registered before have none, and receive nothing
+-- until they are given their partner's products.
+ALTER TABLE webhook_endpoints ADD COLUMN product_ids TEXT NOT NULL DEFAULT '[]';
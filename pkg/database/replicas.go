@@ -0,0 +1,72 @@
+package database
+
+import (
+	"log/slog"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/reqctx"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// UseReplicas spreads the queries db runs outside transactions over the
+// replicas in cfg; writes, transactions and locking reads stay on the
+// primary, as do all reads for a context marked with
+// reqctx.WithPrimaryReads. Without replicas db is left as it is.
+//
+// Register replicas after migrating and seeding, which must see the
+// primary only.
+func UseReplicas(db *gorm.DB, cfg config.DatabaseConfig) error {
+	if len(cfg.Replicas) == 0 {
+		return nil
+	}
+
+	replicas := make([]gorm.Dialector, len(cfg.Replicas))
+	for i, dsn := range cfg.ReplicaDSNs() {
+		replicas[i] = postgres.Open(dsn)
+	}
+	if err := RouteReads(db, cfg.Pool, replicas...); err != nil {
+		return err
+	}
+
+	slog.Info("Routing reads to replicas", "replicas", cfg.Replicas)
+	return nil
+}
+
+// RouteReads is UseReplicas for replicas reached through any dialector;
+// each replica gets a pool sized by pool.
+func RouteReads(db *gorm.DB, pool config.PoolConfig, replicas ...gorm.Dialector) error {
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}).
+		SetMaxOpenConns(pool.MaxOpenConns).
+		SetMaxIdleConns(pool.MaxIdleConns).
+		SetConnMaxLifetime(pool.ConnMaxLifetime).
+		SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	if err := db.Use(resolver); err != nil {
+		return err
+	}
+
+	// runs just before each read executes; marking the statement as a
+	// write makes the resolver switch it to the primary again
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Query().Before("gorm:query").Register("gymondo:primary_reads", primaryReads),
+		callbacks.Row().Before("gorm:row").Register("gymondo:primary_reads", primaryReads),
+		callbacks.Raw().Before("gorm:raw").Register("gymondo:primary_reads", primaryReads),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func primaryReads(db *gorm.DB) {
+	if ctx := db.Statement.Context; ctx != nil && reqctx.PrimaryReads(ctx) {
+		dbresolver.Write.ModifyStatement(db.Statement)
+	}
+}
@@ -0,0 +1,56 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/database"
+	"gymondo_dz/pkg/reqctx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRouteReads(t *testing.T) {
+	dir := t.TempDir()
+	open := func(name string) *gorm.DB {
+		db, err := gorm.Open(sqlite.Open(filepath.Join(dir, name)), &gorm.Config{})
+		require.NoError(t, err)
+		require.NoError(t, db.Exec("CREATE TABLE origin (name TEXT)").Error)
+		require.NoError(t, db.Exec("INSERT INTO origin VALUES (?)", name).Error)
+		return db
+	}
+	db := open("primary")
+	replica := open("replica")
+	require.NoError(t, database.Close(replica))
+
+	require.NoError(t, database.RouteReads(db, config.PoolConfig{MaxOpenConns: 2},
+		sqlite.Open(filepath.Join(dir, "replica"))))
+
+	origin := func(db *gorm.DB) string {
+		var name string
+		require.NoError(t, db.Table("origin").Select("name").Limit(1).Scan(&name).Error)
+		return name
+	}
+	ctx := context.Background()
+
+	assert.Equal(t, "replica", origin(db.WithContext(ctx)), "reads go to the replica")
+	assert.Equal(t, "primary", origin(db.WithContext(reqctx.WithPrimaryReads(ctx))), "unless the context asks for the primary")
+
+	var raw string
+	require.NoError(t, db.WithContext(reqctx.WithPrimaryReads(ctx)).Raw("SELECT name FROM origin").Scan(&raw).Error)
+	assert.Equal(t, "primary", raw, "raw reads too")
+
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		assert.Equal(t, "primary", origin(tx), "transactions stay on the primary")
+		return nil
+	}))
+
+	require.NoError(t, db.Exec("UPDATE origin SET name = ?", "written").Error)
+	assert.Equal(t, "written", origin(db.WithContext(reqctx.WithPrimaryReads(ctx))), "writes go to the primary")
+	assert.Equal(t, "replica", origin(db))
+}
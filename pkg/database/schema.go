@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// SchemaVersion is the schema version this build expects: the version
+// of the newest migration in migrations/.
+const SchemaVersion = 6
+
+// schemaMigration is the single row of schema_migrations: the version
+// of the newest migration applied.
+type schemaMigration struct {
+	Version int `gorm:"primaryKey;autoIncrement:false"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// CheckSchemaVersion fails unless the database schema is at SchemaVersion.
+// It asks the primary, never a replica.
+func CheckSchemaVersion(ctx context.Context, db *gorm.DB) error {
+	var current schemaMigration
+	if err := db.WithContext(ctx).Clauses(dbresolver.Write).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("no schema version recorded")
+		}
+		return err
+	}
+	if current.Version != SchemaVersion {
+		return fmt.Errorf("schema version is %d, expected %d", current.Version, SchemaVersion)
+	}
+	return nil
+}
+
+// Ping checks that a connection to the database can be established.
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
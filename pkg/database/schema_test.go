@@ -0,0 +1,31 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"gymondo_dz/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCheckSchemaVersion(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, database.Ping(ctx, db))
+	assert.Error(t, database.CheckSchemaVersion(ctx, db), "no schema_migrations table yet")
+
+	require.NoError(t, database.Migrate(context.Background(), db))
+	assert.NoError(t, database.CheckSchemaVersion(ctx, db))
+
+	require.NoError(t, db.Exec("UPDATE schema_migrations SET version = ?", database.SchemaVersion+1).Error)
+	assert.EqualError(t, database.CheckSchemaVersion(ctx, db), "schema version is 7, expected 6")
+
+	require.NoError(t, database.Close(db))
+	assert.Error(t, database.Ping(ctx, db))
+}
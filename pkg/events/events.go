@@ -0,0 +1,157 @@
+// Package events defines the subscription events other services consume
+// and the publishers that deliver them.
+//
+// Every event is a JSON envelope described by the JSON Schema in
+// schema/subscription-event.v<N>.json. Fields are only ever added within
+// a schema version; removing or changing one bumps SchemaVersion.
+// Consumers should ignore fields they do not know and may use
+// (subject, sequence) to drop duplicates, which at-least-once delivery
+// produces on retries.
+package events
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gymondo_dz/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// SchemaVersion is the version of the envelope and data this build
+// writes.
+const SchemaVersion = 1
+
+// Source names this service in every event.
+const Source = "gymondo"
+
+//go:embed schema
+var schemas embed.FS
+
+// Schema returns the JSON Schema of the given version.
+func Schema(version int) ([]byte, error) {
+	return schemas.ReadFile(fmt.Sprintf("schema/subscription-event.v%d.json", version))
+}
+
+// Event types.
+const (
+	TypeSubscriptionCreated   = "subscription.created"
+	TypeSubscriptionPaused    = "subscription.paused"
+	TypeSubscriptionUnpaused  = "subscription.unpaused"
+	TypeSubscriptionCancelled = "subscription.cancelled"
+	TypeSubscriptionExpired   = "subscription.expired"
+)
+
+// Types lists every event type.
+var Types = []string{
+	TypeSubscriptionCreated,
+	TypeSubscriptionPaused,
+	TypeSubscriptionUnpaused,
+	TypeSubscriptionCancelled,
+	TypeSubscriptionExpired,
+}
+
+// Event is the envelope every event is published in. Subject is the
+// subscription the event is about and Sequence the subscription version
+// the change produced, so a subscription's events are numbered 1, 2, ...
+// in the order they happened.
+type Event struct {
+	ID            uuid.UUID        `json:"id"`
+	Type          string           `json:"type"`
+	SchemaVersion int              `json:"schema_version"`
+	Source        string           `json:"source"`
+	Subject       uuid.UUID        `json:"subject"`
+	Sequence      int              `json:"sequence"`
+	OccurredAt    time.Time        `json:"occurred_at"`
+	Data          SubscriptionData `json:"data"`
+}
+
+// SubscriptionData is the state of the subscription after the change.
+type SubscriptionData struct {
+	SubscriptionID uuid.UUID                 `json:"subscription_id"`
+	UserID         uuid.UUID                 `json:"user_id"`
+	Status         models.SubscriptionStatus `json:"status"`
+	StartDate      time.Time                 `json:"start_date"`
+	EndDate        time.Time                 `json:"end_date"`
+	PausedAt       *time.Time                `json:"paused_at,omitempty"`
+	CancelledAt    *time.Time                `json:"cancelled_at,omitempty"`
+	Version        int                       `json:"version"`
+	Product        ProductData               `json:"product"`
+}
+
+// ProductData identifies the product subscribed to. Name and prices are
+// those at the time of the event; they are absent if the product was not
+// loaded with the subscription.
+type ProductData struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name,omitempty"`
+	Duration int       `json:"duration_days,omitempty"`
+	Price    float64   `json:"price,omitempty"`
+	TaxRate  float64   `json:"tax_rate,omitempty"`
+	Currency string    `json:"currency,omitempty"`
+}
+
+// NewSubscriptionEvent describes a change of subscription s, which must
+// hold the state after the change.
+func NewSubscriptionEvent(eventType string, s *models.Subscription, occurredAt time.Time) Event {
+	data := SubscriptionData{
+		SubscriptionID: s.ID,
+		UserID:         s.UserID,
+		Status:         s.Status,
+		StartDate:      s.StartDate.UTC(),
+		EndDate:        s.EndDate.UTC(),
+		PausedAt:       utc(s.PausedAt),
+		CancelledAt:    utc(s.CancelledAt),
+		Version:        s.Version,
+		Product:        ProductData{ID: s.ProductID},
+	}
+	if p := s.Product; p != nil {
+		data.Product = ProductData{
+			ID:       p.ID,
+			Name:     p.Name,
+			Duration: int(p.Duration),
+			Price:    p.Price,
+			TaxRate:  p.TaxRate,
+			Currency: p.Currency,
+		}
+	}
+	return Event{
+		ID:            uuid.New(),
+		Type:          eventType,
+		SchemaVersion: SchemaVersion,
+		Source:        Source,
+		Subject:       s.ID,
+		Sequence:      s.Version,
+		OccurredAt:    occurredAt.UTC(),
+		Data:          data,
+	}
+}
+
+// Message is an encoded event as handed to a Publisher. Key is the
+// subject, which brokers partition by to keep a subscription's events in
+// order.
+type Message struct {
+	ID      uuid.UUID
+	Type    string
+	Key     string
+	Payload []byte
+}
+
+// Encode turns e into a message.
+func (e Event) Encode() (Message, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{ID: e.ID, Type: e.Type, Key: e.Subject.String(), Payload: payload}, nil
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
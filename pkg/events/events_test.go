@@ -0,0 +1,195 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func subscription() *models.Subscription {
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.FixedZone("CET", 3600))
+	paused := start.Add(48 * time.Hour)
+	product := &models.Product{ID: uuid.New(), Name: "1-Month Membership", Duration: models.DurationMonth, Price: 29.99, TaxRate: 0.1, Currency: "EUR"}
+	return &models.Subscription{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		ProductID: product.ID,
+		Product:   product,
+		StartDate: start,
+		EndDate:   start.AddDate(0, 0, 30),
+		Status:    models.StatusPaused,
+		PausedAt:  &paused,
+		Version:   2,
+	}
+}
+
+func TestNewSubscriptionEvent(t *testing.T) {
+	s := subscription()
+	at := s.PausedAt.Add(time.Second)
+
+	e := events.NewSubscriptionEvent(events.TypeSubscriptionPaused, s, at)
+	assert.NotEqual(t, uuid.Nil, e.ID)
+	assert.Equal(t, events.TypeSubscriptionPaused, e.Type)
+	assert.Equal(t, events.SchemaVersion, e.SchemaVersion)
+	assert.Equal(t, s.ID, e.Subject)
+	assert.Equal(t, 2, e.Sequence)
+	assert.Equal(t, time.UTC, e.OccurredAt.Location())
+	assert.Equal(t, models.StatusPaused, e.Data.Status)
+	assert.Equal(t, time.UTC, e.Data.PausedAt.Location())
+	assert.Equal(t, "1-Month Membership", e.Data.Product.Name)
+	assert.Equal(t, 30, e.Data.Product.Duration)
+
+	msg, err := e.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, e.ID, msg.ID)
+	assert.Equal(t, s.ID.String(), msg.Key)
+
+	s.Product = nil
+	e = events.NewSubscriptionEvent(events.TypeSubscriptionPaused, s, at)
+	assert.Equal(t, events.ProductData{ID: s.ProductID}, e.Data.Product)
+}
+
+// schemaObject is the part of a JSON Schema object the test checks.
+type schemaObject struct {
+	Required   []string                `json:"required"`
+	Properties map[string]schemaObject `json:"properties"`
+}
+
+// TestEventsMatchSchema keeps the published schema and the Go types in
+// step: every field written is described, and every required one written.
+func TestEventsMatchSchema(t *testing.T) {
+	raw, err := events.Schema(events.SchemaVersion)
+	require.NoError(t, err)
+	var schema schemaObject
+	require.NoError(t, json.Unmarshal(raw, &schema))
+
+	msg, err := events.NewSubscriptionEvent(events.TypeSubscriptionPaused, subscription(), time.Now()).Encode()
+	require.NoError(t, err)
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(msg.Payload, &event))
+
+	var check func(path string, object map[string]any, schema schemaObject)
+	check = func(path string, object map[string]any, schema schemaObject) {
+		for _, name := range schema.Required {
+			assert.Contains(t, object, name, "required %s%s is missing", path, name)
+		}
+		for name, value := range object {
+			property, ok := schema.Properties[name]
+			if !assert.True(t, ok, "%s%s is not in the schema", path, name) {
+				continue
+			}
+			if nested, ok := value.(map[string]any); ok {
+				check(path+name+".", nested, property)
+			}
+		}
+	}
+	check("", event, schema)
+
+	_, err = events.Schema(events.SchemaVersion + 1)
+	assert.Error(t, err)
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var out strings.Builder
+	p := events.NewWriterPublisher(&out)
+	require.NoError(t, p.Publish(context.Background(), events.Message{Payload: []byte(`{"n":1}`)}))
+	require.NoError(t, p.Publish(context.Background(), events.Message{Payload: []byte(`{"n":2}`)}))
+	assert.Equal(t, "{\"n\":1}\n{\"n\":2}\n", out.String())
+	assert.NoError(t, p.Close())
+}
+
+func TestFilePublisherAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	for _, payload := range []string{`{"n":1}`, `{"n":2}`} {
+		p, err := events.NewPublisher(config.EventsConfig{Publisher: "file", File: path})
+		require.NoError(t, err)
+		require.NoError(t, p.Publish(context.Background(), events.Message{Payload: []byte(payload)}))
+		require.NoError(t, p.Close())
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"n\":1}\n{\"n\":2}\n", string(data))
+
+	p, err := events.NewPublisher(config.EventsConfig{Publisher: "none"})
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+// failingPublisher fails every message.
+type failingPublisher struct{ closed bool }
+
+func (p *failingPublisher) Publish(context.Context, events.Message) error {
+	return errors.New("broker unavailable")
+}
+
+func (p *failingPublisher) Close() error {
+	p.closed = true
+	return errors.New("close failed")
+}
+
+func TestFanout(t *testing.T) {
+	var first, last strings.Builder
+	failing := &failingPublisher{}
+	msg := events.Message{Payload: []byte(`{"n":1}`)}
+
+	require.NoError(t, events.Fanout{events.NewWriterPublisher(&first), events.NewWriterPublisher(&last)}.Publish(context.Background(), msg))
+	assert.Equal(t, "{\"n\":1}\n", first.String())
+	assert.Equal(t, "{\"n\":1}\n", last.String())
+
+	fanout := events.Fanout{events.NewWriterPublisher(&first), failing, events.NewWriterPublisher(&last)}
+	assert.EqualError(t, fanout.Publish(context.Background(), msg), "broker unavailable")
+	assert.Equal(t, "{\"n\":1}\n", last.String(), "publishers after a failing one are skipped")
+	assert.EqualError(t, fanout.Close(), "close failed")
+	assert.True(t, failing.closed)
+}
+
+// kafkaBrokersEnv names Kafka brokers with topic auto-creation enabled;
+// without it the Kafka publisher is not tested.
+const kafkaBrokersEnv = "TEST_KAFKA_BROKERS"
+
+func TestKafkaPublisher(t *testing.T) {
+	brokers := os.Getenv(kafkaBrokersEnv)
+	if brokers == "" {
+		t.Skip(kafkaBrokersEnv + " is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	topic := "gymondo-test-" + uuid.NewString()
+	conn, err := kafka.DialContext(ctx, "tcp", strings.Split(brokers, ",")[0])
+	require.NoError(t, err)
+	require.NoError(t, conn.CreateTopics(kafka.TopicConfig{Topic: topic, NumPartitions: 3, ReplicationFactor: 1}))
+	require.NoError(t, conn.Close())
+
+	p := events.NewKafkaPublisher(config.KafkaConfig{Brokers: strings.Split(brokers, ","), Topic: topic})
+	defer p.Close()
+	msg, err := events.NewSubscriptionEvent(events.TypeSubscriptionCreated, subscription(), time.Now()).Encode()
+	require.NoError(t, err)
+	require.NoError(t, p.Publish(ctx, msg))
+
+	r := kafka.NewReader(kafka.ReaderConfig{Brokers: strings.Split(brokers, ","), Topic: topic, GroupID: topic})
+	defer r.Close()
+	got, err := r.ReadMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, msg.Key, string(got.Key))
+	assert.JSONEq(t, string(msg.Payload), string(got.Value))
+	assert.True(t, slices.ContainsFunc(got.Headers, func(h kafka.Header) bool {
+		return h.Key == "event_type" && string(h.Value) == events.TypeSubscriptionCreated
+	}))
+}
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"gymondo_dz/pkg/config"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher produces messages to a Kafka topic, keyed by subject so
+// that a subscription's events land on one partition in order. Each
+// message is acknowledged by all in-sync replicas before Publish returns.
+type KafkaPublisher struct {
+	w *kafka.Writer
+}
+
+func NewKafkaPublisher(cfg config.KafkaConfig) *KafkaPublisher {
+	return &KafkaPublisher{w: &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// the relay publishes one message at a time and waits for it
+		BatchSize:    1,
+		BatchTimeout: time.Millisecond,
+		MaxAttempts:  1,
+	}}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, msg Message) error {
+	return p.w.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(msg.Key),
+		Value: msg.Payload,
+		Headers: []kafka.Header{
+			{Key: "event_id", Value: []byte(msg.ID.String())},
+			{Key: "event_type", Value: []byte(msg.Type)},
+			{Key: "content_type", Value: []byte("application/json")},
+		},
+	})
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.w.Close()
+}
@@ -0,0 +1,100 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"gymondo_dz/pkg/config"
+)
+
+// Publisher delivers messages to consumers. Publish returns once the
+// message is durably accepted; an error means it may or may not have
+// been, and it will be published again.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// NewPublisher builds the publisher cfg selects, or nil for "none".
+func NewPublisher(cfg config.EventsConfig) (Publisher, error) {
+	switch cfg.Publisher {
+	case "none":
+		return nil, nil
+	case "stdout":
+		return NewWriterPublisher(os.Stdout), nil
+	case "file":
+		return OpenFilePublisher(cfg.File)
+	case "kafka":
+		return NewKafkaPublisher(cfg.Kafka), nil
+	}
+	return nil, fmt.Errorf("unknown event publisher %q", cfg.Publisher)
+}
+
+// Fanout publishes every message to each of its publishers in turn. It
+// fails if any of them does, and the message is then published again to
+// all of them, so its publishers must tolerate duplicates.
+type Fanout []Publisher
+
+func (f Fanout) Publish(ctx context.Context, msg Message) error {
+	for _, p := range f {
+		if err := p.Publish(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f Fanout) Close() error {
+	var errs []error
+	for _, p := range f {
+		errs = append(errs, p.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// WriterPublisher writes each message's payload to w on a line of its
+// own.
+type WriterPublisher struct {
+	mu    sync.Mutex
+	w     io.Writer
+	sync  func() error
+	close func() error
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+// OpenFilePublisher appends messages to the file at path, creating it if
+// needed, and syncs it after each one.
+func OpenFilePublisher(path string) (*WriterPublisher, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	return &WriterPublisher{w: f, sync: f.Sync, close: f.Close}, nil
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	line := append(msg.Payload[:len(msg.Payload):len(msg.Payload)], '\n')
+	if _, err := p.w.Write(line); err != nil {
+		return err
+	}
+	if p.sync != nil {
+		return p.sync()
+	}
+	return nil
+}
+
+func (p *WriterPublisher) Close() error {
+	if p.close != nil {
+		return p.close()
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Mode says what Apply does with rows that already exist.
+type Mode string
+
+const (
+	// ModeInsert adds missing rows and leaves existing ones as they are,
+	// so it is safe to run against a database in use.
+	ModeInsert Mode = "insert"
+	// ModeUpsert also overwrites existing rows with the fixture values,
+	// undoing any changes made to them since.
+	ModeUpsert Mode = "upsert"
+)
+
+func (m Mode) IsValid() bool {
+	return m == ModeInsert || m == ModeUpsert
+}
+
+// Result counts the rows a profile consists of.
+type Result struct {
+	Products      int `json:"products"`
+	Translations  int `json:"translations"`
+	Subscriptions int `json:"subscriptions"`
+}
+
+// batchSize keeps inserts of large profiles under the bind parameter
+// limits of the drivers.
+const batchSize = 500
+
+// Apply writes the profile, built against reference, to db in a single
+// transaction. Running it again with the same reference is a no-op in
+// insert mode and restores the fixture rows in upsert mode.
+func Apply(ctx context.Context, db *gorm.DB, p *Profile, mode Mode, reference time.Time) (Result, error) {
+	if !mode.IsValid() {
+		return Result{}, fmt.Errorf("unknown seed mode %q, use insert or upsert", mode)
+	}
+	set, err := p.Build(reference)
+	if err != nil {
+		return Result{}, err
+	}
+
+	conflict := func(columns ...string) clause.OnConflict {
+		c := clause.OnConflict{DoNothing: true}
+		if mode == ModeUpsert {
+			c = clause.OnConflict{UpdateAll: true}
+		}
+		for _, name := range columns {
+			c.Columns = append(c.Columns, clause.Column{Name: name})
+		}
+		return c
+	}
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(set.Products) > 0 {
+			if err := tx.Clauses(conflict("id")).CreateInBatches(set.Products, batchSize).Error; err != nil {
+				return fmt.Errorf("products: %w", err)
+			}
+		}
+		if len(set.Translations) > 0 {
+			if err := tx.Clauses(conflict("product_id", "locale")).CreateInBatches(set.Translations, batchSize).Error; err != nil {
+				return fmt.Errorf("product translations: %w", err)
+			}
+		}
+		if len(set.Subscriptions) > 0 {
+			if err := tx.Clauses(conflict("id")).CreateInBatches(set.Subscriptions, batchSize).Error; err != nil {
+				return fmt.Errorf("subscriptions: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Products:      len(set.Products),
+		Translations:  len(set.Translations),
+		Subscriptions: len(set.Subscriptions),
+	}, nil
+}
+
+// DefaultReference is the reference time used when none is given: the
+// start of the current UTC day, so a profile applied twice on the same day
+// produces the same dates.
+func DefaultReference(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,415 @@
+// Package fixtures loads named sets of products and subscriptions into the
+// database: the demo data, a bulk load-test set and the fixed data of the
+// end-to-end tests. Profiles are YAML or JSON files; the built-in ones live
+// in profiles/.
+//
+// Fixtures are deterministic. Rows are identified by keys from which their
+// IDs are derived (unless a profile gives an ID), user IDs derive from
+// user names, and dates are offsets from a reference time, so loading a
+// profile twice with the same reference yields the same rows.
+package fixtures
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gymondo_dz/pkg/models"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed profiles
+var builtin embed.FS
+
+// namespace scopes the name-based UUIDs derived from fixture keys.
+var namespace = uuid.MustParse("5e6f1c2a-8d0b-4f57-9a43-2c1e7b9d4a60")
+
+// Profile is a set of fixtures as written in a profile file.
+type Profile struct {
+	Name          string         `yaml:"name" json:"name"`
+	Description   string         `yaml:"description" json:"description"`
+	Products      []Product      `yaml:"products" json:"products"`
+	Subscriptions []Subscription `yaml:"subscriptions" json:"subscriptions"`
+	Generate      *Generate      `yaml:"generate" json:"generate"`
+}
+
+// Product is a product fixture. Duration is in days.
+type Product struct {
+	Key          string                 `yaml:"key" json:"key"`
+	ID           string                 `yaml:"id" json:"id"`
+	Name         string                 `yaml:"name" json:"name"`
+	Description  string                 `yaml:"description" json:"description"`
+	Duration     int                    `yaml:"duration" json:"duration"`
+	Price        float64                `yaml:"price" json:"price"`
+	TaxRate      float64                `yaml:"tax_rate" json:"tax_rate"`
+	Currency     string                 `yaml:"currency" json:"currency"`
+	Translations map[string]Translation `yaml:"translations" json:"translations"`
+}
+
+type Translation struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+}
+
+// Subscription is a subscription fixture. Its end date follows from the
+// start and the product's duration; Paused and Cancelled are required for
+// paused and cancelled subscriptions.
+type Subscription struct {
+	Key       string  `yaml:"key" json:"key"`
+	ID        string  `yaml:"id" json:"id"`
+	User      string  `yaml:"user" json:"user"`
+	Product   string  `yaml:"product" json:"product"`
+	Status    string  `yaml:"status" json:"status"`
+	Started   Offset  `yaml:"started" json:"started"`
+	Paused    *Offset `yaml:"paused" json:"paused"`
+	Cancelled *Offset `yaml:"cancelled" json:"cancelled"`
+}
+
+// Generate adds Subscriptions random subscriptions, shared out among Users
+// users and the listed products (all by default). Statuses weighs how
+// often each status is picked, and start dates lie within StartedWithin
+// before the reference. The same Seed always generates the same rows.
+type Generate struct {
+	Subscriptions int            `yaml:"subscriptions" json:"subscriptions"`
+	Users         int            `yaml:"users" json:"users"`
+	Products      []string       `yaml:"products" json:"products"`
+	Statuses      map[string]int `yaml:"statuses" json:"statuses"`
+	StartedWithin Offset         `yaml:"started_within" json:"started_within"`
+	Seed          uint64         `yaml:"seed" json:"seed"`
+}
+
+// Set is a profile resolved into rows.
+type Set struct {
+	Products      []models.Product
+	Translations  []models.ProductTranslation
+	Subscriptions []models.Subscription
+}
+
+// Names lists the built-in profiles.
+func Names() []string {
+	entries, _ := builtin.ReadDir("profiles")
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	return names
+}
+
+// Load reads the built-in profile called name, or the profile file at
+// name when it ends in .yaml, .yml or .json.
+func Load(name string) (*Profile, error) {
+	var (
+		data []byte
+		file string
+		err  error
+	)
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		file = name
+		data, err = os.ReadFile(name)
+	default:
+		for _, ext := range []string{".yaml", ".yml", ".json"} {
+			file = name + ext
+			if data, err = fs.ReadFile(builtin, "profiles/"+file); !errors.Is(err, fs.ErrNotExist) {
+				break
+			}
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("unknown fixture profile %q, choose one of %s or give a .yaml or .json file",
+				name, strings.Join(Names(), ", "))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := Parse(data, filepath.Ext(file))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	return p, nil
+}
+
+// Parse decodes a profile in the format named by ext, .json or .yaml, and
+// checks it. Unknown fields are rejected so typos do not go unnoticed.
+func Parse(data []byte, ext string) (*Profile, error) {
+	var p Profile
+	var err error
+	if ext == ".json" {
+		dec := json.NewDecoder(strings.NewReader(string(data)))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&p)
+	} else {
+		dec := yaml.NewDecoder(strings.NewReader(string(data)))
+		dec.KnownFields(true)
+		err = dec.Decode(&p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *Profile) validate() error {
+	var problems []string
+	products := map[string]Product{}
+	for i, f := range p.Products {
+		if f.Key == "" {
+			problems = append(problems, fmt.Sprintf("products[%d]: key is required", i))
+		} else if _, ok := products[f.Key]; ok {
+			problems = append(problems, fmt.Sprintf("products[%d]: duplicate key %q", i, f.Key))
+		}
+		products[f.Key] = f
+		if !models.SubscriptionDuration(f.Duration).IsValid() {
+			problems = append(problems, fmt.Sprintf("product %q: duration must be 30, 365 or 36500 days", f.Key))
+		}
+		if _, err := f.id(); err != nil {
+			problems = append(problems, fmt.Sprintf("product %q: %v", f.Key, err))
+		}
+	}
+
+	keys := map[string]bool{}
+	for i, f := range p.Subscriptions {
+		if f.Key == "" {
+			problems = append(problems, fmt.Sprintf("subscriptions[%d]: key is required", i))
+		} else if keys[f.Key] {
+			problems = append(problems, fmt.Sprintf("subscriptions[%d]: duplicate key %q", i, f.Key))
+		}
+		keys[f.Key] = true
+		if _, ok := products[f.Product]; !ok {
+			problems = append(problems, fmt.Sprintf("subscription %q: unknown product %q", f.Key, f.Product))
+		}
+		if f.User == "" {
+			problems = append(problems, fmt.Sprintf("subscription %q: user is required", f.Key))
+		}
+		if _, err := f.id(); err != nil {
+			problems = append(problems, fmt.Sprintf("subscription %q: %v", f.Key, err))
+		}
+		status := models.SubscriptionStatus(f.Status)
+		switch {
+		case !status.IsValid():
+			problems = append(problems, fmt.Sprintf("subscription %q: unknown status %q", f.Key, f.Status))
+		case (status == models.StatusPaused) != (f.Paused != nil):
+			problems = append(problems, fmt.Sprintf("subscription %q: paused is required for, and only for, paused subscriptions", f.Key))
+		case (status == models.StatusCancelled) != (f.Cancelled != nil):
+			problems = append(problems, fmt.Sprintf("subscription %q: cancelled is required for, and only for, cancelled subscriptions", f.Key))
+		}
+	}
+
+	if g := p.Generate; g != nil {
+		if g.Subscriptions < 0 || g.Users < 1 {
+			problems = append(problems, "generate: subscriptions must not be negative and users must be at least 1")
+		}
+		for _, key := range g.Products {
+			if _, ok := products[key]; !ok {
+				problems = append(problems, fmt.Sprintf("generate: unknown product %q", key))
+			}
+		}
+		total := 0
+		for status, weight := range g.Statuses {
+			if !models.SubscriptionStatus(status).IsValid() || weight < 0 {
+				problems = append(problems, fmt.Sprintf("generate: invalid status weight %s: %d", status, weight))
+			}
+			total += weight
+		}
+		if total == 0 {
+			problems = append(problems, "generate: statuses must give at least one status a weight")
+		}
+		if g.StartedWithin <= 0 {
+			problems = append(problems, "generate: started_within must be positive")
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Build resolves the profile into rows, with dates relative to reference.
+func (p *Profile) Build(reference time.Time) (*Set, error) {
+	set := &Set{}
+	products := map[string]*models.Product{}
+	for i, f := range p.Products {
+		id, _ := f.id()
+		// one minute apart, so products list in profile order
+		created := reference.Add(time.Duration(i-len(p.Products)) * time.Minute)
+		set.Products = append(set.Products, models.Product{
+			ID:          id,
+			Name:        f.Name,
+			Description: f.Description,
+			Duration:    models.SubscriptionDuration(f.Duration),
+			Price:       f.Price,
+			TaxRate:     f.TaxRate,
+			Currency:    f.Currency,
+			CreatedAt:   created,
+			UpdatedAt:   created,
+		})
+		products[f.Key] = &set.Products[len(set.Products)-1]
+
+		locales := make([]string, 0, len(f.Translations))
+		for locale := range f.Translations {
+			locales = append(locales, locale)
+		}
+		slices.Sort(locales)
+		for _, locale := range locales {
+			t := f.Translations[locale]
+			set.Translations = append(set.Translations, models.ProductTranslation{
+				ProductID: id, Locale: locale, Name: t.Name, Description: t.Description,
+				CreatedAt: created, UpdatedAt: created,
+			})
+		}
+	}
+
+	for _, f := range p.Subscriptions {
+		id, _ := f.id()
+		s := subscription(id, userID(f.User), products[f.Product], models.SubscriptionStatus(f.Status),
+			f.Started.From(reference), at(f.Paused, reference), at(f.Cancelled, reference))
+		if s.Status == models.StatusExpired && !s.EndDate.Before(reference) {
+			return nil, fmt.Errorf("subscription %q is expired but ends after the reference time", f.Key)
+		}
+		if s.Status != models.StatusExpired && s.EndDate.Before(reference) {
+			return nil, fmt.Errorf("subscription %q is %s but ended before the reference time", f.Key, s.Status)
+		}
+		set.Subscriptions = append(set.Subscriptions, s)
+	}
+
+	if p.Generate != nil {
+		set.Subscriptions = append(set.Subscriptions, p.Generate.subscriptions(p.Name, products, p.Products, reference)...)
+	}
+	return set, nil
+}
+
+func (g *Generate) subscriptions(profile string, products map[string]*models.Product, fixtures []Product, reference time.Time) []models.Subscription {
+	keys := g.Products
+	if len(keys) == 0 {
+		for _, f := range fixtures {
+			keys = append(keys, f.Key)
+		}
+	}
+	statuses := make([]string, 0, len(g.Statuses))
+	for status := range g.Statuses {
+		statuses = append(statuses, status)
+	}
+	slices.Sort(statuses)
+	total := 0
+	for _, status := range statuses {
+		total += g.Statuses[status]
+	}
+
+	rng := rand.New(rand.NewPCG(g.Seed, 0))
+	// random duration in [0, d)
+	within := func(d time.Duration) time.Duration {
+		if d <= 0 {
+			return 0
+		}
+		return time.Duration(rng.Int64N(int64(d)))
+	}
+
+	subscriptions := make([]models.Subscription, g.Subscriptions)
+	for i := range subscriptions {
+		product := products[keys[rng.IntN(len(keys))]]
+		status := statuses[0]
+		for n, pick := 0, rng.IntN(total); ; status = statuses[n] {
+			if pick -= g.Statuses[status]; pick < 0 {
+				break
+			}
+			n++
+		}
+
+		// start early enough to have ended if expired, late enough to be
+		// running otherwise
+		length := days(int(product.Duration))
+		var started time.Time
+		if models.SubscriptionStatus(status) == models.StatusExpired {
+			started = reference.Add(-length - time.Hour - within(time.Duration(g.StartedWithin)))
+		} else {
+			started = reference.Add(-within(min(time.Duration(g.StartedWithin), length)))
+		}
+		var paused, cancelled *time.Time
+		event := started.Add(within(reference.Sub(started)))
+		switch models.SubscriptionStatus(status) {
+		case models.StatusPaused:
+			paused = &event
+		case models.StatusCancelled:
+			cancelled = &event
+		}
+
+		key := fmt.Sprintf("%s-%d", profile, i+1)
+		subscriptions[i] = subscription(derive("subscription", key), userID(fmt.Sprintf("%s-user-%d", profile, i%g.Users+1)),
+			product, models.SubscriptionStatus(status), started, paused, cancelled)
+	}
+	return subscriptions
+}
+
+func subscription(id, user uuid.UUID, product *models.Product, status models.SubscriptionStatus, started time.Time, paused, cancelled *time.Time) models.Subscription {
+	return models.Subscription{
+		ID:          id,
+		UserID:      user,
+		ProductID:   product.ID,
+		StartDate:   started,
+		EndDate:     started.Add(days(int(product.Duration))),
+		Status:      status,
+		PausedAt:    paused,
+		CancelledAt: cancelled,
+		CreatedAt:   started,
+		UpdatedAt:   started,
+		Version:     1,
+	}
+}
+
+func (f Product) id() (uuid.UUID, error) {
+	if f.ID != "" {
+		return uuid.Parse(f.ID)
+	}
+	return derive("product", f.Key), nil
+}
+
+func (f Subscription) id() (uuid.UUID, error) {
+	if f.ID != "" {
+		return uuid.Parse(f.ID)
+	}
+	return derive("subscription", f.Key), nil
+}
+
+// userID is the ID of the user called name, or name itself if it is a
+// UUID.
+func userID(name string) uuid.UUID {
+	if id, err := uuid.Parse(name); err == nil {
+		return id
+	}
+	return derive("user", name)
+}
+
+func derive(kind, key string) uuid.UUID {
+	return uuid.NewSHA1(namespace, []byte(kind+":"+key))
+}
+
+func at(o *Offset, reference time.Time) *time.Time {
+	if o == nil {
+		return nil
+	}
+	t := o.From(reference)
+	return &t
+}
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
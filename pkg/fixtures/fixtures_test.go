@@ -0,0 +1,232 @@
+package fixtures_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/database"
+	"gymondo_dz/pkg/fixtures"
+	"gymondo_dz/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+var reference = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func openDB(t *testing.T) *gorm.DB {
+	db, err := database.NewSQLiteConnection(config.SQLiteConfig{
+		Path:        filepath.Join(t.TempDir(), "test.db"),
+		BusyTimeout: 5 * time.Second,
+		JournalMode: "wal",
+	}, gormlogger.Discard)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, database.Migrate(context.Background(), db))
+	return db
+}
+
+func TestBuiltinProfiles(t *testing.T) {
+	assert.ElementsMatch(t, []string{"demo", "e2e", "load-test"}, fixtures.Names())
+
+	for _, name := range fixtures.Names() {
+		t.Run(name, func(t *testing.T) {
+			p, err := fixtures.Load(name)
+			require.NoError(t, err)
+			assert.Equal(t, name, p.Name)
+
+			first, err := p.Build(reference)
+			require.NoError(t, err)
+			second, err := p.Build(reference)
+			require.NoError(t, err)
+			assert.Equal(t, first, second, "building twice must give the same rows")
+			assert.NotEmpty(t, first.Subscriptions)
+
+			products := map[uuid.UUID]models.Product{}
+			for _, product := range first.Products {
+				products[product.ID] = product
+			}
+			for _, s := range first.Subscriptions {
+				duration := time.Duration(products[s.ProductID].Duration) * 24 * time.Hour
+				assert.Equal(t, s.StartDate.Add(duration), s.EndDate, "end date of %s", s.ID)
+				assert.Equal(t, s.Status == models.StatusExpired, s.EndDate.Before(reference), "status of %s", s.ID)
+			}
+		})
+	}
+}
+
+func TestDemoProfile(t *testing.T) {
+	p, err := fixtures.Load("demo")
+	require.NoError(t, err)
+	set, err := p.Build(reference)
+	require.NoError(t, err)
+
+	active := set.Subscriptions[0]
+	assert.Equal(t, uuid.MustParse("AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"), active.ID)
+	assert.Equal(t, reference, active.StartDate)
+	assert.Equal(t, reference.AddDate(0, 0, 30), active.EndDate)
+
+	paused := set.Subscriptions[1]
+	require.NotNil(t, paused.PausedAt)
+	assert.Equal(t, reference.Add(-12*time.Hour), *paused.PausedAt)
+
+	// the same user name gives the same user ID in every profile and run
+	other, err := (&fixtures.Profile{
+		Name:          "other",
+		Products:      []fixtures.Product{{Key: "p", Name: "P", Duration: 30, Price: 1, Currency: "EUR"}},
+		Subscriptions: []fixtures.Subscription{{Key: "s", User: "alice", Product: "p", Status: "active"}},
+	}).Build(reference)
+	require.NoError(t, err)
+	assert.Equal(t, active.UserID, other.Subscriptions[0].UserID)
+}
+
+func TestApplyInsertIsIdempotent(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	p, err := fixtures.Load("demo")
+	require.NoError(t, err)
+
+	for range 2 {
+		result, err := fixtures.Apply(ctx, db, p, fixtures.ModeInsert, reference)
+		require.NoError(t, err)
+		assert.Equal(t, fixtures.Result{Products: 3, Translations: 3, Subscriptions: 4}, result)
+	}
+
+	var products, translations, subscriptions int64
+	require.NoError(t, db.Model(&models.Product{}).Count(&products).Error)
+	require.NoError(t, db.Model(&models.ProductTranslation{}).Count(&translations).Error)
+	require.NoError(t, db.Model(&models.Subscription{}).Count(&subscriptions).Error)
+	assert.Equal(t, []int64{3, 3, 4}, []int64{products, translations, subscriptions})
+}
+
+func TestApplyModes(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	p, err := fixtures.Load("e2e")
+	require.NoError(t, err)
+	_, err = fixtures.Apply(ctx, db, p, fixtures.ModeInsert, reference)
+	require.NoError(t, err)
+
+	id := uuid.MustParse("00000000-0000-4000-8000-000000000101")
+	require.NoError(t, db.Model(&models.Subscription{}).Where("id = ?", id).
+		Updates(map[string]any{"status": models.StatusCancelled, "version": 2}).Error)
+	status := func() models.SubscriptionStatus {
+		var s models.Subscription
+		require.NoError(t, db.First(&s, "id = ?", id).Error)
+		return s.Status
+	}
+
+	_, err = fixtures.Apply(ctx, db, p, fixtures.ModeInsert, reference)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCancelled, status(), "insert must keep changed rows")
+
+	_, err = fixtures.Apply(ctx, db, p, fixtures.ModeUpsert, reference)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusActive, status(), "upsert must reset changed rows")
+
+	_, err = fixtures.Apply(ctx, db, p, fixtures.Mode("replace"), reference)
+	assert.EqualError(t, err, `unknown seed mode "replace", use insert or upsert`)
+}
+
+func TestApplyGenerated(t *testing.T) {
+	db := openDB(t)
+	p, err := fixtures.Load("load-test")
+	require.NoError(t, err)
+	p.Generate.Subscriptions = 1200
+
+	result, err := fixtures.Apply(context.Background(), db, p, fixtures.ModeInsert, reference)
+	require.NoError(t, err)
+	assert.Equal(t, 1200, result.Subscriptions)
+
+	var expired int64
+	require.NoError(t, db.Model(&models.Subscription{}).
+		Where("status = ? AND end_date >= ?", models.StatusExpired, reference).Count(&expired).Error)
+	assert.Zero(t, expired, "generated expired subscriptions must have ended")
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	p, err := fixtures.Load(write("staging.yaml", `
+products:
+  - {key: basic, name: Basic, duration: 365, price: 50, currency: EUR}
+subscriptions:
+  - {key: s1, user: erin, product: basic, status: cancelled, started: -30d, cancelled: -2d12h}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "staging", p.Name)
+	set, err := p.Build(reference)
+	require.NoError(t, err)
+	require.Len(t, set.Subscriptions, 1)
+	assert.Equal(t, reference.Add(-60*time.Hour), *set.Subscriptions[0].CancelledAt)
+
+	p, err = fixtures.Load(write("ci.json", `{"name": "ci", "products": [{"key": "m", "name": "M", "duration": 30, "price": 1, "currency": "EUR"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "ci", p.Name)
+
+	_, err = fixtures.Load(write("typo.yaml", "product: []\n"))
+	assert.ErrorContains(t, err, "field product not found")
+
+	_, err = fixtures.Load(write("invalid.yaml", `
+products:
+  - {key: basic, name: Basic, duration: 7, price: 50, currency: EUR}
+subscriptions:
+  - {key: s1, user: erin, product: premium, status: paused}
+`))
+	assert.ErrorContains(t, err, `product "basic": duration must be 30, 365 or 36500 days`)
+	assert.ErrorContains(t, err, `subscription "s1": unknown product "premium"`)
+
+	p, err = fixtures.Load(write("stale.yaml", `
+products:
+  - {key: basic, name: Basic, duration: 30, price: 5, currency: EUR}
+subscriptions:
+  - {key: s1, user: erin, product: basic, status: active, started: -31d}
+`))
+	require.NoError(t, err)
+	_, err = p.Build(reference)
+	assert.EqualError(t, err, `subscription "s1" is active but ended before the reference time`)
+
+	_, err = fixtures.Load("staging")
+	assert.ErrorContains(t, err, `unknown fixture profile "staging"`)
+}
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		{in: "0", want: 0},
+		{in: "90d", want: 90 * 24 * time.Hour},
+		{in: "-7d", want: -7 * 24 * time.Hour},
+		{in: "-1d12h", want: -36 * time.Hour},
+		{in: "+2h30m", want: 150 * time.Minute},
+		{in: "-12h", want: -12 * time.Hour},
+		{in: "d", err: true},
+		{in: "1w", err: true},
+		{in: "-1d-2h", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := fixtures.ParseOffset(tt.in)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, fixtures.Offset(tt.want), got)
+		})
+	}
+}
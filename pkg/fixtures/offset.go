@@ -0,0 +1,55 @@
+package fixtures
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Offset is a signed span of time relative to the reference time, written
+// as a Go duration that may also count days, such as "-7d", "-1d12h" or
+// "90d". "0" is the reference time itself.
+type Offset time.Duration
+
+// ParseOffset parses an offset.
+func ParseOffset(s string) (Offset, error) {
+	rest, sign := strings.CutPrefix(strings.TrimSpace(s), "-")
+	if !sign {
+		rest = strings.TrimPrefix(rest, "+")
+	}
+
+	var d time.Duration
+	if n, after, ok := strings.Cut(rest, "d"); ok {
+		days, err := strconv.Atoi(n)
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid offset %q", s)
+		}
+		d, rest = time.Duration(days)*24*time.Hour, after
+	}
+	if rest != "" {
+		more, err := time.ParseDuration(rest)
+		if err != nil || more < 0 {
+			return 0, fmt.Errorf("invalid offset %q", s)
+		}
+		d += more
+	}
+	if sign {
+		d = -d
+	}
+	return Offset(d), nil
+}
+
+func (o *Offset) UnmarshalText(text []byte) error {
+	v, err := ParseOffset(string(text))
+	if err != nil {
+		return err
+	}
+	*o = v
+	return nil
+}
+
+// From is the time o away from reference.
+func (o Offset) From(reference time.Time) time.Time {
+	return reference.Add(time.Duration(o))
+}
@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"strings"
+
+	"gymondo_dz/pkg/graph/model"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+)
+
+// newPageArgs takes the pagination arguments of a list, with the default
+// limit for an unset one.
+func newPageArgs(page, limit *int, cursor *string, includeTotal *bool) repositories.PageQuery {
+	q := repositories.PageQuery{
+		Page:         value(page),
+		Limit:        value(limit),
+		Cursor:       value(cursor),
+		IncludeTotal: value(includeTotal),
+	}
+	if limit == nil {
+		q.Limit = repositories.DefaultPageLimit
+	}
+	return q
+}
+
+func pageInfo(p repositories.Pagination) *model.PageInfo {
+	info := &model.PageInfo{Page: p.Page, Limit: p.Limit}
+	if p.Total != nil {
+		total := int(*p.Total)
+		info.Total = &total
+	}
+	if p.NextCursor != "" {
+		info.NextCursor = &p.NextCursor
+	}
+	if p.PrevCursor != "" {
+		info.PrevCursor = &p.PrevCursor
+	}
+	return info
+}
+
+type productsArgs struct {
+	repositories.PageQuery
+	Duration models.SubscriptionDuration `json:"duration" binding:"omitempty,subscription_duration"`
+	MinPrice *float64                    `json:"minPrice" binding:"omitempty,gte=0"`
+	MaxPrice *float64                    `json:"maxPrice" binding:"omitempty,gte=0"`
+	Currency string                      `json:"currency" binding:"omitempty,currency"`
+	Q        string                      `json:"q" binding:"omitempty,max=100"`
+	Sort     model.ProductSort           `json:"sort"`
+	Order    model.SortOrder             `json:"order"`
+}
+
+func (a productsArgs) filter() repositories.ProductFilter {
+	return repositories.ProductFilter{
+		Duration: a.Duration,
+		MinPrice: a.MinPrice,
+		MaxPrice: a.MaxPrice,
+		Currency: a.Currency,
+		Query:    a.Q,
+		SortBy:   strings.ToLower(string(a.Sort)),
+		SortDesc: a.Order == model.SortOrderDesc,
+	}
+}
+
+type subscriptionsArgs struct {
+	repositories.PageQuery
+	UserID    string                    `json:"userId" binding:"required,resource_id"`
+	ProductID string                    `json:"productId" binding:"omitempty,resource_id"`
+	Status    models.SubscriptionStatus `json:"status"`
+}
+
+type idArg struct {
+	ID string `json:"id" binding:"required,resource_id"`
+}
+
+type productIDArg struct {
+	ProductID string `json:"productId" binding:"required,resource_id"`
+}
+
+// value returns what p points to, or the zero value for an argument that
+// was left out.
+func value[T any](p *T) T {
+	var v T
+	if p != nil {
+		v = *p
+	}
+	return v
+}
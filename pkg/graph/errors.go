@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/logging"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// presentError renders an error returned by a resolver as the REST API
+// would: the message is the detail rendered there, and the extensions
+// carry the same error code and field errors. Errors gqlgen raises
+// itself, such as for queries that fail validation, pass through.
+//
+// GraphQL responses are 200 whatever went wrong, so every error is also
+// logged: server errors at error level, the rest at warn.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	// gqlgen wraps what resolvers return to add the path
+	cause := err
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) {
+		if gqlErr.Err == nil {
+			return graphql.DefaultErrorPresenter(ctx, err)
+		}
+		cause = gqlErr.Err
+	}
+
+	appErr := apperrors.From(cause)
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+	presented.Message = appErr.Detail
+	presented.Extensions = map[string]any{"code": appErr.Code}
+	if len(appErr.Fields) > 0 {
+		presented.Extensions["fields"] = appErr.Fields
+	}
+
+	level := slog.LevelWarn
+	if appErr.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	logging.FromContext(ctx).LogAttrs(ctx, level, "GraphQL error",
+		slog.String("field", presented.Path.String()),
+		slog.String("code", appErr.Code),
+		slog.String("error", cause.Error()),
+	)
+	return presented
+}
+
+// recoverPanic turns a panic in a resolver into an internal error and logs
+// it with its stack trace.
+func recoverPanic(ctx context.Context, recovered any) error {
+	logging.FromContext(ctx).ErrorContext(ctx, "panic recovered",
+		slog.Any("panic", recovered),
+		slog.String("stack", string(debug.Stack())),
+	)
+	return fmt.Errorf("panic: %v", recovered)
+}
@@ -0,0 +1,114 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/graph/model"
+	"gymondo_dz/pkg/i18n"
+	"gymondo_dz/pkg/repositories"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// NewHandler serves the schema. Queries can be sent with GET or POST,
+// mutations only with POST; IsMutation tells them apart for routes that
+// limit the two differently.
+// Queries whose estimated cost exceeds cfg.ComplexityLimit are rejected
+// before any resolver runs.
+func NewHandler(
+	cfg config.GraphQLConfig,
+	products repositories.ProductRepository,
+	subscriptions repositories.SubscriptionRepository,
+	translations repositories.TranslationRepository,
+) http.Handler {
+	srv := handler.New(NewExecutableSchema(Config{
+		Resolvers: &Resolver{
+			products:      products,
+			subscriptions: subscriptions,
+			translations:  translations,
+		},
+		Complexity: complexity(),
+	}))
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(cfg.ComplexityLimit))
+	srv.SetErrorPresenter(presentError)
+	srv.SetRecoverFunc(recoverPanic)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		locales := i18n.Negotiate(r.Header.Get("Accept-Language"))
+		ctx := withLoaders(r.Context(), newLoaders(locales, products, translations))
+		srv.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// IsMutation reports whether r runs a mutation. Requests that cannot be
+// parsed count as queries, since they fail before any resolver runs. The
+// body is put back for the handler to read; an error reading it, such as
+// one from http.MaxBytesReader, is returned.
+func IsMutation(r *http.Request) (bool, error) {
+	var params struct {
+		Query         string `json:"query"`
+		OperationName string `json:"operationName"`
+	}
+	if r.Method == http.MethodGet {
+		params.Query = r.URL.Query().Get("query")
+		params.OperationName = r.URL.Query().Get("operationName")
+	} else {
+		body, err := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return false, err
+		}
+		if json.Unmarshal(body, &params) != nil {
+			return false, nil
+		}
+	}
+
+	doc, err := parser.ParseQuery(&ast.Source{Input: params.Query})
+	if err != nil {
+		return false, nil
+	}
+	op := doc.Operations.ForName(params.OperationName)
+	return op != nil && op.Operation == ast.Mutation, nil
+}
+
+// Playground serves GraphiQL, sending queries to endpoint.
+func Playground(endpoint string) http.Handler {
+	return playground.Handler("Gymondo GraphQL", endpoint)
+}
+
+// complexity counts the fields selected from a list once per item it may
+// return; every other field costs one.
+func complexity() ComplexityRoot {
+	var c ComplexityRoot
+	c.Query.Products = func(childComplexity int, _ *model.ProductFilter, _ *model.ProductSort, _ *model.SortOrder, _ *int, limit *int, _ *string, _ *bool) int {
+		return listComplexity(childComplexity, limit)
+	}
+	c.Query.Subscriptions = func(childComplexity int, _ string, _ *string, _ *model.SubscriptionStatus, _ *int, limit *int, _ *string, _ *bool) int {
+		return listComplexity(childComplexity, limit)
+	}
+	return c
+}
+
+func listComplexity(childComplexity int, limit *int) int {
+	items := repositories.DefaultPageLimit
+	if limit != nil {
+		// larger limits fail validation; counting them in full could
+		// overflow
+		items = max(min(*limit, repositories.MaxPageLimit), 1)
+	}
+	return 1 + childComplexity*items
+}
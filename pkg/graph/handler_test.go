@@ -0,0 +1,393 @@
+package graph_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/graph"
+	"gymondo_dz/pkg/logging"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/testutils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testHandler struct {
+	products      *testutils.MockProductRepository
+	subscriptions *testutils.MockSubscriptionRepository
+	translations  *testutils.MockTranslationRepository
+	handler       http.Handler
+}
+
+func newTestHandler(complexityLimit int) *testHandler {
+	th := &testHandler{
+		products:      new(testutils.MockProductRepository),
+		subscriptions: new(testutils.MockSubscriptionRepository),
+		translations:  new(testutils.MockTranslationRepository),
+	}
+	th.handler = graph.NewHandler(config.GraphQLConfig{Enabled: true, ComplexityLimit: complexityLimit},
+		th.products, th.subscriptions, th.translations)
+	return th
+}
+
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func (th *testHandler) post(t *testing.T, query string, header http.Header) response {
+	t.Helper()
+	body, err := json.Marshal(map[string]string{"query": query})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	return th.serve(t, req)
+}
+
+func (th *testHandler) serve(t *testing.T, req *http.Request) response {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	req = req.WithContext(logging.NewContext(req.Context(), logger))
+
+	w := httptest.NewRecorder()
+	th.handler.ServeHTTP(w, req)
+
+	var resp response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return resp
+}
+
+// memberID is the user whose subscriptions the queries list.
+const memberID = "c5b1a1e2-4f0e-4d47-9d2a-3f8e6b1c2d40"
+
+func TestQueries(t *testing.T) {
+	fixedTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	product := models.Product{
+		ID:         uuid.MustParse("465dc700-666c-4b7a-80e2-d9e2967f4442"),
+		Name:       "Test Product",
+		Price:      9.99,
+		TaxRate:    0.10,
+		TotalPrice: 10.989,
+		Currency:   "EUR",
+		Duration:   models.DurationMonth,
+		CreatedAt:  fixedTime,
+		UpdatedAt:  fixedTime,
+	}
+
+	t.Run("products applies defaults and localizes", func(t *testing.T) {
+		th := newTestHandler(1000)
+		th.products.On("GetProducts", mock.Anything,
+			repositories.ProductFilter{SortBy: "created_at"},
+			repositories.PageRequest{Page: 1, Limit: 10},
+		).Return([]models.Product{product}, repositories.Pagination{Page: 1, Limit: 10}, nil)
+		th.translations.On("Localize", mock.Anything, []string{"de", "en"}, mock.Anything).
+			Run(func(args mock.Arguments) {
+				p := args.Get(2).([]*models.Product)[0]
+				p.Name, p.Locale = "Testprodukt", "de"
+			}).Return(nil)
+
+		resp := th.post(t, `{ products { items { id name totalPrice duration locale } pageInfo { page total } } }`,
+			http.Header{"Accept-Language": {"de"}})
+
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{
+			"items": [{"id": "465dc700-666c-4b7a-80e2-d9e2967f4442", "name": "Testprodukt", "totalPrice": 10.989, "duration": 30, "locale": "de"}],
+			"pageInfo": {"page": 1, "total": null}
+		}`, string(resp.Data["products"]))
+	})
+
+	t.Run("products maps sort and filter", func(t *testing.T) {
+		th := newTestHandler(1000)
+		minPrice := 5.0
+		th.products.On("GetProducts", mock.Anything,
+			repositories.ProductFilter{Duration: models.DurationYear, MinPrice: &minPrice, Currency: "EUR", SortBy: "price", SortDesc: true},
+			repositories.PageRequest{Cursor: "abc", Limit: 5},
+		).Return([]models.Product{}, repositories.Pagination{Limit: 5, NextCursor: "def"}, nil)
+
+		resp := th.post(t, `{ products(filter: {duration: 365, minPrice: 5, currency: "EUR"}, sort: PRICE, order: DESC, cursor: "abc", limit: 5) {
+			items { id } pageInfo { nextCursor prevCursor } } }`, nil)
+
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"items": [], "pageInfo": {"nextCursor": "def", "prevCursor": null}}`, string(resp.Data["products"]))
+	})
+
+	t.Run("invalid arguments are reported as field errors", func(t *testing.T) {
+		th := newTestHandler(1000)
+
+		resp := th.post(t, `{ products(limit: 0, page: 2, cursor: "abc", filter: {currency: "XXX"}) { items { id } } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "request validation failed", resp.Errors[0].Message)
+		assert.Equal(t, "validation_error", resp.Errors[0].Extensions["code"])
+		assert.ElementsMatch(t, []any{
+			map[string]any{"field": "page", "message": "cannot be combined with cursor"},
+			map[string]any{"field": "limit", "message": "must be at least 1"},
+			map[string]any{"field": "currency", "message": "must be one of: EUR, USD, GBP, CHF"},
+		}, resp.Errors[0].Extensions["fields"])
+		th.products.AssertNotCalled(t, "GetProducts", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("products rejects a price range that is inverted", func(t *testing.T) {
+		th := newTestHandler(1000)
+
+		resp := th.post(t, `{ products(filter: {minPrice: 20, maxPrice: 10}) { items { id } } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, []any{
+			map[string]any{"field": "maxPrice", "message": "must be greater than or equal to minPrice"},
+		}, resp.Errors[0].Extensions["fields"])
+	})
+
+	t.Run("product is null when there is none", func(t *testing.T) {
+		th := newTestHandler(1000)
+		th.products.On("GetProductsByIDs", mock.Anything, []string{product.ID.String()}).
+			Return([]models.Product{}, nil)
+
+		resp := th.post(t, `{ product(id: "465dc700-666c-4b7a-80e2-d9e2967f4442") { id } }`, nil)
+
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `null`, string(resp.Data["product"]))
+	})
+
+	t.Run("subscriptions localize their preloaded products in one batch", func(t *testing.T) {
+		th := newTestHandler(1000)
+		products := testutils.NewMockProductList(3)
+		subs := make([]models.Subscription, 6)
+		for i := range subs {
+			subs[i] = *testutils.NewMockSubscription()
+			product := products[i%len(products)]
+			subs[i].ProductID, subs[i].Product = product.ID, &product
+		}
+		th.subscriptions.On("ListSubscriptions", mock.Anything,
+			repositories.SubscriptionFilter{UserID: memberID, Status: models.StatusActive},
+			repositories.PageRequest{Page: 1, Limit: 10},
+		).Return(subs, repositories.Pagination{Page: 1, Limit: 10}, nil)
+		th.translations.On("Localize", mock.Anything, []string{"de", "en"}, mock.MatchedBy(func(ps []*models.Product) bool {
+			return len(ps) == len(subs)
+		})).Run(func(args mock.Arguments) {
+			for _, p := range args.Get(2).([]*models.Product) {
+				p.Name, p.Locale = "Testprodukt", "de"
+			}
+		}).Return(nil).Once()
+
+		resp := th.post(t, `{ subscriptions(userId: "`+memberID+`", status: ACTIVE) { items { id status product { id name } } } }`,
+			http.Header{"Accept-Language": {"de"}})
+
+		require.Empty(t, resp.Errors)
+		var page struct {
+			Items []struct {
+				Status  string
+				Product struct{ ID, Name string }
+			}
+		}
+		require.NoError(t, json.Unmarshal(resp.Data["subscriptions"], &page))
+		require.Len(t, page.Items, len(subs))
+		for i, item := range page.Items {
+			assert.Equal(t, "ACTIVE", item.Status)
+			assert.Equal(t, subs[i].ProductID.String(), item.Product.ID)
+			assert.Equal(t, "Testprodukt", item.Product.Name)
+		}
+		th.translations.AssertExpectations(t)
+		th.products.AssertNotCalled(t, "GetProductsByIDs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("subscriptions without their product load it in one batch", func(t *testing.T) {
+		th := newTestHandler(1000)
+		products := testutils.NewMockProductList(3)
+		subs := make([]models.Subscription, 6)
+		for i := range subs {
+			subs[i] = *testutils.NewMockSubscription()
+			subs[i].ProductID = products[i%len(products)].ID
+		}
+		th.subscriptions.On("ListSubscriptions", mock.Anything,
+			repositories.SubscriptionFilter{UserID: memberID, Status: models.StatusActive},
+			repositories.PageRequest{Page: 1, Limit: 10},
+		).Return(subs, repositories.Pagination{Page: 1, Limit: 10}, nil)
+		th.products.On("GetProductsByIDs", mock.Anything, mock.MatchedBy(func(ids []string) bool {
+			return len(ids) == len(products)
+		})).Return(products, nil).Once()
+
+		resp := th.post(t, `{ subscriptions(userId: "`+memberID+`", status: ACTIVE) { items { id product { id } } } }`, nil)
+
+		require.Empty(t, resp.Errors)
+		var page struct {
+			Items []struct{ Product struct{ ID string } }
+		}
+		require.NoError(t, json.Unmarshal(resp.Data["subscriptions"], &page))
+		require.Len(t, page.Items, len(subs))
+		for i, item := range page.Items {
+			assert.Equal(t, subs[i].ProductID.String(), item.Product.ID)
+		}
+		th.products.AssertNumberOfCalls(t, "GetProductsByIDs", 1)
+	})
+
+	t.Run("repository errors carry their code", func(t *testing.T) {
+		th := newTestHandler(1000)
+		th.subscriptions.On("GetSubscription", mock.Anything, product.ID.String()).
+			Return(nil, assert.AnError)
+
+		resp := th.post(t, `{ subscription(id: "465dc700-666c-4b7a-80e2-d9e2967f4442") { id } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "internal server error", resp.Errors[0].Message)
+		assert.Equal(t, "internal_error", resp.Errors[0].Extensions["code"])
+	})
+
+	t.Run("panics become internal errors", func(t *testing.T) {
+		th := newTestHandler(1000)
+		th.subscriptions.On("GetSubscription", mock.Anything, product.ID.String()).
+			Run(func(mock.Arguments) { panic("boom") })
+
+		resp := th.post(t, `{ subscription(id: "465dc700-666c-4b7a-80e2-d9e2967f4442") { id } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "internal_error", resp.Errors[0].Extensions["code"])
+	})
+
+	t.Run("queries over the complexity limit are rejected", func(t *testing.T) {
+		th := newTestHandler(100)
+
+		resp := th.post(t, `{ subscriptions(userId: "`+memberID+`", limit: 100) { items { id product { id name } } } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Contains(t, resp.Errors[0].Message, "exceeds the limit of 100")
+		th.subscriptions.AssertNotCalled(t, "ListSubscriptions", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("subscriptions are listed for one member only", func(t *testing.T) {
+		th := newTestHandler(1000)
+
+		resp := th.post(t, `{ subscriptions(status: ACTIVE) { items { id } } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Contains(t, resp.Errors[0].Message, `argument "userId" of type "ID!" is required`)
+		th.subscriptions.AssertNotCalled(t, "ListSubscriptions", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestMutations(t *testing.T) {
+	id := "465dc700-666c-4b7a-80e2-d9e2967f4442"
+
+	t.Run("pause passes the version", func(t *testing.T) {
+		th := newTestHandler(1000)
+		sub := testutils.NewMockSubscription()
+		sub.Status, sub.Version = models.StatusPaused, 4
+		th.subscriptions.On("PauseSubscription", mock.Anything, id, 3).Return(sub, nil)
+
+		resp := th.post(t, `mutation { pauseSubscription(id: "`+id+`", version: 3) { status version } }`, nil)
+
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"status": "PAUSED", "version": 4}`, string(resp.Data["pauseSubscription"]))
+	})
+
+	t.Run("a stale version is a concurrent modification", func(t *testing.T) {
+		th := newTestHandler(1000)
+		th.subscriptions.On("CancelSubscription", mock.Anything, id, 3).
+			Return(nil, repositories.ErrConcurrentModification)
+
+		resp := th.post(t, `mutation { cancelSubscription(id: "`+id+`", version: 3) { id } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "subscription was modified by another request", resp.Errors[0].Message)
+		assert.Equal(t, "concurrent_modification", resp.Errors[0].Extensions["code"])
+	})
+
+	t.Run("the version is required", func(t *testing.T) {
+		th := newTestHandler(1000)
+
+		resp := th.post(t, `mutation { unpauseSubscription(id: "`+id+`") { id } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Contains(t, resp.Errors[0].Message, `argument "version" of type "Int!" is required`)
+		th.subscriptions.AssertNotCalled(t, "UnpauseSubscription", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("mutations are not accepted over GET", func(t *testing.T) {
+		th := newTestHandler(1000)
+		query := url.Values{"query": {`mutation { pauseSubscription(id: "` + id + `", version: 3) { id } }`}}
+
+		resp := th.serve(t, httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+
+		require.Len(t, resp.Errors, 1)
+		th.subscriptions.AssertNotCalled(t, "PauseSubscription", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("create validates the product ID", func(t *testing.T) {
+		th := newTestHandler(1000)
+
+		resp := th.post(t, `mutation { createSubscription(productId: "nope") { id } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, []any{
+			map[string]any{"field": "productId", "message": "must be a valid UUID"},
+		}, resp.Errors[0].Extensions["fields"])
+	})
+}
+
+func TestIsMutation(t *testing.T) {
+	post := func(body string) *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewBufferString(body))
+	}
+	get := func(query string) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/graphql?"+url.Values{"query": {query}}.Encode(), nil)
+	}
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		mutation bool
+	}{
+		{"query over POST", post(`{"query":"{ products { items { id } } }"}`), false},
+		{"mutation over POST", post(`{"query":"mutation { cancelSubscription(id: \"x\", version: 1) { id } }"}`), true},
+		{"named mutation among queries", post(`{"query":"query A { products { items { id } } } mutation B { cancelSubscription(id: \"x\", version: 1) { id } }","operationName":"B"}`), true},
+		{"named query among mutations", post(`{"query":"query A { products { items { id } } } mutation B { cancelSubscription(id: \"x\", version: 1) { id } }","operationName":"A"}`), false},
+		{"query over GET", get(`{ products { items { id } } }`), false},
+		{"mutation over GET", get(`mutation { cancelSubscription(id: "x", version: 1) { id } }`), true},
+		{"unparsable body", post(`{"query":`), false},
+		{"unparsable query", post(`{"query":"mutation {"}`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before []byte
+			if tt.req.Body != nil {
+				before, _ = io.ReadAll(tt.req.Body)
+				tt.req.Body = io.NopCloser(bytes.NewReader(before))
+			}
+
+			mutation, err := graph.IsMutation(tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.mutation, mutation)
+
+			after, err := io.ReadAll(tt.req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, string(before), string(after), "the handler reads the body again")
+		})
+	}
+
+	t.Run("body over the limit", func(t *testing.T) {
+		req := post(`{"query":"{ products { items { id } } }"}`)
+		req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, 8)
+		_, err := graph.IsMutation(req)
+		var tooLarge *http.MaxBytesError
+		assert.ErrorAs(t, err, &tooLarge)
+	})
+}
@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// loaderWait is how long a loader collects keys before it runs a batch.
+// The resolvers for the items of a list run concurrently, so their keys
+// arrive well within it.
+const loaderWait = time.Millisecond
+
+type loadersKey struct{}
+
+// loaders batch the lookups made while resolving one request, so that
+// the products of a page of subscriptions take one translation lookup
+// rather than one per subscription. Every request gets its own, which
+// also caches what it loaded.
+type loaders struct {
+	// locales are negotiated from the request's Accept-Language header.
+	locales  []string
+	products *dataloader.Loader[uuid.UUID, *models.Product]
+	// localized translates products loaded elsewhere, like those
+	// preloaded with subscriptions, in place.
+	localized *dataloader.Loader[*models.Product, *models.Product]
+}
+
+func newLoaders(locales []string, products repositories.ProductRepository, translations repositories.TranslationRepository) *loaders {
+	return &loaders{
+		locales: locales,
+		products: dataloader.NewBatchedLoader(
+			loadProducts(locales, products, translations),
+			dataloader.WithWait[uuid.UUID, *models.Product](loaderWait),
+		),
+		localized: dataloader.NewBatchedLoader(
+			localizeProducts(locales, translations),
+			dataloader.WithWait[*models.Product, *models.Product](loaderWait),
+		),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// product loads the product with id, localized, as part of a batch.
+func (l *loaders) product(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	return l.products.Load(ctx, id)()
+}
+
+// localize translates a product that is already loaded, as part of a
+// batch.
+func (l *loaders) localize(ctx context.Context, product *models.Product) (*models.Product, error) {
+	return l.localized.Load(ctx, product)()
+}
+
+func loadProducts(locales []string, repo repositories.ProductRepository, translations repositories.TranslationRepository) dataloader.BatchFunc[uuid.UUID, *models.Product] {
+	return func(ctx context.Context, ids []uuid.UUID) []*dataloader.Result[*models.Product] {
+		results := make([]*dataloader.Result[*models.Product], len(ids))
+
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = id.String()
+		}
+		products, err := repo.GetProductsByIDs(ctx, keys)
+		byID := make(map[uuid.UUID]*models.Product, len(products))
+		localized := make([]*models.Product, len(products))
+		for i := range products {
+			byID[products[i].ID] = &products[i]
+			localized[i] = &products[i]
+		}
+		if err == nil {
+			_, err = repositories.LocalizeProducts(ctx, translations, locales, localized...)
+		}
+
+		for i, id := range ids {
+			switch p, ok := byID[id]; {
+			case err != nil:
+				results[i] = &dataloader.Result[*models.Product]{Error: err}
+			case !ok:
+				results[i] = &dataloader.Result[*models.Product]{Error: repositories.ErrProductNotFound}
+			default:
+				results[i] = &dataloader.Result[*models.Product]{Data: p}
+			}
+		}
+		return results
+	}
+}
+
+func localizeProducts(locales []string, translations repositories.TranslationRepository) dataloader.BatchFunc[*models.Product, *models.Product] {
+	return func(ctx context.Context, products []*models.Product) []*dataloader.Result[*models.Product] {
+		_, err := repositories.LocalizeProducts(ctx, translations, locales, products...)
+		results := make([]*dataloader.Result[*models.Product], len(products))
+		for i, p := range products {
+			if err != nil {
+				results[i] = &dataloader.Result[*models.Product]{Error: err}
+			} else {
+				results[i] = &dataloader.Result[*models.Product]{Data: p}
+			}
+		}
+		return results
+	}
+}
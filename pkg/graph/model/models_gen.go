@@ -0,0 +1,175 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package model
+
+import (
+	"fmt"
+	"gymondo_dz/pkg/models"
+	"io"
+	"strconv"
+)
+
+type Mutation struct {
+}
+
+type PageInfo struct {
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+	// Counted in offset mode, and in cursor mode with includeTotal.
+	Total      *int    `json:"total,omitempty"`
+	NextCursor *string `json:"nextCursor,omitempty"`
+	PrevCursor *string `json:"prevCursor,omitempty"`
+}
+
+type ProductFilter struct {
+	// Subscription duration in days: 30, 365 or 36500.
+	Duration *int     `json:"duration,omitempty"`
+	MinPrice *float64 `json:"minPrice,omitempty"`
+	MaxPrice *float64 `json:"maxPrice,omitempty"`
+	// ISO 4217 currency code.
+	Currency *string `json:"currency,omitempty"`
+	// Search term matched against name and description.
+	Q *string `json:"q,omitempty"`
+}
+
+type ProductPage struct {
+	Items    []models.Product `json:"items"`
+	PageInfo *PageInfo        `json:"pageInfo"`
+}
+
+type Query struct {
+}
+
+type SubscriptionPage struct {
+	Items    []models.Subscription `json:"items"`
+	PageInfo *PageInfo             `json:"pageInfo"`
+}
+
+type ProductSort string
+
+const (
+	ProductSortCreatedAt ProductSort = "CREATED_AT"
+	ProductSortPrice     ProductSort = "PRICE"
+	ProductSortName      ProductSort = "NAME"
+)
+
+var AllProductSort = []ProductSort{
+	ProductSortCreatedAt,
+	ProductSortPrice,
+	ProductSortName,
+}
+
+func (e ProductSort) IsValid() bool {
+	switch e {
+	case ProductSortCreatedAt, ProductSortPrice, ProductSortName:
+		return true
+	}
+	return false
+}
+
+func (e ProductSort) String() string {
+	return string(e)
+}
+
+func (e *ProductSort) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ProductSort(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ProductSort", str)
+	}
+	return nil
+}
+
+func (e ProductSort) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SortOrder string
+
+const (
+	SortOrderAsc  SortOrder = "ASC"
+	SortOrderDesc SortOrder = "DESC"
+)
+
+var AllSortOrder = []SortOrder{
+	SortOrderAsc,
+	SortOrderDesc,
+}
+
+func (e SortOrder) IsValid() bool {
+	switch e {
+	case SortOrderAsc, SortOrderDesc:
+		return true
+	}
+	return false
+}
+
+func (e SortOrder) String() string {
+	return string(e)
+}
+
+func (e *SortOrder) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SortOrder(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SortOrder", str)
+	}
+	return nil
+}
+
+func (e SortOrder) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusActive    SubscriptionStatus = "ACTIVE"
+	SubscriptionStatusPaused    SubscriptionStatus = "PAUSED"
+	SubscriptionStatusCancelled SubscriptionStatus = "CANCELLED"
+	SubscriptionStatusExpired   SubscriptionStatus = "EXPIRED"
+)
+
+var AllSubscriptionStatus = []SubscriptionStatus{
+	SubscriptionStatusActive,
+	SubscriptionStatusPaused,
+	SubscriptionStatusCancelled,
+	SubscriptionStatusExpired,
+}
+
+func (e SubscriptionStatus) IsValid() bool {
+	switch e {
+	case SubscriptionStatusActive, SubscriptionStatusPaused, SubscriptionStatusCancelled, SubscriptionStatusExpired:
+		return true
+	}
+	return false
+}
+
+func (e SubscriptionStatus) String() string {
+	return string(e)
+}
+
+func (e *SubscriptionStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SubscriptionStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SubscriptionStatus", str)
+	}
+	return nil
+}
+
+func (e SubscriptionStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
@@ -0,0 +1,41 @@
+// Package graph serves the products and subscriptions over GraphQL at
+// /graphql, on the same repositories as the REST API and with the same
+// validation rules and error codes. The schema is in schema.graphqls;
+// generated.go and model are generated from it by gqlgen.
+package graph
+
+//go:generate go run github.com/99designs/gqlgen@v0.17.66 generate
+
+import (
+	"context"
+
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/reqctx"
+	"gymondo_dz/pkg/validation"
+)
+
+// Resolver resolves the schema. Its methods live in schema.resolvers.go,
+// where gqlgen keeps them across regeneration.
+type Resolver struct {
+	products      repositories.ProductRepository
+	subscriptions repositories.SubscriptionRepository
+	translations  repositories.TranslationRepository
+}
+
+// change applies one of the repository's versioned state changes, which
+// like the If-Match header over HTTP requires the version the caller saw.
+func (r *mutationResolver) change(
+	ctx context.Context,
+	id string,
+	version int,
+	apply func(ctx context.Context, id string, version int) (*models.Subscription, error),
+) (*models.Subscription, error) {
+	args := idArg{ID: id}
+	if err := validation.Struct(&args); err != nil {
+		return nil, err
+	}
+	ctx = reqctx.WithSubscriptionID(ctx, args.ID)
+
+	return apply(ctx, args.ID, version)
+}
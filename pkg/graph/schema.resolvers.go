@@ -0,0 +1,192 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.66
+
+import (
+	"context"
+	"errors"
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/graph/model"
+	"gymondo_dz/pkg/i18n"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/reqctx"
+	"gymondo_dz/pkg/validation"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// CreateSubscription is the resolver for the createSubscription field.
+func (r *mutationResolver) CreateSubscription(ctx context.Context, productID string) (*models.Subscription, error) {
+	args := productIDArg{ProductID: productID}
+	if err := validation.Struct(&args); err != nil {
+		return nil, err
+	}
+
+	product, err := r.products.GetProduct(ctx, args.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	// In a real app, this would come from auth context
+	userID := uuid.New().String()
+	ctx = reqctx.WithUserID(ctx, userID)
+
+	return r.subscriptions.CreateSubscription(ctx, userID, product)
+}
+
+// PauseSubscription is the resolver for the pauseSubscription field.
+func (r *mutationResolver) PauseSubscription(ctx context.Context, id string, version int) (*models.Subscription, error) {
+	return r.change(ctx, id, version, r.subscriptions.PauseSubscription)
+}
+
+// UnpauseSubscription is the resolver for the unpauseSubscription field.
+func (r *mutationResolver) UnpauseSubscription(ctx context.Context, id string, version int) (*models.Subscription, error) {
+	return r.change(ctx, id, version, r.subscriptions.UnpauseSubscription)
+}
+
+// CancelSubscription is the resolver for the cancelSubscription field.
+func (r *mutationResolver) CancelSubscription(ctx context.Context, id string, version int) (*models.Subscription, error) {
+	return r.change(ctx, id, version, r.subscriptions.CancelSubscription)
+}
+
+// Duration is the resolver for the duration field.
+func (r *productResolver) Duration(ctx context.Context, obj *models.Product) (int, error) {
+	return int(obj.Duration), nil
+}
+
+// Locale is the resolver for the locale field.
+func (r *productResolver) Locale(ctx context.Context, obj *models.Product) (string, error) {
+	if obj.Locale == "" {
+		return i18n.DefaultLocale, nil
+	}
+	return obj.Locale, nil
+}
+
+// Products is the resolver for the products field.
+func (r *queryResolver) Products(ctx context.Context, filter *model.ProductFilter, sort *model.ProductSort, order *model.SortOrder, page *int, limit *int, cursor *string, includeTotal *bool) (*model.ProductPage, error) {
+	args := productsArgs{
+		PageQuery: newPageArgs(page, limit, cursor, includeTotal),
+		Sort:      value(sort),
+		Order:     value(order),
+	}
+	if filter != nil {
+		args.Duration = models.SubscriptionDuration(value(filter.Duration))
+		args.MinPrice = filter.MinPrice
+		args.MaxPrice = filter.MaxPrice
+		args.Currency = value(filter.Currency)
+		args.Q = value(filter.Q)
+	}
+	if err := validation.Struct(&args); err != nil {
+		return nil, err
+	}
+
+	if args.MinPrice != nil && args.MaxPrice != nil && *args.MinPrice > *args.MaxPrice {
+		return nil, validation.ErrValidation.WithFields(apperrors.FieldError{
+			Field:   "maxPrice",
+			Message: "must be greater than or equal to minPrice",
+		})
+	}
+
+	products, p, err := r.products.GetProducts(ctx, args.filter(), args.PageRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	localized := make([]*models.Product, len(products))
+	for i := range products {
+		localized[i] = &products[i]
+	}
+	if _, err := repositories.LocalizeProducts(ctx, r.translations, loadersFrom(ctx).locales, localized...); err != nil {
+		return nil, err
+	}
+	return &model.ProductPage{Items: products, PageInfo: pageInfo(p)}, nil
+}
+
+// Product is the resolver for the product field.
+func (r *queryResolver) Product(ctx context.Context, id string) (*models.Product, error) {
+	args := idArg{ID: id}
+	if err := validation.Struct(&args); err != nil {
+		return nil, err
+	}
+
+	product, err := loadersFrom(ctx).product(ctx, uuid.MustParse(args.ID))
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		return nil, nil
+	}
+	return product, err
+}
+
+// Subscriptions is the resolver for the subscriptions field.
+func (r *queryResolver) Subscriptions(ctx context.Context, userID string, productID *string, status *model.SubscriptionStatus, page *int, limit *int, cursor *string, includeTotal *bool) (*model.SubscriptionPage, error) {
+	args := subscriptionsArgs{
+		PageQuery: newPageArgs(page, limit, cursor, includeTotal),
+		UserID:    userID,
+		ProductID: value(productID),
+		Status:    models.SubscriptionStatus(strings.ToLower(string(value(status)))),
+	}
+	if err := validation.Struct(&args); err != nil {
+		return nil, err
+	}
+
+	filter := repositories.SubscriptionFilter{
+		UserID:    args.UserID,
+		ProductID: args.ProductID,
+		Status:    args.Status,
+	}
+	subs, p, err := r.subscriptions.ListSubscriptions(ctx, filter, args.PageRequest())
+	if err != nil {
+		return nil, err
+	}
+	return &model.SubscriptionPage{Items: subs, PageInfo: pageInfo(p)}, nil
+}
+
+// Subscription is the resolver for the subscription field.
+func (r *queryResolver) Subscription(ctx context.Context, id string) (*models.Subscription, error) {
+	args := idArg{ID: id}
+	if err := validation.Struct(&args); err != nil {
+		return nil, err
+	}
+	ctx = reqctx.WithSubscriptionID(ctx, args.ID)
+
+	sub, err := r.subscriptions.GetSubscription(ctx, args.ID)
+	if errors.Is(err, repositories.ErrSubscriptionNotFound) {
+		return nil, nil
+	}
+	return sub, err
+}
+
+// Product is the resolver for the product field.
+func (r *subscriptionResolver) Product(ctx context.Context, obj *models.Subscription) (*models.Product, error) {
+	// The repository preloads the product; the loader localizes the
+	// products of a whole page at once.
+	if obj.Product != nil {
+		return loadersFrom(ctx).localize(ctx, obj.Product)
+	}
+	return loadersFrom(ctx).product(ctx, obj.ProductID)
+}
+
+// Status is the resolver for the status field.
+func (r *subscriptionResolver) Status(ctx context.Context, obj *models.Subscription) (model.SubscriptionStatus, error) {
+	return model.SubscriptionStatus(strings.ToUpper(string(obj.Status))), nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Product returns ProductResolver implementation.
+func (r *Resolver) Product() ProductResolver { return &productResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type productResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
@@ -0,0 +1,121 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gymondo_dz/pkg/grpcapi/gymondov1"
+	"gymondo_dz/pkg/i18n"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newPageQuery takes a PageRequest's parameters, with the default limit
+// for an unset one.
+func newPageQuery(p *gymondov1.PageRequest) repositories.PageQuery {
+	q := repositories.PageQuery{
+		Page:         int(p.GetPage()),
+		Limit:        int(p.GetLimit()),
+		Cursor:       p.GetCursor(),
+		IncludeTotal: p.GetIncludeTotal(),
+	}
+	if q.Limit == 0 {
+		q.Limit = repositories.DefaultPageLimit
+	}
+	return q
+}
+
+func pageInfo(p repositories.Pagination) *gymondov1.PageInfo {
+	return &gymondov1.PageInfo{
+		Page:       int32(p.Page),
+		Limit:      int32(p.Limit),
+		Total:      p.Total,
+		NextCursor: p.NextCursor,
+		PrevCursor: p.PrevCursor,
+	}
+}
+
+// localize translates products into the best locale negotiated from the
+// call's accept-language metadata and reports it in the content-language
+// header, as the REST API does with the HTTP headers of the same names.
+func localize(ctx context.Context, translations repositories.TranslationRepository, products ...*models.Product) error {
+	acceptLanguage := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		acceptLanguage = strings.Join(md.Get("accept-language"), ", ")
+	}
+
+	contentLanguage, err := repositories.LocalizeProducts(ctx, translations, i18n.Negotiate(acceptLanguage), products...)
+	if err != nil {
+		return err
+	}
+	return grpc.SetHeader(ctx, metadata.Pairs("content-language", contentLanguage))
+}
+
+var subscriptionStatuses = map[models.SubscriptionStatus]gymondov1.SubscriptionStatus{
+	models.StatusActive:    gymondov1.SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE,
+	models.StatusPaused:    gymondov1.SubscriptionStatus_SUBSCRIPTION_STATUS_PAUSED,
+	models.StatusCancelled: gymondov1.SubscriptionStatus_SUBSCRIPTION_STATUS_CANCELLED,
+	models.StatusExpired:   gymondov1.SubscriptionStatus_SUBSCRIPTION_STATUS_EXPIRED,
+}
+
+// modelStatus returns the status s stands for, "" for unspecified and
+// "unknown" for values this build does not know, which validation
+// rejects.
+func modelStatus(s gymondov1.SubscriptionStatus) models.SubscriptionStatus {
+	if s == gymondov1.SubscriptionStatus_SUBSCRIPTION_STATUS_UNSPECIFIED {
+		return ""
+	}
+	for status, value := range subscriptionStatuses {
+		if value == s {
+			return status
+		}
+	}
+	return "unknown"
+}
+
+func toProduct(p *models.Product) *gymondov1.Product {
+	if p == nil {
+		return nil
+	}
+	return &gymondov1.Product{
+		Id:          p.ID.String(),
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		TaxRate:     p.TaxRate,
+		TotalPrice:  p.TotalPrice,
+		Currency:    p.Currency,
+		Duration:    int32(p.Duration),
+		CreatedAt:   timestamppb.New(p.CreatedAt),
+		UpdatedAt:   timestamppb.New(p.UpdatedAt),
+	}
+}
+
+func toSubscription(s *models.Subscription) *gymondov1.Subscription {
+	return &gymondov1.Subscription{
+		Id:          s.ID.String(),
+		UserId:      s.UserID.String(),
+		ProductId:   s.ProductID.String(),
+		Product:     toProduct(s.Product),
+		StartDate:   timestamppb.New(s.StartDate),
+		EndDate:     timestamppb.New(s.EndDate),
+		Status:      subscriptionStatuses[s.Status],
+		PausedAt:    optionalTimestamp(s.PausedAt),
+		CancelledAt: optionalTimestamp(s.CancelledAt),
+		Version:     int32(s.Version),
+		CreatedAt:   timestamppb.New(s.CreatedAt),
+		UpdatedAt:   timestamppb.New(s.UpdatedAt),
+	}
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,66 @@
+package grpcapi
+
+import (
+	"gymondo_dz/pkg/apperrors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// errorDomain names this service in the ErrorInfo of every error.
+const errorDomain = "gymondo"
+
+// statusCodes maps the error codes shared with the REST API to gRPC
+// status codes; anything else is Internal.
+var statusCodes = map[string]codes.Code{
+	apperrors.CodeNotFound:               codes.NotFound,
+	apperrors.CodeInvalidID:              codes.InvalidArgument,
+	apperrors.CodeValidation:             codes.InvalidArgument,
+	apperrors.CodeBadRequest:             codes.InvalidArgument,
+	apperrors.CodeInvalidState:           codes.FailedPrecondition,
+	apperrors.CodePreconditionRequired:   codes.FailedPrecondition,
+	apperrors.CodeConcurrentModification: codes.Aborted,
+	apperrors.CodeTimeout:                codes.DeadlineExceeded,
+	apperrors.CodeCanceled:               codes.Canceled,
+	apperrors.CodeRateLimited:            codes.ResourceExhausted,
+	apperrors.CodeUnavailable:            codes.Unavailable,
+}
+
+// Status converts err, as returned by the repositories, into the status
+// clients receive. It is classified as the REST API would: the message is
+// the detail rendered there, an ErrorInfo carries the same error code,
+// and field errors and Retry-After become BadRequest and RetryInfo
+// details. A nil err is OK.
+func Status(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+
+	appErr := apperrors.From(err)
+	code, ok := statusCodes[appErr.Code]
+	if !ok {
+		code = codes.Internal
+	}
+	st := status.New(code, appErr.Detail)
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: appErr.Code, Domain: errorDomain}}
+	if len(appErr.Fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(appErr.Fields))
+		for i, f := range appErr.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message}
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+	if appErr.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(appErr.RetryAfter)})
+	}
+
+	// only fails for an OK status, which this never is
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		return withDetails
+	}
+	return st
+}
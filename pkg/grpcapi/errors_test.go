@@ -0,0 +1,91 @@
+package grpcapi_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/grpcapi"
+	"gymondo_dz/pkg/ratelimit"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/validation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err     error
+		code    codes.Code
+		reason  string
+		message string
+	}{
+		{repositories.ErrProductNotFound, codes.NotFound, apperrors.CodeNotFound, "product not found"},
+		{repositories.ErrSubscriptionNotFound, codes.NotFound, apperrors.CodeNotFound, "subscription not found"},
+		{repositories.ErrInvalidProductID, codes.InvalidArgument, apperrors.CodeInvalidID, "invalid product ID format"},
+		{repositories.ErrInvalidSubscriptionID, codes.InvalidArgument, apperrors.CodeInvalidID, "invalid subscription ID format"},
+		{repositories.ErrInvalidSort, codes.InvalidArgument, apperrors.CodeValidation, "unsupported sort field"},
+		{repositories.ErrInvalidCursor, codes.InvalidArgument, apperrors.CodeValidation, "invalid pagination cursor"},
+		{repositories.ErrProductRequired, codes.InvalidArgument, apperrors.CodeBadRequest, "product reference required"},
+		{repositories.ErrCannotPause, codes.FailedPrecondition, apperrors.CodeInvalidState, "subscription cannot be paused"},
+		{repositories.ErrCannotUnpause, codes.FailedPrecondition, apperrors.CodeInvalidState, "subscription cannot be unpaused"},
+		{repositories.ErrCannotCancel, codes.FailedPrecondition, apperrors.CodeInvalidState, "subscription cannot be cancelled"},
+		{repositories.ErrInvalidProductDuration, codes.FailedPrecondition, apperrors.CodeInvalidState, "product duration must be positive"},
+		{repositories.ErrConcurrentModification, codes.Aborted, apperrors.CodeConcurrentModification, "subscription was modified by another request"},
+		{fmt.Errorf("pause: %w", repositories.ErrCannotPause), codes.FailedPrecondition, apperrors.CodeInvalidState, "subscription cannot be paused"},
+		{context.DeadlineExceeded, codes.DeadlineExceeded, apperrors.CodeTimeout, ""},
+		{context.Canceled, codes.Canceled, apperrors.CodeCanceled, ""},
+		{apperrors.ErrUnavailable, codes.Unavailable, apperrors.CodeUnavailable, ""},
+		{errors.New("boom"), codes.Internal, apperrors.CodeInternal, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			st := grpcapi.Status(tt.err)
+			assert.Equal(t, tt.code, st.Code())
+			if tt.message != "" {
+				assert.Equal(t, tt.message, st.Message())
+			}
+			assert.NotContains(t, st.Message(), "boom")
+
+			require.NotEmpty(t, st.Details())
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			assert.Equal(t, tt.reason, info.GetReason())
+			assert.Equal(t, "gymondo", info.GetDomain())
+		})
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		assert.Equal(t, codes.OK, grpcapi.Status(nil).Code())
+	})
+
+	t.Run("field errors", func(t *testing.T) {
+		err := validation.ErrValidation.WithFields(apperrors.FieldError{Field: "max_price", Message: "must be greater than or equal to min_price"})
+		st := grpcapi.Status(err)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+
+		require.Len(t, st.Details(), 2)
+		badRequest, ok := st.Details()[1].(*errdetails.BadRequest)
+		require.True(t, ok)
+		require.Len(t, badRequest.GetFieldViolations(), 1)
+		assert.Equal(t, "max_price", badRequest.GetFieldViolations()[0].GetField())
+		assert.Equal(t, "must be greater than or equal to min_price", badRequest.GetFieldViolations()[0].GetDescription())
+	})
+
+	t.Run("retry after", func(t *testing.T) {
+		st := grpcapi.Status(ratelimit.ErrRateLimited.WithRetryAfter(3 * time.Second))
+		assert.Equal(t, codes.ResourceExhausted, st.Code())
+
+		require.Len(t, st.Details(), 2)
+		retry, ok := st.Details()[1].(*errdetails.RetryInfo)
+		require.True(t, ok)
+		assert.Equal(t, 3*time.Second, retry.GetRetryDelay().AsDuration())
+	})
+}
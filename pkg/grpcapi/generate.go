@@ -0,0 +1,3 @@
+package grpcapi
+
+//go:generate protoc -I ../../proto --go_out=. --go_opt=module=gymondo_dz/pkg/grpcapi --go-grpc_out=. --go-grpc_opt=module=gymondo_dz/pkg/grpcapi gymondo/v1/common.proto gymondo/v1/product.proto gymondo/v1/subscription.proto
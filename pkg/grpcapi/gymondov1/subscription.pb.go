@@ -0,0 +1,617 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: gymondo/v1/subscription.proto
+
+package gymondov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SubscriptionStatus int32
+
+const (
+	SubscriptionStatus_SUBSCRIPTION_STATUS_UNSPECIFIED SubscriptionStatus = 0
+	SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE      SubscriptionStatus = 1
+	SubscriptionStatus_SUBSCRIPTION_STATUS_PAUSED      SubscriptionStatus = 2
+	SubscriptionStatus_SUBSCRIPTION_STATUS_CANCELLED   SubscriptionStatus = 3
+	SubscriptionStatus_SUBSCRIPTION_STATUS_EXPIRED     SubscriptionStatus = 4
+)
+
+// Enum value maps for SubscriptionStatus.
+var (
+	SubscriptionStatus_name = map[int32]string{
+		0: "SUBSCRIPTION_STATUS_UNSPECIFIED",
+		1: "SUBSCRIPTION_STATUS_ACTIVE",
+		2: "SUBSCRIPTION_STATUS_PAUSED",
+		3: "SUBSCRIPTION_STATUS_CANCELLED",
+		4: "SUBSCRIPTION_STATUS_EXPIRED",
+	}
+	SubscriptionStatus_value = map[string]int32{
+		"SUBSCRIPTION_STATUS_UNSPECIFIED": 0,
+		"SUBSCRIPTION_STATUS_ACTIVE":      1,
+		"SUBSCRIPTION_STATUS_PAUSED":      2,
+		"SUBSCRIPTION_STATUS_CANCELLED":   3,
+		"SUBSCRIPTION_STATUS_EXPIRED":     4,
+	}
+)
+
+func (x SubscriptionStatus) Enum() *SubscriptionStatus {
+	p := new(SubscriptionStatus)
+	*p = x
+	return p
+}
+
+func (x SubscriptionStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SubscriptionStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_gymondo_v1_subscription_proto_enumTypes[0].Descriptor()
+}
+
+func (SubscriptionStatus) Type() protoreflect.EnumType {
+	return &file_gymondo_v1_subscription_proto_enumTypes[0]
+}
+
+func (x SubscriptionStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SubscriptionStatus.Descriptor instead.
+func (SubscriptionStatus) EnumDescriptor() ([]byte, []int) {
+	return file_gymondo_v1_subscription_proto_rawDescGZIP(), []int{0}
+}
+
+type Subscription struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId      string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ProductId   string                 `protobuf:"bytes,3,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Product     *Product               `protobuf:"bytes,4,opt,name=product,proto3" json:"product,omitempty"`
+	StartDate   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	Status      SubscriptionStatus     `protobuf:"varint,7,opt,name=status,proto3,enum=gymondo.v1.SubscriptionStatus" json:"status,omitempty"`
+	PausedAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=paused_at,json=pausedAt,proto3" json:"paused_at,omitempty"`
+	CancelledAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=cancelled_at,json=cancelledAt,proto3" json:"cancelled_at,omitempty"`
+	// Version to pass as expected_version when changing the subscription.
+	Version       int32                  `protobuf:"varint,10,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subscription) Reset() {
+	*x = Subscription{}
+	mi := &file_gymondo_v1_subscription_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscription) ProtoMessage() {}
+
+func (x *Subscription) ProtoReflect() protoreflect.Message {
+	mi := &file_gymondo_v1_subscription_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
+func (*Subscription) Descriptor() ([]byte, []int) {
+	return file_gymondo_v1_subscription_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Subscription) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Subscription) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Subscription) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *Subscription) GetProduct() *Product {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
+func (x *Subscription) GetStartDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *Subscription) GetEndDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+func (x *Subscription) GetStatus() SubscriptionStatus {
+	if x != nil {
+		return x.Status
+	}
+	return SubscriptionStatus_SUBSCRIPTION_STATUS_UNSPECIFIED
+}
+
+func (x *Subscription) GetPausedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PausedAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetCancelledAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CancelledAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Subscription) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListSubscriptionsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Page  *PageRequest           `protobuf:"bytes,1,opt,name=page,proto3" json:"page,omitempty"`
+	// Required: subscriptions are listed for one user at a time.
+	UserId    string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ProductId string `protobuf:"bytes,3,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	// Leave unspecified for every status.
+	Status        SubscriptionStatus `protobuf:"varint,4,opt,name=status,proto3,enum=gymondo.v1.SubscriptionStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubscriptionsRequest) Reset() {
+	*x = ListSubscriptionsRequest{}
+	mi := &file_gymondo_v1_subscription_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubscriptionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubscriptionsRequest) ProtoMessage() {}
+
+func (x *ListSubscriptionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gymondo_v1_subscription_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubscriptionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSubscriptionsRequest) Descriptor() ([]byte, []int) {
+	return file_gymondo_v1_subscription_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListSubscriptionsRequest) GetPage() *PageRequest {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+func (x *ListSubscriptionsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListSubscriptionsRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *ListSubscriptionsRequest) GetStatus() SubscriptionStatus {
+	if x != nil {
+		return x.Status
+	}
+	return SubscriptionStatus_SUBSCRIPTION_STATUS_UNSPECIFIED
+}
+
+type ListSubscriptionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscriptions []*Subscription        `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	Page          *PageInfo              `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubscriptionsResponse) Reset() {
+	*x = ListSubscriptionsResponse{}
+	mi := &file_gymondo_v1_subscription_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubscriptionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubscriptionsResponse) ProtoMessage() {}
+
+func (x *ListSubscriptionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gymondo_v1_subscription_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubscriptionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSubscriptionsResponse) Descriptor() ([]byte, []int) {
+	return file_gymondo_v1_subscription_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListSubscriptionsResponse) GetSubscriptions() []*Subscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+func (x *ListSubscriptionsResponse) GetPage() *PageInfo {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type CreateSubscriptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSubscriptionRequest) Reset() {
+	*x = CreateSubscriptionRequest{}
+	mi := &file_gymondo_v1_subscription_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSubscriptionRequest) ProtoMessage() {}
+
+func (x *CreateSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gymondo_v1_subscription_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*CreateSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_gymondo_v1_subscription_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateSubscriptionRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+type GetSubscriptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSubscriptionRequest) Reset() {
+	*x = GetSubscriptionRequest{}
+	mi := &file_gymondo_v1_subscription_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSubscriptionRequest) ProtoMessage() {}
+
+func (x *GetSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gymondo_v1_subscription_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*GetSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_gymondo_v1_subscription_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetSubscriptionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ChangeSubscriptionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// The version the change is based on; required.
+	ExpectedVersion *int32 `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ChangeSubscriptionRequest) Reset() {
+	*x = ChangeSubscriptionRequest{}
+	mi := &file_gymondo_v1_subscription_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeSubscriptionRequest) ProtoMessage() {}
+
+func (x *ChangeSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gymondo_v1_subscription_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*ChangeSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_gymondo_v1_subscription_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ChangeSubscriptionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ChangeSubscriptionRequest) GetExpectedVersion() int32 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+var File_gymondo_v1_subscription_proto protoreflect.FileDescriptor
+
+const file_gymondo_v1_subscription_proto_rawDesc = "" +
+	"\n" +
+	"\x1dgymondo/v1/subscription.proto\x12\n" +
+	"gymondo.v1\x1a\x17gymondo/v1/common.proto\x1a\x18gymondo/v1/product.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb7\x04\n" +
+	"\fSubscription\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x03 \x01(\tR\tproductId\x12-\n" +
+	"\aproduct\x18\x04 \x01(\v2\x13.gymondo.v1.ProductR\aproduct\x129\n" +
+	"\n" +
+	"start_date\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tstartDate\x125\n" +
+	"\bend_date\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\aendDate\x126\n" +
+	"\x06status\x18\a \x01(\x0e2\x1e.gymondo.v1.SubscriptionStatusR\x06status\x127\n" +
+	"\tpaused_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\bpausedAt\x12=\n" +
+	"\fcancelled_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\vcancelledAt\x12\x18\n" +
+	"\aversion\x18\n" +
+	" \x01(\x05R\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xb7\x01\n" +
+	"\x18ListSubscriptionsRequest\x12+\n" +
+	"\x04page\x18\x01 \x01(\v2\x17.gymondo.v1.PageRequestR\x04page\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x03 \x01(\tR\tproductId\x126\n" +
+	"\x06status\x18\x04 \x01(\x0e2\x1e.gymondo.v1.SubscriptionStatusR\x06status\"\x85\x01\n" +
+	"\x19ListSubscriptionsResponse\x12>\n" +
+	"\rsubscriptions\x18\x01 \x03(\v2\x18.gymondo.v1.SubscriptionR\rsubscriptions\x12(\n" +
+	"\x04page\x18\x02 \x01(\v2\x14.gymondo.v1.PageInfoR\x04page\":\n" +
+	"\x19CreateSubscriptionRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\"(\n" +
+	"\x16GetSubscriptionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"p\n" +
+	"\x19ChangeSubscriptionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12.\n" +
+	"\x10expected_version\x18\x02 \x01(\x05H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version*\xbd\x01\n" +
+	"\x12SubscriptionStatus\x12#\n" +
+	"\x1fSUBSCRIPTION_STATUS_UNSPECIFIED\x10\x00\x12\x1e\n" +
+	"\x1aSUBSCRIPTION_STATUS_ACTIVE\x10\x01\x12\x1e\n" +
+	"\x1aSUBSCRIPTION_STATUS_PAUSED\x10\x02\x12!\n" +
+	"\x1dSUBSCRIPTION_STATUS_CANCELLED\x10\x03\x12\x1f\n" +
+	"\x1bSUBSCRIPTION_STATUS_EXPIRED\x10\x042\xa4\x04\n" +
+	"\x13SubscriptionService\x12`\n" +
+	"\x11ListSubscriptions\x12$.gymondo.v1.ListSubscriptionsRequest\x1a%.gymondo.v1.ListSubscriptionsResponse\x12U\n" +
+	"\x12CreateSubscription\x12%.gymondo.v1.CreateSubscriptionRequest\x1a\x18.gymondo.v1.Subscription\x12O\n" +
+	"\x0fGetSubscription\x12\".gymondo.v1.GetSubscriptionRequest\x1a\x18.gymondo.v1.Subscription\x12T\n" +
+	"\x11PauseSubscription\x12%.gymondo.v1.ChangeSubscriptionRequest\x1a\x18.gymondo.v1.Subscription\x12V\n" +
+	"\x13UnpauseSubscription\x12%.gymondo.v1.ChangeSubscriptionRequest\x1a\x18.gymondo.v1.Subscription\x12U\n" +
+	"\x12CancelSubscription\x12%.gymondo.v1.ChangeSubscriptionRequest\x1a\x18.gymondo.v1.SubscriptionB\"Z gymondo_dz/pkg/grpcapi/gymondov1b\x06proto3"
+
+var (
+	file_gymondo_v1_subscription_proto_rawDescOnce sync.Once
+	file_gymondo_v1_subscription_proto_rawDescData []byte
+)
+
+func file_gymondo_v1_subscription_proto_rawDescGZIP() []byte {
+	file_gymondo_v1_subscription_proto_rawDescOnce.Do(func() {
+		file_gymondo_v1_subscription_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gymondo_v1_subscription_proto_rawDesc), len(file_gymondo_v1_subscription_proto_rawDesc)))
+	})
+	return file_gymondo_v1_subscription_proto_rawDescData
+}
+
+var file_gymondo_v1_subscription_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_gymondo_v1_subscription_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_gymondo_v1_subscription_proto_goTypes = []any{
+	(SubscriptionStatus)(0),           // 0: gymondo.v1.SubscriptionStatus
+	(*Subscription)(nil),              // 1: gymondo.v1.Subscription
+	(*ListSubscriptionsRequest)(nil),  // 2: gymondo.v1.ListSubscriptionsRequest
+	(*ListSubscriptionsResponse)(nil), // 3: gymondo.v1.ListSubscriptionsResponse
+	(*CreateSubscriptionRequest)(nil), // 4: gymondo.v1.CreateSubscriptionRequest
+	(*GetSubscriptionRequest)(nil),    // 5: gymondo.v1.GetSubscriptionRequest
+	(*ChangeSubscriptionRequest)(nil), // 6: gymondo.v1.ChangeSubscriptionRequest
+	(*Product)(nil),                   // 7: gymondo.v1.Product
+	(*timestamppb.Timestamp)(nil),     // 8: google.protobuf.Timestamp
+	(*PageRequest)(nil),               // 9: gymondo.v1.PageRequest
+	(*PageInfo)(nil),                  // 10: gymondo.v1.PageInfo
+}
+var file_gymondo_v1_subscription_proto_depIdxs = []int32{
+	7,  // 0: gymondo.v1.Subscription.product:type_name -> gymondo.v1.Product
+	8,  // 1: gymondo.v1.Subscription.start_date:type_name -> google.protobuf.Timestamp
+	8,  // 2: gymondo.v1.Subscription.end_date:type_name -> google.protobuf.Timestamp
+	0,  // 3: gymondo.v1.Subscription.status:type_name -> gymondo.v1.SubscriptionStatus
+	8,  // 4: gymondo.v1.Subscription.paused_at:type_name -> google.protobuf.Timestamp
+	8,  // 5: gymondo.v1.Subscription.cancelled_at:type_name -> google.protobuf.Timestamp
+	8,  // 6: gymondo.v1.Subscription.created_at:type_name -> google.protobuf.Timestamp
+	8,  // 7: gymondo.v1.Subscription.updated_at:type_name -> google.protobuf.Timestamp
+	9,  // 8: gymondo.v1.ListSubscriptionsRequest.page:type_name -> gymondo.v1.PageRequest
+	0,  // 9: gymondo.v1.ListSubscriptionsRequest.status:type_name -> gymondo.v1.SubscriptionStatus
+	1,  // 10: gymondo.v1.ListSubscriptionsResponse.subscriptions:type_name -> gymondo.v1.Subscription
+	10, // 11: gymondo.v1.ListSubscriptionsResponse.page:type_name -> gymondo.v1.PageInfo
+	2,  // 12: gymondo.v1.SubscriptionService.ListSubscriptions:input_type -> gymondo.v1.ListSubscriptionsRequest
+	4,  // 13: gymondo.v1.SubscriptionService.CreateSubscription:input_type -> gymondo.v1.CreateSubscriptionRequest
+	5,  // 14: gymondo.v1.SubscriptionService.GetSubscription:input_type -> gymondo.v1.GetSubscriptionRequest
+	6,  // 15: gymondo.v1.SubscriptionService.PauseSubscription:input_type -> gymondo.v1.ChangeSubscriptionRequest
+	6,  // 16: gymondo.v1.SubscriptionService.UnpauseSubscription:input_type -> gymondo.v1.ChangeSubscriptionRequest
+	6,  // 17: gymondo.v1.SubscriptionService.CancelSubscription:input_type -> gymondo.v1.ChangeSubscriptionRequest
+	3,  // 18: gymondo.v1.SubscriptionService.ListSubscriptions:output_type -> gymondo.v1.ListSubscriptionsResponse
+	1,  // 19: gymondo.v1.SubscriptionService.CreateSubscription:output_type -> gymondo.v1.Subscription
+	1,  // 20: gymondo.v1.SubscriptionService.GetSubscription:output_type -> gymondo.v1.Subscription
+	1,  // 21: gymondo.v1.SubscriptionService.PauseSubscription:output_type -> gymondo.v1.Subscription
+	1,  // 22: gymondo.v1.SubscriptionService.UnpauseSubscription:output_type -> gymondo.v1.Subscription
+	1,  // 23: gymondo.v1.SubscriptionService.CancelSubscription:output_type -> gymondo.v1.Subscription
+	18, // [18:24] is the sub-list for method output_type
+	12, // [12:18] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_gymondo_v1_subscription_proto_init() }
+func file_gymondo_v1_subscription_proto_init() {
+	if File_gymondo_v1_subscription_proto != nil {
+		return
+	}
+	file_gymondo_v1_common_proto_init()
+	file_gymondo_v1_product_proto_init()
+	file_gymondo_v1_subscription_proto_msgTypes[5].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gymondo_v1_subscription_proto_rawDesc), len(file_gymondo_v1_subscription_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gymondo_v1_subscription_proto_goTypes,
+		DependencyIndexes: file_gymondo_v1_subscription_proto_depIdxs,
+		EnumInfos:         file_gymondo_v1_subscription_proto_enumTypes,
+		MessageInfos:      file_gymondo_v1_subscription_proto_msgTypes,
+	}.Build()
+	File_gymondo_v1_subscription_proto = out.File
+	file_gymondo_v1_subscription_proto_goTypes = nil
+	file_gymondo_v1_subscription_proto_depIdxs = nil
+}
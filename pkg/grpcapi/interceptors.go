@@ -0,0 +1,102 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"gymondo_dz/pkg/logging"
+	"gymondo_dz/pkg/reqctx"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// requestIDKey is the metadata key carrying the request ID, the gRPC
+// counterpart of X-Request-ID.
+const requestIDKey = "x-request-id"
+
+// observe does for calls what the RequestID and Logger middleware do for
+// HTTP requests: it assigns a request ID, reusing a valid one sent by the
+// caller, stores a logger carrying the method in the context and writes
+// one access log entry per call. It also turns the error returned into
+// the status clients receive, logging the error it came from.
+func observe(base *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		id := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(requestIDKey); len(values) > 0 {
+				id = values[0]
+			}
+		}
+		if !reqctx.ValidRequestID(id) {
+			id = uuid.NewString()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+
+		l := base.With(slog.String("method", info.FullMethod))
+		ctx = logging.NewContext(reqctx.WithRequestID(ctx, id), l)
+
+		resp, err := handler(ctx, req)
+		st := Status(err)
+
+		level := slog.LevelInfo
+		switch st.Code() {
+		case codes.OK:
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded:
+			level = slog.LevelError
+		default:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("code", st.Code().String()),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+		}
+		if p, ok := peer.FromContext(ctx); ok {
+			attrs = append(attrs, slog.String("client_addr", p.Addr.String()))
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		l.LogAttrs(ctx, level, "call", attrs...)
+		return resp, st.Err()
+	}
+}
+
+// recoverPanics turns a panic into an internal error and logs it with
+// its stack trace.
+func recoverPanics() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "panic recovered",
+					slog.Any("panic", recovered),
+					slog.String("stack", string(debug.Stack())),
+				)
+				resp, err = nil, fmt.Errorf("panic: %v", recovered)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// timeout bounds the work done for a call as middleware.Timeout does for
+// HTTP requests; a shorter client deadline still applies. A zero d
+// disables it.
+func timeout(d time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if d <= 0 {
+			return handler(ctx, req)
+		}
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,120 @@
+package grpcapi
+
+import (
+	"context"
+
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/grpcapi/gymondov1"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/validation"
+)
+
+type listProductsQuery struct {
+	repositories.PageQuery
+	Duration models.SubscriptionDuration `json:"duration" binding:"omitempty,subscription_duration"`
+	MinPrice *float64                    `json:"min_price" binding:"omitempty,gte=0"`
+	MaxPrice *float64                    `json:"max_price" binding:"omitempty,gte=0"`
+	Currency string                      `json:"currency" binding:"omitempty,currency"`
+	Q        string                      `json:"q" binding:"omitempty,max=100"`
+	Sort     string                      `json:"sort" binding:"oneof=created_at price name"`
+	Order    string                      `json:"order" binding:"oneof=asc desc"`
+}
+
+func newListProductsQuery(req *gymondov1.ListProductsRequest) listProductsQuery {
+	q := listProductsQuery{
+		PageQuery: newPageQuery(req.GetPage()),
+		Duration:  models.SubscriptionDuration(req.GetDuration()),
+		MinPrice:  req.MinPrice,
+		MaxPrice:  req.MaxPrice,
+		Currency:  req.GetCurrency(),
+		Q:         req.GetQ(),
+		Sort:      req.GetSort(),
+		Order:     req.GetOrder(),
+	}
+	if q.Sort == "" {
+		q.Sort = "created_at"
+	}
+	if q.Order == "" {
+		q.Order = "asc"
+	}
+	return q
+}
+
+func (q listProductsQuery) filter() repositories.ProductFilter {
+	return repositories.ProductFilter{
+		Duration: q.Duration,
+		MinPrice: q.MinPrice,
+		MaxPrice: q.MaxPrice,
+		Currency: q.Currency,
+		Query:    q.Q,
+		SortBy:   q.Sort,
+		SortDesc: q.Order == "desc",
+	}
+}
+
+type productID struct {
+	ID string `json:"id" binding:"required,resource_id"`
+}
+
+// productService implements ProductService on the repositories the
+// ProductHandler uses.
+type productService struct {
+	gymondov1.UnimplementedProductServiceServer
+
+	repo         repositories.ProductRepository
+	translations repositories.TranslationRepository
+}
+
+func (s *productService) ListProducts(ctx context.Context, req *gymondov1.ListProductsRequest) (*gymondov1.ListProductsResponse, error) {
+	query := newListProductsQuery(req)
+	if err := validation.Struct(&query); err != nil {
+		return nil, err
+	}
+
+	if query.MinPrice != nil && query.MaxPrice != nil && *query.MinPrice > *query.MaxPrice {
+		return nil, validation.ErrValidation.WithFields(apperrors.FieldError{
+			Field:   "max_price",
+			Message: "must be greater than or equal to min_price",
+		})
+	}
+
+	products, page, err := s.repo.GetProducts(ctx, query.filter(), query.PageRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	localized := make([]*models.Product, len(products))
+	for i := range products {
+		localized[i] = &products[i]
+	}
+	if err := localize(ctx, s.translations, localized...); err != nil {
+		return nil, err
+	}
+
+	resp := &gymondov1.ListProductsResponse{
+		Products: make([]*gymondov1.Product, len(products)),
+		Page:     pageInfo(page),
+	}
+	for i := range products {
+		resp.Products[i] = toProduct(&products[i])
+	}
+	return resp, nil
+}
+
+func (s *productService) GetProduct(ctx context.Context, req *gymondov1.GetProductRequest) (*gymondov1.Product, error) {
+	id := productID{ID: req.GetId()}
+	if err := validation.Struct(&id); err != nil {
+		return nil, err
+	}
+
+	product, err := s.repo.GetProduct(ctx, id.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := localize(ctx, s.translations, product); err != nil {
+		return nil, err
+	}
+	return toProduct(product), nil
+}
@@ -0,0 +1,127 @@
+// Package grpcapi serves the products and subscriptions over gRPC for
+// internal services, on the same repositories as the REST API and with
+// the same validation rules and error codes.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/grpcapi/gymondov1"
+	"gymondo_dz/pkg/ratelimit"
+	"gymondo_dz/pkg/repositories"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server is the gRPC server. Its health service reports SERVING while it
+// accepts calls and NOT_SERVING once draining starts.
+type Server struct {
+	grpc   *grpc.Server
+	health *health.Server
+}
+
+// NewServer registers ProductService and SubscriptionService on a server
+// whose calls are traced, logged through logger and bounded by
+// requestTimeout, like HTTP requests. interceptors, such as metrics and
+// rate limits, run in between, on the errors the services return.
+func NewServer(
+	cfg config.GRPCConfig,
+	requestTimeout time.Duration,
+	logger *slog.Logger,
+	productRepo repositories.ProductRepository,
+	subscriptionRepo repositories.SubscriptionRepository,
+	translations repositories.TranslationRepository,
+	interceptors ...grpc.UnaryServerInterceptor,
+) *Server {
+	chain := append([]grpc.UnaryServerInterceptor{observe(logger), recoverPanics()}, interceptors...)
+	s := &Server{
+		grpc: grpc.NewServer(
+			grpc.StatsHandler(otelgrpc.NewServerHandler()),
+			grpc.ChainUnaryInterceptor(append(chain, timeout(requestTimeout))...),
+		),
+		health: health.NewServer(),
+	}
+
+	gymondov1.RegisterProductServiceServer(s.grpc, &productService{
+		repo:         productRepo,
+		translations: translations,
+	})
+	gymondov1.RegisterSubscriptionServiceServer(s.grpc, &subscriptionService{
+		repo:         subscriptionRepo,
+		productRepo:  productRepo,
+		translations: translations,
+	})
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	if cfg.Reflection {
+		reflection.Register(s.grpc)
+	}
+
+	// not serving until Serve is called
+	s.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	return s
+}
+
+// Serve accepts calls on ln until ctx is cancelled, then waits for the
+// calls in flight to finish. It is meant to run as a server worker.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.grpc.Serve(ln)
+	}()
+	s.setServingStatus(healthpb.HealthCheckResponse_SERVING)
+	slog.Info("gRPC server listening", "addr", ln.Addr().String())
+
+	select {
+	case err := <-serveErr:
+		if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			slog.Error("gRPC server failed", "error", err)
+		}
+	case <-ctx.Done():
+	}
+	s.health.Shutdown()
+	s.grpc.GracefulStop()
+}
+
+// Drain reports NOT_SERVING and stops accepting calls without waiting for
+// the ones in flight, so that calls drain alongside HTTP requests.
+func (s *Server) Drain() {
+	s.health.Shutdown()
+	go s.grpc.GracefulStop()
+}
+
+// RateLimits limits the calls that change subscriptions by write and the
+// other calls by read, as the REST routes are. Health checks are not
+// limited.
+func RateLimits(read, write ratelimit.Policy) func(fullMethod string) (ratelimit.Policy, bool) {
+	return func(fullMethod string) (ratelimit.Policy, bool) {
+		switch fullMethod {
+		case gymondov1.SubscriptionService_CreateSubscription_FullMethodName,
+			gymondov1.SubscriptionService_PauseSubscription_FullMethodName,
+			gymondov1.SubscriptionService_UnpauseSubscription_FullMethodName,
+			gymondov1.SubscriptionService_CancelSubscription_FullMethodName:
+			return write, true
+		case healthpb.Health_Check_FullMethodName:
+			return ratelimit.Policy{}, false
+		}
+		return read, true
+	}
+}
+
+func (s *Server) setServingStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	for _, service := range []string{
+		"",
+		gymondov1.ProductService_ServiceDesc.ServiceName,
+		gymondov1.SubscriptionService_ServiceDesc.ServiceName,
+	} {
+		s.health.SetServingStatus(service, status)
+	}
+}
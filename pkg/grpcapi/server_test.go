@@ -0,0 +1,475 @@
+package grpcapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/grpcapi"
+	"gymondo_dz/pkg/grpcapi/gymondov1"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/ratelimit"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/testutils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+type testServer struct {
+	products      *testutils.MockProductRepository
+	subscriptions *testutils.MockSubscriptionRepository
+	translations  *testutils.MockTranslationRepository
+	conn          *grpc.ClientConn
+	drain         func()
+}
+
+// startServer serves the API over an in-memory connection until the test
+// ends.
+func startServer(t *testing.T, interceptors ...grpc.UnaryServerInterceptor) *testServer {
+	t.Helper()
+
+	ts := &testServer{
+		products:      new(testutils.MockProductRepository),
+		subscriptions: new(testutils.MockSubscriptionRepository),
+		translations:  new(testutils.MockTranslationRepository),
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	srv := grpcapi.NewServer(config.GRPCConfig{Enabled: true}, time.Second, logger,
+		ts.products, ts.subscriptions, ts.translations, interceptors...)
+	ts.drain = srv.Drain
+
+	ln := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		srv.Serve(ctx, ln)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	ts.conn = conn
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		cancel()
+		<-stopped
+	})
+	return ts
+}
+
+func (ts *testServer) productClient() gymondov1.ProductServiceClient {
+	return gymondov1.NewProductServiceClient(ts.conn)
+}
+
+func (ts *testServer) subscriptionClient() gymondov1.SubscriptionServiceClient {
+	return gymondov1.NewSubscriptionServiceClient(ts.conn)
+}
+
+func fieldViolations(t *testing.T, err error) map[string]string {
+	t.Helper()
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	violations := map[string]string{}
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				violations[v.GetField()] = v.GetDescription()
+			}
+		}
+	}
+	return violations
+}
+
+func TestProductService(t *testing.T) {
+	fixedTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	product := models.Product{
+		ID:          uuid.MustParse("465dc700-666c-4b7a-80e2-d9e2967f4442"),
+		Name:        "Test Product",
+		Description: "Test Description",
+		Price:       9.99,
+		TaxRate:     0.10,
+		TotalPrice:  10.989,
+		Currency:    "EUR",
+		Duration:    models.DurationMonth,
+		CreatedAt:   fixedTime,
+		UpdatedAt:   fixedTime,
+	}
+
+	t.Run("ListProducts applies defaults", func(t *testing.T) {
+		ts := startServer(t)
+		ts.products.On("GetProducts", mock.Anything,
+			repositories.ProductFilter{SortBy: "created_at"},
+			repositories.PageRequest{Page: 1, Limit: 10},
+		).Return([]models.Product{product}, repositories.Pagination{Page: 1, Limit: 10, Total: proto.Int64(1)}, nil)
+
+		var header metadata.MD
+		resp, err := ts.productClient().ListProducts(context.Background(), &gymondov1.ListProductsRequest{}, grpc.Header(&header))
+		require.NoError(t, err)
+
+		require.Len(t, resp.GetProducts(), 1)
+		got := resp.GetProducts()[0]
+		assert.Equal(t, product.ID.String(), got.GetId())
+		assert.Equal(t, "Test Product", got.GetName())
+		assert.Equal(t, 10.989, got.GetTotalPrice())
+		assert.Equal(t, int32(30), got.GetDuration())
+		assert.Equal(t, fixedTime, got.GetCreatedAt().AsTime())
+		assert.Equal(t, int64(1), resp.GetPage().GetTotal())
+		assert.Equal(t, []string{"en"}, header.Get("content-language"))
+		assert.NotEmpty(t, header.Get("x-request-id"))
+		ts.products.AssertExpectations(t)
+	})
+
+	t.Run("ListProducts filters", func(t *testing.T) {
+		ts := startServer(t)
+		minPrice, maxPrice := 10.0, 50.5
+		ts.products.On("GetProducts", mock.Anything, repositories.ProductFilter{
+			Duration: models.DurationYear,
+			MinPrice: &minPrice,
+			MaxPrice: &maxPrice,
+			Currency: "EUR",
+			Query:    "yoga",
+			SortBy:   "price",
+			SortDesc: true,
+		}, repositories.PageRequest{Cursor: "abc", Limit: 5}).
+			Return([]models.Product{}, repositories.Pagination{Limit: 5}, nil)
+
+		_, err := ts.productClient().ListProducts(context.Background(), &gymondov1.ListProductsRequest{
+			Page:     &gymondov1.PageRequest{Cursor: "abc", Limit: 5},
+			Duration: int32(models.DurationYear),
+			MinPrice: &minPrice,
+			MaxPrice: &maxPrice,
+			Currency: "EUR",
+			Q:        "yoga",
+			Sort:     "price",
+			Order:    "desc",
+		})
+		require.NoError(t, err)
+		ts.products.AssertExpectations(t)
+	})
+
+	t.Run("ListProducts validates like the REST API", func(t *testing.T) {
+		ts := startServer(t)
+		_, err := ts.productClient().ListProducts(context.Background(), &gymondov1.ListProductsRequest{
+			Page:     &gymondov1.PageRequest{Limit: 1000},
+			Duration: 7,
+			Sort:     "popularity",
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, map[string]string{
+			"limit":    "must be at most 100",
+			"duration": "must be one of: 30, 365, 36500",
+			"sort":     "must be one of: created_at, price, name",
+		}, fieldViolations(t, err))
+
+		minPrice, maxPrice := 20.0, 10.0
+		_, err = ts.productClient().ListProducts(context.Background(), &gymondov1.ListProductsRequest{MinPrice: &minPrice, MaxPrice: &maxPrice})
+		assert.Equal(t, map[string]string{"max_price": "must be greater than or equal to min_price"}, fieldViolations(t, err))
+		ts.products.AssertNotCalled(t, "GetProducts")
+	})
+
+	t.Run("GetProduct localizes", func(t *testing.T) {
+		ts := startServer(t)
+		p := product
+		ts.products.On("GetProduct", mock.Anything, p.ID.String()).Return(&p, nil)
+		ts.translations.On("Localize", mock.Anything, []string{"de", "en"}, []*models.Product{&p}).
+			Run(func(args mock.Arguments) {
+				localized := args.Get(2).([]*models.Product)[0]
+				localized.Name = "Testprodukt"
+				localized.Locale = "de"
+			}).Return(nil)
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "accept-language", "de-AT")
+		var header metadata.MD
+		got, err := ts.productClient().GetProduct(ctx, &gymondov1.GetProductRequest{Id: p.ID.String()}, grpc.Header(&header))
+		require.NoError(t, err)
+		assert.Equal(t, "Testprodukt", got.GetName())
+		assert.Equal(t, []string{"de"}, header.Get("content-language"))
+	})
+
+	t.Run("GetProduct not found", func(t *testing.T) {
+		ts := startServer(t)
+		id := uuid.NewString()
+		ts.products.On("GetProduct", mock.Anything, id).Return(nil, repositories.ErrProductNotFound)
+
+		_, err := ts.productClient().GetProduct(context.Background(), &gymondov1.GetProductRequest{Id: id})
+		st := status.Convert(err)
+		assert.Equal(t, codes.NotFound, st.Code())
+		assert.Equal(t, "product not found", st.Message())
+	})
+
+	t.Run("GetProduct invalid ID", func(t *testing.T) {
+		ts := startServer(t)
+		_, err := ts.productClient().GetProduct(context.Background(), &gymondov1.GetProductRequest{Id: "abc"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, map[string]string{"id": "must be a valid UUID"}, fieldViolations(t, err))
+	})
+
+	t.Run("panics become internal errors", func(t *testing.T) {
+		ts := startServer(t)
+		id := uuid.NewString()
+		ts.products.On("GetProduct", mock.Anything, id).Run(func(mock.Arguments) { panic("boom") })
+
+		_, err := ts.productClient().GetProduct(context.Background(), &gymondov1.GetProductRequest{Id: id})
+		st := status.Convert(err)
+		assert.Equal(t, codes.Internal, st.Code())
+		assert.NotContains(t, st.Message(), "boom")
+
+		// the server keeps serving
+		_, err = ts.productClient().GetProduct(context.Background(), &gymondov1.GetProductRequest{Id: "abc"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("request ID is reused", func(t *testing.T) {
+		ts := startServer(t)
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "req-123")
+		var header metadata.MD
+		_, _ = ts.productClient().GetProduct(ctx, &gymondov1.GetProductRequest{Id: "abc"}, grpc.Header(&header))
+		assert.Equal(t, []string{"req-123"}, header.Get("x-request-id"))
+	})
+
+	t.Run("invalid request IDs are replaced", func(t *testing.T) {
+		ts := startServer(t)
+		forged := "req-123 level=ERROR msg=forged"
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", forged)
+		var header metadata.MD
+		_, _ = ts.productClient().GetProduct(ctx, &gymondov1.GetProductRequest{Id: "abc"}, grpc.Header(&header))
+		require.Len(t, header.Get("x-request-id"), 1)
+		assert.NoError(t, uuid.Validate(header.Get("x-request-id")[0]))
+	})
+}
+
+func TestSubscriptionService(t *testing.T) {
+	now := time.Now().UTC()
+	product := testutils.NewMockProduct()
+	sub := &models.Subscription{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		ProductID: product.ID,
+		Product:   product,
+		Status:    models.StatusActive,
+		StartDate: now,
+		EndDate:   now.Add(30 * 24 * time.Hour),
+		Version:   3,
+	}
+	paused := *sub
+	paused.Status = models.StatusPaused
+	paused.PausedAt = &now
+	paused.Version = 4
+
+	t.Run("ListSubscriptions filters", func(t *testing.T) {
+		ts := startServer(t)
+		ts.subscriptions.On("ListSubscriptions", mock.Anything, repositories.SubscriptionFilter{
+			UserID: sub.UserID.String(),
+			Status: models.StatusActive,
+		}, repositories.PageRequest{Page: 2, Limit: 10}).
+			Return([]models.Subscription{*sub}, repositories.Pagination{Page: 2, Limit: 10}, nil)
+
+		resp, err := ts.subscriptionClient().ListSubscriptions(context.Background(), &gymondov1.ListSubscriptionsRequest{
+			Page:   &gymondov1.PageRequest{Page: 2},
+			UserId: sub.UserID.String(),
+			Status: gymondov1.SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE,
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.GetSubscriptions(), 1)
+		assert.Equal(t, gymondov1.SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE, resp.GetSubscriptions()[0].GetStatus())
+		assert.Equal(t, product.ID.String(), resp.GetSubscriptions()[0].GetProduct().GetId())
+		assert.Equal(t, int32(2), resp.GetPage().GetPage())
+	})
+
+	t.Run("ListSubscriptions validates", func(t *testing.T) {
+		ts := startServer(t)
+		_, err := ts.subscriptionClient().ListSubscriptions(context.Background(), &gymondov1.ListSubscriptionsRequest{
+			UserId: "abc",
+			Status: gymondov1.SubscriptionStatus(42),
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, map[string]string{
+			"user_id": "must be a valid UUID",
+			"status":  "must be one of: active, paused, cancelled, expired",
+		}, fieldViolations(t, err))
+	})
+
+	t.Run("ListSubscriptions requires a user", func(t *testing.T) {
+		ts := startServer(t)
+		_, err := ts.subscriptionClient().ListSubscriptions(context.Background(), &gymondov1.ListSubscriptionsRequest{
+			Status: gymondov1.SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE,
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, map[string]string{"user_id": "is required"}, fieldViolations(t, err))
+		ts.subscriptions.AssertNotCalled(t, "ListSubscriptions")
+	})
+
+	t.Run("CreateSubscription", func(t *testing.T) {
+		ts := startServer(t)
+		ts.products.On("GetProduct", mock.Anything, product.ID.String()).Return(product, nil)
+		ts.subscriptions.On("CreateSubscription", mock.Anything, mock.AnythingOfType("string"), product).Return(sub, nil)
+
+		got, err := ts.subscriptionClient().CreateSubscription(context.Background(), &gymondov1.CreateSubscriptionRequest{ProductId: product.ID.String()})
+		require.NoError(t, err)
+		assert.Equal(t, sub.ID.String(), got.GetId())
+		assert.Equal(t, int32(3), got.GetVersion())
+		assert.Nil(t, got.GetPausedAt())
+	})
+
+	t.Run("CreateSubscription for a missing product", func(t *testing.T) {
+		ts := startServer(t)
+		id := uuid.NewString()
+		ts.products.On("GetProduct", mock.Anything, id).Return(nil, repositories.ErrProductNotFound)
+
+		_, err := ts.subscriptionClient().CreateSubscription(context.Background(), &gymondov1.CreateSubscriptionRequest{ProductId: id})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		ts.subscriptions.AssertNotCalled(t, "CreateSubscription")
+	})
+
+	t.Run("GetSubscription", func(t *testing.T) {
+		ts := startServer(t)
+		ts.subscriptions.On("GetSubscription", mock.Anything, sub.ID.String()).Return(sub, nil)
+
+		got, err := ts.subscriptionClient().GetSubscription(context.Background(), &gymondov1.GetSubscriptionRequest{Id: sub.ID.String()})
+		require.NoError(t, err)
+		assert.Equal(t, sub.UserID.String(), got.GetUserId())
+	})
+
+	t.Run("PauseSubscription", func(t *testing.T) {
+		ts := startServer(t)
+		ts.subscriptions.On("PauseSubscription", mock.Anything, sub.ID.String(), 3).Return(&paused, nil)
+
+		got, err := ts.subscriptionClient().PauseSubscription(context.Background(), &gymondov1.ChangeSubscriptionRequest{
+			Id:              sub.ID.String(),
+			ExpectedVersion: proto.Int32(3),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, gymondov1.SubscriptionStatus_SUBSCRIPTION_STATUS_PAUSED, got.GetStatus())
+		assert.Equal(t, now, got.GetPausedAt().AsTime())
+		assert.Equal(t, int32(4), got.GetVersion())
+	})
+
+	t.Run("changes are answered untranslated when translations fail", func(t *testing.T) {
+		ts := startServer(t)
+		ts.subscriptions.On("PauseSubscription", mock.Anything, sub.ID.String(), 3).Return(&paused, nil)
+		ts.translations.On("Localize", mock.Anything, []string{"de", "en"}, mock.Anything).Return(errors.New("connection refused"))
+
+		var header metadata.MD
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "accept-language", "de")
+		got, err := ts.subscriptionClient().PauseSubscription(ctx, &gymondov1.ChangeSubscriptionRequest{
+			Id:              sub.ID.String(),
+			ExpectedVersion: proto.Int32(3),
+		}, grpc.Header(&header))
+		require.NoError(t, err, "the pause committed")
+		assert.Equal(t, gymondov1.SubscriptionStatus_SUBSCRIPTION_STATUS_PAUSED, got.GetStatus())
+		assert.Equal(t, product.Name, got.GetProduct().GetName())
+		assert.Equal(t, []string{"en"}, header.Get("content-language"))
+	})
+
+	t.Run("changes require the expected version", func(t *testing.T) {
+		ts := startServer(t)
+		_, err := ts.subscriptionClient().CancelSubscription(context.Background(), &gymondov1.ChangeSubscriptionRequest{Id: sub.ID.String()})
+		st := status.Convert(err)
+		assert.Equal(t, codes.FailedPrecondition, st.Code())
+		assert.Equal(t, "missing expected_version", st.Message())
+		ts.subscriptions.AssertNotCalled(t, "CancelSubscription")
+	})
+
+	t.Run("conflicting changes abort", func(t *testing.T) {
+		ts := startServer(t)
+		ts.subscriptions.On("UnpauseSubscription", mock.Anything, sub.ID.String(), 0).Return(nil, repositories.ErrConcurrentModification)
+
+		_, err := ts.subscriptionClient().UnpauseSubscription(context.Background(), &gymondov1.ChangeSubscriptionRequest{
+			Id:              sub.ID.String(),
+			ExpectedVersion: proto.Int32(0),
+		})
+		assert.Equal(t, codes.Aborted, status.Code(err))
+	})
+
+	t.Run("invalid state fails the precondition", func(t *testing.T) {
+		ts := startServer(t)
+		ts.subscriptions.On("CancelSubscription", mock.Anything, sub.ID.String(), 3).Return(nil, repositories.ErrCannotCancel)
+
+		_, err := ts.subscriptionClient().CancelSubscription(context.Background(), &gymondov1.ChangeSubscriptionRequest{
+			Id:              sub.ID.String(),
+			ExpectedVersion: proto.Int32(3),
+		})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+func TestServerHealth(t *testing.T) {
+	ts := startServer(t)
+	client := healthpb.NewHealthClient(ts.conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "gymondo.v1.SubscriptionService"})
+	require.NoError(t, err)
+
+	resp, err := watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	ts.drain()
+	resp, err = watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
+
+func TestServerRateLimits(t *testing.T) {
+	read := ratelimit.Policy{Name: "read", Rate: 0.1, Burst: 1}
+	write := ratelimit.Policy{Name: "write", Rate: 0.1, Burst: 1}
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.ByIP)
+	ts := startServer(t, limiter.LimitCalls(ratelimit.IdentifyCall("x-api-key"), grpcapi.RateLimits(read, write)))
+	product := testutils.NewMockProduct()
+	ts.products.On("GetProduct", mock.Anything, product.ID.String()).Return(product, nil)
+	ctx := context.Background()
+
+	var header metadata.MD
+	_, err := ts.productClient().GetProduct(ctx, &gymondov1.GetProductRequest{Id: product.ID.String()}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, header.Get("ratelimit-limit"))
+	assert.Equal(t, []string{"0"}, header.Get("ratelimit-remaining"))
+	assert.Equal(t, []string{"1;w=10"}, header.Get("ratelimit-policy"))
+
+	_, err = ts.productClient().GetProduct(ctx, &gymondov1.GetProductRequest{Id: product.ID.String()})
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 2)
+	retry, ok := st.Details()[1].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.Equal(t, 10*time.Second, retry.GetRetryDelay().AsDuration())
+
+	// changes have their own bucket, which invalid calls use up too
+	_, err = ts.subscriptionClient().CreateSubscription(ctx, &gymondov1.CreateSubscriptionRequest{ProductId: "nope"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = ts.subscriptionClient().CreateSubscription(ctx, &gymondov1.CreateSubscriptionRequest{ProductId: "nope"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// API clients are counted apart from the address they call from
+	keyed := metadata.AppendToOutgoingContext(ctx, "x-api-key", "secret")
+	_, err = ts.productClient().GetProduct(keyed, &gymondov1.GetProductRequest{Id: product.ID.String()})
+	assert.NoError(t, err)
+
+	for range 3 {
+		_, err := healthpb.NewHealthClient(ts.conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		assert.NoError(t, err, "health checks are not limited")
+	}
+}
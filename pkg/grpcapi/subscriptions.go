@@ -0,0 +1,175 @@
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/grpcapi/gymondov1"
+	"gymondo_dz/pkg/i18n"
+	"gymondo_dz/pkg/logging"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/reqctx"
+	"gymondo_dz/pkg/validation"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var errMissingVersion = apperrors.New(apperrors.CodePreconditionRequired, http.StatusPreconditionRequired, "missing expected_version")
+
+type listSubscriptionsQuery struct {
+	repositories.PageQuery
+	UserID    string                    `json:"user_id" binding:"required,resource_id"`
+	ProductID string                    `json:"product_id" binding:"omitempty,resource_id"`
+	Status    models.SubscriptionStatus `json:"status" binding:"omitempty,subscription_status"`
+}
+
+type subscriptionID struct {
+	ID string `json:"id" binding:"required,resource_id"`
+}
+
+type createSubscriptionQuery struct {
+	ProductID string `json:"product_id" binding:"required,resource_id"`
+}
+
+// subscriptionService implements SubscriptionService on the repositories
+// the SubscriptionHandler uses.
+type subscriptionService struct {
+	gymondov1.UnimplementedSubscriptionServiceServer
+
+	repo         repositories.SubscriptionRepository
+	productRepo  repositories.ProductRepository
+	translations repositories.TranslationRepository
+}
+
+func (s *subscriptionService) ListSubscriptions(ctx context.Context, req *gymondov1.ListSubscriptionsRequest) (*gymondov1.ListSubscriptionsResponse, error) {
+	query := listSubscriptionsQuery{
+		PageQuery: newPageQuery(req.GetPage()),
+		UserID:    req.GetUserId(),
+		ProductID: req.GetProductId(),
+		Status:    modelStatus(req.GetStatus()),
+	}
+	if err := validation.Struct(&query); err != nil {
+		return nil, err
+	}
+	// In a real app, the user would come from auth context; until then
+	// every listing is scoped to one user, as over REST and GraphQL.
+	ctx = reqctx.WithUserID(ctx, query.UserID)
+
+	filter := repositories.SubscriptionFilter{
+		UserID:    query.UserID,
+		ProductID: query.ProductID,
+		Status:    query.Status,
+	}
+	subs, page, err := s.repo.ListSubscriptions(ctx, filter, query.PageRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]*models.Product, len(subs))
+	for i := range subs {
+		products[i] = subs[i].Product
+	}
+	if err := localize(ctx, s.translations, products...); err != nil {
+		return nil, err
+	}
+
+	resp := &gymondov1.ListSubscriptionsResponse{
+		Subscriptions: make([]*gymondov1.Subscription, len(subs)),
+		Page:          pageInfo(page),
+	}
+	for i := range subs {
+		resp.Subscriptions[i] = toSubscription(&subs[i])
+	}
+	return resp, nil
+}
+
+func (s *subscriptionService) CreateSubscription(ctx context.Context, req *gymondov1.CreateSubscriptionRequest) (*gymondov1.Subscription, error) {
+	query := createSubscriptionQuery{ProductID: req.GetProductId()}
+	if err := validation.Struct(&query); err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetProduct(ctx, query.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	// In a real app, this would come from auth context
+	userID := uuid.New().String()
+	ctx = reqctx.WithUserID(ctx, userID)
+
+	sub, err := s.repo.CreateSubscription(ctx, userID, product)
+	if err != nil {
+		return nil, err
+	}
+	return s.respond(ctx, sub)
+}
+
+func (s *subscriptionService) GetSubscription(ctx context.Context, req *gymondov1.GetSubscriptionRequest) (*gymondov1.Subscription, error) {
+	id := subscriptionID{ID: req.GetId()}
+	if err := validation.Struct(&id); err != nil {
+		return nil, err
+	}
+	ctx = reqctx.WithSubscriptionID(ctx, id.ID)
+
+	sub, err := s.repo.GetSubscription(ctx, id.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := localize(ctx, s.translations, sub.Product); err != nil {
+		return nil, err
+	}
+	return toSubscription(sub), nil
+}
+
+func (s *subscriptionService) PauseSubscription(ctx context.Context, req *gymondov1.ChangeSubscriptionRequest) (*gymondov1.Subscription, error) {
+	return s.change(ctx, req, s.repo.PauseSubscription)
+}
+
+func (s *subscriptionService) UnpauseSubscription(ctx context.Context, req *gymondov1.ChangeSubscriptionRequest) (*gymondov1.Subscription, error) {
+	return s.change(ctx, req, s.repo.UnpauseSubscription)
+}
+
+func (s *subscriptionService) CancelSubscription(ctx context.Context, req *gymondov1.ChangeSubscriptionRequest) (*gymondov1.Subscription, error) {
+	return s.change(ctx, req, s.repo.CancelSubscription)
+}
+
+// change applies one of the repository's versioned state changes, which
+// like the If-Match header over HTTP requires the version the caller saw.
+func (s *subscriptionService) change(
+	ctx context.Context,
+	req *gymondov1.ChangeSubscriptionRequest,
+	apply func(ctx context.Context, id string, version int) (*models.Subscription, error),
+) (*gymondov1.Subscription, error) {
+	id := subscriptionID{ID: req.GetId()}
+	if err := validation.Struct(&id); err != nil {
+		return nil, err
+	}
+	ctx = reqctx.WithSubscriptionID(ctx, id.ID)
+
+	if req.ExpectedVersion == nil {
+		return nil, errMissingVersion
+	}
+
+	sub, err := apply(ctx, id.ID, int(req.GetExpectedVersion()))
+	if err != nil {
+		return nil, err
+	}
+	return s.respond(ctx, sub)
+}
+
+// respond converts sub, just changed, with its preloaded product
+// localized. If the translations cannot be read it answers in the default
+// locale rather than failing, so the client does not retry a change that
+// happened.
+func (s *subscriptionService) respond(ctx context.Context, sub *models.Subscription) (*gymondov1.Subscription, error) {
+	if err := localize(ctx, s.translations, sub.Product); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "Failed to localize products, answering in the default locale", "error", err)
+		_ = grpc.SetHeader(ctx, metadata.Pairs("content-language", i18n.DefaultLocale))
+	}
+	return toSubscription(sub), nil
+}
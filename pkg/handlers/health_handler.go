@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gymondo_dz/pkg/buildinfo"
+	"gymondo_dz/pkg/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Lifecycle reports the process state probed by the orchestrator.
+type Lifecycle interface {
+	Live() bool
+	Ready() bool
+}
+
+type healthResponse struct {
+	Status     string                      `json:"status"`
+	Components map[string]health.Component `json:"components,omitempty"`
+	Build      *buildinfo.Info             `json:"build,omitempty"`
+}
+
+type HealthHandler struct {
+	lifecycle Lifecycle
+	checker   *health.Checker
+	build     *buildinfo.Info
+}
+
+// NewHealthHandler wires the probes. build is included in every probe
+// response when non-nil.
+func NewHealthHandler(lifecycle Lifecycle, checker *health.Checker, build *buildinfo.Info) *HealthHandler {
+	return &HealthHandler{lifecycle: lifecycle, checker: checker, build: build}
+}
+
+// @Summary Liveness probe
+// @Description Reports whether the process is running; dependencies are not checked
+// @Tags health
+// @Produce json
+// @Success 200 {object} handlers.healthResponse
+// @Failure 503 {object} handlers.healthResponse
+// @Router /livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	h.respond(c, healthResponse{Status: status(h.lifecycle.Live())})
+}
+
+// @Summary Readiness probe
+// @Description Pings the database and verifies the schema version; turns unavailable as soon as shutdown begins
+// @Tags health
+// @Produce json
+// @Success 200 {object} handlers.healthResponse
+// @Failure 503 {object} handlers.healthResponse
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	if !h.lifecycle.Ready() {
+		h.respond(c, healthResponse{Status: health.StatusUnavailable})
+		return
+	}
+
+	report := h.checker.Run(c.Request.Context())
+	h.respond(c, healthResponse{Status: report.Status, Components: report.Components})
+}
+
+func (h *HealthHandler) respond(c *gin.Context, resp healthResponse) {
+	resp.Build = h.build
+
+	code := http.StatusOK
+	if resp.Status != health.StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, resp)
+}
+
+func status(ok bool) string {
+	if ok {
+		return health.StatusOK
+	}
+	return health.StatusUnavailable
+}
@@ -0,0 +1,119 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/buildinfo"
+	"gymondo_dz/pkg/handlers"
+	"gymondo_dz/pkg/health"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLifecycle struct{ live, ready bool }
+
+func (f fakeLifecycle) Live() bool  { return f.live }
+func (f fakeLifecycle) Ready() bool { return f.ready }
+
+func TestHealthHandler(t *testing.T) {
+	healthy := health.Check{Name: "database", Run: func(context.Context) error { return nil }}
+	failing := health.Check{Name: "migrations", Run: func(context.Context) error { return errors.New("schema version is 0, expected 1") }}
+
+	tests := []struct {
+		name               string
+		path               string
+		lifecycle          fakeLifecycle
+		checks             []health.Check
+		build              *buildinfo.Info
+		expectedStatus     int
+		expectedState      string
+		expectedComponents map[string]string
+	}{
+		{
+			name:           "Live",
+			path:           "/livez",
+			lifecycle:      fakeLifecycle{live: true},
+			checks:         []health.Check{failing},
+			expectedStatus: http.StatusOK,
+			expectedState:  "ok",
+		},
+		{
+			name:               "Ready",
+			path:               "/readyz",
+			lifecycle:          fakeLifecycle{live: true, ready: true},
+			checks:             []health.Check{healthy},
+			expectedStatus:     http.StatusOK,
+			expectedState:      "ok",
+			expectedComponents: map[string]string{"database": "ok"},
+		},
+		{
+			name:               "Dependency down",
+			path:               "/readyz",
+			lifecycle:          fakeLifecycle{live: true, ready: true},
+			checks:             []health.Check{healthy, failing},
+			expectedStatus:     http.StatusServiceUnavailable,
+			expectedState:      "unavailable",
+			expectedComponents: map[string]string{"database": "ok", "migrations": "unavailable"},
+		},
+		{
+			name:           "Draining is live but not ready",
+			path:           "/readyz",
+			lifecycle:      fakeLifecycle{live: true},
+			checks:         []health.Check{healthy},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedState:  "unavailable",
+		},
+		{
+			name:           "Stopped",
+			path:           "/livez",
+			lifecycle:      fakeLifecycle{},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedState:  "unavailable",
+		},
+		{
+			name:           "Build info",
+			path:           "/livez",
+			lifecycle:      fakeLifecycle{live: true},
+			build:          &buildinfo.Info{Version: "v1.2.0", Commit: "abc123"},
+			expectedStatus: http.StatusOK,
+			expectedState:  "ok",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			handler := handlers.NewHealthHandler(tt.lifecycle, health.NewChecker(time.Second, tt.checks...), tt.build)
+			router.GET("/livez", handler.Livez)
+			router.GET("/readyz", handler.Readyz)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var body struct {
+				Status     string                      `json:"status"`
+				Components map[string]health.Component `json:"components"`
+				Build      *buildinfo.Info             `json:"build"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.expectedState, body.Status)
+			assert.Equal(t, tt.build, body.Build)
+
+			assert.Len(t, body.Components, len(tt.expectedComponents))
+			for name, state := range tt.expectedComponents {
+				assert.Equal(t, state, body.Components[name].Status, name)
+			}
+			assert.NotContains(t, w.Body.String(), "schema version", "check errors are not served")
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"log/slog"
+
+	"gymondo_dz/pkg/i18n"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// localize translates products into the best locale negotiated from the
+// request's Accept-Language header and reports it in Content-Language.
+func localize(c *gin.Context, translations repositories.TranslationRepository, products ...*models.Product) error {
+	c.Header("Vary", "Accept-Language")
+
+	locales := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	contentLanguage, err := repositories.LocalizeProducts(c.Request.Context(), translations, locales, products...)
+	if err != nil {
+		return err
+	}
+	c.Header("Content-Language", contentLanguage)
+	return nil
+}
+
+// localizeCommitted localizes products like localize for the response to a
+// change that has already committed. If the translations cannot be read it
+// answers in the default locale rather than failing, so the client does
+// not retry a change that happened.
+func localizeCommitted(c *gin.Context, translations repositories.TranslationRepository, products ...*models.Product) {
+	if err := localize(c, translations, products...); err != nil {
+		slog.WarnContext(c.Request.Context(), "Failed to localize products, answering in the default locale", "error", err)
+		c.Header("Content-Language", i18n.DefaultLocale)
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"gymondo_dz/pkg/api"
+	"gymondo_dz/pkg/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+func pageMeta(p repositories.Pagination) *api.Meta {
+	return &api.Meta{
+		Total:      p.Total,
+		Page:       p.Page,
+		Limit:      p.Limit,
+		NextCursor: p.NextCursor,
+		PrevCursor: p.PrevCursor,
+	}
+}
+
+// setLinkHeader advertises the neighbouring pages as RFC 8288 links.
+func setLinkHeader(c *gin.Context, p repositories.Pagination) {
+	if p.NextCursor == "" && p.PrevCursor == "" {
+		return
+	}
+
+	links := []string{pageLink(c, "", "first")}
+	if p.PrevCursor != "" {
+		links = append(links, pageLink(c, p.PrevCursor, "prev"))
+	}
+	if p.NextCursor != "" {
+		links = append(links, pageLink(c, p.NextCursor, "next"))
+	}
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+func pageLink(c *gin.Context, cursor, rel string) string {
+	u := *c.Request.URL
+	query := u.Query()
+	query.Del("page")
+	query.Del("cursor")
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	u.RawQuery = query.Encode()
+	return fmt.Sprintf("<%s>; rel=%q", u.RequestURI(), rel)
+}
@@ -1,80 +1,140 @@
 package handlers
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
 
 	"gymondo_dz/pkg/api"
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/models"
 	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/validation"
 
 	"github.com/gin-gonic/gin"
 )
 
+type listProductsQuery struct {
+	repositories.PageQuery
+	Duration models.SubscriptionDuration `form:"duration" binding:"omitempty,subscription_duration"`
+	MinPrice *float64                    `form:"min_price" binding:"omitempty,gte=0"`
+	MaxPrice *float64                    `form:"max_price" binding:"omitempty,gte=0"`
+	Currency string                      `form:"currency" binding:"omitempty,currency"`
+	Q        string                      `form:"q" binding:"omitempty,max=100"`
+	Sort     string                      `form:"sort,default=created_at" binding:"oneof=created_at price name"`
+	Order    string                      `form:"order,default=asc" binding:"oneof=asc desc"`
+}
+
+func (q listProductsQuery) filter() repositories.ProductFilter {
+	return repositories.ProductFilter{
+		Duration: q.Duration,
+		MinPrice: q.MinPrice,
+		MaxPrice: q.MaxPrice,
+		Currency: q.Currency,
+		Query:    q.Q,
+		SortBy:   q.Sort,
+		SortDesc: q.Order == "desc",
+	}
+}
+
+type productURI struct {
+	ID string `uri:"id" binding:"required,resource_id"`
+}
+
 type ProductHandler struct {
-	repo repositories.ProductRepository
+	repo         repositories.ProductRepository
+	translations repositories.TranslationRepository
 }
 
-func NewProductHandler(repo repositories.ProductRepository) *ProductHandler {
-	return &ProductHandler{repo: repo}
+func NewProductHandler(repo repositories.ProductRepository, translations repositories.TranslationRepository) *ProductHandler {
+	return &ProductHandler{repo: repo, translations: translations}
 }
 
 // @Summary List all products
-// @Description Get a list of all available subscription products
+// @Description Get a list of all available subscription products, optionally filtered, sorted and searched
 // @Tags products
 // @Produce json
-// @Param page query int false "Page number" default(1) minimum(1)
+// @Param Accept-Language header string false "Preferred locales, e.g. de-AT, fr;q=0.8"
+// @Param page query int false "Page number (offset pagination)" default(1) minimum(1)
 // @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor or meta.prev_cursor (keyset pagination)" maxlength(512)
+// @Param include_total query bool false "Count all matching items in cursor mode" default(false)
+// @Param duration query int false "Subscription duration in days" Enums(30, 365, 36500)
+// @Param min_price query number false "Minimum price" minimum(0)
+// @Param max_price query number false "Maximum price" minimum(0)
+// @Param currency query string false "ISO 4217 currency code" Enums(EUR, USD, GBP, CHF)
+// @Param q query string false "Search term matched against name and description" maxlength(100)
+// @Param sort query string false "Sort field" Enums(created_at, price, name) default(created_at)
+// @Param order query string false "Sort direction" Enums(asc, desc) default(asc)
 // @Success 200 {object} api.Response{data=[]models.Product,meta=api.Meta} "Paginated list of products"
+// @Header 200 {string} Link "RFC 8288 links to the first, prev and next pages"
+// @Failure 400 {object} api.Response "Invalid pagination parameters"
 // @Failure 500 {object} api.Response "Internal server error"
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
 // @Router /products [get]
 func (h *ProductHandler) GetProducts(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
+	var query listProductsQuery
+	if err := validation.BindQuery(c, &query); err != nil {
+		_ = c.Error(err)
+		return
 	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+
+	if query.MinPrice != nil && query.MaxPrice != nil && *query.MinPrice > *query.MaxPrice {
+		_ = c.Error(validation.ErrValidation.WithFields(apperrors.FieldError{
+			Field:   "max_price",
+			Message: "must be greater than or equal to min_price",
+		}))
+		return
 	}
 
-	products, total, err := h.repo.GetProducts(page, limit)
+	products, page, err := h.repo.GetProducts(c.Request.Context(), query.filter(), query.PageRequest())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, api.ErrorResponse("failed to fetch products", "product_error"))
+		_ = c.Error(err)
+		return
+	}
+
+	localized := make([]*models.Product, len(products))
+	for i := range products {
+		localized[i] = &products[i]
+	}
+	if err := localize(c, h.translations, localized...); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	response := api.SuccessResponse(products, &api.Meta{
-		Page:  page,
-		Limit: limit,
-		Total: total,
-	})
-	c.JSON(http.StatusOK, response)
+	setLinkHeader(c, page)
+	c.JSON(http.StatusOK, api.SuccessResponse(products, pageMeta(page)))
 }
 
 // @Summary Get product details
 // @Description Get details for a specific product
 // @Tags products
 // @Produce json
+// @Param Accept-Language header string false "Preferred locales, e.g. de-AT, fr;q=0.8"
 // @Param id path string true "Product ID" format(uuid) example("d337a556-6fd6-47b9-b07f-4e60b9a78d2c")
 // @Success 200 {object} api.Response{data=models.Product} "Product details"
 // @Failure 400 {object} api.Response "Invalid ID format"
 // @Failure 404 {object} api.Response "Product not found"
 // @Failure 500 {object} api.Response "Internal server error"
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
 // @Router /products/{id} [get]
 func (h *ProductHandler) GetProduct(c *gin.Context) {
-	productID := c.Param("id")
-	product, err := h.repo.GetProduct(productID)
-
-	switch {
-	case errors.Is(err, repositories.ErrProductNotFound):
-		c.JSON(http.StatusNotFound, api.ErrorResponse("product not found", "not_found"))
-	case errors.Is(err, repositories.ErrInvalidProductID):
-		c.JSON(http.StatusBadRequest, api.ErrorResponse("invalid product ID", "invalid_id"))
-	case err != nil:
-		c.JSON(http.StatusInternalServerError, api.ErrorResponse("internal server error", "internal_error"))
-	default:
-		c.JSON(http.StatusOK, api.SuccessResponse(product, nil))
+	var uri productURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	product, err := h.repo.GetProduct(c.Request.Context(), uri.ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if err := localize(c, h.translations, product); err != nil {
+		_ = c.Error(err)
+		return
 	}
+
+	c.JSON(http.StatusOK, api.SuccessResponse(product, nil))
 }
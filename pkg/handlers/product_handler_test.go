@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"gymondo_dz/pkg/apperrors"
 	"gymondo_dz/pkg/handlers"
+	"gymondo_dz/pkg/middleware"
 	"gymondo_dz/pkg/models"
 	"gymondo_dz/pkg/repositories"
 	"gymondo_dz/pkg/testutils"
@@ -14,6 +16,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestProductHandler(t *testing.T) {
@@ -25,11 +28,16 @@ func TestProductHandler(t *testing.T) {
 		Description: "Test Description",
 		Price:       9.99,
 		TaxRate:     0.10,
+		Currency:    "EUR",
 		Duration:    models.DurationMonth,
 		CreatedAt:   fixedTime,
 		UpdatedAt:   fixedTime,
 	}
 
+	one := int64(1)
+	defaultFilter := repositories.ProductFilter{SortBy: "created_at"}
+	minPrice, maxPrice := 10.0, 50.5
+
 	// Apply AfterFind hook manually since we're mocking
 	mockProduct.TotalPrice = mockProduct.Price + (mockProduct.Price * mockProduct.TaxRate)
 
@@ -41,6 +49,7 @@ func TestProductHandler(t *testing.T) {
 		mockSetup      func(*testutils.MockProductRepository)
 		expectedStatus int
 		expectedBody   string
+		expectedLink   string
 	}{
 		{
 			name:   "GetProducts success",
@@ -48,10 +57,11 @@ func TestProductHandler(t *testing.T) {
 			path:   "/products",
 			query:  "page=1&limit=10",
 			mockSetup: func(m *testutils.MockProductRepository) {
-				m.On("GetProducts", 1, 10).Return([]models.Product{mockProduct}, int64(1), nil)
+				m.On("GetProducts", mock.Anything, defaultFilter, repositories.PageRequest{Page: 1, Limit: 10}).
+					Return([]models.Product{mockProduct}, repositories.Pagination{Page: 1, Limit: 10, Total: &one}, nil)
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"data":[{"id":"465dc700-666c-4b7a-80e2-d9e2967f4442","name":"Test Product","description":"Test Description","price":9.99,"tax_rate":0.1,"total_price":10.989,"duration":30,"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}],"meta":{"total":1,"page":1,"limit":10}}`,
+			expectedBody:   `{"data":[{"id":"465dc700-666c-4b7a-80e2-d9e2967f4442","name":"Test Product","description":"Test Description","price":9.99,"tax_rate":0.1,"total_price":10.989,"currency":"EUR","duration":30,"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}],"meta":{"total":1,"page":1,"limit":10}}`,
 		},
 		{
 			name:   "GetProducts default pagination",
@@ -59,53 +69,118 @@ func TestProductHandler(t *testing.T) {
 			path:   "/products",
 			query:  "",
 			mockSetup: func(m *testutils.MockProductRepository) {
-				m.On("GetProducts", 1, 10).Return([]models.Product{mockProduct}, int64(1), nil)
+				m.On("GetProducts", mock.Anything, defaultFilter, repositories.PageRequest{Page: 1, Limit: 10}).
+					Return([]models.Product{mockProduct}, repositories.Pagination{Page: 1, Limit: 10, Total: &one}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":[{"id":"465dc700-666c-4b7a-80e2-d9e2967f4442","name":"Test Product","description":"Test Description","price":9.99,"tax_rate":0.1,"total_price":10.989,"currency":"EUR","duration":30,"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}],"meta":{"total":1,"page":1,"limit":10}}`,
+		},
+		{
+			name:   "GetProducts with cursor",
+			method: "GET",
+			path:   "/products",
+			query:  "cursor=abc&limit=1",
+			mockSetup: func(m *testutils.MockProductRepository) {
+				m.On("GetProducts", mock.Anything, defaultFilter, repositories.PageRequest{Limit: 1, Cursor: "abc"}).
+					Return([]models.Product{mockProduct}, repositories.Pagination{Limit: 1, NextCursor: "def", PrevCursor: "xyz"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":[{"id":"465dc700-666c-4b7a-80e2-d9e2967f4442","name":"Test Product","description":"Test Description","price":9.99,"tax_rate":0.1,"total_price":10.989,"currency":"EUR","duration":30,"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}],"meta":{"limit":1,"next_cursor":"def","prev_cursor":"xyz"}}`,
+			expectedLink:   `</products?limit=1>; rel="first", </products?cursor=xyz&limit=1>; rel="prev", </products?cursor=def&limit=1>; rel="next"`,
+		},
+		{
+			name:   "GetProducts filtered and sorted",
+			method: "GET",
+			path:   "/products",
+			query:  "duration=365&min_price=10&max_price=50.5&currency=EUR&q=yoga&sort=price&order=desc",
+			mockSetup: func(m *testutils.MockProductRepository) {
+				filter := repositories.ProductFilter{
+					Duration: models.DurationYear,
+					MinPrice: &minPrice,
+					MaxPrice: &maxPrice,
+					Currency: "EUR",
+					Query:    "yoga",
+					SortBy:   "price",
+					SortDesc: true,
+				}
+				m.On("GetProducts", mock.Anything, filter, repositories.PageRequest{Page: 1, Limit: 10}).
+					Return([]models.Product{}, repositories.Pagination{Page: 1, Limit: 10}, nil)
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"data":[{"id":"465dc700-666c-4b7a-80e2-d9e2967f4442","name":"Test Product","description":"Test Description","price":9.99,"tax_rate":0.1,"total_price":10.989,"duration":30,"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}],"meta":{"total":1,"page":1,"limit":10}}`,
+			expectedBody:   `{"data":[],"meta":{"page":1,"limit":10}}`,
+		},
+		{
+			name:           "GetProducts invalid filters",
+			method:         "GET",
+			path:           "/products",
+			query:          "duration=7&currency=eur&sort=id%3BDROP%20TABLE%20products&order=up",
+			mockSetup:      func(m *testutils.MockProductRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"message":"request validation failed","code":"validation_error","fields":[{"field":"duration","message":"must be one of: 30, 365, 36500"},{"field":"currency","message":"must be one of: EUR, USD, GBP, CHF"},{"field":"sort","message":"must be one of: created_at, price, name"},{"field":"order","message":"must be one of: asc, desc"}]}}`,
+		},
+		{
+			name:           "GetProducts inverted price range",
+			method:         "GET",
+			path:           "/products",
+			query:          "min_price=20&max_price=10",
+			mockSetup:      func(m *testutils.MockProductRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"message":"request validation failed","code":"validation_error","fields":[{"field":"max_price","message":"must be greater than or equal to min_price"}]}}`,
+		},
+		{
+			name:           "GetProducts page and cursor together",
+			method:         "GET",
+			path:           "/products",
+			query:          "page=2&cursor=abc",
+			mockSetup:      func(m *testutils.MockProductRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"message":"request validation failed","code":"validation_error","fields":[{"field":"page","message":"cannot be combined with cursor"}]}}`,
 		},
 		{
 			name:   "GetProduct success",
 			method: "GET",
 			path:   "/products/465dc700-666c-4b7a-80e2-d9e2967f4442",
 			mockSetup: func(m *testutils.MockProductRepository) {
-				m.On("GetProduct", "465dc700-666c-4b7a-80e2-d9e2967f4442").Return(&mockProduct, nil)
+				m.On("GetProduct", mock.Anything, "465dc700-666c-4b7a-80e2-d9e2967f4442").Return(&mockProduct, nil)
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"data":{"id":"465dc700-666c-4b7a-80e2-d9e2967f4442","name":"Test Product","description":"Test Description","price":9.99,"tax_rate":0.1,"total_price":10.989,"duration":30,"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}}`,
+			expectedBody:   `{"data":{"id":"465dc700-666c-4b7a-80e2-d9e2967f4442","name":"Test Product","description":"Test Description","price":9.99,"tax_rate":0.1,"total_price":10.989,"currency":"EUR","duration":30,"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}}`,
 		},
 		{
 			name:   "GetProduct not found",
 			method: "GET",
 			path:   "/products/465dc700-666c-4b7a-80e2-d9e2967f4442",
 			mockSetup: func(m *testutils.MockProductRepository) {
-				m.On("GetProduct", "465dc700-666c-4b7a-80e2-d9e2967f4442").Return(nil, repositories.ErrProductNotFound)
+				m.On("GetProduct", mock.Anything, "465dc700-666c-4b7a-80e2-d9e2967f4442").Return(nil, repositories.ErrProductNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   `{"error":{"message":"product not found","code":"not_found"}}`,
 		},
 		{
-			name:   "GetProduct invalid UUID",
-			method: "GET",
-			path:   "/products/invalid-uuid",
-			mockSetup: func(m *testutils.MockProductRepository) {
-				// Expect the repository to return ErrInvalidProductID
-				m.On("GetProduct", "invalid-uuid").Return(nil, repositories.ErrInvalidProductID)
-			},
+			name:           "GetProduct invalid UUID",
+			method:         "GET",
+			path:           "/products/invalid-uuid",
+			mockSetup:      func(m *testutils.MockProductRepository) {},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":{"message":"invalid product ID","code":"invalid_id"}}`,
+			expectedBody:   `{"error":{"message":"request validation failed","code":"validation_error","fields":[{"field":"id","message":"must be a valid UUID"}]}}`,
 		},
 		{
-			name:   "GetProducts invalid pagination",
-			method: "GET",
-			path:   "/products",
-			query:  "page=-1&limit=1000",
-			mockSetup: func(m *testutils.MockProductRepository) {
-				// expect default values after validation
-				m.On("GetProducts", 1, 10).Return([]models.Product{}, int64(0), nil)
-			},
-			expectedStatus: http.StatusOK,
-			expectedBody:   `{"data":[],"meta":{"page":1,"limit":10}}`,
+			name:           "GetProducts invalid pagination",
+			method:         "GET",
+			path:           "/products",
+			query:          "page=-1&limit=1000",
+			mockSetup:      func(m *testutils.MockProductRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"message":"request validation failed","code":"validation_error","fields":[{"field":"page","message":"must be at least 1"},{"field":"limit","message":"must be at most 100"}]}}`,
+		},
+		{
+			name:           "GetProducts non-numeric pagination",
+			method:         "GET",
+			path:           "/products",
+			query:          "page=abc&limit=10",
+			mockSetup:      func(m *testutils.MockProductRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"message":"request validation failed","code":"validation_error","fields":[{"field":"page","message":"must be an integer"}]}}`,
 		},
 	}
 
@@ -116,8 +191,9 @@ func TestProductHandler(t *testing.T) {
 			tt.mockSetup(mockRepo)
 
 			// Create handler and router
-			handler := handlers.NewProductHandler(mockRepo)
+			handler := handlers.NewProductHandler(mockRepo, new(testutils.MockTranslationRepository))
 			router := gin.Default()
+			router.Use(middleware.ErrorHandler())
 			router.GET("/products", handler.GetProducts)
 			router.GET("/products/:id", handler.GetProduct)
 
@@ -135,7 +211,60 @@ func TestProductHandler(t *testing.T) {
 			// Verify
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, tt.expectedLink, w.Header().Get("Link"))
 			mockRepo.AssertExpectations(t)
 		})
 	}
 }
+
+func TestProductHandlerUnavailable(t *testing.T) {
+	mockRepo := new(testutils.MockProductRepository)
+	mockRepo.On("GetProducts", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, repositories.Pagination{}, apperrors.ErrUnavailable.WithRetryAfter(1500*time.Millisecond))
+
+	handler := handlers.NewProductHandler(mockRepo, new(testutils.MockTranslationRepository))
+	router := gin.Default()
+	router.Use(middleware.ErrorHandler())
+	router.GET("/products", handler.GetProducts)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/products", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":{"message":"service temporarily unavailable","code":"service_unavailable"}}`, w.Body.String())
+}
+
+func TestProductHandlerLocalization(t *testing.T) {
+	product := testutils.NewMockProduct()
+
+	mockRepo := new(testutils.MockProductRepository)
+	mockTranslations := new(testutils.MockTranslationRepository)
+
+	mockRepo.On("GetProduct", mock.Anything, product.ID.String()).Return(product, nil)
+	mockTranslations.On("Localize", mock.Anything, []string{"de", "en"}, []*models.Product{product}).
+		Run(func(args mock.Arguments) {
+			p := args.Get(2).([]*models.Product)[0]
+			p.Name = "Testprodukt"
+			p.Locale = "de"
+		}).
+		Return(nil)
+
+	handler := handlers.NewProductHandler(mockRepo, mockTranslations)
+	router := gin.Default()
+	router.Use(middleware.ErrorHandler())
+	router.GET("/products/:id", handler.GetProduct)
+
+	req := httptest.NewRequest("GET", "/products/"+product.ID.String(), nil)
+	req.Header.Set("Accept-Language", "de-AT")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "de", w.Header().Get("Content-Language"))
+	assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+	assert.Contains(t, w.Body.String(), `"name":"Testprodukt"`)
+
+	mockRepo.AssertExpectations(t)
+	mockTranslations.AssertExpectations(t)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/middleware"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/stream"
+	"gymondo_dz/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// StreamHandler serves subscription changes as Server-Sent Events. Live
+// events come from the hub; a client reconnecting with Last-Event-ID is
+// first sent what it missed from the event store.
+type StreamHandler struct {
+	subscriptions repositories.SubscriptionRepository
+	events        repositories.EventRepository
+	hub           *stream.Hub
+	cfg           config.StreamConfig
+}
+
+func NewStreamHandler(subscriptions repositories.SubscriptionRepository, events repositories.EventRepository, hub *stream.Hub, cfg config.StreamConfig) *StreamHandler {
+	return &StreamHandler{subscriptions: subscriptions, events: events, hub: hub, cfg: cfg}
+}
+
+// @Summary Stream subscription changes
+// @Description Server-Sent Events stream of a subscription's changes as they happen. Each event is named after its type, carries the event ID and has the event envelope as data. Reconnect with Last-Event-ID to be sent the events missed in between, as far as they are still stored; idle streams send a keep-alive comment
+// @Tags subscriptions
+// @Produce text/event-stream
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param Last-Event-ID header string false "ID of the last event received, to resume from"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Router /subscriptions/{id}/events [get]
+func (h *StreamHandler) SubscriptionEvents(c *gin.Context) {
+	var uri subscriptionURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	withSubscriptionID(c, uri.ID)
+
+	sub, err := h.subscriptions.GetSubscription(c.Request.Context(), uri.ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	h.serve(c, sub.ID)
+}
+
+// @Summary Stream all subscription changes
+// @Description Server-Sent Events stream of every subscription's changes as they happen, in the format of the per-subscription stream
+// @Tags admin
+// @Produce text/event-stream
+// @Param Last-Event-ID header string false "ID of the last event received, to resume from"
+// @Success 200 {string} string "event stream"
+// @Failure 401 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Security AdminKey
+// @Router /admin/subscription-events [get]
+func (h *StreamHandler) AllSubscriptionEvents(c *gin.Context) {
+	h.serve(c, uuid.Nil)
+}
+
+// serve streams the events about subject, or all of them for uuid.Nil,
+// until the client goes away or the stream has to end: because the hub
+// closed on shutdown, the client fell behind, or it has more to catch up
+// on than one replay sends. In each case the client reconnects with
+// Last-Event-ID and carries on from where it was.
+func (h *StreamHandler) serve(c *gin.Context, subject uuid.UUID) {
+	// subscribe before replaying so nothing committed in between is
+	// missed; events seen in both are sent once
+	live := h.hub.Subscribe(subject)
+	defer live.Close()
+
+	var replay []events.Event
+	if lastID := c.GetHeader("Last-Event-ID"); lastID != "" {
+		var err error
+		replay, err = h.events.EventsAfter(c.Request.Context(), subject, lastID, h.cfg.ReplayLimit)
+		if errors.Is(err, repositories.ErrEventNotFound) {
+			slog.DebugContext(c.Request.Context(), "Cannot resume event stream", "last_event_id", lastID)
+		} else if err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+
+	// the stream outlives both the request timeout and the write timeout
+	ctx := middleware.WithoutTimeout(c)
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		slog.DebugContext(ctx, "Cannot lift the write deadline of an event stream", "error", err)
+	}
+
+	c.Header("Content-Type", stream.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if err := stream.WriteRetry(c.Writer, h.cfg.Retry); err != nil {
+		return
+	}
+
+	sent := make(map[uuid.UUID]bool, len(replay))
+	for _, e := range replay {
+		if err := stream.WriteEvent(c.Writer, e); err != nil {
+			return
+		}
+		sent[e.ID] = true
+	}
+	c.Writer.Flush()
+	if len(replay) == h.cfg.ReplayLimit {
+		return
+	}
+
+	h.forward(ctx, c, live, sent)
+}
+
+// forward writes live events, and a keep-alive whenever the stream has
+// been idle for a heartbeat, until the stream ends.
+func (h *StreamHandler) forward(ctx context.Context, c *gin.Context, live *stream.Subscription, sent map[uuid.UUID]bool) {
+	heartbeat := time.NewTicker(h.cfg.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-live.Events():
+			if !ok {
+				return
+			}
+			if sent[e.ID] {
+				continue
+			}
+			err = stream.WriteEvent(c.Writer, e)
+			heartbeat.Reset(h.cfg.Heartbeat)
+		case <-heartbeat.C:
+			err = stream.WriteComment(c.Writer, "keep-alive")
+		}
+		if err != nil {
+			return
+		}
+		c.Writer.Flush()
+	}
+}
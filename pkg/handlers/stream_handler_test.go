@@ -0,0 +1,177 @@
+package handlers_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/handlers"
+	"gymondo_dz/pkg/middleware"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/stream"
+	"gymondo_dz/pkg/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var streamConfig = config.StreamConfig{
+	Heartbeat:   50 * time.Millisecond,
+	Retry:       time.Second,
+	Buffer:      10,
+	ReplayLimit: 3,
+}
+
+type streamFixture struct {
+	subs   *testutils.MockSubscriptionRepository
+	events *testutils.MockEventRepository
+	hub    *stream.Hub
+	server *httptest.Server
+}
+
+func newStreamFixture(t *testing.T) *streamFixture {
+	f := &streamFixture{
+		subs:   new(testutils.MockSubscriptionRepository),
+		events: new(testutils.MockEventRepository),
+		hub:    stream.NewHub(streamConfig.Buffer),
+	}
+	handler := handlers.NewStreamHandler(f.subs, f.events, f.hub, streamConfig)
+
+	router := gin.New()
+	// far shorter than the streams are kept open
+	router.Use(middleware.Timeout(20*time.Millisecond), middleware.ErrorHandler())
+	router.GET("/subscriptions/:id/events", handler.SubscriptionEvents)
+	router.GET("/admin/subscription-events", handler.AllSubscriptionEvents)
+	f.server = httptest.NewServer(router)
+	t.Cleanup(func() {
+		f.hub.Close()
+		f.server.Close()
+	})
+	return f
+}
+
+// open starts a stream and returns a reader of its frames.
+func (f *streamFixture) open(t *testing.T, path, lastEventID string) (*http.Response, func() string) {
+	req, err := http.NewRequest(http.MethodGet, f.server.URL+path, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", stream.ContentType)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	lines := bufio.NewReader(resp.Body)
+	next := func() string {
+		var frame []string
+		for {
+			line, err := lines.ReadString('\n')
+			if err != nil {
+				return "EOF"
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" {
+				return strings.Join(frame, "\n")
+			}
+			frame = append(frame, line)
+		}
+	}
+	return resp, next
+}
+
+func frame(t *testing.T, e events.Event) string {
+	msg, err := e.Encode()
+	require.NoError(t, err)
+	return "id: " + e.ID.String() + "\nevent: " + e.Type + "\ndata: " + string(msg.Payload)
+}
+
+func subscriptionEvent(s *models.Subscription, eventType string) events.Event {
+	s.Version++
+	return events.NewSubscriptionEvent(eventType, s, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestStreamResumesAndFollowsLiveEvents(t *testing.T) {
+	f := newStreamFixture(t)
+	sub := testutils.NewMockSubscription()
+	paused := subscriptionEvent(sub, events.TypeSubscriptionPaused)
+	unpaused := subscriptionEvent(sub, events.TypeSubscriptionUnpaused)
+	lastID := uuid.NewString()
+
+	f.subs.On("GetSubscription", mock.Anything, sub.ID.String()).Return(sub, nil)
+	f.events.On("EventsAfter", mock.Anything, sub.ID, lastID, streamConfig.ReplayLimit).
+		Return([]events.Event{paused}, nil)
+
+	resp, next := f.open(t, "/subscriptions/"+sub.ID.String()+"/events", lastID)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, stream.ContentType, resp.Header.Get("Content-Type"))
+	assert.Equal(t, "retry: 1000", next())
+	assert.Equal(t, frame(t, paused), next(), "missed events are replayed first")
+
+	// the replayed event also reaching the hub is not sent twice, and
+	// other subscriptions' events are not sent at all
+	f.hub.Publish(paused)
+	f.hub.Publish(subscriptionEvent(testutils.NewMockSubscription(), events.TypeSubscriptionCreated))
+	f.hub.Publish(unpaused)
+	assert.Equal(t, frame(t, unpaused), next())
+
+	assert.Equal(t, ": keep-alive", next(), "idle streams are kept alive past the request timeout")
+
+	f.hub.Close()
+	assert.Equal(t, "EOF", next(), "the stream ends when the hub closes")
+	f.subs.AssertExpectations(t)
+	f.events.AssertExpectations(t)
+}
+
+func TestStreamEndsAfterAFullReplay(t *testing.T) {
+	f := newStreamFixture(t)
+	sub := testutils.NewMockSubscription()
+	var replay []events.Event
+	for range streamConfig.ReplayLimit {
+		replay = append(replay, subscriptionEvent(sub, events.TypeSubscriptionPaused))
+	}
+	lastID := uuid.NewString()
+	f.events.On("EventsAfter", mock.Anything, uuid.Nil, lastID, streamConfig.ReplayLimit).Return(replay, nil)
+
+	_, next := f.open(t, "/admin/subscription-events", lastID)
+	assert.Equal(t, "retry: 1000", next())
+	for _, e := range replay {
+		assert.Equal(t, frame(t, e), next())
+	}
+	assert.Equal(t, "EOF", next(), "the client reconnects to catch up on the rest")
+}
+
+func TestStreamWithUnknownLastEventIDGoesLive(t *testing.T) {
+	f := newStreamFixture(t)
+	lastID := uuid.NewString()
+	f.events.On("EventsAfter", mock.Anything, uuid.Nil, lastID, streamConfig.ReplayLimit).
+		Return(nil, repositories.ErrEventNotFound)
+
+	_, next := f.open(t, "/admin/subscription-events", lastID)
+	assert.Equal(t, "retry: 1000", next())
+
+	created := subscriptionEvent(testutils.NewMockSubscription(), events.TypeSubscriptionCreated)
+	f.hub.Publish(created)
+	assert.Equal(t, frame(t, created), next())
+}
+
+func TestStreamOfUnknownSubscription(t *testing.T) {
+	f := newStreamFixture(t)
+	id := uuid.New()
+	f.subs.On("GetSubscription", mock.Anything, id.String()).Return(nil, repositories.ErrSubscriptionNotFound)
+
+	resp, _ := f.open(t, "/subscriptions/"+id.String()+"/events", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = f.open(t, "/subscriptions/not-a-uuid/events", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
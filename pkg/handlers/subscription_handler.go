@@ -1,29 +1,55 @@
 package handlers
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 
 	"gymondo_dz/pkg/api"
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/models"
 	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/reqctx"
+	"gymondo_dz/pkg/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+var (
+	errMissingIfMatch = apperrors.New(apperrors.CodePreconditionRequired, http.StatusPreconditionRequired, "missing If-Match header")
+	errInvalidIfMatch = apperrors.New(apperrors.CodeBadRequest, http.StatusBadRequest, "invalid If-Match header format")
+)
+
+type listSubscriptionsQuery struct {
+	repositories.PageQuery
+	UserID    string                    `form:"user_id" binding:"required,resource_id"`
+	ProductID string                    `form:"product_id" binding:"omitempty,resource_id"`
+	Status    models.SubscriptionStatus `form:"status" binding:"omitempty,subscription_status"`
+}
+
+type subscriptionURI struct {
+	ID string `uri:"id" binding:"required,resource_id"`
+}
+
+type productSubscriptionURI struct {
+	ProductID string `uri:"product_id" binding:"required,resource_id"`
+}
+
 type SubscriptionHandler struct {
-	repo        repositories.SubscriptionRepository
-	productRepo repositories.ProductRepository
+	repo         repositories.SubscriptionRepository
+	productRepo  repositories.ProductRepository
+	translations repositories.TranslationRepository
 }
 
 func NewSubscriptionHandler(
 	repo repositories.SubscriptionRepository,
 	productRepo repositories.ProductRepository,
+	translations repositories.TranslationRepository,
 ) *SubscriptionHandler {
 	return &SubscriptionHandler{
-		repo:        repo,
-		productRepo: productRepo,
+		repo:         repo,
+		productRepo:  productRepo,
+		translations: translations,
 	}
 }
 
@@ -32,48 +58,123 @@ func NewSubscriptionHandler(
 // @Tags subscriptions
 // @Accept  json
 // @Produce  json
-// @Param product_id path string true "Product ID"
+// @Param Accept-Language header string false "Preferred locales for the embedded product"
+// @Param product_id path string true "Product ID" format(uuid)
 // @Success 201 {object} api.Response{data=models.Subscription}
 // @Failure 400 {object} api.Response
 // @Failure 404 {object} api.Response
 // @Failure 500 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
 // @Router /subscriptions/{product_id} [post]
 func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
-	productID := c.Param("product_id")
+	var uri productSubscriptionURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
 
-	product, err := h.productRepo.GetProduct(productID)
+	product, err := h.productRepo.GetProduct(c.Request.Context(), uri.ProductID)
 	if err != nil {
-		h.handleError(c, err)
+		_ = c.Error(err)
 		return
 	}
 
 	// In a real app, this would come from auth context
 	userID := uuid.New().String()
+	c.Request = c.Request.WithContext(reqctx.WithUserID(c.Request.Context(), userID))
+
+	sub, err := h.repo.CreateSubscription(c.Request.Context(), userID, product)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	h.respond(c, http.StatusCreated, sub)
+}
+
+// @Summary List subscriptions
+// @Description List a user's subscriptions, optionally filtered by product and status
+// @Tags subscriptions
+// @Produce  json
+// @Param Accept-Language header string false "Preferred locales for the embedded product"
+// @Param user_id query string true "User ID" format(uuid)
+// @Param product_id query string false "Product ID" format(uuid)
+// @Param status query string false "Subscription status" Enums(active, paused, cancelled, expired)
+// @Param page query int false "Page number (offset pagination)" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor or meta.prev_cursor (keyset pagination)" maxlength(512)
+// @Param include_total query bool false "Count all matching items in cursor mode" default(false)
+// @Success 200 {object} api.Response{data=[]models.Subscription,meta=api.Meta}
+// @Header 200 {string} Link "RFC 8288 links to the first, prev and next pages"
+// @Failure 400 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Router /subscriptions [get]
+func (h *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
+	var query listSubscriptionsQuery
+	if err := validation.BindQuery(c, &query); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	// In a real app, the user would come from auth context; until then
+	// every listing is scoped to one user so nobody can page through all.
+	c.Request = c.Request.WithContext(reqctx.WithUserID(c.Request.Context(), query.UserID))
 
-	sub, err := h.repo.CreateSubscription(userID, product)
+	filter := repositories.SubscriptionFilter{
+		UserID:    query.UserID,
+		ProductID: query.ProductID,
+		Status:    query.Status,
+	}
+	subs, page, err := h.repo.ListSubscriptions(c.Request.Context(), filter, query.PageRequest())
 	if err != nil {
-		h.handleError(c, err)
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, api.SuccessResponse(sub, nil))
+	products := make([]*models.Product, len(subs))
+	for i := range subs {
+		products[i] = subs[i].Product
+	}
+	if err := localize(c, h.translations, products...); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setLinkHeader(c, page)
+	c.JSON(http.StatusOK, api.SuccessResponse(subs, pageMeta(page)))
 }
 
 // @Summary Get subscription details
 // @Description Get subscription by ID
 // @Tags subscriptions
 // @Produce  json
-// @Param id path string true "Subscription ID"
+// @Param Accept-Language header string false "Preferred locales for the embedded product"
+// @Param id path string true "Subscription ID" format(uuid)
 // @Success 200 {object} api.Response{data=models.Subscription}
 // @Failure 400 {object} api.Response
 // @Failure 404 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
 // @Router /subscriptions/{id} [get]
 func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
-	subID := c.Param("id")
+	var uri subscriptionURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	withSubscriptionID(c, uri.ID)
 
-	sub, err := h.repo.GetSubscription(subID)
+	sub, err := h.repo.GetSubscription(c.Request.Context(), uri.ID)
 	if err != nil {
-		h.handleError(c, err)
+		_ = c.Error(err)
+		return
+	}
+	c.Request = c.Request.WithContext(reqctx.WithUserID(c.Request.Context(), sub.UserID.String()))
+
+	if err := localize(c, h.translations, sub.Product); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
@@ -84,152 +185,143 @@ func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
 // @Description Pause subscription by ID
 // @Tags subscriptions
 // @Produce  json
-// @Param id path string true "Subscription ID"
+// @Param Accept-Language header string false "Preferred locales for the embedded product"
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param If-Match header int true "Expected subscription version"
 // @Success 200 {object} api.Response{data=models.Subscription}
 // @Failure 400 {object} api.Response
 // @Failure 404 {object} api.Response
 // @Failure 409 {object} api.Response
+// @Failure 428 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
 // @Router /subscriptions/{id}/pause [patch]
 func (h *SubscriptionHandler) PauseSubscription(c *gin.Context) {
-	subID := c.Param("id")
-
-	versionHeader := c.GetHeader("If-Match")
-	if versionHeader == "" {
-		c.JSON(http.StatusPreconditionRequired, gin.H{
-			"error": "Missing If-Match header",
-		})
+	var uri subscriptionURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
 		return
 	}
+	withSubscriptionID(c, uri.ID)
 
-	version, err := strconv.Atoi(versionHeader)
+	version, err := versionFromIfMatch(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid If-Match header format",
-		})
+		_ = c.Error(err)
 		return
 	}
 
-	sub, err := h.repo.PauseSubscription(subID, version)
+	sub, err := h.repo.PauseSubscription(c.Request.Context(), uri.ID, version)
 	if err != nil {
-		h.handleError(c, err)
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, api.SuccessResponse(sub, nil))
+	h.respond(c, http.StatusOK, sub)
 }
 
 // @Summary Unpause subscription
 // @Description Unpause subscription by ID
 // @Tags subscriptions
 // @Produce  json
-// @Param id path string true "Subscription ID"
+// @Param Accept-Language header string false "Preferred locales for the embedded product"
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param If-Match header int true "Expected subscription version"
 // @Success 200 {object} api.Response{data=models.Subscription}
 // @Failure 400 {object} api.Response
 // @Failure 404 {object} api.Response
 // @Failure 409 {object} api.Response
+// @Failure 428 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
 // @Router /subscriptions/{id}/unpause [patch]
 func (h *SubscriptionHandler) UnpauseSubscription(c *gin.Context) {
-	subID := c.Param("id")
-
-	versionHeader := c.GetHeader("If-Match")
-	if versionHeader == "" {
-		c.JSON(http.StatusPreconditionRequired, gin.H{
-			"error": "Missing If-Match header",
-		})
+	var uri subscriptionURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
 		return
 	}
+	withSubscriptionID(c, uri.ID)
 
-	version, err := strconv.Atoi(versionHeader)
+	version, err := versionFromIfMatch(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid If-Match header format",
-		})
+		_ = c.Error(err)
 		return
 	}
 
-	sub, err := h.repo.UnpauseSubscription(subID, version)
+	sub, err := h.repo.UnpauseSubscription(c.Request.Context(), uri.ID, version)
 	if err != nil {
-		h.handleError(c, err)
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, api.SuccessResponse(sub, nil))
+	h.respond(c, http.StatusOK, sub)
 }
 
 // @Summary Cancel subscription
 // @Description Cancel subscription by ID
 // @Tags subscriptions
 // @Produce  json
-// @Param id path string true "Subscription ID"
+// @Param Accept-Language header string false "Preferred locales for the embedded product"
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param If-Match header int true "Expected subscription version"
 // @Success 200 {object} api.Response{data=models.Subscription}
 // @Failure 400 {object} api.Response
 // @Failure 404 {object} api.Response
 // @Failure 409 {object} api.Response
+// @Failure 428 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
 // @Router /subscriptions/{id} [delete]
 func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
-	subID := c.Param("id")
-	versionHeader := c.GetHeader("If-Match")
-	if versionHeader == "" {
-		c.JSON(http.StatusPreconditionRequired, gin.H{
-			"error": "Missing If-Match header",
-		})
+	var uri subscriptionURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
 		return
 	}
+	withSubscriptionID(c, uri.ID)
 
-	version, err := strconv.Atoi(versionHeader)
+	version, err := versionFromIfMatch(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid If-Match header format",
-		})
+		_ = c.Error(err)
 		return
 	}
 
-	sub, err := h.repo.CancelSubscription(subID, version)
+	sub, err := h.repo.CancelSubscription(c.Request.Context(), uri.ID, version)
 	if err != nil {
-		h.handleError(c, err)
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, api.SuccessResponse(sub, nil))
+	h.respond(c, http.StatusOK, sub)
+}
+
+// respond writes sub, just changed, with its preloaded product localized.
+func (h *SubscriptionHandler) respond(c *gin.Context, status int, sub *models.Subscription) {
+	c.Request = c.Request.WithContext(reqctx.WithUserID(c.Request.Context(), sub.UserID.String()))
+	withSubscriptionID(c, sub.ID.String())
+
+	localizeCommitted(c, h.translations, sub.Product)
+	c.JSON(status, api.SuccessResponse(sub, nil))
+}
+
+// withSubscriptionID records the subscription the request operates on, so
+// every log line written on its behalf carries the ID.
+func withSubscriptionID(c *gin.Context, id string) {
+	c.Request = c.Request.WithContext(reqctx.WithSubscriptionID(c.Request.Context(), id))
 }
 
-func (h *SubscriptionHandler) handleError(c *gin.Context, err error) {
-	var status int
-	var message, code string
-
-	switch {
-	case errors.Is(err, repositories.ErrSubscriptionNotFound),
-		errors.Is(err, repositories.ErrProductNotFound):
-		status = http.StatusNotFound
-		message = "resource not found"
-		code = "not_found"
-	case errors.Is(err, repositories.ErrInvalidSubscriptionID),
-		errors.Is(err, repositories.ErrInvalidProductID):
-		status = http.StatusBadRequest
-		message = "invalid ID format"
-		code = "invalid_id"
-	case errors.Is(err, repositories.ErrCannotPause):
-		status = http.StatusConflict
-		message = "cannot pause subscription"
-		code = "invalid_state"
-	case errors.Is(err, repositories.ErrCannotUnpause):
-		status = http.StatusConflict
-		message = "cannot unpause subscription"
-		code = "invalid_state"
-	case errors.Is(err, repositories.ErrCannotCancel):
-		status = http.StatusConflict
-		message = "cannot cancel subscription"
-		code = "invalid_state"
-	case errors.Is(err, repositories.ErrConcurrentModification):
-		status = http.StatusConflict
-		message = "subscription was modified by another request"
-		code = "concurrent_modification"
-	default:
-		status = http.StatusInternalServerError
-		message = "internal server error"
-		code = "internal_error"
-	}
-
-	c.JSON(status, api.ErrorResponse(message, code))
-	c.Abort() // Prevent any further handlers from being called
+// versionFromIfMatch reads the expected subscription version used for
+// optimistic locking from the If-Match header.
+func versionFromIfMatch(c *gin.Context) (int, error) {
+	versionHeader := c.GetHeader("If-Match")
+	if versionHeader == "" {
+		return 0, errMissingIfMatch
+	}
+
+	version, err := strconv.Atoi(versionHeader)
+	if err != nil {
+		return 0, errInvalidIfMatch
+	}
+
+	return version, nil
 }
@@ -1,7 +1,9 @@
 package handlers_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -9,9 +11,12 @@ import (
 	"time"
 
 	"gymondo_dz/pkg/api"
+	"gymondo_dz/pkg/apperrors"
 	"gymondo_dz/pkg/handlers"
+	"gymondo_dz/pkg/middleware"
 	"gymondo_dz/pkg/models"
 	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/reqctx"
 	"gymondo_dz/pkg/testutils"
 
 	"github.com/gin-gonic/gin"
@@ -22,6 +27,8 @@ import (
 
 func setupSubscriptionRouter(h *handlers.SubscriptionHandler) *gin.Engine {
 	router := gin.Default()
+	router.Use(middleware.RequestID(), middleware.ErrorHandler())
+	router.GET("/subscriptions", h.ListSubscriptions)
 	router.POST("/products/:product_id/subscriptions", h.CreateSubscription)
 	router.GET("/subscriptions/:id", h.GetSubscription)
 	router.PATCH("/subscriptions/:id/pause", h.PauseSubscription)
@@ -72,10 +79,10 @@ func TestSubscriptionHandler(t *testing.T) {
 		mockProductRepo := new(testutils.MockProductRepository)
 		mockSubRepo := new(testutils.MockSubscriptionRepository)
 
-		mockProductRepo.On("GetProduct", validProduct.ID.String()).Return(validProduct, nil)
-		mockSubRepo.On("CreateSubscription", mock.Anything, validProduct).Return(activeSub, nil)
+		mockProductRepo.On("GetProduct", mock.Anything, validProduct.ID.String()).Return(validProduct, nil)
+		mockSubRepo.On("CreateSubscription", mock.Anything, mock.Anything, validProduct).Return(activeSub, nil)
 
-		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo)
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
 		router := setupSubscriptionRouter(handler)
 
 		req := httptest.NewRequest("POST", "/products/"+validProduct.ID.String()+"/subscriptions", nil)
@@ -102,9 +109,9 @@ func TestSubscriptionHandler(t *testing.T) {
 		mockProductRepo := new(testutils.MockProductRepository)
 		mockSubRepo := new(testutils.MockSubscriptionRepository)
 
-		mockSubRepo.On("GetSubscription", activeSub.ID.String()).Return(activeSub, nil)
+		mockSubRepo.On("GetSubscription", mock.Anything, activeSub.ID.String()).Return(activeSub, nil)
 
-		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo)
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
 		router := setupSubscriptionRouter(handler)
 
 		req := httptest.NewRequest("GET", "/subscriptions/"+activeSub.ID.String(), nil)
@@ -126,14 +133,79 @@ func TestSubscriptionHandler(t *testing.T) {
 		mockSubRepo.AssertExpectations(t)
 	})
 
+	t.Run("List Subscriptions - Filtered", func(t *testing.T) {
+		mockProductRepo := new(testutils.MockProductRepository)
+		mockSubRepo := new(testutils.MockSubscriptionRepository)
+
+		filter := repositories.SubscriptionFilter{UserID: activeSub.UserID.String(), Status: models.StatusActive}
+		mockSubRepo.On("ListSubscriptions", mock.Anything, filter, repositories.PageRequest{Page: 1, Limit: 10}).
+			Return([]models.Subscription{*activeSub}, repositories.Pagination{Page: 1, Limit: 10, NextCursor: "next"}, nil)
+
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
+		router := setupSubscriptionRouter(handler)
+
+		req := httptest.NewRequest("GET", "/subscriptions?status=active&user_id="+activeSub.UserID.String(), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Link"), `rel="next"`)
+
+		var response api.Response
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Len(t, response.Data, 1)
+		assert.Equal(t, "next", response.Meta.NextCursor)
+
+		mockSubRepo.AssertExpectations(t)
+	})
+
+	t.Run("List Subscriptions - Invalid Status", func(t *testing.T) {
+		mockProductRepo := new(testutils.MockProductRepository)
+		mockSubRepo := new(testutils.MockSubscriptionRepository)
+
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
+		router := setupSubscriptionRouter(handler)
+
+		req := httptest.NewRequest("GET", "/subscriptions?status=frozen&user_id="+activeSub.UserID.String(), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response api.Response
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "status", response.Error.Fields[0].Field)
+	})
+
+	t.Run("List Subscriptions - Requires User", func(t *testing.T) {
+		mockProductRepo := new(testutils.MockProductRepository)
+		mockSubRepo := new(testutils.MockSubscriptionRepository)
+
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
+		router := setupSubscriptionRouter(handler)
+
+		req := httptest.NewRequest("GET", "/subscriptions?status=active", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response api.Response
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []apperrors.FieldError{{Field: "user_id", Message: "is required"}}, response.Error.Fields)
+		mockSubRepo.AssertNotCalled(t, "ListSubscriptions")
+	})
+
 	t.Run("Pause Subscription - Success", func(t *testing.T) {
 		mockProductRepo := new(testutils.MockProductRepository)
 		mockSubRepo := new(testutils.MockSubscriptionRepository)
 
 		expectedVersion := 1
-		mockSubRepo.On("PauseSubscription", activeSub.ID.String(), expectedVersion).Return(pausedSub, nil)
+		mockSubRepo.On("PauseSubscription", mock.Anything, activeSub.ID.String(), expectedVersion).Return(pausedSub, nil)
 
-		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo)
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
 		router := setupSubscriptionRouter(handler)
 
 		req := httptest.NewRequest("PATCH", "/subscriptions/"+activeSub.ID.String()+"/pause", nil)
@@ -153,14 +225,45 @@ func TestSubscriptionHandler(t *testing.T) {
 		mockSubRepo.AssertExpectations(t)
 	})
 
+	t.Run("Pause Subscription - Untranslated When Translations Fail", func(t *testing.T) {
+		mockProductRepo := new(testutils.MockProductRepository)
+		mockSubRepo := new(testutils.MockSubscriptionRepository)
+		mockTranslations := new(testutils.MockTranslationRepository)
+
+		sub := *pausedSub
+		product := *validProduct
+		sub.Product = &product
+		mockSubRepo.On("PauseSubscription", mock.Anything, activeSub.ID.String(), 1).Return(&sub, nil)
+		mockTranslations.On("Localize", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, mockTranslations)
+		router := setupSubscriptionRouter(handler)
+
+		req := httptest.NewRequest("PATCH", "/subscriptions/"+activeSub.ID.String()+"/pause", nil)
+		req.Header.Set("If-Match", "1")
+		req.Header.Set("Accept-Language", "de")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "the pause committed")
+		assert.Equal(t, "en", w.Header().Get("Content-Language"))
+
+		var response api.Response
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		responseData := response.Data.(map[string]interface{})
+		assert.Equal(t, string(models.StatusPaused), responseData["status"])
+		assert.Equal(t, "Test Product", responseData["product"].(map[string]interface{})["name"])
+
+		mockTranslations.AssertExpectations(t)
+	})
+
 	t.Run("Create with Invalid Product ID", func(t *testing.T) {
 		mockProductRepo := new(testutils.MockProductRepository)
 		mockSubRepo := new(testutils.MockSubscriptionRepository)
 
 		invalidID := "invalid-uuid"
-		mockProductRepo.On("GetProduct", invalidID).Return(nil, repositories.ErrInvalidProductID)
 
-		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo)
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
 		router := setupSubscriptionRouter(handler)
 
 		req := httptest.NewRequest("POST", "/products/"+invalidID+"/subscriptions", nil)
@@ -172,10 +275,34 @@ func TestSubscriptionHandler(t *testing.T) {
 		var response api.Response
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "invalid ID format", response.Error.Message)
-		assert.Equal(t, "invalid_id", response.Error.Code)
+		assert.Equal(t, "request validation failed", response.Error.Message)
+		assert.Equal(t, "validation_error", response.Error.Code)
+		assert.Equal(t, []apperrors.FieldError{{Field: "product_id", Message: "must be a valid UUID"}}, response.Error.Fields)
 
-		mockProductRepo.AssertExpectations(t)
+		mockProductRepo.AssertNotCalled(t, "GetProduct", invalidID)
+	})
+
+	t.Run("Pause with Invalid Subscription ID", func(t *testing.T) {
+		mockProductRepo := new(testutils.MockProductRepository)
+		mockSubRepo := new(testutils.MockSubscriptionRepository)
+
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
+		router := setupSubscriptionRouter(handler)
+
+		req := httptest.NewRequest("PATCH", "/subscriptions/not-a-uuid/pause", nil)
+		req.Header.Set("If-Match", "1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response api.Response
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "validation_error", response.Error.Code)
+		assert.Equal(t, []apperrors.FieldError{{Field: "id", Message: "must be a valid UUID"}}, response.Error.Fields)
+
+		mockSubRepo.AssertNotCalled(t, "PauseSubscription", mock.Anything, mock.Anything)
 	})
 
 	t.Run("Pause Already Cancelled Subscription", func(t *testing.T) {
@@ -183,9 +310,9 @@ func TestSubscriptionHandler(t *testing.T) {
 		mockSubRepo := new(testutils.MockSubscriptionRepository)
 
 		expectedVersion := 1
-		mockSubRepo.On("PauseSubscription", cancelledSub.ID.String(), expectedVersion).Return(nil, repositories.ErrCannotPause)
+		mockSubRepo.On("PauseSubscription", mock.Anything, cancelledSub.ID.String(), expectedVersion).Return(nil, repositories.ErrCannotPause)
 
-		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo)
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
 		router := setupSubscriptionRouter(handler)
 
 		req := httptest.NewRequest("PATCH", "/subscriptions/"+cancelledSub.ID.String()+"/pause", nil)
@@ -198,7 +325,7 @@ func TestSubscriptionHandler(t *testing.T) {
 		var response api.Response
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "cannot pause subscription", response.Error.Message)
+		assert.Equal(t, "subscription cannot be paused", response.Error.Message)
 		assert.Equal(t, "invalid_state", response.Error.Code)
 
 		mockSubRepo.AssertExpectations(t)
@@ -208,7 +335,7 @@ func TestSubscriptionHandler(t *testing.T) {
 		mockProductRepo := new(testutils.MockProductRepository)
 		mockSubRepo := new(testutils.MockSubscriptionRepository)
 
-		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo)
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
 		router := setupSubscriptionRouter(handler)
 
 		req := httptest.NewRequest("PATCH", "/subscriptions/"+activeSub.ID.String()+"/pause", nil)
@@ -217,4 +344,130 @@ func TestSubscriptionHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
 	})
+
+	t.Run("Pause Subscription - Missing Version as Problem", func(t *testing.T) {
+		mockProductRepo := new(testutils.MockProductRepository)
+		mockSubRepo := new(testutils.MockSubscriptionRepository)
+
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
+		router := setupSubscriptionRouter(handler)
+
+		req := httptest.NewRequest("PATCH", "/subscriptions/"+activeSub.ID.String()+"/pause", nil)
+		req.Header.Set("Accept", api.MIMEProblemJSON)
+		req.Header.Set(middleware.RequestIDHeader, "req-123")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+		assert.Equal(t, api.MIMEProblemJSON, w.Header().Get("Content-Type"))
+
+		var problem api.Problem
+		err := json.Unmarshal(w.Body.Bytes(), &problem)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusPreconditionRequired, problem.Status)
+		assert.Equal(t, "precondition_required", problem.Code)
+		assert.Equal(t, "missing If-Match header", problem.Detail)
+		assert.Equal(t, "/subscriptions/"+activeSub.ID.String()+"/pause", problem.Instance)
+		assert.Equal(t, "req-123", problem.RequestID)
+	})
+
+	t.Run("Get Subscription - Unexpected Error Is Not Leaked", func(t *testing.T) {
+		mockProductRepo := new(testutils.MockProductRepository)
+		mockSubRepo := new(testutils.MockSubscriptionRepository)
+
+		mockSubRepo.On("GetSubscription", mock.Anything, activeSub.ID.String()).Return(nil, errors.New("pq: connection refused"))
+
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
+		router := setupSubscriptionRouter(handler)
+
+		req := httptest.NewRequest("GET", "/subscriptions/"+activeSub.ID.String(), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+		var response api.Response
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "internal server error", response.Error.Message)
+		assert.Equal(t, "internal_error", response.Error.Code)
+		assert.NotEmpty(t, response.Error.RequestID)
+
+		mockSubRepo.AssertExpectations(t)
+	})
+
+	t.Run("Create Subscription - Request Context Reaches Repository", func(t *testing.T) {
+		mockProductRepo := new(testutils.MockProductRepository)
+		mockSubRepo := new(testutils.MockSubscriptionRepository)
+
+		requestScoped := mock.MatchedBy(func(ctx context.Context) bool {
+			return reqctx.RequestID(ctx) == "req-456"
+		})
+		withUser := mock.MatchedBy(func(ctx context.Context) bool {
+			return reqctx.RequestID(ctx) == "req-456" && reqctx.UserID(ctx) != ""
+		})
+		mockProductRepo.On("GetProduct", requestScoped, validProduct.ID.String()).Return(validProduct, nil)
+		mockSubRepo.On("CreateSubscription", withUser, mock.AnythingOfType("string"), validProduct).Return(activeSub, nil)
+
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
+		router := setupSubscriptionRouter(handler)
+
+		req := httptest.NewRequest("POST", "/products/"+validProduct.ID.String()+"/subscriptions", nil)
+		req.Header.Set(middleware.RequestIDHeader, "req-456")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockProductRepo.AssertExpectations(t)
+		mockSubRepo.AssertExpectations(t)
+	})
+
+	t.Run("Pause Subscription - Subscription ID Reaches Repository", func(t *testing.T) {
+		mockProductRepo := new(testutils.MockProductRepository)
+		mockSubRepo := new(testutils.MockSubscriptionRepository)
+
+		scoped := mock.MatchedBy(func(ctx context.Context) bool {
+			return reqctx.SubscriptionID(ctx) == activeSub.ID.String()
+		})
+		mockSubRepo.On("PauseSubscription", scoped, activeSub.ID.String(), 1).Return(pausedSub, nil)
+
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
+		router := setupSubscriptionRouter(handler)
+
+		req := httptest.NewRequest("PATCH", "/subscriptions/"+activeSub.ID.String()+"/pause", nil)
+		req.Header.Set("If-Match", "1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockSubRepo.AssertExpectations(t)
+	})
+
+	t.Run("Get Subscription - Deadline Exceeded", func(t *testing.T) {
+		mockProductRepo := new(testutils.MockProductRepository)
+		mockSubRepo := new(testutils.MockSubscriptionRepository)
+
+		// like a database query, the repository runs until the request
+		// deadline aborts it and then reports the context error
+		mockSubRepo.On("GetSubscription", mock.Anything, activeSub.ID.String()).
+			Run(func(args mock.Arguments) {
+				<-args.Get(0).(context.Context).Done()
+			}).
+			Return(nil, context.DeadlineExceeded)
+
+		handler := handlers.NewSubscriptionHandler(mockSubRepo, mockProductRepo, new(testutils.MockTranslationRepository))
+		router := gin.New()
+		router.Use(middleware.RequestID(), middleware.Timeout(20*time.Millisecond), middleware.ErrorHandler())
+		router.GET("/subscriptions/:id", handler.GetSubscription)
+
+		req := httptest.NewRequest("GET", "/subscriptions/"+activeSub.ID.String(), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+		var response api.Response
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "timeout", response.Error.Code)
+	})
 }
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gymondo_dz/pkg/api"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type translationURI struct {
+	ProductID string `uri:"id" binding:"required,resource_id"`
+	Locale    string `uri:"locale" binding:"required,locale"`
+}
+
+type translationRequest struct {
+	Name        string `json:"name" binding:"required,min=3,max=100"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+type TranslationHandler struct {
+	repo repositories.TranslationRepository
+}
+
+func NewTranslationHandler(repo repositories.TranslationRepository) *TranslationHandler {
+	return &TranslationHandler{repo: repo}
+}
+
+// @Summary List product translations
+// @Description Get every translation of a product's name and description
+// @Tags admin
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Success 200 {object} api.Response{data=[]models.ProductTranslation}
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Security AdminKey
+// @Router /admin/products/{id}/translations [get]
+func (h *TranslationHandler) ListTranslations(c *gin.Context) {
+	var uri productURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	translations, err := h.repo.ListTranslations(c.Request.Context(), uri.ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.SuccessResponse(translations, nil))
+}
+
+// @Summary Create or replace a product translation
+// @Description Set a product's name and description for one locale
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param locale path string true "Locale" Enums(de, fr, es)
+// @Param translation body translationRequest true "Translated content"
+// @Success 200 {object} api.Response{data=models.ProductTranslation}
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Security AdminKey
+// @Router /admin/products/{id}/translations/{locale} [put]
+func (h *TranslationHandler) PutTranslation(c *gin.Context) {
+	var uri translationURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var req translationRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	translation, err := h.repo.UpsertTranslation(c.Request.Context(), &models.ProductTranslation{
+		ProductID:   uuid.MustParse(uri.ProductID),
+		Locale:      uri.Locale,
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.SuccessResponse(translation, nil))
+}
+
+// @Summary Delete a product translation
+// @Description Remove a product's translation for one locale
+// @Tags admin
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param locale path string true "Locale" Enums(de, fr, es)
+// @Success 204
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Security AdminKey
+// @Router /admin/products/{id}/translations/{locale} [delete]
+func (h *TranslationHandler) DeleteTranslation(c *gin.Context) {
+	var uri translationURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if err := h.repo.DeleteTranslation(c.Request.Context(), uri.ProductID, uri.Locale); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
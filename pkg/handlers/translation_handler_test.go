@@ -0,0 +1,128 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/handlers"
+	"gymondo_dz/pkg/middleware"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTranslationHandler(t *testing.T) {
+	productID := uuid.MustParse("465dc700-666c-4b7a-80e2-d9e2967f4442")
+	fixedTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		mockSetup      func(*testutils.MockTranslationRepository)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "PutTranslation success",
+			method: "PUT",
+			path:   "/admin/products/" + productID.String() + "/translations/de",
+			body:   `{"name":"Monatsabo","description":"Ein Monat"}`,
+			mockSetup: func(m *testutils.MockTranslationRepository) {
+				m.On("UpsertTranslation", mock.Anything, mock.MatchedBy(func(tr *models.ProductTranslation) bool {
+					return tr.ProductID == productID && tr.Locale == "de" && tr.Name == "Monatsabo"
+				})).Return(&models.ProductTranslation{
+					ProductID:   productID,
+					Locale:      "de",
+					Name:        "Monatsabo",
+					Description: "Ein Monat",
+					CreatedAt:   fixedTime,
+					UpdatedAt:   fixedTime,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":{"product_id":"465dc700-666c-4b7a-80e2-d9e2967f4442","locale":"de","name":"Monatsabo","description":"Ein Monat","created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}}`,
+		},
+		{
+			name:           "PutTranslation unsupported locale and missing name",
+			method:         "PUT",
+			path:           "/admin/products/" + productID.String() + "/translations/en",
+			body:           `{}`,
+			mockSetup:      func(m *testutils.MockTranslationRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"message":"request validation failed","code":"validation_error","fields":[{"field":"locale","message":"must be one of: de, fr, es"}]}}`,
+		},
+		{
+			name:           "PutTranslation invalid body",
+			method:         "PUT",
+			path:           "/admin/products/" + productID.String() + "/translations/fr",
+			body:           `{"description":"x"}`,
+			mockSetup:      func(m *testutils.MockTranslationRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"message":"request validation failed","code":"validation_error","fields":[{"field":"name","message":"is required"}]}}`,
+		},
+		{
+			name:   "PutTranslation unknown product",
+			method: "PUT",
+			path:   "/admin/products/" + productID.String() + "/translations/fr",
+			body:   `{"name":"Abonnement"}`,
+			mockSetup: func(m *testutils.MockTranslationRepository) {
+				m.On("UpsertTranslation", mock.Anything, mock.Anything).Return(nil, repositories.ErrProductNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":{"message":"product not found","code":"not_found"}}`,
+		},
+		{
+			name:   "ListTranslations success",
+			method: "GET",
+			path:   "/admin/products/" + productID.String() + "/translations",
+			mockSetup: func(m *testutils.MockTranslationRepository) {
+				m.On("ListTranslations", mock.Anything, productID.String()).Return([]models.ProductTranslation{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":[]}`,
+		},
+		{
+			name:   "DeleteTranslation not found",
+			method: "DELETE",
+			path:   "/admin/products/" + productID.String() + "/translations/es",
+			mockSetup: func(m *testutils.MockTranslationRepository) {
+				m.On("DeleteTranslation", mock.Anything, productID.String(), "es").Return(repositories.ErrTranslationNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":{"message":"translation not found","code":"not_found"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(testutils.MockTranslationRepository)
+			tt.mockSetup(mockRepo)
+
+			handler := handlers.NewTranslationHandler(mockRepo)
+			router := gin.Default()
+			router.Use(middleware.ErrorHandler())
+			router.GET("/admin/products/:id/translations", handler.ListTranslations)
+			router.PUT("/admin/products/:id/translations/:locale", handler.PutTranslation)
+			router.DELETE("/admin/products/:id/translations/:locale", handler.DeleteTranslation)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
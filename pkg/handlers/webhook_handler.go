@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gymondo_dz/pkg/api"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type webhookURI struct {
+	ID string `uri:"id" binding:"required,resource_id"`
+}
+
+type createWebhookRequest struct {
+	URL         string `json:"url" binding:"required,max=2048,webhook_url"`
+	Description string `json:"description" binding:"max=255"`
+	// EventTypes subscribes to the listed event types; leave it empty for all.
+	EventTypes []string `json:"event_types" binding:"omitempty,unique,dive,event_type"`
+	// ProductIDs are the partner's products: only events about
+	// subscriptions to them are delivered.
+	ProductIDs []string `json:"product_ids" binding:"required,min=1,unique,dive,resource_id"`
+}
+
+type updateWebhookRequest struct {
+	URL         *string   `json:"url" binding:"omitempty,max=2048,webhook_url"`
+	Description *string   `json:"description" binding:"omitempty,max=255"`
+	EventTypes  *[]string `json:"event_types" binding:"omitempty,unique,dive,event_type"`
+	ProductIDs  *[]string `json:"product_ids" binding:"omitempty,min=1,unique,dive,resource_id"`
+	Enabled     *bool     `json:"enabled"`
+}
+
+type listDeliveriesQuery struct {
+	repositories.PageQuery
+	Status models.WebhookDeliveryStatus `form:"status" binding:"omitempty,delivery_status"`
+}
+
+// createdWebhookEndpoint is the only response that carries the secret
+// deliveries are signed with.
+type createdWebhookEndpoint struct {
+	models.WebhookEndpoint
+	Secret string `json:"secret" example:"whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"`
+}
+
+type WebhookHandler struct {
+	repo repositories.WebhookRepository
+}
+
+func NewWebhookHandler(repo repositories.WebhookRepository) *WebhookHandler {
+	return &WebhookHandler{repo: repo}
+}
+
+// @Summary Register a webhook endpoint
+// @Description Register a URL that the events about subscriptions to the given products are POSTed to. The response holds the signing secret, which is not shown again
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param endpoint body createWebhookRequest true "Endpoint"
+// @Success 201 {object} api.Response{data=createdWebhookEndpoint}
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Security AdminKey
+// @Router /admin/webhooks [post]
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	var req createWebhookRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	endpoint, err := h.repo.CreateEndpoint(c.Request.Context(), &models.WebhookEndpoint{
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+		ProductIDs:  productIDs(req.ProductIDs),
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, api.SuccessResponse(createdWebhookEndpoint{WebhookEndpoint: *endpoint, Secret: endpoint.Secret}, nil))
+}
+
+// @Summary List webhook endpoints
+// @Description List every registered webhook endpoint
+// @Tags admin
+// @Produce json
+// @Success 200 {object} api.Response{data=[]models.WebhookEndpoint}
+// @Failure 401 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Security AdminKey
+// @Router /admin/webhooks [get]
+func (h *WebhookHandler) ListEndpoints(c *gin.Context) {
+	endpoints, err := h.repo.ListEndpoints(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.SuccessResponse(endpoints, nil))
+}
+
+// @Summary Get a webhook endpoint
+// @Description Get a webhook endpoint, including whether it was disabled and why
+// @Tags admin
+// @Produce json
+// @Param id path string true "Endpoint ID" format(uuid)
+// @Success 200 {object} api.Response{data=models.WebhookEndpoint}
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Security AdminKey
+// @Router /admin/webhooks/{id} [get]
+func (h *WebhookHandler) GetEndpoint(c *gin.Context) {
+	var uri webhookURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	endpoint, err := h.repo.GetEndpoint(c.Request.Context(), uri.ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.SuccessResponse(endpoint, nil))
+}
+
+// @Summary Update a webhook endpoint
+// @Description Change an endpoint's URL, description, event types or products, or enable or disable it. Enabling an endpoint resumes its pending deliveries
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Endpoint ID" format(uuid)
+// @Param changes body updateWebhookRequest true "Fields to change"
+// @Success 200 {object} api.Response{data=models.WebhookEndpoint}
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Security AdminKey
+// @Router /admin/webhooks/{id} [patch]
+func (h *WebhookHandler) UpdateEndpoint(c *gin.Context) {
+	var uri webhookURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var req updateWebhookRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	changes := repositories.WebhookEndpointChanges{
+		URL:         req.URL,
+		Description: req.Description,
+		Enabled:     req.Enabled,
+	}
+	if req.EventTypes != nil {
+		eventTypes := models.EventTypes(*req.EventTypes)
+		changes.EventTypes = &eventTypes
+	}
+	if req.ProductIDs != nil {
+		ids := productIDs(*req.ProductIDs)
+		changes.ProductIDs = &ids
+	}
+
+	endpoint, err := h.repo.UpdateEndpoint(c.Request.Context(), uri.ID, changes)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.SuccessResponse(endpoint, nil))
+}
+
+// @Summary Delete a webhook endpoint
+// @Description Remove a webhook endpoint together with its deliveries
+// @Tags admin
+// @Produce json
+// @Param id path string true "Endpoint ID" format(uuid)
+// @Success 204
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Security AdminKey
+// @Router /admin/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	var uri webhookURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if err := h.repo.DeleteEndpoint(c.Request.Context(), uri.ID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List webhook deliveries
+// @Description List an endpoint's deliveries, newest first, with the outcome of their last attempt
+// @Tags admin
+// @Produce json
+// @Param id path string true "Endpoint ID" format(uuid)
+// @Param status query string false "Delivery status" Enums(pending, succeeded, failed)
+// @Param page query int false "Page number (offset pagination)" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor or meta.prev_cursor (keyset pagination)" maxlength(512)
+// @Param include_total query bool false "Count all matching items in cursor mode" default(false)
+// @Success 200 {object} api.Response{data=[]models.WebhookDelivery,meta=api.Meta}
+// @Header 200 {string} Link "RFC 8288 links to the first, prev and next pages"
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Security AdminKey
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	var uri webhookURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var query listDeliveriesQuery
+	if err := validation.BindQuery(c, &query); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	filter := repositories.WebhookDeliveryFilter{Status: query.Status}
+	deliveries, page, err := h.repo.ListDeliveries(c.Request.Context(), uri.ID, filter, query.PageRequest())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setLinkHeader(c, page)
+	c.JSON(http.StatusOK, api.SuccessResponse(deliveries, pageMeta(page)))
+}
+
+// @Summary Get a webhook delivery
+// @Description Get a delivery with the log of its attempts and the endpoint's responses
+// @Tags admin
+// @Produce json
+// @Param id path string true "Delivery ID" format(uuid)
+// @Success 200 {object} api.Response{data=models.WebhookDelivery}
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Security AdminKey
+// @Router /admin/webhook-deliveries/{id} [get]
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	var uri webhookURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	delivery, err := h.repo.GetDelivery(c.Request.Context(), uri.ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.SuccessResponse(delivery, nil))
+}
+
+// @Summary Redeliver a webhook
+// @Description Send a delivery again right away with a fresh set of attempts, whether it succeeded, failed or is still pending
+// @Tags admin
+// @Produce json
+// @Param id path string true "Delivery ID" format(uuid)
+// @Success 202 {object} api.Response{data=models.WebhookDelivery}
+// @Failure 400 {object} api.Response
+// @Failure 401 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 409 {object} api.Response
+// @Failure 429 {object} api.Response
+// @Failure 503 {object} api.Response
+// @Security AdminKey
+// @Router /admin/webhook-deliveries/{id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	var uri webhookURI
+	if err := validation.BindURI(c, &uri); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	delivery, err := h.repo.Redeliver(c.Request.Context(), uri.ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, api.SuccessResponse(delivery, nil))
+}
+
+// productIDs converts IDs that passed resource_id validation.
+func productIDs(ids []string) models.ProductIDs {
+	parsed := make(models.ProductIDs, len(ids))
+	for i, id := range ids {
+		parsed[i] = uuid.MustParse(id)
+	}
+	return parsed
+}
@@ -0,0 +1,228 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/handlers"
+	"gymondo_dz/pkg/middleware"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	endpointID := uuid.MustParse("7b0c1d3e-2f4a-4b5c-8d6e-9f0a1b2c3d4e")
+	deliveryID := uuid.MustParse("0e5f7a9b-1c3d-4e5f-a6b7-c8d9e0f1a2b3")
+	eventID := uuid.MustParse("3c4d5e6f-7a8b-4c9d-8e0f-1a2b3c4d5e6f")
+	productID := uuid.MustParse("465dc700-666c-4b7a-80e2-d9e2967f4442")
+	fixedTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	endpoint := &models.WebhookEndpoint{
+		ID:         endpointID,
+		URL:        "https://partner.example/hooks",
+		Secret:     "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw",
+		EventTypes: models.EventTypes{"subscription.paused"},
+		ProductIDs: models.ProductIDs{productID},
+		Enabled:    true,
+		CreatedAt:  fixedTime,
+		UpdatedAt:  fixedTime,
+	}
+	endpointJSON := `{"id":"7b0c1d3e-2f4a-4b5c-8d6e-9f0a1b2c3d4e","url":"https://partner.example/hooks","event_types":["subscription.paused"],"product_ids":["465dc700-666c-4b7a-80e2-d9e2967f4442"],"enabled":true,"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}`
+	code := http.StatusOK
+	delivery := &models.WebhookDelivery{
+		ID:               deliveryID,
+		EndpointID:       endpointID,
+		EventID:          eventID,
+		EventType:        "subscription.paused",
+		Status:           models.DeliveryPending,
+		NextAttemptAt:    fixedTime,
+		LastResponseCode: &code,
+		CreatedAt:        fixedTime,
+		UpdatedAt:        fixedTime,
+	}
+	deliveryJSON := `{"id":"0e5f7a9b-1c3d-4e5f-a6b7-c8d9e0f1a2b3","endpoint_id":"7b0c1d3e-2f4a-4b5c-8d6e-9f0a1b2c3d4e","event_id":"3c4d5e6f-7a8b-4c9d-8e0f-1a2b3c4d5e6f","event_type":"subscription.paused","status":"pending","attempts":0,"next_attempt_at":"2025-01-01T00:00:00Z","last_response_code":200,"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}`
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		mockSetup      func(*testutils.MockWebhookRepository)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "CreateEndpoint returns the secret",
+			method: "POST",
+			path:   "/admin/webhooks",
+			body:   `{"url":"https://partner.example/hooks","event_types":["subscription.paused"],"product_ids":["465dc700-666c-4b7a-80e2-d9e2967f4442"]}`,
+			mockSetup: func(m *testutils.MockWebhookRepository) {
+				m.On("CreateEndpoint", mock.Anything, mock.MatchedBy(func(e *models.WebhookEndpoint) bool {
+					return e.URL == "https://partner.example/hooks" && len(e.EventTypes) == 1 &&
+						len(e.ProductIDs) == 1 && e.ProductIDs[0] == productID
+				})).Return(endpoint, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"data":` + strings.TrimSuffix(endpointJSON, "}") + `,"secret":"whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"}}`,
+		},
+		{
+			name:           "CreateEndpoint invalid URL and event type",
+			method:         "POST",
+			path:           "/admin/webhooks",
+			body:           `{"url":"ftp://partner.example","event_types":["subscription.paused","subscription.renewed"],"product_ids":["465dc700-666c-4b7a-80e2-d9e2967f4442"]}`,
+			mockSetup:      func(m *testutils.MockWebhookRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"error":{"message":"request validation failed","code":"validation_error","fields":[` +
+				`{"field":"url","message":"must be an absolute http or https URL"},` +
+				`{"field":"event_types[1]","message":"must be one of: subscription.created, subscription.paused, subscription.unpaused, subscription.cancelled, subscription.expired"}]}}`,
+		},
+		{
+			name:           "CreateEndpoint without products",
+			method:         "POST",
+			path:           "/admin/webhooks",
+			body:           `{"url":"https://partner.example/hooks"}`,
+			mockSetup:      func(m *testutils.MockWebhookRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"message":"request validation failed","code":"validation_error","fields":[{"field":"product_ids","message":"is required"}]}}`,
+		},
+		{
+			name:   "GetEndpoint hides the secret",
+			method: "GET",
+			path:   "/admin/webhooks/" + endpointID.String(),
+			mockSetup: func(m *testutils.MockWebhookRepository) {
+				m.On("GetEndpoint", mock.Anything, endpointID.String()).Return(endpoint, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":` + endpointJSON + `}`,
+		},
+		{
+			name:   "UpdateEndpoint",
+			method: "PATCH",
+			path:   "/admin/webhooks/" + endpointID.String(),
+			body:   `{"enabled":true,"event_types":[],"product_ids":["465dc700-666c-4b7a-80e2-d9e2967f4442"]}`,
+			mockSetup: func(m *testutils.MockWebhookRepository) {
+				m.On("UpdateEndpoint", mock.Anything, endpointID.String(), mock.MatchedBy(func(c repositories.WebhookEndpointChanges) bool {
+					return c.URL == nil && *c.Enabled && c.EventTypes != nil && len(*c.EventTypes) == 0 &&
+						c.ProductIDs != nil && len(*c.ProductIDs) == 1
+				})).Return(endpoint, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":` + endpointJSON + `}`,
+		},
+		{
+			name:           "UpdateEndpoint duplicate event types",
+			method:         "PATCH",
+			path:           "/admin/webhooks/" + endpointID.String(),
+			body:           `{"event_types":["subscription.paused","subscription.paused"]}`,
+			mockSetup:      func(m *testutils.MockWebhookRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"message":"request validation failed","code":"validation_error","fields":[{"field":"event_types","message":"must not contain duplicates"}]}}`,
+		},
+		{
+			name:           "UpdateEndpoint without products",
+			method:         "PATCH",
+			path:           "/admin/webhooks/" + endpointID.String(),
+			body:           `{"product_ids":[]}`,
+			mockSetup:      func(m *testutils.MockWebhookRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"message":"request validation failed","code":"validation_error","fields":[{"field":"product_ids","message":"must have at least 1 items"}]}}`,
+		},
+		{
+			name:   "DeleteEndpoint not found",
+			method: "DELETE",
+			path:   "/admin/webhooks/" + endpointID.String(),
+			mockSetup: func(m *testutils.MockWebhookRepository) {
+				m.On("DeleteEndpoint", mock.Anything, endpointID.String()).Return(repositories.ErrWebhookEndpointNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":{"message":"webhook endpoint not found","code":"not_found"}}`,
+		},
+		{
+			name:   "ListDeliveries",
+			method: "GET",
+			path:   "/admin/webhooks/" + endpointID.String() + "/deliveries?status=pending",
+			mockSetup: func(m *testutils.MockWebhookRepository) {
+				m.On("ListDeliveries", mock.Anything, endpointID.String(),
+					repositories.WebhookDeliveryFilter{Status: models.DeliveryPending},
+					repositories.PageRequest{Page: 1, Limit: 10},
+				).Return([]models.WebhookDelivery{*delivery}, repositories.Pagination{Page: 1, Limit: 10}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":[` + deliveryJSON + `],"meta":{"page":1,"limit":10}}`,
+		},
+		{
+			name:           "ListDeliveries unknown status",
+			method:         "GET",
+			path:           "/admin/webhooks/" + endpointID.String() + "/deliveries?status=lost",
+			mockSetup:      func(m *testutils.MockWebhookRepository) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"message":"request validation failed","code":"validation_error","fields":[{"field":"status","message":"must be one of: pending, succeeded, failed"}]}}`,
+		},
+		{
+			name:   "GetDelivery",
+			method: "GET",
+			path:   "/admin/webhook-deliveries/" + deliveryID.String(),
+			mockSetup: func(m *testutils.MockWebhookRepository) {
+				m.On("GetDelivery", mock.Anything, deliveryID.String()).Return(delivery, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":` + deliveryJSON + `}`,
+		},
+		{
+			name:   "Redeliver",
+			method: "POST",
+			path:   "/admin/webhook-deliveries/" + deliveryID.String() + "/redeliver",
+			mockSetup: func(m *testutils.MockWebhookRepository) {
+				m.On("Redeliver", mock.Anything, deliveryID.String()).Return(delivery, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `{"data":` + deliveryJSON + `}`,
+		},
+		{
+			name:   "Redeliver to a disabled endpoint",
+			method: "POST",
+			path:   "/admin/webhook-deliveries/" + deliveryID.String() + "/redeliver",
+			mockSetup: func(m *testutils.MockWebhookRepository) {
+				m.On("Redeliver", mock.Anything, deliveryID.String()).Return(nil, repositories.ErrWebhookEndpointDisabled)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"error":{"message":"webhook endpoint is disabled","code":"invalid_state"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(testutils.MockWebhookRepository)
+			tt.mockSetup(mockRepo)
+
+			handler := handlers.NewWebhookHandler(mockRepo)
+			router := gin.Default()
+			router.Use(middleware.ErrorHandler())
+			router.POST("/admin/webhooks", handler.CreateEndpoint)
+			router.GET("/admin/webhooks", handler.ListEndpoints)
+			router.GET("/admin/webhooks/:id", handler.GetEndpoint)
+			router.PATCH("/admin/webhooks/:id", handler.UpdateEndpoint)
+			router.DELETE("/admin/webhooks/:id", handler.DeleteEndpoint)
+			router.GET("/admin/webhooks/:id/deliveries", handler.ListDeliveries)
+			router.GET("/admin/webhook-deliveries/:id", handler.GetDelivery)
+			router.POST("/admin/webhook-deliveries/:id/redeliver", handler.Redeliver)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
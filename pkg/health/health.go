@@ -0,0 +1,94 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gymondo_dz/pkg/logging"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// Check probes one dependency; a nil error means it is healthy.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Component is the outcome of one check. Error says why it failed; it is
+// logged but never served, since driver errors can name hosts and users.
+type Component struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"-"`
+}
+
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs every check concurrently, each bounded by timeout.
+type Checker struct {
+	timeout time.Duration
+	checks  []Check
+}
+
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{timeout: timeout, checks: checks}
+}
+
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Components: make(map[string]Component, len(c.checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			component := run(ctx, c.timeout, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[check.Name] = component
+			if component.Status != StatusOK {
+				report.Status = StatusUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func run(ctx context.Context, timeout time.Duration, check Check) Component {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	component := Component{
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		component.Status = StatusUnavailable
+		component.Error = err.Error()
+		logging.FromContext(ctx).WarnContext(ctx, "Health check failed", "check", check.Name, "error", err)
+	}
+	return component
+}
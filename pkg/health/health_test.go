@@ -0,0 +1,40 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/health"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker(t *testing.T) {
+	checker := health.NewChecker(50*time.Millisecond,
+		health.Check{Name: "fast", Run: func(context.Context) error { return nil }},
+		health.Check{Name: "broken", Run: func(context.Context) error { return errors.New("connection refused") }},
+		health.Check{Name: "slow", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+
+	start := time.Now()
+	report := checker.Run(context.Background())
+	assert.Less(t, time.Since(start), time.Second, "checks run concurrently and are bounded by the timeout")
+
+	assert.False(t, report.OK())
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+	assert.Equal(t, health.StatusOK, report.Components["fast"].Status)
+	assert.Equal(t, "connection refused", report.Components["broken"].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Components["slow"].Error)
+	assert.GreaterOrEqual(t, report.Components["slow"].LatencyMs, float64(50))
+}
+
+func TestCheckerAllHealthy(t *testing.T) {
+	report := health.NewChecker(time.Second, health.Check{Name: "database", Run: func(context.Context) error { return nil }}).Run(context.Background())
+	assert.True(t, report.OK())
+	assert.Len(t, report.Components, 1)
+}
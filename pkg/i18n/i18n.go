@@ -0,0 +1,48 @@
+package i18n
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is the language product names and descriptions are
+// authored in; translations exist for the other SupportedLocales.
+const DefaultLocale = "en"
+
+var SupportedLocales = []string{"en", "de", "fr", "es"}
+
+// IsTranslatable reports whether content can be translated into locale.
+func IsTranslatable(locale string) bool {
+	return locale != DefaultLocale && slices.Contains(SupportedLocales, locale)
+}
+
+// Negotiate turns an Accept-Language header into the ordered list of
+// supported locales to try. Regional tags fall back to their base
+// language ("de-AT" tries "de") and the chain always ends with
+// DefaultLocale.
+func Negotiate(acceptLanguage string) []string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return []string{DefaultLocale}
+	}
+
+	var chain []string
+	for _, tag := range tags {
+		candidates := []string{strings.ToLower(tag.String())}
+		if base, _ := tag.Base(); base.String() != candidates[0] {
+			candidates = append(candidates, base.String())
+		}
+		for _, locale := range candidates {
+			if slices.Contains(SupportedLocales, locale) && !slices.Contains(chain, locale) {
+				chain = append(chain, locale)
+			}
+		}
+	}
+
+	if !slices.Contains(chain, DefaultLocale) {
+		chain = append(chain, DefaultLocale)
+	}
+	return chain
+}
@@ -0,0 +1,31 @@
+package i18n_test
+
+import (
+	"testing"
+
+	"gymondo_dz/pkg/i18n"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expected       []string
+	}{
+		{name: "Missing header", acceptLanguage: "", expected: []string{"en"}},
+		{name: "Supported language", acceptLanguage: "de", expected: []string{"de", "en"}},
+		{name: "Regional tag falls back to base", acceptLanguage: "de-AT", expected: []string{"de", "en"}},
+		{name: "Ordered by quality", acceptLanguage: "es;q=0.5, fr-CH, fr;q=0.9", expected: []string{"fr", "es", "en"}},
+		{name: "Default before others", acceptLanguage: "en-GB, de;q=0.8", expected: []string{"en", "de"}},
+		{name: "Unsupported languages ignored", acceptLanguage: "it, pt-BR", expected: []string{"en"}},
+		{name: "Malformed header", acceptLanguage: "@@@", expected: []string{"en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, i18n.Negotiate(tt.acceptLanguage))
+		})
+	}
+}
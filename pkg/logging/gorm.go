@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger adapts slog to gorm's logger interface. Failed statements are
+// logged at error level, statements slower than the threshold at warn and
+// everything else at debug. SQL is logged with placeholders only, so bound
+// values such as user IDs never reach the logs.
+type GormLogger struct {
+	logger *slog.Logger
+	level  gormlogger.LogLevel
+	slow   time.Duration
+}
+
+// NewGormLogger logs through l unless the statement's context carries a
+// request-scoped logger. A zero slow threshold disables slow-query warnings.
+func NewGormLogger(l *slog.Logger, slow time.Duration) *GormLogger {
+	return &GormLogger{logger: l, level: gormlogger.Info, slow: slow}
+}
+
+func (g *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *g
+	clone.level = level
+	return &clone
+}
+
+func (g *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= gormlogger.Info {
+		g.from(ctx).InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= gormlogger.Warn {
+		g.from(ctx).WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= gormlogger.Error {
+		g.from(ctx).ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if g.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	l := g.from(ctx)
+	attrs := func() []slog.Attr {
+		sql, rows := fc()
+		return []slog.Attr{
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+		}
+	}
+
+	switch {
+	case err != nil && g.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		// an aborted request is the caller's doing, not a database fault
+		level := slog.LevelError
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			level = slog.LevelWarn
+		}
+		l.LogAttrs(ctx, level, "query failed", append(attrs(), slog.String("error", err.Error()))...)
+	case g.slow > 0 && elapsed > g.slow && g.level >= gormlogger.Warn:
+		l.LogAttrs(ctx, slog.LevelWarn, "slow query", append(attrs(), slog.Duration("threshold", g.slow))...)
+	case g.level >= gormlogger.Info && l.Enabled(ctx, slog.LevelDebug):
+		l.LogAttrs(ctx, slog.LevelDebug, "query", attrs()...)
+	}
+}
+
+// ParamsFilter keeps bound values out of the logged SQL.
+func (g *GormLogger) ParamsFilter(_ context.Context, sql string, _ ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+func (g *GormLogger) from(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(key{}).(*slog.Logger); ok {
+		return l
+	}
+	return g.logger
+}
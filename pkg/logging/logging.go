@@ -0,0 +1,85 @@
+// Package logging builds the service's structured slog logger and carries
+// request-scoped loggers through context.Context.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/reqctx"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type key struct{}
+
+// New returns a logger writing to w in the configured format, filtered at
+// the configured level. Records logged with a context are annotated with
+// the request, user and subscription IDs and the trace ID it carries.
+func New(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(cfg.Level)}
+
+	var h slog.Handler
+	if cfg.Format == "text" {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{h})
+}
+
+// ParseLevel maps a configured level name to its slog level, defaulting
+// to info for unknown names (config.Validate rejects those up front).
+func ParseLevel(s string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, key{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or slog.Default().
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(key{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// contextHandler reads the request-scoped values at log time rather than
+// when the logger is created, so IDs that only become known halfway
+// through a request (the user of a new subscription) still show up.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := reqctx.RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if id := reqctx.UserID(ctx); id != "" {
+		r.AddAttrs(slog.String("user_id", id))
+	}
+	if id := reqctx.SubscriptionID(ctx); id != "" {
+		r.AddAttrs(slog.String("subscription_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
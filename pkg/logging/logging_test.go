@@ -0,0 +1,145 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/logging"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/reqctx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// entries decodes one JSON object per logged line.
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var e map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &e), line)
+		out = append(out, e)
+	}
+	return out
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(config.LogConfig{Level: "info", Format: "json"}, &buf)
+
+	ctx := reqctx.WithRequestID(context.Background(), "req-1")
+	ctx = reqctx.WithUserID(ctx, "user-1")
+	ctx = reqctx.WithSubscriptionID(ctx, "sub-1")
+
+	logger.DebugContext(ctx, "hidden")
+	logger.With("route", "/subscriptions/:id").InfoContext(ctx, "shown")
+	logger.Info("no context")
+
+	got := entries(t, &buf)
+	require.Len(t, got, 2)
+	assert.Equal(t, "shown", got[0]["msg"])
+	assert.Equal(t, "INFO", got[0]["level"])
+	assert.Equal(t, "/subscriptions/:id", got[0]["route"])
+	assert.Equal(t, "req-1", got[0]["request_id"])
+	assert.Equal(t, "user-1", got[0]["user_id"])
+	assert.Equal(t, "sub-1", got[0]["subscription_id"])
+	assert.NotContains(t, got[1], "request_id")
+}
+
+func TestNewTextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logging.New(config.LogConfig{Level: "warn", Format: "text"}, &buf).Warn("careful", "n", 1)
+	assert.Contains(t, buf.String(), `level=WARN msg=careful n=1`)
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Same(t, logging.FromContext(context.Background()), logging.FromContext(context.Background()))
+
+	var buf bytes.Buffer
+	logger := logging.New(config.LogConfig{Level: "info"}, &buf)
+	assert.Same(t, logger, logging.FromContext(logging.NewContext(context.Background(), logger)))
+}
+
+func TestGormLogger(t *testing.T) {
+	tests := []struct {
+		name  string
+		level string
+		slow  time.Duration
+		run   func(db *gorm.DB) error
+		want  []string
+	}{
+		{
+			name:  "statements logged at debug",
+			level: "debug",
+			run: func(db *gorm.DB) error {
+				return db.Where("name = ?", "secret").Find(&[]models.Product{}).Error
+			},
+			want: []string{"DEBUG query"},
+		},
+		{
+			name:  "statements hidden above debug",
+			level: "info",
+			run: func(db *gorm.DB) error {
+				return db.Find(&[]models.Product{}).Error
+			},
+		},
+		{
+			name:  "slow statements logged at warn",
+			level: "info",
+			slow:  time.Nanosecond,
+			run: func(db *gorm.DB) error {
+				return db.Where("name = ?", "secret").Find(&[]models.Product{}).Error
+			},
+			want: []string{"WARN slow query"},
+		},
+		{
+			name:  "not found is not an error",
+			level: "info",
+			run: func(db *gorm.DB) error {
+				return db.First(&models.Product{}).Error
+			},
+		},
+		{
+			name:  "failures logged at error",
+			level: "info",
+			run: func(db *gorm.DB) error {
+				return db.Exec("SELECT * FROM missing WHERE id = ?", "secret").Error
+			},
+			want: []string{"ERROR query failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+			require.NoError(t, err)
+			require.NoError(t, db.AutoMigrate(&models.Product{}))
+
+			var buf bytes.Buffer
+			logger := logging.New(config.LogConfig{Level: tt.level}, &buf)
+			db.Logger = logging.NewGormLogger(logger, tt.slow)
+
+			ctx := reqctx.WithRequestID(context.Background(), "req-1")
+			_ = tt.run(db.WithContext(ctx))
+
+			var got []string
+			for _, e := range entries(t, &buf) {
+				got = append(got, e["level"].(string)+" "+e["msg"].(string))
+				assert.Equal(t, "req-1", e["request_id"])
+				assert.NotContains(t, e["sql"], "secret", "bound values must not be logged")
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
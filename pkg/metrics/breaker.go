@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"errors"
+
+	"gymondo_dz/pkg/resilience"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// InstrumentBreaker exports the state, retries and rejections of a
+// resilience.Executor, read from it at scrape time.
+func (m *Metrics) InstrumentBreaker(e *resilience.Executor) error {
+	labels := prometheus.Labels{"breaker": e.Name()}
+	return errors.Join(
+		m.registry.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "circuit_breaker_state",
+			Help:        "Circuit breaker state: 0 closed, 1 half-open, 2 open.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(e.State()) })),
+		m.registry.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "circuit_breaker_rejections_total",
+			Help:        "Calls rejected while the circuit breaker was open.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(e.Rejections()) })),
+		m.registry.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "retries_total",
+			Help:        "Retries after transient errors.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(e.Retries()) })),
+	)
+}
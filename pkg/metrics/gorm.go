@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gymondo_dz/pkg/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// activeQueryTimeout bounds the query run on every scrape.
+const activeQueryTimeout = 2 * time.Second
+
+// InstrumentDB times every GORM statement and registers connection pool
+// statistics and the active subscriptions gauge.
+func (m *Metrics) InstrumentDB(db *gorm.DB) error {
+	cb := db.Callback()
+	err := errors.Join(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", startTimer),
+		cb.Create().After("gorm:create").Register("metrics:after_create", m.observeQuery("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", startTimer),
+		cb.Query().After("gorm:query").Register("metrics:after_query", m.observeQuery("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", startTimer),
+		cb.Update().After("gorm:update").Register("metrics:after_update", m.observeQuery("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", startTimer),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", m.observeQuery("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", startTimer),
+		cb.Row().After("gorm:row").Register("metrics:after_row", m.observeQuery("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", startTimer),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", m.observeQuery("raw")),
+	)
+	if err != nil {
+		return err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return errors.Join(
+		m.registry.Register(collectors.NewDBStatsCollector(sqlDB, namespace)),
+		m.registry.Register(newActiveSubscriptions(db)),
+	)
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func (m *Metrics) observeQuery(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		m.dbQueryDuration.WithLabelValues(op, table).Observe(time.Since(v.(time.Time)).Seconds())
+	}
+}
+
+// activeSubscriptions reports the current number of active subscriptions
+// per product, counted at scrape time.
+type activeSubscriptions struct {
+	db   *gorm.DB
+	desc *prometheus.Desc
+}
+
+func newActiveSubscriptions(db *gorm.DB) *activeSubscriptions {
+	return &activeSubscriptions{
+		db: db,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "subscriptions_active"),
+			"Active subscriptions by product.",
+			[]string{"product_id"}, nil,
+		),
+	}
+}
+
+func (a *activeSubscriptions) Describe(ch chan<- *prometheus.Desc) {
+	ch <- a.desc
+}
+
+func (a *activeSubscriptions) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), activeQueryTimeout)
+	defer cancel()
+
+	var rows []struct {
+		ProductID string
+		Count     int64
+	}
+	err := a.db.WithContext(ctx).Model(&models.Subscription{}).
+		Select("product_id, COUNT(*) AS count").
+		Where("status = ?", models.StatusActive).
+		Group("product_id").
+		Scan(&rows).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to collect active subscriptions", "error", err)
+		ch <- prometheus.NewInvalidMetric(a.desc, err)
+		return
+	}
+
+	for _, row := range rows {
+		ch <- prometheus.MustNewConstMetric(a.desc, prometheus.GaugeValue, float64(row.Count), row.ProductID)
+	}
+}
@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "gymondo"
+
+// Metrics owns the Prometheus registry and every collector the service
+// exports.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+	grpcCalls          *prometheus.CounterVec
+	grpcDuration       *prometheus.HistogramVec
+	dbQueryDuration    *prometheus.HistogramVec
+	subscriptionEvents *prometheus.CounterVec
+	conflicts          *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		grpcCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_calls_total",
+			Help:      "gRPC calls by method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_call_duration_seconds",
+			Help:      "gRPC call latency by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database statement latency by operation and table.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		subscriptionEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "subscription_events_total",
+			Help:      "Subscription state changes by event and product.",
+		}, []string{"event", "product_id"}),
+		conflicts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "subscription_conflicts_total",
+			Help:      "Requests rejected because the subscription was modified concurrently, by route or gRPC method.",
+		}, []string{"route"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.grpcCalls,
+		m.grpcDuration,
+		m.dbQueryDuration,
+		m.subscriptionEvents,
+		m.conflicts,
+	)
+	return m
+}
+
+// Registry is exposed so other packages can register their own collectors.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middleware records every request under its route template, so
+// /subscriptions/:id stays one series regardless of the ID.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		m.httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+
+		for _, err := range c.Errors {
+			if errors.Is(err.Err, repositories.ErrConcurrentModification) {
+				m.conflicts.WithLabelValues(route).Inc()
+				break
+			}
+		}
+	}
+}
+
+// UnaryServerInterceptor records every gRPC call as Middleware records
+// HTTP requests, under the status toStatus turns the returned error into.
+func (m *Metrics) UnaryServerInterceptor(toStatus func(error) *status.Status) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := toStatus(err).Code().String()
+		m.grpcCalls.WithLabelValues(info.FullMethod, code).Inc()
+		m.grpcDuration.WithLabelValues(info.FullMethod, code).Observe(time.Since(start).Seconds())
+		if errors.Is(err, repositories.ErrConcurrentModification) {
+			m.conflicts.WithLabelValues(info.FullMethod).Inc()
+		}
+		return resp, err
+	}
+}
+
+// SubscriptionChanged implements repositories.SubscriptionObserver.
+func (m *Metrics) SubscriptionChanged(event repositories.SubscriptionEvent, subscription *models.Subscription, _ events.Event) {
+	m.subscriptionEvents.WithLabelValues(string(event), subscription.ProductID.String()).Inc()
+}
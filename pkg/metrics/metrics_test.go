@@ -0,0 +1,148 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/database"
+	"gymondo_dz/pkg/grpcapi"
+	"gymondo_dz/pkg/metrics"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/resilience"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := metrics.New()
+
+	router := gin.New()
+	router.Use(m.Middleware())
+	router.GET("/subscriptions/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.PATCH("/subscriptions/:id/pause", func(c *gin.Context) {
+		_ = c.Error(repositories.ErrConcurrentModification)
+		c.Status(http.StatusConflict)
+	})
+
+	for _, path := range []string{"/subscriptions/a", "/subscriptions/b"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPatch, "/subscriptions/a/pause", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `gymondo_http_requests_total{method="GET",route="/subscriptions/:id",status="200"} 2`)
+	assert.Contains(t, body, `gymondo_http_requests_total{method="PATCH",route="/subscriptions/:id/pause",status="409"} 1`)
+	assert.Contains(t, body, `gymondo_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `gymondo_http_request_duration_seconds_count{method="GET",route="/subscriptions/:id",status="200"} 2`)
+	assert.Contains(t, body, `gymondo_subscription_conflicts_total{route="/subscriptions/:id/pause"} 1`)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	m := metrics.New()
+	intercept := m.UnaryServerInterceptor(grpcapi.Status)
+	call := func(method string, err error) {
+		_, _ = intercept(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+			func(context.Context, any) (any, error) { return nil, err })
+	}
+
+	call("/gymondo.v1.SubscriptionService/GetSubscription", nil)
+	call("/gymondo.v1.SubscriptionService/GetSubscription", repositories.ErrSubscriptionNotFound)
+	call("/gymondo.v1.SubscriptionService/PauseSubscription", repositories.ErrConcurrentModification)
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `gymondo_grpc_calls_total{code="OK",method="/gymondo.v1.SubscriptionService/GetSubscription"} 1`)
+	assert.Contains(t, body, `gymondo_grpc_calls_total{code="NotFound",method="/gymondo.v1.SubscriptionService/GetSubscription"} 1`)
+	assert.Contains(t, body, `gymondo_grpc_calls_total{code="Aborted",method="/gymondo.v1.SubscriptionService/PauseSubscription"} 1`)
+	assert.Contains(t, body, `gymondo_grpc_call_duration_seconds_count{code="OK",method="/gymondo.v1.SubscriptionService/GetSubscription"} 1`)
+	assert.Contains(t, body, `gymondo_subscription_conflicts_total{route="/gymondo.v1.SubscriptionService/PauseSubscription"} 1`)
+}
+
+func TestInstrumentDB(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(context.Background(), db))
+
+	m := metrics.New()
+	require.NoError(t, m.InstrumentDB(db))
+	repo := repositories.NewSubscriptionRepository(db, m)
+
+	product := &models.Product{ID: uuid.New(), Name: "Monthly", Duration: models.DurationMonth, Price: 9.99}
+	require.NoError(t, db.Create(product).Error)
+
+	first, err := repo.CreateSubscription(context.Background(), uuid.New().String(), product)
+	require.NoError(t, err)
+	_, err = repo.CreateSubscription(context.Background(), uuid.New().String(), product)
+	require.NoError(t, err)
+	_, err = repo.PauseSubscription(context.Background(), first.ID.String(), first.Version)
+	require.NoError(t, err)
+
+	expected := `
+# HELP gymondo_subscriptions_active Active subscriptions by product.
+# TYPE gymondo_subscriptions_active gauge
+gymondo_subscriptions_active{product_id="` + product.ID.String() + `"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(m.Registry(), strings.NewReader(expected), "gymondo_subscriptions_active"))
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `gymondo_subscription_events_total{event="created",product_id="`+product.ID.String()+`"} 2`)
+	assert.Contains(t, body, `gymondo_subscription_events_total{event="paused",product_id="`+product.ID.String()+`"} 1`)
+	assert.Contains(t, body, `gymondo_db_query_duration_seconds_count{operation="create",table="subscriptions"}`)
+	assert.Contains(t, body, `gymondo_db_query_duration_seconds_count{operation="query",table="subscriptions"}`)
+	assert.Contains(t, body, `go_sql_open_connections{db_name="gymondo"}`)
+
+	// the gauge follows the database, not the event stream
+	require.NoError(t, db.Model(&models.Subscription{}).Where("1 = 1").Update("status", models.StatusExpired).Error)
+	assert.NoError(t, testutil.GatherAndCompare(m.Registry(), strings.NewReader(""), "gymondo_subscriptions_active"))
+}
+
+func TestInstrumentBreaker(t *testing.T) {
+	exec := resilience.New("database", config.ResilienceConfig{
+		BreakerFailures: 1, BreakerOpenTimeout: time.Hour,
+		RetryAttempts: 2, RetryBaseDelay: time.Microsecond, RetryMaxDelay: time.Microsecond,
+	})
+	m := metrics.New()
+	require.NoError(t, m.InstrumentBreaker(exec))
+
+	down := &pgconn.PgError{Code: "57P03"}
+	_ = exec.Do(context.Background(), resilience.RetryIdempotent, func(context.Context) error { return down })
+	_ = exec.Do(context.Background(), resilience.RetryIdempotent, func(context.Context) error { return nil })
+
+	// the retry after the first failure already finds the breaker open
+	expected := `
+# HELP gymondo_circuit_breaker_rejections_total Calls rejected while the circuit breaker was open.
+# TYPE gymondo_circuit_breaker_rejections_total counter
+gymondo_circuit_breaker_rejections_total{breaker="database"} 2
+# HELP gymondo_circuit_breaker_state Circuit breaker state: 0 closed, 1 half-open, 2 open.
+# TYPE gymondo_circuit_breaker_state gauge
+gymondo_circuit_breaker_state{breaker="database"} 2
+# HELP gymondo_retries_total Retries after transient errors.
+# TYPE gymondo_retries_total counter
+gymondo_retries_total{breaker="database"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(m.Registry(), strings.NewReader(expected),
+		"gymondo_circuit_breaker_state", "gymondo_circuit_breaker_rejections_total", "gymondo_retries_total"))
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"gymondo_dz/pkg/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrUnauthorized = apperrors.New(apperrors.CodeUnauthorized, http.StatusUnauthorized, "missing or invalid admin API key")
+
+// AdminAuth lets through requests that send one of keys as a bearer token
+// in the Authorization header and rejects the rest with 401. With no keys
+// it rejects every request, so admin endpoints are never open by default.
+func AdminAuth(keys []string) gin.HandlerFunc {
+	// comparing digests takes the same time whatever the key's length
+	digests := make([][sha256.Size]byte, len(keys))
+	for i, key := range keys {
+		digests[i] = sha256.Sum256([]byte(key))
+	}
+
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if ok && token != "" {
+			sum := sha256.Sum256([]byte(token))
+			for _, digest := range digests {
+				if subtle.ConstantTimeCompare(sum[:], digest[:]) == 1 {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+		_ = c.Error(ErrUnauthorized)
+		c.Abort()
+	}
+}
@@ -0,0 +1,55 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gymondo_dz/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const key = "admin-key-0123456789abcdef0123456789"
+
+	newRouter := func(keys ...string) *gin.Engine {
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
+		router.GET("/admin/webhooks", middleware.AdminAuth(keys), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		return router
+	}
+
+	tests := []struct {
+		name          string
+		keys          []string
+		authorization string
+		want          int
+	}{
+		{name: "Valid key", keys: []string{"other-key-0123456789abcdef0123456", key}, authorization: "Bearer " + key, want: http.StatusNoContent},
+		{name: "Missing header", keys: []string{key}, want: http.StatusUnauthorized},
+		{name: "Wrong key", keys: []string{key}, authorization: "Bearer " + key[:len(key)-1], want: http.StatusUnauthorized},
+		{name: "Not a bearer token", keys: []string{key}, authorization: "Basic " + key, want: http.StatusUnauthorized},
+		{name: "Empty token", keys: []string{key}, authorization: "Bearer ", want: http.StatusUnauthorized},
+		{name: "No keys configured", authorization: "Bearer " + key, want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/webhooks", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			newRouter(tt.keys...).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+			if tt.want == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="admin"`, w.Header().Get("WWW-Authenticate"))
+				assert.Contains(t, w.Body.String(), `"code":"unauthorized"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+
+	"gymondo_dz/pkg/api"
+	"gymondo_dz/pkg/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler renders the last error attached with c.Error once the
+// handler chain has finished. Clients asking for application/problem+json
+// get RFC 7807 problem details, everyone else the standard envelope.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		RenderError(c, c.Errors.Last().Err)
+	}
+}
+
+// RenderError writes err immediately, for code paths that run outside
+// the ErrorHandler chain (e.g. middleware that aborts early).
+func RenderError(c *gin.Context, err error) {
+	appErr := apperrors.From(err)
+	requestID := GetRequestID(c)
+	if appErr.RetryAfter > 0 {
+		// whole seconds, rounded up so clients never retry too early
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+	}
+
+	switch c.NegotiateFormat(gin.MIMEJSON, api.MIMEProblemJSON) {
+	case api.MIMEProblemJSON:
+		c.Header("Content-Type", api.MIMEProblemJSON)
+		c.AbortWithStatusJSON(appErr.Status, api.Problem{
+			Type:      api.ProblemType(appErr.Code),
+			Title:     appErr.Title,
+			Status:    appErr.Status,
+			Detail:    appErr.Detail,
+			Instance:  c.Request.URL.Path,
+			Code:      appErr.Code,
+			Errors:    appErr.Fields,
+			RequestID: requestID,
+		})
+	default:
+		c.AbortWithStatusJSON(appErr.Status, api.Response{
+			Error: &api.Error{
+				Message:   appErr.Detail,
+				Code:      appErr.Code,
+				Fields:    appErr.Fields,
+				RequestID: requestID,
+			},
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"gymondo_dz/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger stores a request-scoped logger carrying the method and route in
+// the request context (see logging.FromContext) and writes one access log
+// entry per request once the handler chain has finished: 5xx responses
+// are logged at error level, 4xx at warn and the rest at info.
+func Logger(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		l := base.With(slog.String("method", c.Request.Method), slog.String("route", route))
+		c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), l))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.Last().Error()))
+		}
+		// handlers may have replaced the request context with one carrying
+		// more IDs, so log with the final one
+		l.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery turns a panic into a 500 response rendered like any other
+// error and logs it with its stack trace, replacing gin's text output.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		ctx := c.Request.Context()
+		logging.FromContext(ctx).ErrorContext(ctx, "panic recovered",
+			slog.Any("panic", recovered),
+			slog.String("stack", string(debug.Stack())),
+		)
+		err := fmt.Errorf("panic: %v", recovered)
+		_ = c.Error(err)
+		RenderError(c, err)
+	})
+}
@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/logging"
+	"gymondo_dz/pkg/middleware"
+	"gymondo_dz/pkg/reqctx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	router := gin.New()
+	router.Use(
+		middleware.RequestID(),
+		middleware.Logger(logging.New(config.LogConfig{Level: "info"}, &buf)),
+		middleware.Recovery(),
+		middleware.ErrorHandler(),
+	)
+	router.GET("/subscriptions/:id", func(c *gin.Context) {
+		ctx := reqctx.WithSubscriptionID(c.Request.Context(), c.Param("id"))
+		c.Request = c.Request.WithContext(ctx)
+		logging.FromContext(ctx).InfoContext(ctx, "looking up")
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	req := httptest.NewRequest(http.MethodGet, "/subscriptions/abc", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"internal_error"`)
+
+	var got []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &e), line)
+		got = append(got, e)
+	}
+	require.Len(t, got, 4)
+
+	assert.Equal(t, "looking up", got[0]["msg"])
+	assert.Equal(t, "/subscriptions/:id", got[0]["route"])
+	assert.Equal(t, "req-1", got[0]["request_id"])
+	assert.Equal(t, "abc", got[0]["subscription_id"])
+
+	assert.Equal(t, "request", got[1]["msg"])
+	assert.Equal(t, "INFO", got[1]["level"])
+	assert.Equal(t, "GET", got[1]["method"])
+	assert.Equal(t, "/subscriptions/abc", got[1]["path"])
+	assert.EqualValues(t, http.StatusNoContent, got[1]["status"])
+	assert.Equal(t, "req-1", got[1]["request_id"])
+	assert.Equal(t, "abc", got[1]["subscription_id"])
+
+	assert.Equal(t, "panic recovered", got[2]["msg"])
+	assert.Equal(t, "boom", got[2]["panic"])
+	assert.Contains(t, got[2]["stack"], "runtime/debug.Stack")
+
+	assert.Equal(t, "request", got[3]["msg"])
+	assert.Equal(t, "ERROR", got[3]["level"])
+	assert.EqualValues(t, http.StatusInternalServerError, got[3]["status"])
+	assert.Equal(t, "panic: boom", got[3]["error"])
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"gymondo_dz/pkg/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrPayloadTooLarge = apperrors.New(apperrors.CodePayloadTooLarge, http.StatusRequestEntityTooLarge, "request body is too large")
+
+// MaxBodySize rejects requests that announce a body larger than limit
+// bytes with 413 and cuts off the body of the rest after limit bytes, so
+// whatever reads it, the handler or a rate limiter before it, never
+// buffers more. Reading past the limit fails with *http.MaxBytesError.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			_ = c.Error(ErrPayloadTooLarge)
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"gymondo_dz/pkg/cache"
+	"gymondo_dz/pkg/logging"
+	"gymondo_dz/pkg/reqctx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadYourWrites sends the database reads of a client that changed
+// something within the last window to the primary (see
+// reqctx.WithPrimaryReads), so replication lag never hides a client's own
+// writes from it; the reads of a changing request go there as well.
+// Clients are told apart by key, and recent writers are remembered in
+// store, which instances may share.
+func ReadYourWrites(store cache.Store, window time.Duration, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if window <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		client := "wrote:" + key(c)
+		primary := !isSafeMethod(c.Request.Method)
+		if !primary {
+			_, wrote, err := store.Get(ctx, client)
+			if err != nil {
+				logging.FromContext(ctx).WarnContext(ctx, "Read-your-writes store unavailable, reading from replicas", "error", err)
+			}
+			primary = wrote
+		}
+		if primary {
+			c.Request = c.Request.WithContext(reqctx.WithPrimaryReads(ctx))
+		}
+
+		c.Next()
+
+		if !isSafeMethod(c.Request.Method) && len(c.Errors) == 0 && c.Writer.Status() < http.StatusBadRequest {
+			if err := store.Set(ctx, client, nil, window); err != nil {
+				logging.FromContext(ctx).WarnContext(ctx, "Failed to remember write for read-your-writes", "error", err)
+			}
+		}
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,54 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/cache"
+	"gymondo_dz/pkg/middleware"
+	"gymondo_dz/pkg/reqctx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := cache.NewLRU(10)
+	now := time.Now()
+	store.Now = func() time.Time { return now }
+
+	router := gin.New()
+	router.Use(middleware.ReadYourWrites(store, 5*time.Second, func(c *gin.Context) string {
+		return c.GetHeader("X-Client")
+	}))
+	primary := func(c *gin.Context) {
+		c.Header("X-Primary", strconv.FormatBool(reqctx.PrimaryReads(c.Request.Context())))
+	}
+	router.GET("/subscriptions", primary)
+	router.POST("/subscriptions", primary)
+	router.POST("/fail", func(c *gin.Context) { c.Status(http.StatusConflict) })
+
+	send := func(method, path, client string) string {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Client", client)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Header().Get("X-Primary")
+	}
+
+	assert.Equal(t, "false", send(http.MethodGet, "/subscriptions", "a"), "reads go to replicas")
+	send(http.MethodPost, "/fail", "a")
+	assert.Equal(t, "false", send(http.MethodGet, "/subscriptions", "a"), "a failed write changes nothing")
+
+	assert.Equal(t, "true", send(http.MethodPost, "/subscriptions", "a"), "writes read the primary")
+	assert.Equal(t, "true", send(http.MethodGet, "/subscriptions", "a"), "and so does the writer afterwards")
+	assert.Equal(t, "false", send(http.MethodGet, "/subscriptions", "b"), "but no other client")
+
+	now = now.Add(6 * time.Second)
+	assert.Equal(t, "false", send(http.MethodGet, "/subscriptions", "a"), "until the window has passed")
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"gymondo_dz/pkg/reqctx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+)
+
+// RequestID assigns every request an ID, reusing the caller's X-Request-ID
+// when it is a valid one (see reqctx.ValidRequestID), and echoes it back
+// in the response headers. The ID is also stored in the request context
+// (see reqctx.RequestID).
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !reqctx.ValidRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set(requestIDKey, id)
+		c.Request = c.Request.WithContext(reqctx.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID assigned by RequestID, or "" if the
+// middleware is not installed.
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
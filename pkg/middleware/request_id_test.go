@@ -0,0 +1,56 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gymondo_dz/pkg/middleware"
+	"gymondo_dz/pkg/reqctx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, reqctx.RequestID(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		reused bool
+	}{
+		{name: "UUID", header: "0b6f1c1e-8a4f-4c52-9a0e-4f2d3c7b9e11", reused: true},
+		{name: "Trace-style ID", header: "lb.frontend_7-abc.123", reused: true},
+		{name: "Longest allowed", header: strings.Repeat("a", 128), reused: true},
+		{name: "Missing"},
+		{name: "Too long", header: strings.Repeat("a", 129)},
+		{name: "Log line injection", header: "abc\n{\"level\":\"ERROR\"}"},
+		{name: "Spaces", header: "abc def"},
+		{name: "Non-ASCII", header: "ä"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(middleware.RequestIDHeader, tt.header)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(middleware.RequestIDHeader)
+			assert.Equal(t, id, w.Body.String(), "the echoed ID is the one in the context")
+			if tt.reused {
+				assert.Equal(t, tt.header, id)
+				return
+			}
+			assert.NotEqual(t, tt.header, id)
+			assert.NoError(t, uuid.Validate(id), "a fresh ID is assigned")
+		})
+	}
+}
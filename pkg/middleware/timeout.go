@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// untimedContextKey holds the request context as it was before Timeout
+// put its deadline on it.
+const untimedContextKey = "untimed_context"
+
+// Timeout bounds the work done on behalf of a request: the request
+// context is cancelled after d, aborting any database query still running
+// on it. Handlers surface the resulting context error, which ErrorHandler
+// renders as 504. A zero d disables the deadline.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+
+		c.Set(untimedContextKey, c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// WithoutTimeout returns the request context without the deadline
+// Timeout put on it, for responses that are long-lived by design such as
+// event streams. It is still cancelled when the client goes away.
+func WithoutTimeout(c *gin.Context) context.Context {
+	if v, ok := c.Get(untimedContextKey); ok {
+		return v.(context.Context)
+	}
+	return c.Request.Context()
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent is an event waiting in, or already relayed from, the
+// outbox. Position orders all events; Sequence orders those of one
+// aggregate, the subscription the event is about.
+type OutboxEvent struct {
+	Position      int64     `gorm:"primaryKey;autoIncrement"`
+	ID            uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	AggregateID   uuid.UUID `gorm:"type:uuid;not null"`
+	Sequence      int       `gorm:"not null"`
+	Type          string    `gorm:"size:64;not null"`
+	Payload       string    `gorm:"not null"`
+	CreatedAt     time.Time `gorm:"not null"`
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"not null"`
+	LastError     *string
+	PublishedAt   *time.Time `gorm:"index"`
+}
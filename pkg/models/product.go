@@ -15,6 +15,14 @@ const (
 	DurationLifetime SubscriptionDuration = 365 * 100
 )
 
+func (d SubscriptionDuration) IsValid() bool {
+	switch d {
+	case DurationMonth, DurationYear, DurationLifetime:
+		return true
+	}
+	return false
+}
+
 type Product struct {
 	ID          uuid.UUID            `gorm:"type:uuid;primaryKey" json:"id"`
 	Name        string               `gorm:"size:100;not null" json:"name"`
@@ -22,14 +30,18 @@ type Product struct {
 	Price       float64              `gorm:"type:decimal(10,2);not null" json:"price"`
 	TaxRate     float64              `gorm:"type:decimal(5,2);default:0.10" json:"tax_rate"`
 	TotalPrice  float64              `gorm:"-" json:"total_price"` // ignored by GORM, only for JSON response
+	Currency    string               `gorm:"size:3;not null;default:'EUR'" json:"currency"`
 	Duration    SubscriptionDuration `gorm:"not null" json:"duration"`
 	CreatedAt   time.Time            `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time            `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt   gorm.DeletedAt       `gorm:"index" json:"-"` // Explicitly ignored in JSON
+	Locale      string               `gorm:"-" json:"-"`     // locale Name/Description are in, set when localized
 }
 
 func (p *Product) BeforeCreate(tx *gorm.DB) (err error) {
-	p.ID = uuid.New()
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
 	return
 }
 
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductTranslation holds a product's name and description in one locale.
+type ProductTranslation struct {
+	ProductID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"product_id"`
+	Locale      string    `gorm:"size:35;primaryKey" json:"locale"`
+	Name        string    `gorm:"size:100;not null" json:"name"`
+	Description string    `gorm:"size:255" json:"description,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
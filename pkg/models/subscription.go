@@ -16,6 +16,14 @@ const (
 	StatusExpired   SubscriptionStatus = "expired"
 )
 
+func (s SubscriptionStatus) IsValid() bool {
+	switch s {
+	case StatusActive, StatusPaused, StatusCancelled, StatusExpired:
+		return true
+	}
+	return false
+}
+
 type Subscription struct {
 	ID          uuid.UUID          `gorm:"type:uuid;primaryKey" json:"id"`
 	UserID      uuid.UUID          `gorm:"type:uuid;not null" json:"user_id"`
@@ -33,6 +41,8 @@ type Subscription struct {
 }
 
 func (s *Subscription) BeforeCreate(tx *gorm.DB) (err error) {
-	s.ID = uuid.New()
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
 	return
 }
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	DeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+func (s WebhookDeliveryStatus) IsValid() bool {
+	switch s {
+	case DeliveryPending, DeliverySucceeded, DeliveryFailed:
+		return true
+	}
+	return false
+}
+
+// EventTypes lists the event types an endpoint subscribes to, stored as a
+// JSON array. An empty list subscribes to every type.
+type EventTypes []string
+
+// Includes reports whether eventType is subscribed to.
+func (t EventTypes) Includes(eventType string) bool {
+	return len(t) == 0 || slices.Contains(t, eventType)
+}
+
+func (t EventTypes) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]string(t))
+	return string(b), err
+}
+
+func (t *EventTypes) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	case []byte:
+		return json.Unmarshal(v, t)
+	case nil:
+		*t = EventTypes{}
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into EventTypes", src)
+}
+
+// ProductIDs lists the products an endpoint receives the subscription
+// events of, stored as a JSON array: the partner's own products. Unlike
+// EventTypes, an empty list matches nothing.
+type ProductIDs []uuid.UUID
+
+// Includes reports whether events about productID are delivered.
+func (p ProductIDs) Includes(productID uuid.UUID) bool {
+	return slices.Contains(p, productID)
+}
+
+func (p ProductIDs) Value() (driver.Value, error) {
+	if p == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]uuid.UUID(p))
+	return string(b), err
+}
+
+func (p *ProductIDs) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	case []byte:
+		return json.Unmarshal(v, p)
+	case nil:
+		*p = ProductIDs{}
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into ProductIDs", src)
+}
+
+// WebhookEndpoint is a partner URL the events about subscriptions to
+// ProductIDs are delivered to, signed with Secret. FailingSince is when
+// the current run of failed deliveries began; an endpoint failing for too
+// long is disabled.
+type WebhookEndpoint struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	URL            string     `gorm:"size:2048;not null" json:"url"`
+	Description    string     `gorm:"size:255" json:"description,omitempty"`
+	Secret         string     `gorm:"size:64;not null" json:"-"` // only ever shown when created
+	EventTypes     EventTypes `gorm:"type:text;not null" json:"event_types"`
+	ProductIDs     ProductIDs `gorm:"type:text;not null" json:"product_ids"`
+	Enabled        bool       `gorm:"not null" json:"enabled"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason string     `gorm:"size:255" json:"disabled_reason,omitempty"`
+	FailingSince   *time.Time `json:"failing_since,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (e *WebhookEndpoint) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// WebhookDelivery is one event on its way to one endpoint. Retries and
+// manual redeliveries reuse it; AttemptLog records every attempt.
+type WebhookDelivery struct {
+	ID               uuid.UUID             `gorm:"type:uuid;primaryKey" json:"id"`
+	EndpointID       uuid.UUID             `gorm:"type:uuid;not null" json:"endpoint_id"`
+	EventID          uuid.UUID             `gorm:"type:uuid;not null" json:"event_id"`
+	EventType        string                `gorm:"size:64;not null" json:"event_type"`
+	Payload          string                `gorm:"not null" json:"-"`
+	Status           WebhookDeliveryStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Attempts         int                   `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt    time.Time             `gorm:"not null" json:"next_attempt_at"`
+	LastAttemptAt    *time.Time            `json:"last_attempt_at,omitempty"`
+	LastResponseCode *int                  `json:"last_response_code,omitempty"`
+	LastError        *string               `json:"last_error,omitempty"`
+	DeliveredAt      *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt        time.Time             `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time             `gorm:"autoUpdateTime" json:"updated_at"`
+	AttemptLog       []WebhookAttempt      `gorm:"foreignKey:DeliveryID" json:"attempt_log,omitempty"`
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return
+}
+
+// WebhookAttempt is one entry of the delivery log. ResponseCode is unset
+// if the endpoint could not be reached. What the endpoint answered is not
+// kept: it could be anything the endpoint's network reaches.
+type WebhookAttempt struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement" json:"-"`
+	DeliveryID   uuid.UUID `gorm:"type:uuid;not null" json:"-"`
+	AttemptedAt  time.Time `gorm:"not null" json:"attempted_at"`
+	ResponseCode *int      `json:"response_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMS   int64     `gorm:"column:duration_ms;not null" json:"duration_ms"`
+}
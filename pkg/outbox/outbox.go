@@ -0,0 +1,37 @@
+// Package outbox implements the transactional outbox for subscription
+// events: Append stores an event in the transaction of the change it
+// describes, so an event exists if and only if the change committed, and
+// the Relay publishes stored events afterwards, at least once and in
+// order per subscription.
+package outbox
+
+import (
+	"fmt"
+
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// Append stores e through tx, which should be the transaction that made
+// the change e describes.
+func Append(tx *gorm.DB, e events.Event) error {
+	msg, err := e.Encode()
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", e.Type, err)
+	}
+	row := models.OutboxEvent{
+		ID:            e.ID,
+		AggregateID:   e.Subject,
+		Sequence:      e.Sequence,
+		Type:          e.Type,
+		Payload:       string(msg.Payload),
+		CreatedAt:     e.OccurredAt,
+		NextAttemptAt: e.OccurredAt,
+	}
+	if err := tx.Create(&row).Error; err != nil {
+		return fmt.Errorf("failed to store %s event: %w", e.Type, err)
+	}
+	return nil
+}
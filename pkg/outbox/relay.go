@@ -0,0 +1,205 @@
+package outbox
+
+import (
+	"context"
+	"hash/crc32"
+	"math/rand/v2"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/logging"
+	"gymondo_dz/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// relayLockID keys the Postgres advisory lock that lets one instance
+// claim events at a time.
+var relayLockID = int64(crc32.ChecksumIEEE([]byte("gymondo_dz:outbox_relay")))
+
+// Relay publishes the events waiting in the outbox.
+//
+// An event that fails to publish is retried with exponential backoff, and
+// the later events of its subscription wait for it, so consumers see a
+// subscription's events in sequence order. Other subscriptions are not
+// held up. Each poll claims a batch with a lease in a short transaction,
+// taken on Postgres under an advisory lock so instances take turns and
+// running a relay in every instance is safe. Events are published outside
+// that transaction and their outcomes recorded one by one, so a shutdown
+// loses at most the outcome of the event being published, which is then
+// published again once its lease runs out.
+type Relay struct {
+	db        *gorm.DB
+	publisher events.Publisher
+	cfg       config.EventsConfig
+}
+
+func NewRelay(db *gorm.DB, publisher events.Publisher, cfg config.EventsConfig) *Relay {
+	return &Relay{db: db, publisher: publisher, cfg: cfg}
+}
+
+// Run relays every PollInterval until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		// keep going while there is a backlog
+		for {
+			published, err := r.RelayPending(ctx)
+			if err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "Failed to relay outbox events", "error", err)
+			}
+			if err != nil || published < r.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending publishes up to BatchSize due events and removes published
+// events older than Retention. It returns how many events it published.
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	pending, err := r.claim(ctx, time.Now())
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	published := 0
+	failed := map[uuid.UUID]bool{}
+	var held []uuid.UUID
+	for i := range pending {
+		if ctx.Err() != nil {
+			// shutting down; the leases of the rest run out
+			return published, ctx.Err()
+		}
+		e := &pending[i]
+		if failed[e.AggregateID] {
+			held = append(held, e.ID)
+			continue
+		}
+		publishCtx, cancel := context.WithTimeout(ctx, r.cfg.PublishTimeout)
+		publishErr := r.publisher.Publish(publishCtx, events.Message{ID: e.ID, Type: e.Type, Key: e.AggregateID.String(), Payload: []byte(e.Payload)})
+		cancel()
+		if publishErr != nil && ctx.Err() != nil {
+			// cut short by shutdown; the lease runs out and it is published again
+			return published, ctx.Err()
+		}
+		if err := r.record(ctx, e, publishErr); err != nil {
+			return published, err
+		}
+		if publishErr != nil {
+			failed[e.AggregateID] = true
+			continue
+		}
+		published++
+	}
+	return published, r.release(ctx, held)
+}
+
+// claim removes expired events and picks the due events, leasing them
+// long enough to be published so other relays skip their subscriptions
+// meanwhile. Subscriptions whose oldest pending event is waiting for a
+// retry or leased are skipped, or their later events would overtake it.
+func (r *Relay) claim(ctx context.Context, now time.Time) ([]models.OutboxEvent, error) {
+	var pending []models.OutboxEvent
+	err := r.locked(ctx, func(tx *gorm.DB) error {
+		if err := tx.Where("published_at < ?", now.Add(-r.cfg.Retention)).Delete(&models.OutboxEvent{}).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("published_at IS NULL").
+			Where(`NOT EXISTS (SELECT 1 FROM outbox_events waiting WHERE waiting.aggregate_id = outbox_events.aggregate_id
+				AND waiting.published_at IS NULL AND waiting.next_attempt_at > ?)`, now).
+			Order("position").
+			Limit(r.cfg.BatchSize).
+			Find(&pending).Error
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, 0, len(pending))
+		for _, e := range pending {
+			ids = append(ids, e.ID)
+		}
+		lease := now.Add(time.Duration(len(pending)+1) * r.cfg.PublishTimeout)
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", lease).Error
+	})
+	return pending, err
+}
+
+// record stores the outcome of an attempt to publish e, which also ends
+// its lease. It does so even if ctx is done, so a published event is not
+// published again needlessly.
+func (r *Relay) record(ctx context.Context, e *models.OutboxEvent, publishErr error) error {
+	db := r.db.WithContext(context.WithoutCancel(ctx))
+	now := time.Now()
+	attempts := e.Attempts + 1
+	if publishErr == nil {
+		return db.Model(e).Updates(map[string]interface{}{
+			"attempts":     attempts,
+			"published_at": now,
+			"last_error":   nil,
+		}).Error
+	}
+
+	retryIn := r.backoff(attempts)
+	logging.FromContext(ctx).WarnContext(ctx, "Failed to publish event",
+		"event_id", e.ID, "type", e.Type, "subscription_id", e.AggregateID,
+		"attempts", attempts, "retry_in", retryIn, "error", publishErr)
+	return db.Model(e).Updates(map[string]interface{}{
+		"attempts":        attempts,
+		"next_attempt_at": now.Add(retryIn),
+		"last_error":      publishErr.Error(),
+	}).Error
+}
+
+// release ends the lease of claimed events that were not attempted
+// because an earlier event of their subscription failed, so they follow
+// it as soon as its retry is due rather than when their lease runs out.
+func (r *Relay) release(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(context.WithoutCancel(ctx)).Model(&models.OutboxEvent{}).
+		Where("id IN ?", ids).Update("next_attempt_at", time.Now()).Error
+}
+
+// backoff doubles the base delay per failed attempt up to the maximum and
+// picks a random delay in its upper half.
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.cfg.RetryMaxDelay
+	if shift := attempts - 1; shift < 32 && r.cfg.RetryBaseDelay<<shift < d {
+		d = r.cfg.RetryBaseDelay << shift
+	}
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2)
+}
+
+// locked runs fn in a transaction, holding the relay lock on Postgres,
+// and skips it if another instance holds the lock. Other databases are
+// used by a single instance.
+func (r *Relay) locked(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() != "postgres" {
+			return fn(tx)
+		}
+		var acquired bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", relayLockID).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+		return fn(tx)
+	})
+}
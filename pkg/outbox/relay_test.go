@@ -0,0 +1,239 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/database"
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/outbox"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+var relayConfig = config.EventsConfig{
+	PollInterval:   10 * time.Millisecond,
+	BatchSize:      100,
+	PublishTimeout: time.Second,
+	RetryBaseDelay: time.Minute,
+	RetryMaxDelay:  time.Hour,
+	Retention:      24 * time.Hour,
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	db, err := database.NewSQLiteConnection(config.SQLiteConfig{
+		Path:        filepath.Join(t.TempDir(), "test.db"),
+		BusyTimeout: 5 * time.Second,
+		JournalMode: "wal",
+	}, gormlogger.Discard)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, database.Migrate(context.Background(), db))
+	return db
+}
+
+// fakePublisher records what it publishes and fails for the subjects in
+// failing.
+type fakePublisher struct {
+	mu        sync.Mutex
+	published []events.Message
+	failing   map[string]bool
+}
+
+func (p *fakePublisher) Publish(_ context.Context, msg events.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failing[msg.Key] {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, msg)
+	return nil
+}
+
+func (p *fakePublisher) Close() error { return nil }
+
+// sequences lists the sequence numbers published per subject, in
+// publishing order.
+func (p *fakePublisher) sequences(t *testing.T) map[uuid.UUID][]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	seen := map[uuid.UUID][]int{}
+	for _, msg := range p.published {
+		var e events.Event
+		require.NoError(t, json.Unmarshal(msg.Payload, &e))
+		assert.Equal(t, e.Subject.String(), msg.Key)
+		seen[e.Subject] = append(seen[e.Subject], e.Sequence)
+	}
+	return seen
+}
+
+// appendEvents stores n events for subscription s, versions 1 to n.
+func appendEvents(t *testing.T, db *gorm.DB, s *models.Subscription, n int) {
+	for version := 1; version <= n; version++ {
+		s.Version = version
+		require.NoError(t, outbox.Append(db, events.NewSubscriptionEvent(events.TypeSubscriptionPaused, s, time.Now())))
+	}
+}
+
+func TestRelayPublishesInOrder(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	a := &models.Subscription{ID: uuid.New(), ProductID: uuid.New()}
+	b := &models.Subscription{ID: uuid.New(), ProductID: uuid.New()}
+	appendEvents(t, db, a, 3)
+	appendEvents(t, db, b, 2)
+
+	publisher := &fakePublisher{}
+	n, err := outbox.NewRelay(db, publisher, relayConfig).RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, map[uuid.UUID][]int{a.ID: {1, 2, 3}, b.ID: {1, 2}}, publisher.sequences(t))
+
+	var pending int64
+	require.NoError(t, db.Model(&models.OutboxEvent{}).Where("published_at IS NULL").Count(&pending).Error)
+	assert.Zero(t, pending)
+
+	n, err = outbox.NewRelay(db, publisher, relayConfig).RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n, "published events are not published again")
+}
+
+func TestRelayHoldsBackSubscriptionUntilRetry(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	a := &models.Subscription{ID: uuid.New(), ProductID: uuid.New()}
+	b := &models.Subscription{ID: uuid.New(), ProductID: uuid.New()}
+	appendEvents(t, db, a, 2)
+	appendEvents(t, db, b, 2)
+
+	publisher := &fakePublisher{failing: map[string]bool{a.ID.String(): true}}
+	relay := outbox.NewRelay(db, publisher, relayConfig)
+	n, err := relay.RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "the other subscription is not held up")
+	assert.Equal(t, map[uuid.UUID][]int{b.ID: {1, 2}}, publisher.sequences(t))
+
+	var failed models.OutboxEvent
+	require.NoError(t, db.Where("aggregate_id = ? AND sequence = 1", a.ID).First(&failed).Error)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, "broker unavailable", *failed.LastError)
+	assert.WithinRange(t, failed.NextAttemptAt, time.Now().Add(29*time.Second), time.Now().Add(time.Minute))
+
+	// the broker is back, but the retry is not due yet
+	publisher.failing = nil
+	n, err = relay.RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n, "later events wait for the failed one")
+
+	require.NoError(t, db.Model(&models.OutboxEvent{}).Where("aggregate_id = ?", a.ID).
+		Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+	n, err = relay.RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	var stored []models.OutboxEvent
+	require.NoError(t, db.Where("aggregate_id = ?", a.ID).Order("position").Find(&stored).Error)
+	for _, e := range stored {
+		assert.NotNil(t, e.PublishedAt)
+		assert.Nil(t, e.LastError)
+	}
+	assert.Equal(t, []int{1, 2}, publisher.sequences(t)[a.ID])
+}
+
+// cancellingPublisher publishes through next and then cancels the relay,
+// as a shutdown would in the middle of a batch.
+type cancellingPublisher struct {
+	next   events.Publisher
+	cancel context.CancelFunc
+}
+
+func (p *cancellingPublisher) Publish(ctx context.Context, msg events.Message) error {
+	if err := p.next.Publish(ctx, msg); err != nil {
+		return err
+	}
+	p.cancel()
+	return nil
+}
+
+func (p *cancellingPublisher) Close() error { return nil }
+
+func TestRelayRecordsPublishedEventsOnShutdown(t *testing.T) {
+	db := openDB(t)
+	s := &models.Subscription{ID: uuid.New(), ProductID: uuid.New()}
+	appendEvents(t, db, s, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	publisher := &fakePublisher{}
+	n, err := outbox.NewRelay(db, &cancellingPublisher{next: publisher, cancel: cancel}, relayConfig).RelayPending(ctx)
+	assert.Equal(t, 1, n)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	var stored []models.OutboxEvent
+	require.NoError(t, db.Where("aggregate_id = ?", s.ID).Order("position").Find(&stored).Error)
+	require.Len(t, stored, 2)
+	assert.NotNil(t, stored[0].PublishedAt, "the published event is recorded despite the shutdown")
+	assert.Nil(t, stored[1].PublishedAt)
+	assert.True(t, stored[1].NextAttemptAt.After(time.Now()), "the unpublished event stays leased")
+
+	// another relay leaves the subscription alone until the lease runs out
+	other := &fakePublisher{}
+	n, err = outbox.NewRelay(db, other, relayConfig).RelayPending(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	require.NoError(t, db.Model(&models.OutboxEvent{}).Where("aggregate_id = ?", s.ID).
+		Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+	n, err = outbox.NewRelay(db, other, relayConfig).RelayPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int{2}, other.sequences(t)[s.ID])
+}
+
+func TestRelayDeletesExpiredEvents(t *testing.T) {
+	db := openDB(t)
+	s := &models.Subscription{ID: uuid.New(), ProductID: uuid.New()}
+	appendEvents(t, db, s, 2)
+	relay := outbox.NewRelay(db, &fakePublisher{}, relayConfig)
+	_, err := relay.RelayPending(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, db.Model(&models.OutboxEvent{}).Where("sequence = 1").
+		Update("published_at", time.Now().Add(-25*time.Hour)).Error)
+	_, err = relay.RelayPending(context.Background())
+	require.NoError(t, err)
+
+	var sequences []int
+	require.NoError(t, db.Model(&models.OutboxEvent{}).Pluck("sequence", &sequences).Error)
+	assert.Equal(t, []int{2}, sequences)
+}
+
+func TestRelayRun(t *testing.T) {
+	db := openDB(t)
+	s := &models.Subscription{ID: uuid.New(), ProductID: uuid.New()}
+	publisher := &fakePublisher{}
+	cfg := relayConfig
+	cfg.BatchSize = 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		outbox.NewRelay(db, publisher, cfg).Run(ctx)
+		close(done)
+	}()
+
+	appendEvents(t, db, s, 5)
+	assert.Eventually(t, func() bool { return len(publisher.sequences(t)[s.ID]) == 5 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Run drops buckets that have refilled.
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory, so every instance enforces
+// its own limits.
+type MemoryStore struct {
+	// Now defaults to time.Now; tests replace it to control refills.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // when the bucket will have refilled completely
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{Now: time.Now, buckets: map[string]*bucket{}}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, p Policy) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(p.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = min(float64(p.Burst), b.tokens+now.Sub(b.last).Seconds()*p.Rate)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	res := newResult(p, allowed, b.tokens)
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
+
+// Sweep drops every bucket that has refilled by now; a fresh bucket is
+// indistinguishable from it, so no client gains or loses quota.
+func (s *MemoryStore) Sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Now()
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Len returns the number of buckets held.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+// Run sweeps periodically until ctx is cancelled, keeping memory bounded
+// by the number of recently active clients.
+func (s *MemoryStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Sweep()
+		}
+	}
+}
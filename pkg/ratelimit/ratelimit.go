@@ -0,0 +1,207 @@
+// Package ratelimit enforces per-client token-bucket limits on routes.
+// Buckets live in a Store: MemoryStore for a single instance, RedisStore
+// when several instances must share limits.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/logging"
+	"gymondo_dz/pkg/reqctx"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+var ErrRateLimited = apperrors.New(apperrors.CodeRateLimited, http.StatusTooManyRequests, "too many requests")
+
+// Policy is a token bucket holding up to Burst tokens and refilling Rate
+// tokens per second; every request takes one. Name keeps the buckets of
+// different policies apart.
+type Policy struct {
+	Name  string
+	Rate  float64
+	Burst int
+}
+
+// window is the time an empty bucket takes to refill completely.
+func (p Policy) window() time.Duration {
+	return time.Duration(float64(p.Burst) / p.Rate * float64(time.Second))
+}
+
+// header describes p as the RateLimit-Policy header does.
+func (p Policy) header() string {
+	return fmt.Sprintf("%d;w=%d", p.Burst, seconds(p.window()))
+}
+
+// Result describes the bucket after a request took, or failed to take, a
+// token from it.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, if not allowed
+}
+
+// newResult derives the Result from the tokens left in the bucket.
+func newResult(p Policy, allowed bool, tokens float64) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     p.Burst,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(p.Burst) - tokens) / p.Rate * float64(time.Second)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / p.Rate * float64(time.Second))
+	}
+	return res
+}
+
+// Store keeps the token buckets. Take removes a token from the bucket
+// under key, creating a full one if it does not exist yet.
+type Store interface {
+	Take(ctx context.Context, key string, p Policy) (Result, error)
+}
+
+// KeyFunc identifies the client a request is counted against.
+type KeyFunc func(c *gin.Context) string
+
+// ByIP counts requests against the client IP, as resolved through the
+// router's trusted proxies.
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// Identify counts requests against the API key sent in header, else the
+// authenticated user (reqctx.UserID), else the client IP. Keys are hashed
+// so they are never stored in clear.
+func Identify(header string) KeyFunc {
+	return func(c *gin.Context) string {
+		if header != "" {
+			if key := c.GetHeader(header); key != "" {
+				return apiKey(key)
+			}
+		}
+		if id := reqctx.UserID(c.Request.Context()); id != "" {
+			return "user:" + id
+		}
+		return ByIP(c)
+	}
+}
+
+// CallKeyFunc identifies the client a gRPC call is counted against.
+type CallKeyFunc func(ctx context.Context) string
+
+// IdentifyCall is Identify for gRPC calls: the API key is read from the
+// metadata under header, and the IP is the peer's.
+func IdentifyCall(header string) CallKeyFunc {
+	return func(ctx context.Context) string {
+		if header != "" {
+			if values := metadata.ValueFromIncomingContext(ctx, header); len(values) > 0 && values[0] != "" {
+				return apiKey(values[0])
+			}
+		}
+		if id := reqctx.UserID(ctx); id != "" {
+			return "user:" + id
+		}
+		addr := ""
+		if p, ok := peer.FromContext(ctx); ok {
+			addr = p.Addr.String()
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				addr = host
+			}
+		}
+		return "ip:" + addr
+	}
+}
+
+func apiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "key:" + hex.EncodeToString(sum[:16])
+}
+
+type Limiter struct {
+	store Store
+	key   KeyFunc
+}
+
+func New(store Store, key KeyFunc) *Limiter {
+	return &Limiter{store: store, key: key}
+}
+
+// Limit rejects requests beyond p with 429 and advertises the client's
+// quota in RateLimit-* headers. Requests are let through if the store
+// fails, so an unavailable backend never takes the API down with it.
+func (l *Limiter) Limit(p Policy) gin.HandlerFunc {
+	policy := p.header()
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		res, err := l.store.Take(ctx, p.Name+":"+l.key(c), p)
+		if err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "Rate limiter unavailable, allowing request", "policy", p.Name, "error", err)
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+		h.Set("RateLimit-Policy", policy)
+
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(max(1, seconds(res.RetryAfter))))
+			_ = c.Error(ErrRateLimited)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// LimitCalls is Limit for gRPC calls, taking the policy for each call's
+// method from policyFor; methods without one are not limited. Calls
+// beyond it fail with ErrRateLimited, and the quota is advertised in
+// ratelimit-* header metadata.
+func (l *Limiter) LimitCalls(key CallKeyFunc, policyFor func(fullMethod string) (Policy, bool)) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		p, ok := policyFor(info.FullMethod)
+		if !ok {
+			return handler(ctx, req)
+		}
+		res, err := l.store.Take(ctx, p.Name+":"+key(ctx), p)
+		if err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "Rate limiter unavailable, allowing call", "policy", p.Name, "error", err)
+			return handler(ctx, req)
+		}
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(
+			"ratelimit-limit", strconv.Itoa(res.Limit),
+			"ratelimit-remaining", strconv.Itoa(res.Remaining),
+			"ratelimit-reset", strconv.Itoa(seconds(res.Reset)),
+			"ratelimit-policy", p.header(),
+		))
+		if !res.Allowed {
+			return nil, ErrRateLimited.WithRetryAfter(time.Duration(max(1, seconds(res.RetryAfter))) * time.Second)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// seconds rounds d up to whole seconds, as the headers require.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,231 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/middleware"
+	"gymondo_dz/pkg/ratelimit"
+	"gymondo_dz/pkg/reqctx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newStore() (*ratelimit.MemoryStore, *clock) {
+	clk := &clock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := ratelimit.NewMemoryStore()
+	store.Now = clk.Now
+	return store, clk
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	policy := ratelimit.Policy{Name: "test", Rate: 2, Burst: 2}
+	store, clk := newStore()
+
+	take := func() ratelimit.Result {
+		res, err := store.Take(ctx, "client", policy)
+		require.NoError(t, err)
+		return res
+	}
+
+	assert.Equal(t, ratelimit.Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 500 * time.Millisecond}, take())
+	assert.Equal(t, ratelimit.Result{Allowed: true, Limit: 2, Remaining: 0, Reset: time.Second}, take())
+	assert.Equal(t, ratelimit.Result{Allowed: false, Limit: 2, Remaining: 0, Reset: time.Second, RetryAfter: 500 * time.Millisecond}, take())
+
+	clk.Advance(250 * time.Millisecond)
+	res := take()
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 250*time.Millisecond, res.RetryAfter)
+
+	clk.Advance(250 * time.Millisecond)
+	assert.True(t, take().Allowed, "a token refills after 1/rate")
+
+	other, err := store.Take(ctx, "other", policy)
+	require.NoError(t, err)
+	assert.True(t, other.Allowed, "clients have separate buckets")
+
+	store.Sweep()
+	assert.Equal(t, 2, store.Len(), "buckets still refilling are kept")
+	clk.Advance(time.Second)
+	store.Sweep()
+	assert.Equal(t, 0, store.Len(), "full buckets are dropped")
+}
+
+func setupRouter(store ratelimit.Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	limiter := ratelimit.New(store, ratelimit.Identify("X-API-Key"))
+	read := limiter.Limit(ratelimit.Policy{Name: "read", Rate: 1, Burst: 2})
+	write := limiter.Limit(ratelimit.Policy{Name: "write", Rate: 0.1, Burst: 1})
+
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.ErrorHandler())
+	router.GET("/products", read, func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/subscriptions", write, func(c *gin.Context) { c.Status(http.StatusCreated) })
+	return router
+}
+
+func serve(router *gin.Engine, method, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestLimit(t *testing.T) {
+	store, clk := newStore()
+	router := setupRouter(store)
+
+	w := serve(router, http.MethodGet, "/products", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=2", w.Header().Get("RateLimit-Policy"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/products", nil).Code)
+
+	w = serve(router, http.MethodGet, "/products", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":{"message":"too many requests","code":"rate_limited","request_id":"`+w.Header().Get(middleware.RequestIDHeader)+`"}}`, w.Body.String())
+
+	// the write policy has its own, stricter bucket
+	w = serve(router, http.MethodPost, "/subscriptions", nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "1;w=10", w.Header().Get("RateLimit-Policy"))
+	w = serve(router, http.MethodPost, "/subscriptions", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+
+	// API clients are counted separately from the IP they connect from
+	apiKey := http.Header{"X-Api-Key": {"secret"}}
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/products", apiKey).Code)
+
+	clk.Advance(time.Second)
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/products", nil).Code)
+}
+
+func TestIdentify(t *testing.T) {
+	key := ratelimit.Identify("X-API-Key")
+	newContext := func(header http.Header, userID string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.RemoteAddr = "192.0.2.1:1234"
+		c.Request.Header = header
+		if userID != "" {
+			c.Request = c.Request.WithContext(reqctx.WithUserID(c.Request.Context(), userID))
+		}
+		return c
+	}
+
+	assert.Equal(t, "ip:192.0.2.1", key(newContext(http.Header{}, "")))
+	assert.Equal(t, "user:u1", key(newContext(http.Header{}, "u1")))
+
+	apiKey := key(newContext(http.Header{"X-Api-Key": {"secret"}}, "u1"))
+	assert.Regexp(t, `^key:[0-9a-f]{32}$`, apiKey)
+	assert.NotContains(t, apiKey, "secret")
+}
+
+func TestIdentifyCall(t *testing.T) {
+	key := ratelimit.IdentifyCall("X-API-Key")
+	newContext := func(md metadata.MD, userID string) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 1234}})
+		ctx = metadata.NewIncomingContext(ctx, md)
+		if userID != "" {
+			ctx = reqctx.WithUserID(ctx, userID)
+		}
+		return ctx
+	}
+
+	assert.Equal(t, "ip:192.0.2.1", key(newContext(metadata.MD{}, "")))
+	assert.Equal(t, "user:u1", key(newContext(metadata.MD{}, "u1")))
+
+	apiKey := key(newContext(metadata.Pairs("x-api-key", "secret"), "u1"))
+	assert.Regexp(t, `^key:[0-9a-f]{32}$`, apiKey)
+	assert.Equal(t, ratelimit.Identify("X-API-Key")(func() *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("X-API-Key", "secret")
+		return c
+	}()), apiKey, "a key counts against the same bucket over HTTP and gRPC")
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Policy) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func TestLimitFailsOpen(t *testing.T) {
+	router := setupRouter(failingStore{})
+	for range 3 {
+		w := serve(router, http.MethodPost, "/subscriptions", nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestLimitCallsFailsOpen(t *testing.T) {
+	limiter := ratelimit.New(failingStore{}, ratelimit.ByIP)
+	intercept := limiter.LimitCalls(ratelimit.IdentifyCall(""), func(string) (ratelimit.Policy, bool) {
+		return ratelimit.Policy{Name: "write", Rate: 0.1, Burst: 1}, true
+	})
+	for range 3 {
+		resp, err := intercept(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test/Call"},
+			func(context.Context, any) (any, error) { return "ok", nil })
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp)
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	policy := ratelimit.Policy{Name: "write", Rate: 0.5, Burst: 3}
+
+	var gotKeys []string
+	var gotArgs []any
+	reply := any([]any{int64(1), int64(1500)})
+	store := ratelimit.NewRedisStore(ratelimit.ScripterFunc(
+		func(_ context.Context, script string, keys []string, args ...any) (any, error) {
+			assert.Contains(t, script, "redis.call('TIME')")
+			gotKeys, gotArgs = keys, args
+			return reply, nil
+		}), "ratelimit:")
+
+	res, err := store.Take(context.Background(), "write:ip:192.0.2.1", policy)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ratelimit:write:ip:192.0.2.1"}, gotKeys)
+	assert.Equal(t, []any{"0.5", 3}, gotArgs)
+	assert.Equal(t, ratelimit.Result{Allowed: true, Limit: 3, Remaining: 1, Reset: 3 * time.Second}, res)
+
+	reply = []any{int64(0), int64(250)}
+	res, err = store.Take(context.Background(), "write:ip:192.0.2.1", policy)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 1500*time.Millisecond, res.RetryAfter)
+
+	reply = "OK"
+	_, err = store.Take(context.Background(), "write:ip:192.0.2.1", policy)
+	assert.ErrorContains(t, err, "unexpected reply")
+}
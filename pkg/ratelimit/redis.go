@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Scripter is the one Redis command RedisStore needs. Any Redis-compatible
+// client can provide it; with go-redis, for example:
+//
+//	ratelimit.ScripterFunc(func(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+//		return client.Eval(ctx, script, keys, args...).Result()
+//	})
+type Scripter interface {
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
+}
+
+type ScripterFunc func(ctx context.Context, script string, keys []string, args ...any) (any, error)
+
+func (f ScripterFunc) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	return f(ctx, script, keys, args...)
+}
+
+// takeScript refills and takes from the bucket atomically on the server,
+// using the server clock so instances with skewed clocks agree. Tokens are
+// returned in thousandths since Redis truncates numbers in replies to
+// integers. Buckets expire once they would have refilled.
+const takeScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, math.floor(tokens * 1000)}
+`
+
+// RedisStore shares buckets between instances through Redis.
+type RedisStore struct {
+	client Scripter
+	prefix string
+}
+
+// NewRedisStore keeps buckets under keys starting with prefix.
+func NewRedisStore(client Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, p Policy) (Result, error) {
+	reply, err := s.client.Eval(ctx, takeScript, []string{s.prefix + key},
+		strconv.FormatFloat(p.Rate, 'g', -1, 64), p.Burst)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to take token: %w", err)
+	}
+
+	values, ok := reply.([]any)
+	if !ok || len(values) != 2 {
+		return Result{}, fmt.Errorf("unexpected reply %v", reply)
+	}
+	allowed, ok1 := values[0].(int64)
+	milli, ok2 := values[1].(int64)
+	if !ok1 || !ok2 {
+		return Result{}, fmt.Errorf("unexpected reply %v", reply)
+	}
+	return newResult(p, allowed == 1, float64(milli)/1000), nil
+}
@@ -0,0 +1,61 @@
+package repositories_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/database"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// postgresDSNEnv names a Postgres database the repository suites may wipe;
+// without it they only run against SQLite, except in CI, where a missing DSN
+// fails the suite rather than quietly dropping Postgres coverage.
+const postgresDSNEnv = "TEST_POSTGRES_DSN"
+
+// forEachBackend runs test once per storage backend, each time against an
+// empty database at the current schema version. SQLite uses a fresh file
+// opened the way the service opens it, so locking and busy handling are
+// those of production.
+func forEachBackend(t *testing.T, test func(t *testing.T, db *gorm.DB)) {
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := database.NewSQLiteConnection(config.SQLiteConfig{
+			Path:        filepath.Join(t.TempDir(), "test.db"),
+			BusyTimeout: 5 * time.Second,
+			JournalMode: "wal",
+		}, gormlogger.Discard)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = database.Close(db) })
+		require.NoError(t, database.Migrate(context.Background(), db))
+
+		test(t, db)
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv(postgresDSNEnv)
+		if dsn == "" {
+			if os.Getenv("CI") != "" {
+				t.Fatal(postgresDSNEnv + " must be set in CI")
+			}
+			t.Skip(postgresDSNEnv + " is not set")
+		}
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = database.Close(db) })
+
+		m, err := database.NewMigrator(db)
+		require.NoError(t, err)
+		require.NoError(t, m.To(context.Background(), 0))
+		require.NoError(t, m.Up(context.Background()))
+
+		test(t, db)
+	})
+}
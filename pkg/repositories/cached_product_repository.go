@@ -0,0 +1,258 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"gymondo_dz/pkg/cache"
+	"gymondo_dz/pkg/logging"
+	"gymondo_dz/pkg/models"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// generationKey holds a random token that prefixes every other key, so
+// replacing it invalidates all cached products and product lists at once
+// (also in a shared remote store) without having to enumerate them.
+const generationKey = "products:generation"
+
+// CachedProductRepository serves products and product lists from a
+// cache.Store and loads misses from the wrapped repository. Concurrent
+// misses for the same key share one load. Values are stored encoded, so
+// callers always get their own copy and may localize it in place.
+//
+// Entries live for the TTL unless Invalidate is called, which happens
+// after every product write made through the repository. Writes that
+// bypass it show once the TTL expires: with an in-process store that
+// includes gymctl's, since gymctl cannot reach the service's memory.
+type CachedProductRepository struct {
+	next  ProductRepository
+	store cache.Store
+	ttl   time.Duration
+	group singleflight.Group
+}
+
+func NewCachedProductRepository(next ProductRepository, store cache.Store, ttl time.Duration) *CachedProductRepository {
+	return &CachedProductRepository{next: next, store: store, ttl: ttl}
+}
+
+type productPage struct {
+	Products   []models.Product
+	Pagination Pagination
+}
+
+func (r *CachedProductRepository) GetProducts(ctx context.Context, filter ProductFilter, page PageRequest) ([]models.Product, Pagination, error) {
+	raw, err := json.Marshal(struct {
+		Filter ProductFilter
+		Page   PageRequest
+	}{filter, page})
+	if err != nil {
+		return nil, Pagination{}, err
+	}
+	sum := sha256.Sum256(raw)
+
+	result, err := cached(ctx, r, "list:"+hex.EncodeToString(sum[:]), func(ctx context.Context) (productPage, error) {
+		products, pagination, err := r.next.GetProducts(ctx, filter, page)
+		return productPage{products, pagination}, err
+	})
+	if err == nil && result.Products == nil {
+		// gob does not tell empty and nil slices apart
+		result.Products = []models.Product{}
+	}
+	return result.Products, result.Pagination, err
+}
+
+func (r *CachedProductRepository) GetProduct(ctx context.Context, id string) (*models.Product, error) {
+	productID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidProductID
+	}
+
+	return cached(ctx, r, "id:"+productID.String(), func(ctx context.Context) (*models.Product, error) {
+		return r.next.GetProduct(ctx, id)
+	})
+}
+
+// GetProductsByIDs serves the products cached by GetProduct and loads the
+// rest with one call to the wrapped repository, caching them in turn.
+func (r *CachedProductRepository) GetProductsByIDs(ctx context.Context, ids []string) ([]models.Product, error) {
+	logger := logging.FromContext(ctx)
+
+	gen, err := r.generation(ctx)
+	if err != nil {
+		logger.WarnContext(ctx, "Product cache unavailable", "error", err)
+		return r.next.GetProductsByIDs(ctx, ids)
+	}
+	key := func(id uuid.UUID) string { return "products:" + gen + ":id:" + id.String() }
+
+	products := make([]models.Product, 0, len(ids))
+	var missing []string
+	for _, id := range ids {
+		productID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, ErrInvalidProductID
+		}
+
+		data, ok, err := r.store.Get(ctx, key(productID))
+		if err != nil {
+			logger.WarnContext(ctx, "Product cache unavailable", "error", err)
+		}
+		var product *models.Product
+		if ok && decode(data, &product) == nil {
+			products = append(products, *product)
+			continue
+		}
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return products, nil
+	}
+
+	loaded, err := r.next.GetProductsByIDs(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for i := range loaded {
+		data, err := encode(&loaded[i])
+		if err == nil {
+			err = r.store.Set(ctx, key(loaded[i].ID), data, r.ttl)
+		}
+		if err != nil {
+			logger.WarnContext(ctx, "Failed to cache products", "error", err)
+		}
+	}
+	return append(products, loaded...), nil
+}
+
+// CreateProduct and UpdateProduct write through to the wrapped repository
+// and invalidate the cache once the write has committed. Invalidating any
+// earlier would let a concurrent read cache the old row again.
+
+func (r *CachedProductRepository) CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
+	created, err := r.next.CreateProduct(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidateAfterWrite(ctx)
+	return created, nil
+}
+
+func (r *CachedProductRepository) UpdateProduct(ctx context.Context, id string, changes ProductChanges) (*models.Product, error) {
+	updated, err := r.next.UpdateProduct(ctx, id, changes)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidateAfterWrite(ctx)
+	return updated, nil
+}
+
+// Invalidate drops every cached product and product list.
+func (r *CachedProductRepository) Invalidate(ctx context.Context) error {
+	return r.store.Set(ctx, generationKey, []byte(uuid.NewString()), 0)
+}
+
+// invalidateAfterWrite invalidates the cache after a successful write.
+// The write stands either way, so a failure is only logged.
+func (r *CachedProductRepository) invalidateAfterWrite(ctx context.Context) {
+	if err := r.Invalidate(ctx); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "Failed to invalidate product cache", "error", err)
+	}
+}
+
+// generation returns the current cache generation, starting a new one if
+// the store has none (it was never set, or was evicted).
+func (r *CachedProductRepository) generation(ctx context.Context) (string, error) {
+	gen, ok, err := r.store.Get(ctx, generationKey)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return string(gen), nil
+	}
+
+	gen = []byte(uuid.NewString())
+	return string(gen), r.store.Set(ctx, generationKey, gen, 0)
+}
+
+// cached returns the value under key, loading and storing it on a miss.
+// The cache only ever speeds things up: store failures are logged and the
+// value is loaded from the wrapped repository instead.
+func cached[T any](ctx context.Context, r *CachedProductRepository, key string, load func(context.Context) (T, error)) (T, error) {
+	var zero T
+	logger := logging.FromContext(ctx)
+
+	gen, err := r.generation(ctx)
+	if err != nil {
+		logger.WarnContext(ctx, "Product cache unavailable", "error", err)
+		return load(ctx)
+	}
+	key = "products:" + gen + ":" + key
+
+	data, ok, err := r.store.Get(ctx, key)
+	if err != nil {
+		logger.WarnContext(ctx, "Product cache unavailable", "error", err)
+	}
+	if ok {
+		var value T
+		err := decode(data, &value)
+		if err == nil {
+			return value, nil
+		}
+		logger.WarnContext(ctx, "Discarding undecodable product cache entry", "key", key, "error", err)
+	}
+
+	ch := r.group.DoChan(key, func() (any, error) {
+		// the load outlives a caller that gives up, since others may be
+		// waiting on it, but keeps the first caller's deadline
+		loadCtx := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			loadCtx, cancel = context.WithDeadline(loadCtx, deadline)
+			defer cancel()
+		}
+
+		value, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := encode(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.store.Set(loadCtx, key, data, r.ttl); err != nil {
+			logger.WarnContext(loadCtx, "Failed to cache products", "error", err)
+		}
+		return data, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		var value T
+		if err := decode(res.Val.([]byte), &value); err != nil {
+			return zero, err
+		}
+		return value, nil
+	}
+}
+
+func encode(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decode(data []byte, value any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+}
@@ -0,0 +1,269 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"gymondo_dz/pkg/cache"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/testutils"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedProductRepository(t *testing.T) {
+	ctx := context.Background()
+	product := testutils.NewMockProduct()
+	id := product.ID.String()
+
+	t.Run("Products are loaded once and copied", func(t *testing.T) {
+		next := new(testutils.MockProductRepository)
+		next.On("GetProduct", mock.Anything, id).Return(product, nil).Once()
+		repo := repositories.NewCachedProductRepository(next, cache.NewLRU(10), time.Minute)
+
+		first, err := repo.GetProduct(ctx, id)
+		require.NoError(t, err)
+		first.Name = "Localized"
+
+		second, err := repo.GetProduct(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, product.Name, second.Name)
+		assert.Equal(t, product.ID, second.ID)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("Batches load only the products not cached", func(t *testing.T) {
+		other := testutils.NewMockProduct()
+		next := new(testutils.MockProductRepository)
+		next.On("GetProduct", mock.Anything, id).Return(product, nil).Once()
+		next.On("GetProductsByIDs", mock.Anything, []string{other.ID.String()}).Return([]models.Product{*other}, nil).Once()
+		repo := repositories.NewCachedProductRepository(next, cache.NewLRU(10), time.Minute)
+
+		_, err := repo.GetProduct(ctx, id)
+		require.NoError(t, err)
+		for range 2 {
+			products, err := repo.GetProductsByIDs(ctx, []string{id, other.ID.String()})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []uuid.UUID{product.ID, other.ID}, []uuid.UUID{products[0].ID, products[1].ID})
+		}
+
+		// and the products loaded in a batch are served to GetProduct
+		got, err := repo.GetProduct(ctx, other.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, other.Name, got.Name)
+
+		_, err = repo.GetProductsByIDs(ctx, []string{"not-a-uuid"})
+		assert.ErrorIs(t, err, repositories.ErrInvalidProductID)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("Lists are cached per filter and page", func(t *testing.T) {
+		next := new(testutils.MockProductRepository)
+		one := int64(1)
+		monthly := repositories.ProductFilter{Duration: models.DurationMonth}
+		yearly := repositories.ProductFilter{Duration: models.DurationYear}
+		page := repositories.PageRequest{Page: 1, Limit: 10}
+		next.On("GetProducts", mock.Anything, monthly, page).
+			Return([]models.Product{*product}, repositories.Pagination{Page: 1, Limit: 10, Total: &one}, nil).Once()
+		next.On("GetProducts", mock.Anything, yearly, page).
+			Return([]models.Product{}, repositories.Pagination{Page: 1, Limit: 10}, nil).Once()
+		repo := repositories.NewCachedProductRepository(next, cache.NewLRU(10), time.Minute)
+
+		for range 2 {
+			products, pagination, err := repo.GetProducts(ctx, monthly, page)
+			require.NoError(t, err)
+			assert.Len(t, products, 1)
+			assert.Equal(t, int64(1), *pagination.Total)
+
+			products, _, err = repo.GetProducts(ctx, yearly, page)
+			require.NoError(t, err)
+			assert.NotNil(t, products, "empty pages stay empty, not nil")
+			assert.Empty(t, products)
+		}
+		next.AssertExpectations(t)
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		next := new(testutils.MockProductRepository)
+		next.On("GetProduct", mock.Anything, id).Return(nil, repositories.ErrProductNotFound).Twice()
+		repo := repositories.NewCachedProductRepository(next, cache.NewLRU(10), time.Minute)
+
+		for range 2 {
+			_, err := repo.GetProduct(ctx, id)
+			assert.ErrorIs(t, err, repositories.ErrProductNotFound)
+		}
+
+		_, err := repo.GetProduct(ctx, "not-a-uuid")
+		assert.ErrorIs(t, err, repositories.ErrInvalidProductID)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("Invalidate drops every entry", func(t *testing.T) {
+		next := new(testutils.MockProductRepository)
+		next.On("GetProduct", mock.Anything, id).Return(product, nil).Twice()
+		repo := repositories.NewCachedProductRepository(next, cache.NewLRU(10), time.Minute)
+
+		_, err := repo.GetProduct(ctx, id)
+		require.NoError(t, err)
+		require.NoError(t, repo.Invalidate(ctx))
+		_, err = repo.GetProduct(ctx, id)
+		require.NoError(t, err)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("Concurrent misses share one load", func(t *testing.T) {
+		release := make(chan struct{})
+		next := new(testutils.MockProductRepository)
+		next.On("GetProduct", mock.Anything, id).
+			Run(func(mock.Arguments) { <-release }).
+			Return(product, nil).Once()
+		repo := repositories.NewCachedProductRepository(next, cache.NewLRU(10), time.Minute)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p, err := repo.GetProduct(ctx, id)
+				assert.NoError(t, err)
+				assert.Equal(t, product.Name, p.Name)
+			}()
+		}
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		next.AssertExpectations(t)
+	})
+
+	t.Run("A caller giving up does not abort the shared load", func(t *testing.T) {
+		release := make(chan struct{})
+		loaded := make(chan error, 1)
+		next := new(testutils.MockProductRepository)
+		next.On("GetProduct", mock.Anything, id).
+			Run(func(args mock.Arguments) {
+				<-release
+				loaded <- args.Get(0).(context.Context).Err()
+			}).
+			Return(product, nil).Once()
+		repo := repositories.NewCachedProductRepository(next, cache.NewLRU(10), time.Minute)
+
+		callerCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := repo.GetProduct(callerCtx, id)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		close(release)
+		assert.NoError(t, <-loaded)
+		p, err := repo.GetProduct(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, product.Name, p.Name)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("Store failures fall back to the repository", func(t *testing.T) {
+		next := new(testutils.MockProductRepository)
+		next.On("GetProduct", mock.Anything, id).Return(product, nil).Twice()
+		repo := repositories.NewCachedProductRepository(next, failingStore{}, time.Minute)
+
+		for range 2 {
+			p, err := repo.GetProduct(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, product.Name, p.Name)
+		}
+		next.AssertExpectations(t)
+	})
+}
+
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("connection refused")
+}
+
+func (failingStore) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func (failingStore) Delete(context.Context, string) error {
+	return errors.New("connection refused")
+}
+
+func TestCachedProductRepositoryInvalidatesAfterWrites(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("A read racing an update cannot keep the old product cached", func(t *testing.T) {
+		old := testutils.NewMockProduct()
+		id := old.ID.String()
+		updated := *old
+		updated.Name = "Monthly Plus"
+		name := updated.Name
+
+		next := new(testutils.MockProductRepository)
+		repo := repositories.NewCachedProductRepository(next, cache.NewLRU(10), time.Hour)
+		next.On("GetProduct", mock.Anything, id).Return(old, nil).Once()
+		next.On("UpdateProduct", mock.Anything, id, repositories.ProductChanges{Name: &name}).
+			Run(func(mock.Arguments) {
+				// the write has not committed yet, so this read loads
+				// and caches the old row
+				racing, err := repo.GetProduct(ctx, id)
+				require.NoError(t, err)
+				assert.Equal(t, old.Name, racing.Name)
+			}).
+			Return(&updated, nil).Once()
+		next.On("GetProduct", mock.Anything, id).Return(&updated, nil).Once()
+
+		_, err := repo.UpdateProduct(ctx, id, repositories.ProductChanges{Name: &name})
+		require.NoError(t, err)
+		got, err := repo.GetProduct(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "Monthly Plus", got.Name)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("Creating a product invalidates the lists", func(t *testing.T) {
+		product := testutils.NewMockProduct()
+		page := repositories.PageRequest{Page: 1, Limit: 10}
+		next := new(testutils.MockProductRepository)
+		next.On("GetProducts", mock.Anything, repositories.ProductFilter{}, page).
+			Return([]models.Product{}, repositories.Pagination{Page: 1, Limit: 10}, nil).Once()
+		next.On("CreateProduct", mock.Anything, product).Return(product, nil).Once()
+		next.On("GetProducts", mock.Anything, repositories.ProductFilter{}, page).
+			Return([]models.Product{*product}, repositories.Pagination{Page: 1, Limit: 10}, nil).Once()
+		repo := repositories.NewCachedProductRepository(next, cache.NewLRU(10), time.Hour)
+
+		products, _, err := repo.GetProducts(ctx, repositories.ProductFilter{}, page)
+		require.NoError(t, err)
+		assert.Empty(t, products)
+		_, err = repo.CreateProduct(ctx, product)
+		require.NoError(t, err)
+		products, _, err = repo.GetProducts(ctx, repositories.ProductFilter{}, page)
+		require.NoError(t, err)
+		assert.Len(t, products, 1)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("Failed writes keep the cache", func(t *testing.T) {
+		product := testutils.NewMockProduct()
+		id := product.ID.String()
+		name := "Monthly Plus"
+		next := new(testutils.MockProductRepository)
+		next.On("GetProduct", mock.Anything, id).Return(product, nil).Once()
+		next.On("UpdateProduct", mock.Anything, id, repositories.ProductChanges{Name: &name}).
+			Return(nil, repositories.ErrProductNotFound).Once()
+		repo := repositories.NewCachedProductRepository(next, cache.NewLRU(10), time.Hour)
+
+		_, err := repo.GetProduct(ctx, id)
+		require.NoError(t, err)
+		_, err = repo.UpdateProduct(ctx, id, repositories.ProductChanges{Name: &name})
+		assert.ErrorIs(t, err, repositories.ErrProductNotFound)
+		_, err = repo.GetProduct(ctx, id)
+		require.NoError(t, err)
+		next.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,82 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/database"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// slowDB returns a database whose queries on products first run a
+// statement that never finishes on its own, standing in for a slow query.
+func slowDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(context.Background(), db))
+
+	err = db.Callback().Query().Before("gorm:query").Register("test:slow", func(tx *gorm.DB) {
+		if tx.Statement.Table != "products" {
+			return
+		}
+		var n int64
+		err := tx.Statement.ConnPool.QueryRowContext(tx.Statement.Context,
+			"WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT max(x) FROM c").Scan(&n)
+		_ = tx.AddError(err)
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func TestDeadlineAbortsRunningQuery(t *testing.T) {
+	repo := repositories.NewProductRepository(slowDB(t))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := repo.GetProduct(ctx, "465dc700-666c-4b7a-80e2-d9e2967f4442")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second, "the query must stop when the deadline passes")
+}
+
+func TestCancelAbortsRunningQuery(t *testing.T) {
+	repo := repositories.NewProductRepository(slowDB(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, _, err := repo.GetProducts(ctx, repositories.ProductFilter{}, repositories.PageRequest{})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 2*time.Second, "the query must stop when the client goes away")
+}
+
+func TestCancelledContextSkipsWrites(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(context.Background(), db))
+
+	product := &models.Product{Name: "Monthly", Duration: models.DurationMonth, Price: 9.99}
+	require.NoError(t, db.Create(product).Error)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = repositories.NewSubscriptionRepository(db).CreateSubscription(ctx, "6ae3b222-af74-4c53-ac50-263d383a5a4b", product)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	var count int64
+	require.NoError(t, db.Model(&models.Subscription{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
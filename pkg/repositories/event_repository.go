@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/models"
+	"net/http"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrEventNotFound = apperrors.New(apperrors.CodeNotFound, http.StatusNotFound, "event not found")
+
+// EventRepository reads the subscription events kept in the outbox.
+type EventRepository interface {
+	// EventsAfter returns up to limit events recorded after the event
+	// with ID after, oldest first, about subject only unless subject is
+	// uuid.Nil. It fails with ErrEventNotFound if no event has that ID,
+	// which is also the case once the relay's retention deleted it.
+	EventsAfter(ctx context.Context, subject uuid.UUID, after string, limit int) ([]events.Event, error)
+}
+
+type EventRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewEventRepository(db *gorm.DB) EventRepository {
+	return &EventRepositoryImpl{db: db}
+}
+
+func (r *EventRepositoryImpl) EventsAfter(ctx context.Context, subject uuid.UUID, after string, limit int) ([]events.Event, error) {
+	afterID, err := uuid.Parse(after)
+	if err != nil {
+		return nil, ErrEventNotFound
+	}
+
+	db := r.db.WithContext(ctx)
+	var last models.OutboxEvent
+	if err := db.Select("position").First(&last, "id = ?", afterID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+
+	query := db.Where("position > ?", last.Position)
+	if subject != uuid.Nil {
+		query = query.Where("aggregate_id = ?", subject)
+	}
+	var rows []models.OutboxEvent
+	if err := query.Order("position").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	found := make([]events.Event, len(rows))
+	for i, row := range rows {
+		if err := json.Unmarshal([]byte(row.Payload), &found[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode event %s: %w", row.ID, err)
+		}
+	}
+	return found, nil
+}
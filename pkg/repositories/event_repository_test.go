@@ -0,0 +1,76 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type EventRepositoryTestSuite struct {
+	suite.Suite
+	db       *gorm.DB
+	repo     repositories.EventRepository
+	observer *recordingObserver
+	subRepo  repositories.SubscriptionRepository
+}
+
+func (s *EventRepositoryTestSuite) SetupTest() {
+	s.db.Exec("DELETE FROM outbox_events")
+	s.db.Exec("DELETE FROM subscriptions")
+	s.db.Exec("DELETE FROM products")
+	s.observer = &recordingObserver{}
+	s.repo = repositories.NewEventRepository(s.db)
+	s.subRepo = repositories.NewSubscriptionRepository(s.db, s.observer)
+}
+
+func TestEventRepositorySuite(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db *gorm.DB) {
+		suite.Run(t, &EventRepositoryTestSuite{db: db})
+	})
+}
+
+// createPaused creates a subscription and pauses it, recording two
+// events.
+func (s *EventRepositoryTestSuite) createPaused(product *models.Product) *models.Subscription {
+	sub, err := s.subRepo.CreateSubscription(context.Background(), uuid.NewString(), product)
+	s.Require().NoError(err)
+	sub, err = s.subRepo.PauseSubscription(context.Background(), sub.ID.String(), sub.Version)
+	s.Require().NoError(err)
+	return sub
+}
+
+func (s *EventRepositoryTestSuite) TestEventsAfter() {
+	product := &models.Product{Name: "Monthly", Duration: 30, Price: 9.99}
+	s.Require().NoError(s.db.Create(product).Error)
+	first := s.createPaused(product)
+	second := s.createPaused(product)
+	recorded := s.observer.recorded
+	s.Require().Len(recorded, 4)
+
+	found, err := s.repo.EventsAfter(context.Background(), uuid.Nil, recorded[0].ID.String(), 10)
+	s.Require().NoError(err)
+	s.Equal(recorded[1:], found, "the firehose replays every later event in order")
+
+	found, err = s.repo.EventsAfter(context.Background(), first.ID, recorded[0].ID.String(), 10)
+	s.Require().NoError(err)
+	s.Equal(recorded[1:2], found, "only events about the subject")
+
+	found, err = s.repo.EventsAfter(context.Background(), second.ID, recorded[0].ID.String(), 1)
+	s.Require().NoError(err)
+	s.Equal(recorded[2:3], found, "at most limit events")
+
+	found, err = s.repo.EventsAfter(context.Background(), uuid.Nil, recorded[3].ID.String(), 10)
+	s.Require().NoError(err)
+	s.Empty(found)
+
+	_, err = s.repo.EventsAfter(context.Background(), uuid.Nil, uuid.NewString(), 10)
+	s.ErrorIs(err, repositories.ErrEventNotFound)
+	_, err = s.repo.EventsAfter(context.Background(), uuid.Nil, "not-a-uuid", 10)
+	s.ErrorIs(err, repositories.ErrEventNotFound)
+}
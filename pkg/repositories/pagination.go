@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"gymondo_dz/pkg/apperrors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageLimit = 10
+	MaxPageLimit     = 100
+)
+
+var ErrInvalidCursor = apperrors.New(apperrors.CodeValidation, http.StatusBadRequest, "invalid pagination cursor")
+
+// PageRequest selects a page either by offset (Page) or, when Cursor is
+// set, by keyset on the sort column and id.
+type PageRequest struct {
+	Page         int
+	Limit        int
+	Cursor       string
+	IncludeTotal bool
+}
+
+// PageQuery holds the pagination parameters every API takes for a list,
+// with the rules they are validated by. Page selects offset pagination
+// (the default); Cursor switches to keyset pagination using the
+// NextCursor/PrevCursor values from a previous page.
+type PageQuery struct {
+	Page         int    `form:"page" json:"page" binding:"omitempty,min=1,excluded_with=Cursor"`
+	Limit        int    `form:"limit,default=10" json:"limit" binding:"min=1,max=100"`
+	Cursor       string `form:"cursor" json:"cursor" binding:"omitempty,max=512"`
+	IncludeTotal bool   `form:"include_total" json:"include_total"`
+}
+
+// PageRequest returns the page q asks for, the first unless it names a
+// page or a cursor.
+func (q PageQuery) PageRequest() PageRequest {
+	page := q.Page
+	if page == 0 && q.Cursor == "" {
+		page = 1
+	}
+	return PageRequest{
+		Page:         page,
+		Limit:        q.Limit,
+		Cursor:       q.Cursor,
+		IncludeTotal: q.IncludeTotal,
+	}
+}
+
+// Pagination describes the page that was returned. Total is only set in
+// offset mode or when IncludeTotal was requested.
+type Pagination struct {
+	Page       int
+	Limit      int
+	Total      *int64
+	NextCursor string
+	PrevCursor string
+}
+
+// ordering is a whitelisted sort column; id is always the tie-breaker.
+type ordering struct {
+	column string
+	desc   bool
+}
+
+func (o ordering) String() string {
+	if o.desc {
+		return o.column + ":desc"
+	}
+	return o.column + ":asc"
+}
+
+var defaultOrdering = ordering{column: "created_at"}
+
+type cursor struct {
+	Sort     string     `json:"s,omitempty"`
+	Time     *time.Time `json:"t,omitempty"`
+	Number   *float64   `json:"n,omitempty"`
+	Text     *string    `json:"x,omitempty"`
+	ID       uuid.UUID  `json:"id"`
+	Backward bool       `json:"b,omitempty"`
+}
+
+func newCursor(order ordering, value any, id uuid.UUID, backward bool) cursor {
+	c := cursor{Sort: order.String(), ID: id, Backward: backward}
+	switch v := value.(type) {
+	case time.Time:
+		c.Time = &v
+	case float64:
+		c.Number = &v
+	case string:
+		c.Text = &v
+	default:
+		panic(fmt.Sprintf("repositories: unsupported cursor value %T", value))
+	}
+	return c
+}
+
+func (c cursor) value() any {
+	switch {
+	case c.Time != nil:
+		return *c.Time
+	case c.Number != nil:
+		return *c.Number
+	case c.Text != nil:
+		return *c.Text
+	}
+	return nil
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string, order ordering) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == uuid.Nil || c.value() == nil {
+		return c, ErrInvalidCursor
+	}
+	// a cursor only makes sense for the ordering it was issued for
+	if c.Sort != order.String() {
+		return c, ErrInvalidCursor.WithDetail("pagination cursor does not match the requested sort order")
+	}
+	return c, nil
+}
+
+// paginate loads one page of query sorted by order and id. key must
+// return an item's sort value and id so cursors can be built from it.
+func paginate[T any](query *gorm.DB, req PageRequest, order ordering, key func(*T) (any, uuid.UUID)) ([]T, Pagination, error) {
+	limit := req.Limit
+	if limit < 1 || limit > MaxPageLimit {
+		limit = DefaultPageLimit
+	}
+	page := Pagination{Limit: limit}
+
+	var (
+		after    *cursor
+		backward bool
+	)
+	if req.Cursor != "" {
+		c, err := decodeCursor(req.Cursor, order)
+		if err != nil {
+			return nil, page, err
+		}
+		after = &c
+		backward = c.Backward
+	} else {
+		page.Page = req.Page
+		if page.Page < 1 {
+			page.Page = 1
+		}
+	}
+
+	if req.IncludeTotal || after == nil {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, page, err
+		}
+		page.Total = &total
+	}
+
+	// Walking backwards flips both the comparison and the scan direction;
+	// the rows are reversed again after loading.
+	desc := order.desc != backward
+	cmp, dir := ">", "ASC"
+	if desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	q := query.Session(&gorm.Session{}).
+		Order(order.column + " " + dir).
+		Order("id " + dir).
+		Limit(limit + 1)
+	if after == nil {
+		q = q.Offset((page.Page - 1) * limit)
+	} else {
+		v := after.value()
+		q = q.Where(fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", order.column, cmp), v, v, after.ID)
+	}
+
+	var items []T
+	if err := q.Find(&items).Error; err != nil {
+		return nil, page, err
+	}
+
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+	if len(items) == 0 {
+		return items, page, nil
+	}
+
+	first, last := &items[0], &items[len(items)-1]
+	hasNext := hasMore || backward
+	hasPrev := (hasMore && backward) || (after != nil && !backward) || page.Page > 1
+
+	if hasNext {
+		v, id := key(last)
+		page.NextCursor = encodeCursor(newCursor(order, v, id, false))
+	}
+	if hasPrev {
+		v, id := key(first)
+		page.PrevCursor = encodeCursor(newCursor(order, v, id, true))
+	}
+
+	return items, page, nil
+}
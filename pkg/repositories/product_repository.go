@@ -1,27 +1,61 @@
 package repositories
 
 import (
+	"context"
 	"errors"
+	"gymondo_dz/pkg/apperrors"
 	"gymondo_dz/pkg/models"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 var (
-	ErrProductNotFound  = errors.New("product not found")
-	ErrInvalidProductID = errors.New("invalid product ID format")
+	ErrProductNotFound  = apperrors.New(apperrors.CodeNotFound, http.StatusNotFound, "product not found")
+	ErrInvalidProductID = apperrors.New(apperrors.CodeInvalidID, http.StatusBadRequest, "invalid product ID format")
+	ErrInvalidSort      = apperrors.New(apperrors.CodeValidation, http.StatusBadRequest, "unsupported sort field")
+	ErrInvalidProduct   = apperrors.New(apperrors.CodeValidation, http.StatusBadRequest, "invalid product")
 )
 
-type ProductRepository interface {
-	GetProducts(page, limit int) ([]models.Product, int64, error)
-	GetProduct(id string) (*models.Product, error)
+// ProductSortFields whitelists the columns GetProducts can be sorted by.
+var ProductSortFields = []string{"created_at", "price", "name"}
+
+// ProductFilter narrows and orders GetProducts; zero values match everything
+// and sort by creation time, oldest first.
+type ProductFilter struct {
+	Duration models.SubscriptionDuration
+	MinPrice *float64
+	MaxPrice *float64
+	Currency string
+	Query    string
+	SortBy   string
+	SortDesc bool
 }
 
-type Pagination struct {
-	Page  int
-	Limit int
-	Total int64
+// ProductChanges lists the fields UpdateProduct sets; nil fields keep
+// their current value.
+type ProductChanges struct {
+	Name        *string
+	Description *string
+	Price       *float64
+	TaxRate     *float64
+	Currency    *string
+	Duration    *models.SubscriptionDuration
+}
+
+type ProductRepository interface {
+	GetProducts(ctx context.Context, filter ProductFilter, page PageRequest) ([]models.Product, Pagination, error)
+	GetProduct(ctx context.Context, id string) (*models.Product, error)
+	// GetProductsByIDs returns the products with the given IDs in no
+	// particular order, leaving out those that do not exist.
+	GetProductsByIDs(ctx context.Context, ids []string) ([]models.Product, error)
+	CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error)
+	UpdateProduct(ctx context.Context, id string, changes ProductChanges) (*models.Product, error)
 }
 
 type ProductRepositoryImpl struct {
@@ -32,39 +66,67 @@ func NewProductRepository(db *gorm.DB) ProductRepository {
 	return &ProductRepositoryImpl{db: db}
 }
 
-func (r *ProductRepositoryImpl) GetProducts(page, limit int) ([]models.Product, int64, error) {
-	var products []models.Product
-	var total int64
-
-	if err := r.db.Model(&models.Product{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+func (r *ProductRepositoryImpl) GetProducts(ctx context.Context, filter ProductFilter, page PageRequest) ([]models.Product, Pagination, error) {
+	order := defaultOrdering
+	if filter.SortBy != "" {
+		if !slices.Contains(ProductSortFields, filter.SortBy) {
+			return nil, Pagination{}, ErrInvalidSort
+		}
+		order.column = filter.SortBy
 	}
+	order.desc = filter.SortDesc
 
-	if page < 1 {
-		page = 1
+	query := r.db.WithContext(ctx).Model(&models.Product{})
+	if filter.Duration != 0 {
+		query = query.Where("duration = ?", filter.Duration)
 	}
-
-	if limit < 1 || limit > 100 {
-		limit = 10
+	if filter.MinPrice != nil {
+		query = query.Where("price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("price <= ?", *filter.MaxPrice)
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		query = r.search(query, q)
 	}
 
-	offset := (page - 1) * limit
+	return paginate(query, page, order, func(p *models.Product) (any, uuid.UUID) {
+		switch order.column {
+		case "price":
+			return p.Price, p.ID
+		case "name":
+			return p.Name, p.ID
+		default:
+			return p.CreatedAt, p.ID
+		}
+	})
+}
 
-	result := r.db.Order("created_at ASC").Offset(offset).Limit(limit).Find(&products)
-	if result.Error != nil {
-		return nil, 0, result.Error
+// search matches q against name and description, using full-text search
+// on Postgres and a case-insensitive LIKE on SQLite.
+func (r *ProductRepositoryImpl) search(query *gorm.DB, q string) *gorm.DB {
+	if r.db.Dialector.Name() == "postgres" {
+		return query.Where(
+			"to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(description, '')) @@ plainto_tsquery('simple', ?)", q)
 	}
-	return products, total, nil
+
+	pattern := "%" + likeEscaper.Replace(strings.ToLower(q)) + "%"
+	return query.Where(`(LOWER(name) LIKE ? ESCAPE '\' OR LOWER(description) LIKE ? ESCAPE '\')`, pattern, pattern)
 }
 
-func (r *ProductRepositoryImpl) GetProduct(id string) (*models.Product, error) {
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (r *ProductRepositoryImpl) GetProduct(ctx context.Context, id string) (*models.Product, error) {
 	productID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, ErrInvalidProductID
 	}
 
 	var product models.Product
-	result := r.db.Debug().Where("id = ?", productID).First(&product)
+	result := r.db.WithContext(ctx).Where("id = ?", productID).First(&product)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, ErrProductNotFound
@@ -74,3 +136,124 @@ func (r *ProductRepositoryImpl) GetProduct(id string) (*models.Product, error) {
 
 	return &product, nil
 }
+
+func (r *ProductRepositoryImpl) GetProductsByIDs(ctx context.Context, ids []string) ([]models.Product, error) {
+	productIDs := make([]uuid.UUID, len(ids))
+	for i, id := range ids {
+		productID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, ErrInvalidProductID
+		}
+		productIDs[i] = productID
+	}
+
+	products := []models.Product{}
+	if len(productIDs) == 0 {
+		return products, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *ProductRepositoryImpl) CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
+	if err := validateProduct(product); err != nil {
+		return nil, err
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		// reload for the database defaults and the computed total price
+		return tx.First(product, "id = ?", product.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (r *ProductRepositoryImpl) UpdateProduct(ctx context.Context, id string, changes ProductChanges) (*models.Product, error) {
+	productID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidProductID
+	}
+
+	var product models.Product
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&product, "id = ?", productID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if changes.Name != nil {
+			product.Name, updates["name"] = *changes.Name, *changes.Name
+		}
+		if changes.Description != nil {
+			product.Description, updates["description"] = *changes.Description, *changes.Description
+		}
+		if changes.Price != nil {
+			product.Price, updates["price"] = *changes.Price, *changes.Price
+		}
+		if changes.TaxRate != nil {
+			product.TaxRate, updates["tax_rate"] = *changes.TaxRate, *changes.TaxRate
+		}
+		if changes.Currency != nil {
+			product.Currency, updates["currency"] = *changes.Currency, *changes.Currency
+		}
+		if changes.Duration != nil {
+			product.Duration, updates["duration"] = *changes.Duration, *changes.Duration
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := validateProduct(&product); err != nil {
+			return err
+		}
+
+		updates["updated_at"] = time.Now()
+		if err := tx.Model(&product).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.First(&product, "id = ?", productID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+func validateProduct(p *models.Product) error {
+	var fields []apperrors.FieldError
+	if name := strings.TrimSpace(p.Name); name == "" || len(name) > 100 {
+		fields = append(fields, apperrors.FieldError{Field: "name", Message: "must be 1 to 100 characters"})
+	}
+	if len(p.Description) > 255 {
+		fields = append(fields, apperrors.FieldError{Field: "description", Message: "must be at most 255 characters"})
+	}
+	if p.Price < 0 {
+		fields = append(fields, apperrors.FieldError{Field: "price", Message: "must not be negative"})
+	}
+	if p.TaxRate < 0 || p.TaxRate > 1 {
+		fields = append(fields, apperrors.FieldError{Field: "tax_rate", Message: "must be between 0 and 1"})
+	}
+	if !currencyCode.MatchString(p.Currency) {
+		fields = append(fields, apperrors.FieldError{Field: "currency", Message: "must be a three-letter ISO 4217 code"})
+	}
+	if !p.Duration.IsValid() {
+		fields = append(fields, apperrors.FieldError{Field: "duration", Message: "must be one of 30, 365, 36500"})
+	}
+	if len(fields) > 0 {
+		return ErrInvalidProduct.WithFields(fields...)
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package repositories_test
 
 import (
+	"context"
+	"gymondo_dz/pkg/apperrors"
 	"gymondo_dz/pkg/models"
 	"gymondo_dz/pkg/repositories"
 	"strconv"
@@ -10,7 +12,6 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -21,20 +22,6 @@ type ProductRepositoryTestSuite struct {
 }
 
 func (s *ProductRepositoryTestSuite) SetupSuite() {
-	var err error
-	s.db, err = gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{
-		SkipDefaultTransaction: true,
-	})
-	if err != nil {
-		s.FailNow("Failed to connect to test database")
-	}
-
-	// Create tables
-	err = s.db.AutoMigrate(&models.Product{})
-	if err != nil {
-		s.FailNow("Failed to migrate database: " + err.Error())
-	}
-
 	s.repo = repositories.NewProductRepository(s.db)
 }
 
@@ -101,13 +88,15 @@ func (s *ProductRepositoryTestSuite) SetupTest() {
 }
 
 func TestProductRepositorySuite(t *testing.T) {
-	suite.Run(t, new(ProductRepositoryTestSuite))
+	forEachBackend(t, func(t *testing.T, db *gorm.DB) {
+		suite.Run(t, &ProductRepositoryTestSuite{db: db})
+	})
 }
 
 func (s *ProductRepositoryTestSuite) TestGetProducts() {
-	products, total, err := s.repo.GetProducts(1, 10)
+	products, page, err := s.repo.GetProducts(context.Background(), repositories.ProductFilter{}, repositories.PageRequest{Page: 1, Limit: 10})
 	assert.NoError(s.T(), err)
-	assert.Equal(s.T(), int64(3), total)
+	assert.Equal(s.T(), int64(3), *page.Total)
 	assert.Len(s.T(), products, 3)
 
 	// Verify order is correct (oldest first)
@@ -116,6 +105,157 @@ func (s *ProductRepositoryTestSuite) TestGetProducts() {
 	assert.Equal(s.T(), "Lifetime Plan", products[2].Name)
 }
 
+func (s *ProductRepositoryTestSuite) TestGetProductsCursor() {
+	// Walk forward one item at a time
+	first, page, err := s.repo.GetProducts(context.Background(), repositories.ProductFilter{}, repositories.PageRequest{Limit: 1})
+	s.NoError(err)
+	s.Len(first, 1)
+	s.Equal("Monthly Plan", first[0].Name)
+	s.Empty(page.PrevCursor)
+	s.NotEmpty(page.NextCursor)
+
+	second, page, err := s.repo.GetProducts(context.Background(), repositories.ProductFilter{}, repositories.PageRequest{Limit: 1, Cursor: page.NextCursor})
+	s.NoError(err)
+	s.Len(second, 1)
+	s.Equal("Yearly Plan", second[0].Name)
+	s.Nil(page.Total, "total is only counted on request in cursor mode")
+	s.NotEmpty(page.PrevCursor)
+	s.NotEmpty(page.NextCursor)
+	prevCursor := page.PrevCursor
+
+	third, page, err := s.repo.GetProducts(context.Background(), repositories.ProductFilter{}, repositories.PageRequest{Limit: 1, Cursor: page.NextCursor, IncludeTotal: true})
+	s.NoError(err)
+	s.Len(third, 1)
+	s.Equal("Lifetime Plan", third[0].Name)
+	s.Equal(int64(3), *page.Total)
+	s.Empty(page.NextCursor)
+
+	// Walk back from the second page
+	back, page, err := s.repo.GetProducts(context.Background(), repositories.ProductFilter{}, repositories.PageRequest{Limit: 1, Cursor: prevCursor})
+	s.NoError(err)
+	s.Len(back, 1)
+	s.Equal("Monthly Plan", back[0].Name)
+	s.Empty(page.PrevCursor)
+	s.NotEmpty(page.NextCursor)
+}
+
+func (s *ProductRepositoryTestSuite) TestGetProductsCursorStableUnderInserts() {
+	firstPage, page, err := s.repo.GetProducts(context.Background(), repositories.ProductFilter{}, repositories.PageRequest{Limit: 2})
+	s.NoError(err)
+	s.Len(firstPage, 2)
+
+	// A product created before the cursor position must not shift the next page
+	s.NoError(s.db.Create(&models.Product{
+		Name:      "Weekly Plan",
+		Price:     2.99,
+		Duration:  models.DurationMonth,
+		CreatedAt: time.Now().UTC().Add(-4 * time.Hour),
+	}).Error)
+
+	nextPage, _, err := s.repo.GetProducts(context.Background(), repositories.ProductFilter{}, repositories.PageRequest{Limit: 2, Cursor: page.NextCursor})
+	s.NoError(err)
+	s.Len(nextPage, 1)
+	s.Equal("Lifetime Plan", nextPage[0].Name)
+}
+
+func (s *ProductRepositoryTestSuite) TestGetProductsInvalidCursor() {
+	_, _, err := s.repo.GetProducts(context.Background(), repositories.ProductFilter{}, repositories.PageRequest{Limit: 1, Cursor: "not-a-cursor"})
+	s.ErrorIs(err, repositories.ErrInvalidCursor)
+}
+
+func (s *ProductRepositoryTestSuite) TestGetProductsFiltered() {
+	minPrice, maxPrice := 50.0, 500.0
+
+	tests := []struct {
+		name          string
+		filter        repositories.ProductFilter
+		expectedNames []string
+	}{
+		{
+			name:          "By duration",
+			filter:        repositories.ProductFilter{Duration: models.DurationYear},
+			expectedNames: []string{"Yearly Plan"},
+		},
+		{
+			name:          "By price range",
+			filter:        repositories.ProductFilter{MinPrice: &minPrice, MaxPrice: &maxPrice},
+			expectedNames: []string{"Yearly Plan"},
+		},
+		{
+			name:          "By currency",
+			filter:        repositories.ProductFilter{Currency: "USD"},
+			expectedNames: []string{},
+		},
+		{
+			name:          "Search is case-insensitive over name and description",
+			filter:        repositories.ProductFilter{Query: "LIFETIME"},
+			expectedNames: []string{"Lifetime Plan"},
+		},
+		{
+			name:          "Search matches description",
+			filter:        repositories.ProductFilter{Query: "1 month"},
+			expectedNames: []string{"Monthly Plan"},
+		},
+		{
+			name:          "Search escapes LIKE wildcards",
+			filter:        repositories.ProductFilter{Query: "%"},
+			expectedNames: []string{},
+		},
+		{
+			name:          "Sort by price descending",
+			filter:        repositories.ProductFilter{SortBy: "price", SortDesc: true},
+			expectedNames: []string{"Lifetime Plan", "Yearly Plan", "Monthly Plan"},
+		},
+		{
+			name:          "Sort by name",
+			filter:        repositories.ProductFilter{SortBy: "name"},
+			expectedNames: []string{"Lifetime Plan", "Monthly Plan", "Yearly Plan"},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			products, _, err := s.repo.GetProducts(context.Background(), tt.filter, repositories.PageRequest{Page: 1, Limit: 10})
+			s.NoError(err)
+
+			names := []string{}
+			for _, p := range products {
+				names = append(names, p.Name)
+			}
+			s.Equal(tt.expectedNames, names)
+		})
+	}
+}
+
+func (s *ProductRepositoryTestSuite) TestGetProductsSortedCursor() {
+	filter := repositories.ProductFilter{SortBy: "price", SortDesc: true}
+
+	first, page, err := s.repo.GetProducts(context.Background(), filter, repositories.PageRequest{Limit: 2})
+	s.NoError(err)
+	s.Equal("Lifetime Plan", first[0].Name)
+	s.Equal("Yearly Plan", first[1].Name)
+
+	rest, page, err := s.repo.GetProducts(context.Background(), filter, repositories.PageRequest{Limit: 2, Cursor: page.NextCursor})
+	s.NoError(err)
+	s.Len(rest, 1)
+	s.Equal("Monthly Plan", rest[0].Name)
+
+	back, _, err := s.repo.GetProducts(context.Background(), filter, repositories.PageRequest{Limit: 2, Cursor: page.PrevCursor})
+	s.NoError(err)
+	s.Len(back, 2)
+	s.Equal("Lifetime Plan", back[0].Name)
+	s.Equal("Yearly Plan", back[1].Name)
+
+	// a cursor issued for one ordering is rejected for another
+	_, _, err = s.repo.GetProducts(context.Background(), repositories.ProductFilter{SortBy: "name"}, repositories.PageRequest{Limit: 2, Cursor: page.PrevCursor})
+	s.ErrorIs(err, repositories.ErrInvalidCursor)
+}
+
+func (s *ProductRepositoryTestSuite) TestGetProductsInvalidSort() {
+	_, _, err := s.repo.GetProducts(context.Background(), repositories.ProductFilter{SortBy: "id; DROP TABLE products"}, repositories.PageRequest{})
+	s.ErrorIs(err, repositories.ErrInvalidSort)
+}
+
 func (s *ProductRepositoryTestSuite) TestGetProductsPerformance() {
 	// Seed large dataset
 	for i := 0; i < 1000; i++ {
@@ -127,7 +267,7 @@ func (s *ProductRepositoryTestSuite) TestGetProductsPerformance() {
 	}
 
 	start := time.Now()
-	_, _, err := s.repo.GetProducts(1, 100)
+	_, _, err := s.repo.GetProducts(context.Background(), repositories.ProductFilter{}, repositories.PageRequest{Page: 1, Limit: 100})
 	s.NoError(err)
 	s.True(time.Since(start) < time.Second, "Pagination query too slow")
 }
@@ -160,7 +300,7 @@ func (s *ProductRepositoryTestSuite) TestGetProduct() {
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			product, err := s.repo.GetProduct(tt.id)
+			product, err := s.repo.GetProduct(context.Background(), tt.id)
 
 			if tt.expectError {
 				assert.Error(s.T(), err)
@@ -175,3 +315,73 @@ func (s *ProductRepositoryTestSuite) TestGetProduct() {
 		})
 	}
 }
+
+func (s *ProductRepositoryTestSuite) TestGetProductsByIDs() {
+	ctx := context.Background()
+
+	products, err := s.repo.GetProductsByIDs(ctx, []string{
+		"33333333-3333-3333-3333-333333333333",
+		"11111111-1111-1111-1111-111111111111",
+		"00000000-0000-0000-0000-000000000000",
+	})
+	s.Require().NoError(err)
+	names := make([]string, len(products))
+	for i, p := range products {
+		names[i] = p.Name
+		s.InDelta(p.Price*1.10, p.TotalPrice, 0.001, "total price is computed")
+	}
+	s.ElementsMatch([]string{"Monthly Plan", "Lifetime Plan"}, names)
+
+	products, err = s.repo.GetProductsByIDs(ctx, nil)
+	s.NoError(err)
+	s.Empty(products)
+
+	_, err = s.repo.GetProductsByIDs(ctx, []string{"not-a-uuid"})
+	s.ErrorIs(err, repositories.ErrInvalidProductID)
+}
+
+func (s *ProductRepositoryTestSuite) TestCreateProduct() {
+	ctx := context.Background()
+
+	created, err := s.repo.CreateProduct(ctx, &models.Product{
+		Name: "Quarterly Plan", Price: 24.99, TaxRate: 0.19, Currency: "EUR", Duration: models.DurationMonth,
+	})
+	s.Require().NoError(err)
+	s.NotEqual(uuid.Nil, created.ID)
+
+	fetched, err := s.repo.GetProduct(ctx, created.ID.String())
+	s.Require().NoError(err)
+	s.Equal("Quarterly Plan", fetched.Name)
+	s.InDelta(29.7381, fetched.TotalPrice, 0.0001)
+
+	_, err = s.repo.CreateProduct(ctx, &models.Product{Price: -1, TaxRate: 2, Currency: "eur", Duration: 7})
+	var appErr *apperrors.Error
+	s.Require().ErrorAs(err, &appErr)
+	fields := make([]string, len(appErr.Fields))
+	for i, f := range appErr.Fields {
+		fields[i] = f.Field
+	}
+	s.Equal([]string{"name", "price", "tax_rate", "currency", "duration"}, fields)
+}
+
+func (s *ProductRepositoryTestSuite) TestUpdateProduct() {
+	ctx := context.Background()
+	name, price := "Monthly Plus", 12.5
+
+	updated, err := s.repo.UpdateProduct(ctx, "11111111-1111-1111-1111-111111111111",
+		repositories.ProductChanges{Name: &name, Price: &price})
+	s.Require().NoError(err)
+	s.Equal("Monthly Plus", updated.Name)
+	s.Equal(12.5, updated.Price)
+	s.Equal("1 month subscription", updated.Description, "fields left out stay unchanged")
+
+	empty := ""
+	_, err = s.repo.UpdateProduct(ctx, "11111111-1111-1111-1111-111111111111", repositories.ProductChanges{Name: &empty})
+	s.ErrorIs(err, repositories.ErrInvalidProduct)
+
+	_, err = s.repo.UpdateProduct(ctx, "00000000-0000-0000-0000-000000000000", repositories.ProductChanges{Name: &name})
+	s.ErrorIs(err, repositories.ErrProductNotFound)
+
+	_, err = s.repo.UpdateProduct(ctx, "not-a-uuid", repositories.ProductChanges{Name: &name})
+	s.ErrorIs(err, repositories.ErrInvalidProductID)
+}
@@ -0,0 +1,291 @@
+package repositories
+
+import (
+	"context"
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/resilience"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The resilient repositories run every call of the repository they wrap
+// through a resilience.Executor, which fails fast with 503 while the
+// database is down. Reads and writes that can be repeated are retried on
+// any transient error; the other writes, which each run in a single
+// transaction, only on errors the database guarantees left no trace.
+
+type ResilientProductRepository struct {
+	next ProductRepository
+	exec *resilience.Executor
+}
+
+func NewResilientProductRepository(next ProductRepository, exec *resilience.Executor) ProductRepository {
+	return &ResilientProductRepository{next: next, exec: exec}
+}
+
+func (r *ResilientProductRepository) GetProducts(ctx context.Context, filter ProductFilter, page PageRequest) ([]models.Product, Pagination, error) {
+	var products []models.Product
+	var pagination Pagination
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		products, pagination, err = r.next.GetProducts(ctx, filter, page)
+		return err
+	})
+	return products, pagination, err
+}
+
+func (r *ResilientProductRepository) GetProduct(ctx context.Context, id string) (*models.Product, error) {
+	var product *models.Product
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		product, err = r.next.GetProduct(ctx, id)
+		return err
+	})
+	return product, err
+}
+
+func (r *ResilientProductRepository) GetProductsByIDs(ctx context.Context, ids []string) ([]models.Product, error) {
+	var products []models.Product
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		products, err = r.next.GetProductsByIDs(ctx, ids)
+		return err
+	})
+	return products, err
+}
+
+// CreateProduct is not idempotent: every attempt creates a new product.
+func (r *ResilientProductRepository) CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
+	var created *models.Product
+	err := r.exec.Do(ctx, resilience.RetryTransactional, func(ctx context.Context) (err error) {
+		created, err = r.next.CreateProduct(ctx, product)
+		return err
+	})
+	return created, err
+}
+
+// UpdateProduct sets the same values however often it runs.
+func (r *ResilientProductRepository) UpdateProduct(ctx context.Context, id string, changes ProductChanges) (*models.Product, error) {
+	var product *models.Product
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		product, err = r.next.UpdateProduct(ctx, id, changes)
+		return err
+	})
+	return product, err
+}
+
+type ResilientSubscriptionRepository struct {
+	next SubscriptionRepository
+	exec *resilience.Executor
+}
+
+func NewResilientSubscriptionRepository(next SubscriptionRepository, exec *resilience.Executor) SubscriptionRepository {
+	return &ResilientSubscriptionRepository{next: next, exec: exec}
+}
+
+func (r *ResilientSubscriptionRepository) ListSubscriptions(ctx context.Context, filter SubscriptionFilter, page PageRequest) ([]models.Subscription, Pagination, error) {
+	var subscriptions []models.Subscription
+	var pagination Pagination
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		subscriptions, pagination, err = r.next.ListSubscriptions(ctx, filter, page)
+		return err
+	})
+	return subscriptions, pagination, err
+}
+
+func (r *ResilientSubscriptionRepository) GetSubscription(ctx context.Context, id string) (*models.Subscription, error) {
+	return r.one(ctx, resilience.RetryIdempotent, func(ctx context.Context) (*models.Subscription, error) {
+		return r.next.GetSubscription(ctx, id)
+	})
+}
+
+// CreateSubscription is not idempotent: repeating it after a lost
+// connection could create the subscription twice.
+func (r *ResilientSubscriptionRepository) CreateSubscription(ctx context.Context, id string, product *models.Product) (*models.Subscription, error) {
+	return r.one(ctx, resilience.RetryTransactional, func(ctx context.Context) (*models.Subscription, error) {
+		return r.next.CreateSubscription(ctx, id, product)
+	})
+}
+
+// The state changes are guarded by the version; repeating one that did
+// commit would report a conflict rather than apply it twice, so they are
+// only retried when it certainly did not.
+
+func (r *ResilientSubscriptionRepository) PauseSubscription(ctx context.Context, id string, version int) (*models.Subscription, error) {
+	return r.one(ctx, resilience.RetryTransactional, func(ctx context.Context) (*models.Subscription, error) {
+		return r.next.PauseSubscription(ctx, id, version)
+	})
+}
+
+func (r *ResilientSubscriptionRepository) UnpauseSubscription(ctx context.Context, id string, version int) (*models.Subscription, error) {
+	return r.one(ctx, resilience.RetryTransactional, func(ctx context.Context) (*models.Subscription, error) {
+		return r.next.UnpauseSubscription(ctx, id, version)
+	})
+}
+
+func (r *ResilientSubscriptionRepository) CancelSubscription(ctx context.Context, id string, version int) (*models.Subscription, error) {
+	return r.one(ctx, resilience.RetryTransactional, func(ctx context.Context) (*models.Subscription, error) {
+		return r.next.CancelSubscription(ctx, id, version)
+	})
+}
+
+// ExpireSubscriptions only expires what has ended, so a repeated sweep
+// picks up where the failed one stopped.
+func (r *ResilientSubscriptionRepository) ExpireSubscriptions(ctx context.Context, now time.Time) (int, error) {
+	var expired int
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		expired, err = r.next.ExpireSubscriptions(ctx, now)
+		return err
+	})
+	return expired, err
+}
+
+func (r *ResilientSubscriptionRepository) one(ctx context.Context, retry resilience.Retry, fn func(context.Context) (*models.Subscription, error)) (*models.Subscription, error) {
+	var sub *models.Subscription
+	err := r.exec.Do(ctx, retry, func(ctx context.Context) (err error) {
+		sub, err = fn(ctx)
+		return err
+	})
+	return sub, err
+}
+
+type ResilientTranslationRepository struct {
+	next TranslationRepository
+	exec *resilience.Executor
+}
+
+func NewResilientTranslationRepository(next TranslationRepository, exec *resilience.Executor) TranslationRepository {
+	return &ResilientTranslationRepository{next: next, exec: exec}
+}
+
+func (r *ResilientTranslationRepository) ListTranslations(ctx context.Context, productID string) ([]models.ProductTranslation, error) {
+	var translations []models.ProductTranslation
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		translations, err = r.next.ListTranslations(ctx, productID)
+		return err
+	})
+	return translations, err
+}
+
+// UpsertTranslation writes the same values however often it runs.
+func (r *ResilientTranslationRepository) UpsertTranslation(ctx context.Context, translation *models.ProductTranslation) (*models.ProductTranslation, error) {
+	var saved *models.ProductTranslation
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		saved, err = r.next.UpsertTranslation(ctx, translation)
+		return err
+	})
+	return saved, err
+}
+
+// DeleteTranslation repeated after it committed would report not found.
+func (r *ResilientTranslationRepository) DeleteTranslation(ctx context.Context, productID, locale string) error {
+	return r.exec.Do(ctx, resilience.RetryTransactional, func(ctx context.Context) error {
+		return r.next.DeleteTranslation(ctx, productID, locale)
+	})
+}
+
+func (r *ResilientTranslationRepository) Localize(ctx context.Context, locales []string, products ...*models.Product) error {
+	return r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) error {
+		return r.next.Localize(ctx, locales, products...)
+	})
+}
+
+type ResilientWebhookRepository struct {
+	next WebhookRepository
+	exec *resilience.Executor
+}
+
+func NewResilientWebhookRepository(next WebhookRepository, exec *resilience.Executor) WebhookRepository {
+	return &ResilientWebhookRepository{next: next, exec: exec}
+}
+
+// CreateEndpoint is not idempotent: every attempt creates a new endpoint.
+func (r *ResilientWebhookRepository) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) (*models.WebhookEndpoint, error) {
+	var created *models.WebhookEndpoint
+	err := r.exec.Do(ctx, resilience.RetryTransactional, func(ctx context.Context) (err error) {
+		created, err = r.next.CreateEndpoint(ctx, endpoint)
+		return err
+	})
+	return created, err
+}
+
+func (r *ResilientWebhookRepository) ListEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		endpoints, err = r.next.ListEndpoints(ctx)
+		return err
+	})
+	return endpoints, err
+}
+
+func (r *ResilientWebhookRepository) GetEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	var endpoint *models.WebhookEndpoint
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		endpoint, err = r.next.GetEndpoint(ctx, id)
+		return err
+	})
+	return endpoint, err
+}
+
+// UpdateEndpoint sets the same values however often it runs.
+func (r *ResilientWebhookRepository) UpdateEndpoint(ctx context.Context, id string, changes WebhookEndpointChanges) (*models.WebhookEndpoint, error) {
+	var endpoint *models.WebhookEndpoint
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		endpoint, err = r.next.UpdateEndpoint(ctx, id, changes)
+		return err
+	})
+	return endpoint, err
+}
+
+// DeleteEndpoint repeated after it committed would report not found.
+func (r *ResilientWebhookRepository) DeleteEndpoint(ctx context.Context, id string) error {
+	return r.exec.Do(ctx, resilience.RetryTransactional, func(ctx context.Context) error {
+		return r.next.DeleteEndpoint(ctx, id)
+	})
+}
+
+func (r *ResilientWebhookRepository) ListDeliveries(ctx context.Context, endpointID string, filter WebhookDeliveryFilter, page PageRequest) ([]models.WebhookDelivery, Pagination, error) {
+	var deliveries []models.WebhookDelivery
+	var pagination Pagination
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		deliveries, pagination, err = r.next.ListDeliveries(ctx, endpointID, filter, page)
+		return err
+	})
+	return deliveries, pagination, err
+}
+
+func (r *ResilientWebhookRepository) GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	var delivery *models.WebhookDelivery
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		delivery, err = r.next.GetDelivery(ctx, id)
+		return err
+	})
+	return delivery, err
+}
+
+// Redeliver only ever queues the delivery once more, however often it runs.
+func (r *ResilientWebhookRepository) Redeliver(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	var delivery *models.WebhookDelivery
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		delivery, err = r.next.Redeliver(ctx, id)
+		return err
+	})
+	return delivery, err
+}
+
+type ResilientEventRepository struct {
+	next EventRepository
+	exec *resilience.Executor
+}
+
+func NewResilientEventRepository(next EventRepository, exec *resilience.Executor) EventRepository {
+	return &ResilientEventRepository{next: next, exec: exec}
+}
+
+func (r *ResilientEventRepository) EventsAfter(ctx context.Context, subject uuid.UUID, after string, limit int) ([]events.Event, error) {
+	var found []events.Event
+	err := r.exec.Do(ctx, resilience.RetryIdempotent, func(ctx context.Context) (err error) {
+		found, err = r.next.EventsAfter(ctx, subject, after, limit)
+		return err
+	})
+	return found, err
+}
@@ -1,49 +1,131 @@
 package repositories
 
 import (
+	"context"
 	"errors"
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/events"
 	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/outbox"
+	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
-	ErrInvalidSubscriptionID  = errors.New("invalid subscription ID format")
-	ErrSubscriptionNotFound   = errors.New("error not found")
-	ErrCannotPause            = errors.New("subscription cannot be paused")
-	ErrCannotUnpause          = errors.New("subscription cannot be unpaused")
-	ErrCannotCancel           = errors.New("subscription cannot be cancelled")
-	ErrProductRequired        = errors.New("product reference required")
-	ErrInvalidProductDuration = errors.New("product duration must be positive")
-	ErrConcurrentModification = errors.New("subscription was modified by another request")
+	ErrInvalidSubscriptionID  = apperrors.New(apperrors.CodeInvalidID, http.StatusBadRequest, "invalid subscription ID format")
+	ErrSubscriptionNotFound   = apperrors.New(apperrors.CodeNotFound, http.StatusNotFound, "subscription not found")
+	ErrCannotPause            = apperrors.New(apperrors.CodeInvalidState, http.StatusConflict, "subscription cannot be paused")
+	ErrCannotUnpause          = apperrors.New(apperrors.CodeInvalidState, http.StatusConflict, "subscription cannot be unpaused")
+	ErrCannotCancel           = apperrors.New(apperrors.CodeInvalidState, http.StatusConflict, "subscription cannot be cancelled")
+	ErrProductRequired        = apperrors.New(apperrors.CodeBadRequest, http.StatusBadRequest, "product reference required")
+	ErrInvalidProductDuration = apperrors.New(apperrors.CodeInvalidState, http.StatusUnprocessableEntity, "product duration must be positive")
+	ErrConcurrentModification = apperrors.New(apperrors.CodeConcurrentModification, http.StatusConflict, "subscription was modified by another request")
 )
 
+// SubscriptionFilter narrows ListSubscriptions; zero values match everything.
+type SubscriptionFilter struct {
+	UserID    string
+	ProductID string
+	Status    models.SubscriptionStatus
+}
+
+type SubscriptionEvent string
+
+const (
+	EventSubscriptionCreated   SubscriptionEvent = "created"
+	EventSubscriptionPaused    SubscriptionEvent = "paused"
+	EventSubscriptionUnpaused  SubscriptionEvent = "unpaused"
+	EventSubscriptionCancelled SubscriptionEvent = "cancelled"
+	EventSubscriptionExpired   SubscriptionEvent = "expired"
+)
+
+var eventTypes = map[SubscriptionEvent]string{
+	EventSubscriptionCreated:   events.TypeSubscriptionCreated,
+	EventSubscriptionPaused:    events.TypeSubscriptionPaused,
+	EventSubscriptionUnpaused:  events.TypeSubscriptionUnpaused,
+	EventSubscriptionCancelled: events.TypeSubscriptionCancelled,
+	EventSubscriptionExpired:   events.TypeSubscriptionExpired,
+}
+
+// SubscriptionObserver is told about every subscription state change
+// once it has been committed, along with the event recorded for it in
+// the outbox.
+type SubscriptionObserver interface {
+	SubscriptionChanged(event SubscriptionEvent, subscription *models.Subscription, recorded events.Event)
+}
+
 type SubscriptionRepository interface {
-	GetSubscription(id string) (*models.Subscription, error)
-	CreateSubscription(id string, product *models.Product) (*models.Subscription, error)
-	PauseSubscription(id string, version int) (*models.Subscription, error)
-	UnpauseSubscription(id string, version int) (*models.Subscription, error)
-	CancelSubscription(id string, version int) (*models.Subscription, error)
+	ListSubscriptions(ctx context.Context, filter SubscriptionFilter, page PageRequest) ([]models.Subscription, Pagination, error)
+	GetSubscription(ctx context.Context, id string) (*models.Subscription, error)
+	CreateSubscription(ctx context.Context, id string, product *models.Product) (*models.Subscription, error)
+	PauseSubscription(ctx context.Context, id string, version int) (*models.Subscription, error)
+	UnpauseSubscription(ctx context.Context, id string, version int) (*models.Subscription, error)
+	CancelSubscription(ctx context.Context, id string, version int) (*models.Subscription, error)
+	ExpireSubscriptions(ctx context.Context, now time.Time) (int, error)
 }
 
 type SubscriptionRepositoryImpl struct {
-	db *gorm.DB
+	db        *gorm.DB
+	observers []SubscriptionObserver
 }
 
-func NewSubscriptionRepository(db *gorm.DB) SubscriptionRepository {
-	return &SubscriptionRepositoryImpl{db: db}
+func NewSubscriptionRepository(db *gorm.DB, observers ...SubscriptionObserver) SubscriptionRepository {
+	return &SubscriptionRepositoryImpl{db: db, observers: observers}
 }
 
-func (r *SubscriptionRepositoryImpl) GetSubscription(id string) (*models.Subscription, error) {
+// publish adds the event for a change of subscription to the outbox
+// through tx, the transaction making the change, so the event is
+// published if and only if the change commits. It returns the stored
+// event for notify.
+func publish(tx *gorm.DB, event SubscriptionEvent, subscription *models.Subscription, at time.Time) (events.Event, error) {
+	e := events.NewSubscriptionEvent(eventTypes[event], subscription, at)
+	return e, outbox.Append(tx, e)
+}
+
+func (r *SubscriptionRepositoryImpl) notify(event SubscriptionEvent, subscription *models.Subscription, recorded events.Event) {
+	for _, o := range r.observers {
+		o.SubscriptionChanged(event, subscription, recorded)
+	}
+}
+
+func (r *SubscriptionRepositoryImpl) ListSubscriptions(ctx context.Context, filter SubscriptionFilter, page PageRequest) ([]models.Subscription, Pagination, error) {
+	query := r.db.WithContext(ctx).Model(&models.Subscription{}).Preload("Product")
+
+	if filter.UserID != "" {
+		userID, err := uuid.Parse(filter.UserID)
+		if err != nil {
+			return nil, Pagination{}, ErrInvalidSubscriptionID
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+	if filter.ProductID != "" {
+		productID, err := uuid.Parse(filter.ProductID)
+		if err != nil {
+			return nil, Pagination{}, ErrInvalidProductID
+		}
+		query = query.Where("product_id = ?", productID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	return paginate(query, page, defaultOrdering, func(s *models.Subscription) (any, uuid.UUID) {
+		return s.CreatedAt, s.ID
+	})
+}
+
+func (r *SubscriptionRepositoryImpl) GetSubscription(ctx context.Context, id string) (*models.Subscription, error) {
 	subID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, ErrInvalidSubscriptionID
 	}
 
 	var subscription models.Subscription
-	result := r.db.Preload("Product").First(&subscription, "id = ?", subID)
+	result := r.db.WithContext(ctx).Preload("Product").First(&subscription, "id = ?", subID)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, ErrSubscriptionNotFound
@@ -51,22 +133,57 @@ func (r *SubscriptionRepositoryImpl) GetSubscription(id string) (*models.Subscri
 		return nil, result.Error
 	}
 
-	// auto-expire if needed
+	// auto-expire if needed; the row read may come from a lagging replica,
+	// so the decision is taken again on the primary
 	if subscription.EndDate.Before(time.Now()) && subscription.Status != models.StatusExpired {
-		err := r.db.Model(&subscription).Updates(map[string]interface{}{
+		return r.expireOnRead(ctx, subID)
+	}
+
+	return &subscription, nil
+}
+
+func (r *SubscriptionRepositoryImpl) expireOnRead(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	var subscription models.Subscription
+	var recorded events.Event
+	expired := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Product").
+			First(&subscription, "id = ?", id).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSubscriptionNotFound
+			}
+			return err
+		}
+
+		now := time.Now()
+		if !subscription.EndDate.Before(now) || subscription.Status == models.StatusExpired {
+			return nil
+		}
+		expired = true
+		if err := tx.Model(&subscription).Omit(clause.Associations).Updates(map[string]interface{}{
 			"status":     models.StatusExpired,
 			"version":    subscription.Version + 1,
-			"updated_at": time.Now(),
-		}).Error
-		if err != nil {
-			return nil, err
+			"updated_at": now,
+		}).Error; err != nil {
+			return err
 		}
+		var err error
+		recorded, err = publish(tx, EventSubscriptionExpired, &subscription, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	if expired {
+		r.notify(EventSubscriptionExpired, &subscription, recorded)
+	}
 	return &subscription, nil
 }
 
-func (r *SubscriptionRepositoryImpl) CreateSubscription(userID string, product *models.Product) (*models.Subscription, error) {
+func (r *SubscriptionRepositoryImpl) CreateSubscription(ctx context.Context, userID string, product *models.Product) (*models.Subscription, error) {
 	if product == nil {
 		return nil, ErrProductRequired
 	}
@@ -91,25 +208,34 @@ func (r *SubscriptionRepositoryImpl) CreateSubscription(userID string, product *
 		UpdatedAt: now,
 	}
 
-	err = r.db.Transaction(func(tx *gorm.DB) error {
+	var recorded events.Event
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(newSub).Error; err != nil {
 			return err
 		}
-		return tx.Preload("Product").First(newSub, "id = ?", newSub.ID).Error
+		if err := tx.Preload("Product").First(newSub, "id = ?", newSub.ID).Error; err != nil {
+			return err
+		}
+		var err error
+		recorded, err = publish(tx, EventSubscriptionCreated, newSub, now)
+		return err
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
+	r.notify(EventSubscriptionCreated, newSub, recorded)
 	return newSub, nil
 }
 
-func (r *SubscriptionRepositoryImpl) PauseSubscription(id string, expectedVersion int) (*models.Subscription, error) {
+func (r *SubscriptionRepositoryImpl) PauseSubscription(ctx context.Context, id string, expectedVersion int) (*models.Subscription, error) {
 	var subscription models.Subscription
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		// Lock the record for update
-		if err := tx.Set("gorm:query_option", "FOR UPDATE").
+	var recorded events.Event
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the record for update; on SQLite, which ignores FOR UPDATE,
+		// the IMMEDIATE transaction already holds the write lock
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
 			Preload("Product").
 			First(&subscription, "id = ?", id).
 			Error; err != nil {
@@ -135,21 +261,29 @@ func (r *SubscriptionRepositoryImpl) PauseSubscription(id string, expectedVersio
 			"updated_at": now,
 		}
 
-		return tx.Model(&subscription).Updates(updates).Error
+		if err := tx.Model(&subscription).Omit(clause.Associations).Updates(updates).Error; err != nil {
+			return err
+		}
+		var err error
+		recorded, err = publish(tx, EventSubscriptionPaused, &subscription, now)
+		return err
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
+	r.notify(EventSubscriptionPaused, &subscription, recorded)
 	return &subscription, nil
 }
 
-func (r *SubscriptionRepositoryImpl) UnpauseSubscription(id string, expectedVersion int) (*models.Subscription, error) {
+func (r *SubscriptionRepositoryImpl) UnpauseSubscription(ctx context.Context, id string, expectedVersion int) (*models.Subscription, error) {
 	var subscription models.Subscription
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		// Lock the record for update
-		if err := tx.Set("gorm:query_option", "FOR UPDATE").
+	var recorded events.Event
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the record for update; on SQLite, which ignores FOR UPDATE,
+		// the IMMEDIATE transaction already holds the write lock
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
 			Preload("Product").
 			First(&subscription, "id = ?", id).
 			Error; err != nil {
@@ -182,21 +316,29 @@ func (r *SubscriptionRepositoryImpl) UnpauseSubscription(id string, expectedVers
 			"updated_at": now,
 		}
 
-		return tx.Model(&subscription).Updates(updates).Error
+		if err := tx.Model(&subscription).Omit(clause.Associations).Updates(updates).Error; err != nil {
+			return err
+		}
+		var err error
+		recorded, err = publish(tx, EventSubscriptionUnpaused, &subscription, now)
+		return err
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
+	r.notify(EventSubscriptionUnpaused, &subscription, recorded)
 	return &subscription, nil
 }
 
-func (r *SubscriptionRepositoryImpl) CancelSubscription(id string, expectedVersion int) (*models.Subscription, error) {
+func (r *SubscriptionRepositoryImpl) CancelSubscription(ctx context.Context, id string, expectedVersion int) (*models.Subscription, error) {
 	var subscription models.Subscription
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		// Lock the record for update
-		if err := tx.Set("gorm:query_option", "FOR UPDATE").
+	var recorded events.Event
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the record for update; on SQLite, which ignores FOR UPDATE,
+		// the IMMEDIATE transaction already holds the write lock
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
 			Preload("Product").
 			First(&subscription, "id = ?", id).
 			Error; err != nil {
@@ -222,12 +364,83 @@ func (r *SubscriptionRepositoryImpl) CancelSubscription(id string, expectedVersi
 			"updated_at":   now,
 		}
 
-		return tx.Model(&subscription).Updates(updates).Error
+		if err := tx.Model(&subscription).Omit(clause.Associations).Updates(updates).Error; err != nil {
+			return err
+		}
+		var err error
+		recorded, err = publish(tx, EventSubscriptionCancelled, &subscription, now)
+		return err
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
+	r.notify(EventSubscriptionCancelled, &subscription, recorded)
 	return &subscription, nil
 }
+
+// expireBatchSize bounds how many subscriptions one sweep round loads.
+const expireBatchSize = 100
+
+// ExpireSubscriptions marks every subscription that ended before now as
+// expired, as GetSubscription does lazily for the one it reads, and
+// returns how many it expired. Subscriptions changed concurrently are
+// left for the next sweep.
+func (r *SubscriptionRepositoryImpl) ExpireSubscriptions(ctx context.Context, now time.Time) (int, error) {
+	expired := 0
+	for {
+		var batch []models.Subscription
+		err := r.db.WithContext(ctx).Preload("Product").
+			Where("status <> ? AND end_date < ?", models.StatusExpired, now).
+			Order("end_date").
+			Limit(expireBatchSize).
+			Find(&batch).Error
+		if err != nil {
+			return expired, err
+		}
+
+		round := 0
+		for i := range batch {
+			subscription := &batch[i]
+			var recorded events.Event
+			changed := false
+			err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				result := tx.Model(&models.Subscription{}).
+					Where("id = ? AND version = ?", subscription.ID, subscription.Version).
+					Updates(map[string]interface{}{
+						"status":     models.StatusExpired,
+						"version":    subscription.Version + 1,
+						"updated_at": now,
+					})
+				if result.Error != nil || result.RowsAffected == 0 {
+					return result.Error
+				}
+
+				changed = true
+				subscription.Status = models.StatusExpired
+				subscription.Version++
+				subscription.UpdatedAt = now
+				var err error
+				recorded, err = publish(tx, EventSubscriptionExpired, subscription, now)
+				return err
+			})
+			if err != nil {
+				return expired, err
+			}
+			if !changed {
+				continue
+			}
+
+			r.notify(EventSubscriptionExpired, subscription, recorded)
+			round++
+		}
+		expired += round
+
+		// a short batch was the last one; a round without progress means
+		// the rest is being changed concurrently
+		if len(batch) < expireBatchSize || round == 0 {
+			return expired, nil
+		}
+	}
+}
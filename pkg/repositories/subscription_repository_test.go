@@ -1,17 +1,18 @@
 package repositories_test
 
 import (
+	"context"
+	"encoding/json"
 	"sync"
 	"testing"
 	"time"
 
-	"gymondo_dz/pkg/database"
+	"gymondo_dz/pkg/events"
 	"gymondo_dz/pkg/models"
 	"gymondo_dz/pkg/repositories"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/suite"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -23,30 +24,21 @@ type SubscriptionRepositoryTestSuite struct {
 }
 
 func (s *SubscriptionRepositoryTestSuite) SetupSuite() {
-	// Setup in-memory SQLite database
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	if err != nil {
-		s.FailNow("Failed to connect to test database")
-	}
-
-	// Run migrations
-	if err := database.AutoMigrate(db, true); err != nil {
-		s.FailNow("Failed to migrate test database")
-	}
-
-	s.db = db
-	s.productRepo = repositories.NewProductRepository(db)
-	s.subRepo = repositories.NewSubscriptionRepository(db)
+	s.productRepo = repositories.NewProductRepository(s.db)
+	s.subRepo = repositories.NewSubscriptionRepository(s.db)
 }
 
 func (s *SubscriptionRepositoryTestSuite) SetupTest() {
 	// Clear all data before each test
+	s.db.Exec("DELETE FROM outbox_events")
 	s.db.Exec("DELETE FROM subscriptions")
 	s.db.Exec("DELETE FROM products")
 }
 
 func TestSubscriptionRepositorySuite(t *testing.T) {
-	suite.Run(t, new(SubscriptionRepositoryTestSuite))
+	forEachBackend(t, func(t *testing.T, db *gorm.DB) {
+		suite.Run(t, &SubscriptionRepositoryTestSuite{db: db})
+	})
 }
 
 func (s *SubscriptionRepositoryTestSuite) seedTestProduct() *models.Product {
@@ -65,7 +57,7 @@ func (s *SubscriptionRepositoryTestSuite) TestCreateSubscription() {
 	userID := uuid.New().String()
 
 	// Test valid creation
-	sub, err := s.subRepo.CreateSubscription(userID, product)
+	sub, err := s.subRepo.CreateSubscription(context.Background(), userID, product)
 	s.NoError(err)
 	s.NotNil(sub)
 	s.Equal(userID, sub.UserID.String())
@@ -103,7 +95,7 @@ func (s *SubscriptionRepositoryTestSuite) TestCreateSubscription() {
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			sub, err := s.subRepo.CreateSubscription(tt.userID, tt.product)
+			sub, err := s.subRepo.CreateSubscription(context.Background(), tt.userID, tt.product)
 			s.Error(err)
 			s.Equal(tt.expectedError, err)
 			s.Nil(sub)
@@ -111,26 +103,77 @@ func (s *SubscriptionRepositoryTestSuite) TestCreateSubscription() {
 	}
 }
 
+func (s *SubscriptionRepositoryTestSuite) TestListSubscriptions() {
+	product := s.seedTestProduct()
+	userID := uuid.New().String()
+
+	var created []*models.Subscription
+	for range 3 {
+		sub, err := s.subRepo.CreateSubscription(context.Background(), userID, product)
+		s.NoError(err)
+		created = append(created, sub)
+	}
+	_, err := s.subRepo.CreateSubscription(context.Background(), uuid.New().String(), product)
+	s.NoError(err)
+
+	filter := repositories.SubscriptionFilter{UserID: userID}
+	firstPage, page, err := s.subRepo.ListSubscriptions(context.Background(), filter, repositories.PageRequest{Limit: 2, IncludeTotal: true})
+	s.NoError(err)
+	s.Len(firstPage, 2)
+	s.Equal(int64(3), *page.Total)
+	s.NotNil(firstPage[0].Product)
+	s.NotEmpty(page.NextCursor)
+
+	secondPage, page, err := s.subRepo.ListSubscriptions(context.Background(), filter, repositories.PageRequest{Limit: 2, Cursor: page.NextCursor})
+	s.NoError(err)
+	s.Len(secondPage, 1)
+	s.Empty(page.NextCursor)
+
+	var ids []uuid.UUID
+	for _, sub := range append(firstPage, secondPage...) {
+		s.Equal(userID, sub.UserID.String())
+		ids = append(ids, sub.ID)
+	}
+	for _, sub := range created {
+		s.Contains(ids, sub.ID)
+	}
+
+	// Status filter
+	_, err = s.subRepo.CancelSubscription(context.Background(), created[0].ID.String(), created[0].Version)
+	s.NoError(err)
+	cancelled, _, err := s.subRepo.ListSubscriptions(context.Background(),
+		repositories.SubscriptionFilter{UserID: userID, Status: models.StatusCancelled},
+		repositories.PageRequest{Page: 1, Limit: 10},
+	)
+	s.NoError(err)
+	s.Len(cancelled, 1)
+	s.Equal(created[0].ID, cancelled[0].ID)
+
+	// Invalid filter
+	_, _, err = s.subRepo.ListSubscriptions(context.Background(), repositories.SubscriptionFilter{UserID: "invalid-uuid"}, repositories.PageRequest{})
+	s.ErrorIs(err, repositories.ErrInvalidSubscriptionID)
+}
+
 func (s *SubscriptionRepositoryTestSuite) TestGetSubscription() {
 	product := s.seedTestProduct()
 	userID := uuid.New().String()
 
 	// Create test subscription
-	sub, err := s.subRepo.CreateSubscription(userID, product)
+	sub, err := s.subRepo.CreateSubscription(context.Background(), userID, product)
 	s.NoError(err)
 
 	// Test successful get
-	retrieved, err := s.subRepo.GetSubscription(sub.ID.String())
+	retrieved, err := s.subRepo.GetSubscription(context.Background(), sub.ID.String())
 	s.NoError(err)
 	s.Equal(sub.ID, retrieved.ID)
 
 	// Test not found
-	_, err = s.subRepo.GetSubscription(uuid.New().String())
+	_, err = s.subRepo.GetSubscription(context.Background(), uuid.New().String())
 	s.Error(err)
 	s.Equal(repositories.ErrSubscriptionNotFound, err)
 
 	// Test invalid ID
-	_, err = s.subRepo.GetSubscription("invalid-uuid")
+	_, err = s.subRepo.GetSubscription(context.Background(), "invalid-uuid")
 	s.Error(err)
 	s.Equal(repositories.ErrInvalidSubscriptionID, err)
 }
@@ -138,41 +181,41 @@ func (s *SubscriptionRepositoryTestSuite) TestGetSubscription() {
 func (s *SubscriptionRepositoryTestSuite) TestPauseUnpauseSubscription() {
 	product := s.seedTestProduct()
 	userID := uuid.New().String()
-	sub, err := s.subRepo.CreateSubscription(userID, product)
+	sub, err := s.subRepo.CreateSubscription(context.Background(), userID, product)
 	s.NoError(err)
 	s.Equal(1, sub.Version)
 
 	// Test pause with correct version
-	pausedSub, err := s.subRepo.PauseSubscription(sub.ID.String(), sub.Version)
+	pausedSub, err := s.subRepo.PauseSubscription(context.Background(), sub.ID.String(), sub.Version)
 	s.NoError(err)
 	s.Equal(models.StatusPaused, pausedSub.Status)
 	s.NotNil(pausedSub.PausedAt)
 	s.Equal(2, pausedSub.Version)
 
 	// Test cannot pause with stale version
-	_, err = s.subRepo.PauseSubscription(sub.ID.String(), 1)
+	_, err = s.subRepo.PauseSubscription(context.Background(), sub.ID.String(), 1)
 	s.Error(err)
 	s.Equal(repositories.ErrConcurrentModification, err)
 
 	// Test cannot pause already paused (even with correct version)
-	_, err = s.subRepo.PauseSubscription(sub.ID.String(), 2)
+	_, err = s.subRepo.PauseSubscription(context.Background(), sub.ID.String(), 2)
 	s.Error(err)
 	s.Equal(repositories.ErrCannotPause, err)
 
 	// Test unpause with correct version
-	unpausedSub, err := s.subRepo.UnpauseSubscription(sub.ID.String(), 2)
+	unpausedSub, err := s.subRepo.UnpauseSubscription(context.Background(), sub.ID.String(), 2)
 	s.NoError(err)
 	s.Equal(models.StatusActive, unpausedSub.Status)
 	s.Nil(unpausedSub.PausedAt)
 	s.Equal(3, unpausedSub.Version)
 
 	// Test cannot unpause with stale version
-	_, err = s.subRepo.UnpauseSubscription(sub.ID.String(), 2)
+	_, err = s.subRepo.UnpauseSubscription(context.Background(), sub.ID.String(), 2)
 	s.Error(err)
 	s.Equal(repositories.ErrConcurrentModification, err)
 
 	// Test cannot unpause active (even with correct version)
-	_, err = s.subRepo.UnpauseSubscription(sub.ID.String(), 3)
+	_, err = s.subRepo.UnpauseSubscription(context.Background(), sub.ID.String(), 3)
 	s.Error(err)
 	s.Equal(repositories.ErrCannotUnpause, err)
 }
@@ -180,24 +223,24 @@ func (s *SubscriptionRepositoryTestSuite) TestPauseUnpauseSubscription() {
 func (s *SubscriptionRepositoryTestSuite) TestCancelSubscription() {
 	product := s.seedTestProduct()
 	userID := uuid.New().String()
-	sub, err := s.subRepo.CreateSubscription(userID, product)
+	sub, err := s.subRepo.CreateSubscription(context.Background(), userID, product)
 	s.NoError(err)
 	s.Equal(1, sub.Version)
 
 	// Test cancel with correct version
-	cancelledSub, err := s.subRepo.CancelSubscription(sub.ID.String(), 1)
+	cancelledSub, err := s.subRepo.CancelSubscription(context.Background(), sub.ID.String(), 1)
 	s.NoError(err)
 	s.Equal(models.StatusCancelled, cancelledSub.Status)
 	s.NotNil(cancelledSub.CancelledAt)
 	s.Equal(2, cancelledSub.Version)
 
 	// Test cannot cancel with stale version
-	_, err = s.subRepo.CancelSubscription(sub.ID.String(), 1)
+	_, err = s.subRepo.CancelSubscription(context.Background(), sub.ID.String(), 1)
 	s.Error(err)
 	s.Equal(repositories.ErrConcurrentModification, err)
 
 	// Test cannot cancel already cancelled (even with correct version)
-	_, err = s.subRepo.CancelSubscription(sub.ID.String(), 2)
+	_, err = s.subRepo.CancelSubscription(context.Background(), sub.ID.String(), 2)
 	s.Error(err)
 	s.Equal(repositories.ErrCannotCancel, err)
 }
@@ -205,7 +248,7 @@ func (s *SubscriptionRepositoryTestSuite) TestCancelSubscription() {
 func (s *SubscriptionRepositoryTestSuite) TestAutoExpiration() {
 	product := s.seedTestProduct()
 	userID := uuid.New().String()
-	sub, err := s.subRepo.CreateSubscription(userID, product)
+	sub, err := s.subRepo.CreateSubscription(context.Background(), userID, product)
 	s.NoError(err)
 	originalVersion := sub.Version
 
@@ -217,7 +260,7 @@ func (s *SubscriptionRepositoryTestSuite) TestAutoExpiration() {
 		})
 
 	// Test auto-expiration on get
-	retrieved, err := s.subRepo.GetSubscription(sub.ID.String())
+	retrieved, err := s.subRepo.GetSubscription(context.Background(), sub.ID.String())
 	s.NoError(err)
 	s.Equal(models.StatusExpired, retrieved.Status)
 	s.Equal(originalVersion+1, retrieved.Version)
@@ -226,13 +269,13 @@ func (s *SubscriptionRepositoryTestSuite) TestAutoExpiration() {
 func (s *SubscriptionRepositoryTestSuite) TestUnpauseExtendsSubscription() {
 	product := s.seedTestProduct()
 	userID := uuid.New().String()
-	sub, err := s.subRepo.CreateSubscription(userID, product)
+	sub, err := s.subRepo.CreateSubscription(context.Background(), userID, product)
 	s.NoError(err)
 	s.Equal(1, sub.Version)
 
 	// Pause the subscription and record time
 	beforePause := time.Now()
-	pausedSub, err := s.subRepo.PauseSubscription(sub.ID.String(), 1)
+	pausedSub, err := s.subRepo.PauseSubscription(context.Background(), sub.ID.String(), 1)
 	s.NoError(err)
 	s.Equal(2, pausedSub.Version)
 
@@ -247,7 +290,7 @@ func (s *SubscriptionRepositoryTestSuite) TestUnpauseExtendsSubscription() {
 	beforeUnpause := time.Now()
 
 	// Unpause
-	unpausedSub, err := s.subRepo.UnpauseSubscription(sub.ID.String(), 2)
+	unpausedSub, err := s.subRepo.UnpauseSubscription(context.Background(), sub.ID.String(), 2)
 	s.NoError(err)
 	s.Equal(3, unpausedSub.Version)
 
@@ -257,34 +300,34 @@ func (s *SubscriptionRepositoryTestSuite) TestUnpauseExtendsSubscription() {
 	s.Equal(models.StatusActive, unpausedSub.Status)
 	s.Nil(unpausedSub.PausedAt)
 
-	_, err = s.subRepo.UnpauseSubscription(pausedSub.ID.String(), 2) // stale version
+	_, err = s.subRepo.UnpauseSubscription(context.Background(), pausedSub.ID.String(), 2) // stale version
 	s.ErrorIs(err, repositories.ErrConcurrentModification)
 }
 
 func (s *SubscriptionRepositoryTestSuite) TestConcurrentUpdates() {
 	product := s.seedTestProduct()
 	userID := uuid.New().String()
-	sub, _ := s.subRepo.CreateSubscription(userID, product)
+	sub, _ := s.subRepo.CreateSubscription(context.Background(), userID, product)
 
 	// Simulate concurrent update by modifying the version directly in DB
 	s.db.Model(&models.Subscription{}).Where("id = ?", sub.ID).
 		Update("version", sub.Version+1)
 
 	// All operations should fail with ErrConcurrentModification
-	_, err := s.subRepo.PauseSubscription(sub.ID.String(), sub.Version)
+	_, err := s.subRepo.PauseSubscription(context.Background(), sub.ID.String(), sub.Version)
 	s.ErrorIs(err, repositories.ErrConcurrentModification)
 
-	_, err = s.subRepo.UnpauseSubscription(sub.ID.String(), sub.Version)
+	_, err = s.subRepo.UnpauseSubscription(context.Background(), sub.ID.String(), sub.Version)
 	s.ErrorIs(err, repositories.ErrConcurrentModification)
 
-	_, err = s.subRepo.CancelSubscription(sub.ID.String(), sub.Version)
+	_, err = s.subRepo.CancelSubscription(context.Background(), sub.ID.String(), sub.Version)
 	s.ErrorIs(err, repositories.ErrConcurrentModification)
 }
 
 func (s *SubscriptionRepositoryTestSuite) TestConcurrentPauseCancel() {
 	product := s.seedTestProduct()
 	userID := uuid.New().String()
-	sub, _ := s.subRepo.CreateSubscription(userID, product)
+	sub, _ := s.subRepo.CreateSubscription(context.Background(), userID, product)
 
 	// Simulate two concurrent operations
 	var wg sync.WaitGroup
@@ -294,12 +337,12 @@ func (s *SubscriptionRepositoryTestSuite) TestConcurrentPauseCancel() {
 
 	go func() {
 		defer wg.Done()
-		_, pauseErr = s.subRepo.PauseSubscription(sub.ID.String(), sub.Version)
+		_, pauseErr = s.subRepo.PauseSubscription(context.Background(), sub.ID.String(), sub.Version)
 	}()
 
 	go func() {
 		defer wg.Done()
-		_, cancelErr = s.subRepo.CancelSubscription(sub.ID.String(), sub.Version)
+		_, cancelErr = s.subRepo.CancelSubscription(context.Background(), sub.ID.String(), sub.Version)
 	}()
 
 	wg.Wait()
@@ -307,3 +350,192 @@ func (s *SubscriptionRepositoryTestSuite) TestConcurrentPauseCancel() {
 	// Only one should succeed
 	s.True((pauseErr == nil && cancelErr != nil) || (pauseErr != nil && cancelErr == nil))
 }
+
+type recordingObserver struct {
+	events     []repositories.SubscriptionEvent
+	productIDs []uuid.UUID
+	recorded   []events.Event
+}
+
+func (o *recordingObserver) SubscriptionChanged(event repositories.SubscriptionEvent, subscription *models.Subscription, recorded events.Event) {
+	o.events = append(o.events, event)
+	o.productIDs = append(o.productIDs, subscription.ProductID)
+	o.recorded = append(o.recorded, recorded)
+}
+
+func (s *SubscriptionRepositoryTestSuite) TestObserverNotifiedOfStateChanges() {
+	observer := &recordingObserver{}
+	repo := repositories.NewSubscriptionRepository(s.db, observer)
+	product := s.seedTestProduct()
+
+	sub, err := repo.CreateSubscription(context.Background(), uuid.New().String(), product)
+	s.Require().NoError(err)
+	sub, err = repo.PauseSubscription(context.Background(), sub.ID.String(), sub.Version)
+	s.Require().NoError(err)
+	sub, err = repo.UnpauseSubscription(context.Background(), sub.ID.String(), sub.Version)
+	s.Require().NoError(err)
+
+	// a rejected change is not reported
+	_, err = repo.CancelSubscription(context.Background(), sub.ID.String(), 1)
+	s.ErrorIs(err, repositories.ErrConcurrentModification)
+
+	sub, err = repo.CancelSubscription(context.Background(), sub.ID.String(), sub.Version)
+	s.Require().NoError(err)
+
+	s.db.Model(&models.Subscription{}).Where("id = ?", sub.ID).Update("end_date", time.Now().Add(-time.Hour))
+	_, err = repo.GetSubscription(context.Background(), sub.ID.String())
+	s.Require().NoError(err)
+
+	s.Equal([]repositories.SubscriptionEvent{
+		repositories.EventSubscriptionCreated,
+		repositories.EventSubscriptionPaused,
+		repositories.EventSubscriptionUnpaused,
+		repositories.EventSubscriptionCancelled,
+		repositories.EventSubscriptionExpired,
+	}, observer.events)
+
+	// state changes must not re-save the preloaded product
+	for _, id := range observer.productIDs {
+		s.Equal(product.ID, id)
+	}
+	var products int64
+	s.db.Model(&models.Product{}).Count(&products)
+	s.Equal(int64(1), products)
+
+	// observers see the events exactly as the outbox stored them
+	var stored []models.OutboxEvent
+	s.Require().NoError(s.db.Where("aggregate_id = ?", sub.ID).Order("position").Find(&stored).Error)
+	s.Require().Len(observer.recorded, len(stored))
+	for i, e := range observer.recorded {
+		s.Equal(stored[i].ID, e.ID)
+		s.Equal(stored[i].Sequence, e.Sequence)
+	}
+}
+
+func (s *SubscriptionRepositoryTestSuite) TestChangesWriteOutboxEvents() {
+	ctx := context.Background()
+	product := s.seedTestProduct()
+
+	sub, err := s.subRepo.CreateSubscription(ctx, uuid.New().String(), product)
+	s.Require().NoError(err)
+	sub, err = s.subRepo.PauseSubscription(ctx, sub.ID.String(), sub.Version)
+	s.Require().NoError(err)
+	_, err = s.subRepo.PauseSubscription(ctx, sub.ID.String(), sub.Version)
+	s.ErrorIs(err, repositories.ErrCannotPause, "a rejected change writes no event")
+	sub, err = s.subRepo.UnpauseSubscription(ctx, sub.ID.String(), sub.Version)
+	s.Require().NoError(err)
+	sub, err = s.subRepo.CancelSubscription(ctx, sub.ID.String(), sub.Version)
+	s.Require().NoError(err)
+	s.db.Model(&models.Subscription{}).Where("id = ?", sub.ID).Update("end_date", time.Now().Add(-time.Hour))
+	_, err = s.subRepo.ExpireSubscriptions(ctx, time.Now())
+	s.Require().NoError(err)
+
+	var stored []models.OutboxEvent
+	s.Require().NoError(s.db.Order("position").Find(&stored).Error)
+	var types []string
+	for i, e := range stored {
+		types = append(types, e.Type)
+		s.Equal(sub.ID, e.AggregateID)
+		s.Equal(i+1, e.Sequence, "sequence follows the subscription version")
+		s.Nil(e.PublishedAt)
+	}
+	s.Equal([]string{
+		events.TypeSubscriptionCreated,
+		events.TypeSubscriptionPaused,
+		events.TypeSubscriptionUnpaused,
+		events.TypeSubscriptionCancelled,
+		events.TypeSubscriptionExpired,
+	}, types)
+
+	var paused events.Event
+	s.Require().NoError(json.Unmarshal([]byte(stored[1].Payload), &paused))
+	s.Equal(stored[1].ID, paused.ID)
+	s.Equal(events.SchemaVersion, paused.SchemaVersion)
+	s.Equal(models.StatusPaused, paused.Data.Status)
+	s.NotNil(paused.Data.PausedAt)
+	s.Equal(2, paused.Data.Version)
+	s.Equal(product.Name, paused.Data.Product.Name)
+}
+
+func (s *SubscriptionRepositoryTestSuite) TestChangeRolledBackWithItsEvent() {
+	ctx := context.Background()
+	sub, err := s.subRepo.CreateSubscription(ctx, uuid.New().String(), s.seedTestProduct())
+	s.Require().NoError(err)
+
+	// an event already holding the next sequence makes storing the pause event fail
+	s.Require().NoError(s.db.Create(&models.OutboxEvent{
+		ID: uuid.New(), AggregateID: sub.ID, Sequence: sub.Version + 1, Type: events.TypeSubscriptionPaused,
+		Payload: "{}", CreatedAt: time.Now(), NextAttemptAt: time.Now(),
+	}).Error)
+
+	_, err = s.subRepo.PauseSubscription(ctx, sub.ID.String(), sub.Version)
+	s.Error(err)
+
+	var stored models.Subscription
+	s.Require().NoError(s.db.First(&stored, "id = ?", sub.ID).Error)
+	s.Equal(models.StatusActive, stored.Status)
+	s.Equal(sub.Version, stored.Version)
+}
+
+func (s *SubscriptionRepositoryTestSuite) TestExpireSubscriptions() {
+	ctx := context.Background()
+	product := s.seedTestProduct()
+	now := time.Now()
+
+	var ended []*models.Subscription
+	for range 3 {
+		sub, err := s.subRepo.CreateSubscription(ctx, uuid.New().String(), product)
+		s.Require().NoError(err)
+		s.db.Model(&models.Subscription{}).Where("id = ?", sub.ID).Update("end_date", now.Add(-time.Hour))
+		ended = append(ended, sub)
+	}
+	running, err := s.subRepo.CreateSubscription(ctx, uuid.New().String(), product)
+	s.Require().NoError(err)
+
+	n, err := s.subRepo.ExpireSubscriptions(ctx, now)
+	s.Require().NoError(err)
+	s.Equal(3, n)
+
+	for _, sub := range ended {
+		var stored models.Subscription
+		s.Require().NoError(s.db.First(&stored, "id = ?", sub.ID).Error)
+		s.Equal(models.StatusExpired, stored.Status)
+		s.Equal(sub.Version+1, stored.Version)
+	}
+	var stored models.Subscription
+	s.Require().NoError(s.db.First(&stored, "id = ?", running.ID).Error)
+	s.Equal(models.StatusActive, stored.Status)
+
+	n, err = s.subRepo.ExpireSubscriptions(ctx, now)
+	s.Require().NoError(err)
+	s.Zero(n, "a second sweep finds nothing left to expire")
+}
+
+// Writers racing for the same version must lose with a conflict, never
+// with a lock error, on every backend.
+func (s *SubscriptionRepositoryTestSuite) TestConcurrentWritersSerialize() {
+	product := s.seedTestProduct()
+	sub, err := s.subRepo.CreateSubscription(context.Background(), uuid.New().String(), product)
+	s.Require().NoError(err)
+
+	errs := make([]error, 10)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = s.subRepo.CancelSubscription(context.Background(), sub.ID.String(), sub.Version)
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		s.ErrorIs(err, repositories.ErrConcurrentModification)
+	}
+	s.Equal(1, succeeded)
+}
@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/i18n"
+	"gymondo_dz/pkg/models"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTranslationNotFound = apperrors.New(apperrors.CodeNotFound, http.StatusNotFound, "translation not found")
+	ErrUnsupportedLocale   = apperrors.New(apperrors.CodeValidation, http.StatusBadRequest, "unsupported locale")
+)
+
+type TranslationRepository interface {
+	ListTranslations(ctx context.Context, productID string) ([]models.ProductTranslation, error)
+	UpsertTranslation(ctx context.Context, translation *models.ProductTranslation) (*models.ProductTranslation, error)
+	DeleteTranslation(ctx context.Context, productID, locale string) error
+	// Localize overwrites each product's name and description with the
+	// first translation found along the locale fallback chain.
+	Localize(ctx context.Context, locales []string, products ...*models.Product) error
+}
+
+type TranslationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTranslationRepository(db *gorm.DB) TranslationRepository {
+	return &TranslationRepositoryImpl{db: db}
+}
+
+func (r *TranslationRepositoryImpl) ListTranslations(ctx context.Context, productID string) ([]models.ProductTranslation, error) {
+	id, err := r.existingProductID(r.db.WithContext(ctx), productID)
+	if err != nil {
+		return nil, err
+	}
+
+	var translations []models.ProductTranslation
+	if err := r.db.WithContext(ctx).Where("product_id = ?", id).Order("locale ASC").Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+func (r *TranslationRepositoryImpl) UpsertTranslation(ctx context.Context, translation *models.ProductTranslation) (*models.ProductTranslation, error) {
+	if !i18n.IsTranslatable(translation.Locale) {
+		return nil, ErrUnsupportedLocale
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := r.existingProductID(tx, translation.ProductID.String()); err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "locale"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "description", "updated_at"}),
+		}).Create(translation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return translation, nil
+}
+
+func (r *TranslationRepositoryImpl) DeleteTranslation(ctx context.Context, productID, locale string) error {
+	id, err := uuid.Parse(productID)
+	if err != nil {
+		return ErrInvalidProductID
+	}
+
+	result := r.db.WithContext(ctx).Where("product_id = ? AND locale = ?", id, locale).Delete(&models.ProductTranslation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTranslationNotFound
+	}
+	return nil
+}
+
+func (r *TranslationRepositoryImpl) Localize(ctx context.Context, locales []string, products ...*models.Product) error {
+	// everything before the default locale in the chain is worth looking up
+	var wanted []string
+	for _, locale := range locales {
+		if locale == i18n.DefaultLocale {
+			break
+		}
+		wanted = append(wanted, locale)
+	}
+
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, p := range products {
+		if p != nil {
+			p.Locale = i18n.DefaultLocale
+			ids = append(ids, p.ID)
+		}
+	}
+	if len(wanted) == 0 || len(ids) == 0 {
+		return nil
+	}
+
+	var rows []models.ProductTranslation
+	if err := r.db.WithContext(ctx).Where("product_id IN ? AND locale IN ?", ids, wanted).Find(&rows).Error; err != nil {
+		return err
+	}
+
+	byProduct := make(map[uuid.UUID]map[string]models.ProductTranslation, len(rows))
+	for _, row := range rows {
+		if byProduct[row.ProductID] == nil {
+			byProduct[row.ProductID] = make(map[string]models.ProductTranslation)
+		}
+		byProduct[row.ProductID][row.Locale] = row
+	}
+
+	for _, p := range products {
+		if p == nil {
+			continue
+		}
+		for _, locale := range wanted {
+			if t, ok := byProduct[p.ID][locale]; ok {
+				p.Name = t.Name
+				if t.Description != "" {
+					p.Description = t.Description
+				}
+				p.Locale = locale
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// LocalizeProducts translates products into the best of locales, as
+// negotiated by i18n.Negotiate, and returns the locales they ended up in
+// for a Content-Language header. Every API localizes through it.
+func LocalizeProducts(ctx context.Context, translations TranslationRepository, locales []string, products ...*models.Product) (string, error) {
+	if locales[0] == i18n.DefaultLocale {
+		return i18n.DefaultLocale, nil
+	}
+
+	if err := translations.Localize(ctx, locales, products...); err != nil {
+		return "", err
+	}
+
+	var used []string
+	for _, p := range products {
+		if p != nil && p.Locale != "" && !slices.Contains(used, p.Locale) {
+			used = append(used, p.Locale)
+		}
+	}
+	if len(used) == 0 {
+		used = append(used, i18n.DefaultLocale)
+	}
+	return strings.Join(used, ", "), nil
+}
+
+func (r *TranslationRepositoryImpl) existingProductID(db *gorm.DB, productID string) (uuid.UUID, error) {
+	id, err := uuid.Parse(productID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidProductID
+	}
+
+	var product models.Product
+	if err := db.Select("id").First(&product, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, ErrProductNotFound
+		}
+		return uuid.Nil, err
+	}
+	return id, nil
+}
@@ -0,0 +1,145 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type TranslationRepositoryTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	repo    repositories.TranslationRepository
+	product *models.Product
+}
+
+func (s *TranslationRepositoryTestSuite) SetupSuite() {
+	s.repo = repositories.NewTranslationRepository(s.db)
+}
+
+func (s *TranslationRepositoryTestSuite) SetupTest() {
+	s.db.Exec("DELETE FROM product_translations")
+	s.db.Exec("DELETE FROM subscriptions")
+	s.db.Exec("DELETE FROM products")
+
+	s.product = &models.Product{
+		Name:        "Monthly Plan",
+		Description: "1 month subscription",
+		Duration:    models.DurationMonth,
+		Price:       9.99,
+	}
+	s.NoError(s.db.Create(s.product).Error)
+}
+
+func TestTranslationRepositorySuite(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db *gorm.DB) {
+		suite.Run(t, &TranslationRepositoryTestSuite{db: db})
+	})
+}
+
+func (s *TranslationRepositoryTestSuite) TestUpsertAndListTranslations() {
+	_, err := s.repo.UpsertTranslation(context.Background(), &models.ProductTranslation{
+		ProductID: s.product.ID, Locale: "de", Name: "Monatsabo", Description: "1 Monat",
+	})
+	s.NoError(err)
+
+	// Upserting the same locale replaces the content
+	_, err = s.repo.UpsertTranslation(context.Background(), &models.ProductTranslation{
+		ProductID: s.product.ID, Locale: "de", Name: "Monatsmitgliedschaft",
+	})
+	s.NoError(err)
+
+	_, err = s.repo.UpsertTranslation(context.Background(), &models.ProductTranslation{
+		ProductID: s.product.ID, Locale: "fr", Name: "Abonnement mensuel",
+	})
+	s.NoError(err)
+
+	translations, err := s.repo.ListTranslations(context.Background(), s.product.ID.String())
+	s.NoError(err)
+	s.Len(translations, 2)
+	s.Equal("de", translations[0].Locale)
+	s.Equal("Monatsmitgliedschaft", translations[0].Name)
+	s.Equal("fr", translations[1].Locale)
+}
+
+func (s *TranslationRepositoryTestSuite) TestUpsertTranslationErrors() {
+	_, err := s.repo.UpsertTranslation(context.Background(), &models.ProductTranslation{
+		ProductID: s.product.ID, Locale: "en", Name: "Monthly",
+	})
+	s.ErrorIs(err, repositories.ErrUnsupportedLocale)
+
+	_, err = s.repo.UpsertTranslation(context.Background(), &models.ProductTranslation{
+		ProductID: uuid.New(), Locale: "de", Name: "Monatsabo",
+	})
+	s.ErrorIs(err, repositories.ErrProductNotFound)
+
+	_, err = s.repo.ListTranslations(context.Background(), "invalid-uuid")
+	s.ErrorIs(err, repositories.ErrInvalidProductID)
+}
+
+func (s *TranslationRepositoryTestSuite) TestDeleteTranslation() {
+	_, err := s.repo.UpsertTranslation(context.Background(), &models.ProductTranslation{
+		ProductID: s.product.ID, Locale: "es", Name: "Plan mensual",
+	})
+	s.NoError(err)
+
+	s.NoError(s.repo.DeleteTranslation(context.Background(), s.product.ID.String(), "es"))
+	s.ErrorIs(s.repo.DeleteTranslation(context.Background(), s.product.ID.String(), "es"), repositories.ErrTranslationNotFound)
+}
+
+func (s *TranslationRepositoryTestSuite) TestLocalize() {
+	_, err := s.repo.UpsertTranslation(context.Background(), &models.ProductTranslation{
+		ProductID: s.product.ID, Locale: "fr", Name: "Abonnement mensuel",
+	})
+	s.NoError(err)
+
+	other := &models.Product{Name: "Yearly Plan", Description: "1 year subscription", Duration: models.DurationYear, Price: 99.99}
+	s.NoError(s.db.Create(other).Error)
+
+	monthly := *s.product
+	s.NoError(s.repo.Localize(context.Background(), []string{"de", "fr", "en"}, &monthly, other, nil))
+
+	// first available locale along the chain wins, description falls back
+	s.Equal("Abonnement mensuel", monthly.Name)
+	s.Equal("1 month subscription", monthly.Description)
+	s.Equal("fr", monthly.Locale)
+
+	// no translation at all keeps the default content
+	s.Equal("Yearly Plan", other.Name)
+	s.Equal("en", other.Locale)
+
+	// the default locale stops the chain
+	monthly = *s.product
+	s.NoError(s.repo.Localize(context.Background(), []string{"en", "fr"}, &monthly))
+	s.Equal("Monthly Plan", monthly.Name)
+}
+
+func (s *TranslationRepositoryTestSuite) TestLocalizeProducts() {
+	_, err := s.repo.UpsertTranslation(context.Background(), &models.ProductTranslation{
+		ProductID: s.product.ID, Locale: "fr", Name: "Abonnement mensuel",
+	})
+	s.NoError(err)
+
+	other := &models.Product{Name: "Yearly Plan", Description: "1 year subscription", Duration: models.DurationYear, Price: 99.99}
+	s.NoError(s.db.Create(other).Error)
+
+	// every locale the products ended up in is reported
+	monthly := *s.product
+	contentLanguage, err := repositories.LocalizeProducts(context.Background(), s.repo, []string{"fr", "en"}, &monthly, other, nil)
+	s.NoError(err)
+	s.Equal("Abonnement mensuel", monthly.Name)
+	s.Equal("fr, en", contentLanguage)
+
+	// the default locale needs no lookup
+	monthly = *s.product
+	contentLanguage, err = repositories.LocalizeProducts(context.Background(), s.repo, []string{"en", "fr"}, &monthly)
+	s.NoError(err)
+	s.Equal("Monthly Plan", monthly.Name)
+	s.Equal("en", contentLanguage)
+}
@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/webhooks"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrWebhookEndpointNotFound  = apperrors.New(apperrors.CodeNotFound, http.StatusNotFound, "webhook endpoint not found")
+	ErrInvalidWebhookEndpointID = apperrors.New(apperrors.CodeInvalidID, http.StatusBadRequest, "invalid webhook endpoint ID format")
+	ErrWebhookDeliveryNotFound  = apperrors.New(apperrors.CodeNotFound, http.StatusNotFound, "webhook delivery not found")
+	ErrInvalidWebhookDeliveryID = apperrors.New(apperrors.CodeInvalidID, http.StatusBadRequest, "invalid webhook delivery ID format")
+	ErrWebhookEndpointDisabled  = apperrors.New(apperrors.CodeInvalidState, http.StatusConflict, "webhook endpoint is disabled")
+)
+
+// disabledByAdmin is the reason recorded when an endpoint is disabled
+// through the API rather than for failing.
+const disabledByAdmin = "disabled by an administrator"
+
+// WebhookEndpointChanges lists the fields UpdateEndpoint sets; nil fields
+// keep their current value. Enabling an endpoint forgets its failures.
+type WebhookEndpointChanges struct {
+	URL         *string
+	Description *string
+	EventTypes  *models.EventTypes
+	ProductIDs  *models.ProductIDs
+	Enabled     *bool
+}
+
+type WebhookDeliveryFilter struct {
+	Status models.WebhookDeliveryStatus
+}
+
+type WebhookRepository interface {
+	// CreateEndpoint stores a new endpoint with a freshly generated
+	// secret, which is only ever returned here.
+	CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) (*models.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	GetEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, id string, changes WebhookEndpointChanges) (*models.WebhookEndpoint, error)
+	// DeleteEndpoint removes an endpoint with its deliveries.
+	DeleteEndpoint(ctx context.Context, id string) error
+	// ListDeliveries returns an endpoint's deliveries, newest first.
+	ListDeliveries(ctx context.Context, endpointID string, filter WebhookDeliveryFilter, page PageRequest) ([]models.WebhookDelivery, Pagination, error)
+	// GetDelivery returns a delivery with its attempt log.
+	GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error)
+	// Redeliver queues a delivery to be sent again right away, with a
+	// fresh set of attempts, whatever became of it before.
+	Redeliver(ctx context.Context, id string) (*models.WebhookDelivery, error)
+}
+
+type WebhookRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &WebhookRepositoryImpl{db: db}
+}
+
+func (r *WebhookRepositoryImpl) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) (*models.WebhookEndpoint, error) {
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Secret = secret
+	endpoint.Enabled = true
+	if endpoint.EventTypes == nil {
+		endpoint.EventTypes = models.EventTypes{}
+	}
+	if endpoint.ProductIDs == nil {
+		endpoint.ProductIDs = models.ProductIDs{}
+	}
+
+	if err := r.db.WithContext(ctx).Create(endpoint).Error; err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+func (r *WebhookRepositoryImpl) ListEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	endpoints := []models.WebhookEndpoint{}
+	if err := r.db.WithContext(ctx).Order("created_at ASC, id ASC").Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (r *WebhookRepositoryImpl) GetEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	return r.findEndpoint(r.db.WithContext(ctx), id)
+}
+
+func (r *WebhookRepositoryImpl) UpdateEndpoint(ctx context.Context, id string, changes WebhookEndpointChanges) (*models.WebhookEndpoint, error) {
+	var endpoint *models.WebhookEndpoint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		endpoint, err = r.findEndpoint(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if changes.URL != nil {
+			updates["url"] = *changes.URL
+		}
+		if changes.Description != nil {
+			updates["description"] = *changes.Description
+		}
+		if changes.EventTypes != nil {
+			updates["event_types"] = *changes.EventTypes
+		}
+		if changes.ProductIDs != nil {
+			updates["product_ids"] = *changes.ProductIDs
+		}
+		if changes.Enabled != nil && *changes.Enabled != endpoint.Enabled {
+			updates["enabled"] = *changes.Enabled
+			if *changes.Enabled {
+				updates["disabled_at"] = nil
+				updates["disabled_reason"] = ""
+				updates["failing_since"] = nil
+			} else {
+				updates["disabled_at"] = time.Now()
+				updates["disabled_reason"] = disabledByAdmin
+			}
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := tx.Model(endpoint).Updates(updates).Error; err != nil {
+			return err
+		}
+		// reload into a fresh value: scanning leaves pointer fields set
+		// that are now NULL
+		endpoint, err = r.findEndpoint(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+func (r *WebhookRepositoryImpl) DeleteEndpoint(ctx context.Context, id string) error {
+	endpointID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidWebhookEndpointID
+	}
+
+	result := r.db.WithContext(ctx).Delete(&models.WebhookEndpoint{}, "id = ?", endpointID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepositoryImpl) ListDeliveries(ctx context.Context, endpointID string, filter WebhookDeliveryFilter, page PageRequest) ([]models.WebhookDelivery, Pagination, error) {
+	db := r.db.WithContext(ctx)
+	endpoint, err := r.findEndpoint(db, endpointID)
+	if err != nil {
+		return nil, Pagination{}, err
+	}
+
+	query := db.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpoint.ID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	return paginate(query, page, ordering{column: "created_at", desc: true}, func(d *models.WebhookDelivery) (any, uuid.UUID) {
+		return d.CreatedAt, d.ID
+	})
+}
+
+func (r *WebhookRepositoryImpl) GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	deliveryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidWebhookDeliveryID
+	}
+
+	var delivery models.WebhookDelivery
+	err = r.db.WithContext(ctx).
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&delivery, "id = ?", deliveryID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookRepositoryImpl) Redeliver(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	deliveryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidWebhookDeliveryID
+	}
+
+	var delivery models.WebhookDelivery
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, "id = ?", deliveryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrWebhookDeliveryNotFound
+			}
+			return err
+		}
+
+		var endpoint models.WebhookEndpoint
+		if err := tx.Select("enabled").First(&endpoint, "id = ?", delivery.EndpointID).Error; err != nil {
+			return err
+		}
+		if !endpoint.Enabled {
+			return ErrWebhookEndpointDisabled
+		}
+
+		return tx.Model(&delivery).Updates(map[string]interface{}{
+			"status":          models.DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"delivered_at":    nil,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookRepositoryImpl) findEndpoint(db *gorm.DB, id string) (*models.WebhookEndpoint, error) {
+	endpointID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidWebhookEndpointID
+	}
+
+	var endpoint models.WebhookEndpoint
+	if err := db.First(&endpoint, "id = ?", endpointID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookEndpointNotFound
+		}
+		return nil, err
+	}
+	return &endpoint, nil
+}
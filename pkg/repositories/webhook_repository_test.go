@@ -0,0 +1,184 @@
+package repositories_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type WebhookRepositoryTestSuite struct {
+	suite.Suite
+	db   *gorm.DB
+	repo repositories.WebhookRepository
+}
+
+func (s *WebhookRepositoryTestSuite) SetupSuite() {
+	s.repo = repositories.NewWebhookRepository(s.db)
+}
+
+func (s *WebhookRepositoryTestSuite) SetupTest() {
+	s.db.Exec("DELETE FROM webhook_attempts")
+	s.db.Exec("DELETE FROM webhook_deliveries")
+	s.db.Exec("DELETE FROM webhook_endpoints")
+}
+
+func TestWebhookRepositorySuite(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db *gorm.DB) {
+		suite.Run(t, &WebhookRepositoryTestSuite{db: db})
+	})
+}
+
+func (s *WebhookRepositoryTestSuite) createEndpoint(eventTypes ...string) *models.WebhookEndpoint {
+	endpoint, err := s.repo.CreateEndpoint(context.Background(), &models.WebhookEndpoint{
+		URL:        "https://partner.example/hooks",
+		EventTypes: eventTypes,
+	})
+	s.Require().NoError(err)
+	return endpoint
+}
+
+// createDelivery stores a delivery to endpoint that has already failed
+// for good.
+func (s *WebhookRepositoryTestSuite) createDelivery(endpoint *models.WebhookEndpoint, createdAt time.Time) *models.WebhookDelivery {
+	code := 500
+	delivery := &models.WebhookDelivery{
+		EndpointID:       endpoint.ID,
+		EventID:          uuid.New(),
+		EventType:        events.TypeSubscriptionPaused,
+		Payload:          `{}`,
+		Status:           models.DeliveryFailed,
+		Attempts:         3,
+		NextAttemptAt:    createdAt,
+		LastResponseCode: &code,
+		CreatedAt:        createdAt,
+	}
+	s.Require().NoError(s.db.Create(delivery).Error)
+	s.Require().NoError(s.db.Create(&models.WebhookAttempt{
+		DeliveryID: delivery.ID, AttemptedAt: createdAt, ResponseCode: &code, DurationMS: 12,
+	}).Error)
+	return delivery
+}
+
+func (s *WebhookRepositoryTestSuite) TestCreateEndpoint() {
+	endpoint := s.createEndpoint(events.TypeSubscriptionCreated)
+	s.True(strings.HasPrefix(endpoint.Secret, "whsec_"))
+	s.True(endpoint.Enabled)
+
+	stored, err := s.repo.GetEndpoint(context.Background(), endpoint.ID.String())
+	s.Require().NoError(err)
+	s.Equal(endpoint.Secret, stored.Secret)
+	s.Equal(models.EventTypes{events.TypeSubscriptionCreated}, stored.EventTypes)
+	s.Equal(models.ProductIDs{}, stored.ProductIDs)
+
+	other := s.createEndpoint()
+	s.NotEqual(endpoint.Secret, other.Secret)
+	s.Equal(models.EventTypes{}, other.EventTypes)
+
+	endpoints, err := s.repo.ListEndpoints(context.Background())
+	s.Require().NoError(err)
+	s.Len(endpoints, 2)
+}
+
+func (s *WebhookRepositoryTestSuite) TestUpdateEndpoint() {
+	endpoint := s.createEndpoint()
+	url := "https://partner.example/v2/hooks"
+	types := models.EventTypes{events.TypeSubscriptionCancelled}
+	products := models.ProductIDs{uuid.New(), uuid.New()}
+	disabled := false
+
+	updated, err := s.repo.UpdateEndpoint(context.Background(), endpoint.ID.String(), repositories.WebhookEndpointChanges{
+		URL: &url, EventTypes: &types, ProductIDs: &products, Enabled: &disabled,
+	})
+	s.Require().NoError(err)
+	s.Equal(url, updated.URL)
+	s.Equal(types, updated.EventTypes)
+	s.Equal(products, updated.ProductIDs)
+	s.False(updated.Enabled)
+	s.NotNil(updated.DisabledAt)
+	s.Equal("disabled by an administrator", updated.DisabledReason)
+
+	s.Require().NoError(s.db.Model(&models.WebhookEndpoint{}).Where("id = ?", endpoint.ID).
+		Update("failing_since", time.Now()).Error)
+	enabled := true
+	updated, err = s.repo.UpdateEndpoint(context.Background(), endpoint.ID.String(), repositories.WebhookEndpointChanges{Enabled: &enabled})
+	s.Require().NoError(err)
+	s.True(updated.Enabled)
+	s.Nil(updated.DisabledAt)
+	s.Nil(updated.FailingSince, "enabling forgets past failures")
+	s.Empty(updated.DisabledReason)
+	s.Equal(url, updated.URL)
+
+	_, err = s.repo.UpdateEndpoint(context.Background(), uuid.NewString(), repositories.WebhookEndpointChanges{URL: &url})
+	s.ErrorIs(err, repositories.ErrWebhookEndpointNotFound)
+}
+
+func (s *WebhookRepositoryTestSuite) TestDeleteEndpointRemovesDeliveries() {
+	endpoint := s.createEndpoint()
+	delivery := s.createDelivery(endpoint, time.Now())
+
+	s.Require().NoError(s.repo.DeleteEndpoint(context.Background(), endpoint.ID.String()))
+	_, err := s.repo.GetDelivery(context.Background(), delivery.ID.String())
+	s.ErrorIs(err, repositories.ErrWebhookDeliveryNotFound)
+
+	s.ErrorIs(s.repo.DeleteEndpoint(context.Background(), endpoint.ID.String()), repositories.ErrWebhookEndpointNotFound)
+	s.ErrorIs(s.repo.DeleteEndpoint(context.Background(), "not-a-uuid"), repositories.ErrInvalidWebhookEndpointID)
+}
+
+func (s *WebhookRepositoryTestSuite) TestListDeliveries() {
+	endpoint := s.createEndpoint()
+	now := time.Now().UTC().Truncate(time.Second)
+	older := s.createDelivery(endpoint, now.Add(-time.Hour))
+	newer := s.createDelivery(endpoint, now)
+	s.createDelivery(s.createEndpoint(), now)
+
+	deliveries, page, err := s.repo.ListDeliveries(context.Background(), endpoint.ID.String(),
+		repositories.WebhookDeliveryFilter{}, repositories.PageRequest{Page: 1, Limit: 10})
+	s.Require().NoError(err)
+	s.Require().Len(deliveries, 2)
+	s.Equal(newer.ID, deliveries[0].ID, "newest first")
+	s.Equal(older.ID, deliveries[1].ID)
+	s.EqualValues(2, *page.Total)
+
+	deliveries, _, err = s.repo.ListDeliveries(context.Background(), endpoint.ID.String(),
+		repositories.WebhookDeliveryFilter{Status: models.DeliveryPending}, repositories.PageRequest{Page: 1, Limit: 10})
+	s.Require().NoError(err)
+	s.Empty(deliveries)
+
+	_, _, err = s.repo.ListDeliveries(context.Background(), uuid.NewString(),
+		repositories.WebhookDeliveryFilter{}, repositories.PageRequest{Page: 1, Limit: 10})
+	s.ErrorIs(err, repositories.ErrWebhookEndpointNotFound)
+}
+
+func (s *WebhookRepositoryTestSuite) TestRedeliver() {
+	endpoint := s.createEndpoint()
+	delivery := s.createDelivery(endpoint, time.Now().Add(-time.Hour))
+
+	redelivered, err := s.repo.Redeliver(context.Background(), delivery.ID.String())
+	s.Require().NoError(err)
+	s.Equal(models.DeliveryPending, redelivered.Status)
+	s.Zero(redelivered.Attempts)
+	s.WithinDuration(time.Now(), redelivered.NextAttemptAt, time.Minute)
+
+	stored, err := s.repo.GetDelivery(context.Background(), delivery.ID.String())
+	s.Require().NoError(err)
+	s.Equal(models.DeliveryPending, stored.Status)
+	s.Len(stored.AttemptLog, 1, "the log of earlier attempts is kept")
+
+	disabled := false
+	_, err = s.repo.UpdateEndpoint(context.Background(), endpoint.ID.String(), repositories.WebhookEndpointChanges{Enabled: &disabled})
+	s.Require().NoError(err)
+	_, err = s.repo.Redeliver(context.Background(), delivery.ID.String())
+	s.ErrorIs(err, repositories.ErrWebhookEndpointDisabled)
+
+	_, err = s.repo.Redeliver(context.Background(), uuid.NewString())
+	s.ErrorIs(err, repositories.ErrWebhookDeliveryNotFound)
+}
@@ -0,0 +1,77 @@
+// Package reqctx carries request-scoped values through context.Context so
+// they reach layers that never see the HTTP request, such as repositories.
+package reqctx
+
+import "context"
+
+type key int
+
+const (
+	requestIDKey key = iota
+	userIDKey
+	subscriptionIDKey
+	primaryReadsKey
+)
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the ID of the request ctx belongs to, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// maxRequestIDLength bounds the request IDs taken from callers, since
+// they are echoed back and written to every log line.
+const maxRequestIDLength = 128
+
+// ValidRequestID reports whether a request ID sent by a caller may be
+// reused: 1 to 128 ASCII letters, digits, dots, underscores and hyphens,
+// so it can neither forge log lines nor bloat them.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func WithUserID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, userIDKey, id)
+}
+
+// UserID returns the user the request acts for, or "".
+func UserID(ctx context.Context) string {
+	id, _ := ctx.Value(userIDKey).(string)
+	return id
+}
+
+func WithSubscriptionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, subscriptionIDKey, id)
+}
+
+// SubscriptionID returns the subscription the request operates on, or "".
+func SubscriptionID(ctx context.Context) string {
+	id, _ := ctx.Value(subscriptionIDKey).(string)
+	return id
+}
+
+// WithPrimaryReads asks for the reads made on behalf of ctx to go to the
+// primary database rather than a replica that may lag behind it.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey, true)
+}
+
+// PrimaryReads reports whether reads for ctx must go to the primary.
+func PrimaryReads(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadsKey).(bool)
+	return primary
+}
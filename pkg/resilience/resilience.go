@@ -0,0 +1,224 @@
+// Package resilience guards calls to a dependency with a circuit breaker
+// and bounded retries, so a degraded database fails requests fast instead
+// of tying each one up until the driver gives up.
+package resilience
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/logging"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"github.com/sony/gobreaker"
+	"gorm.io/gorm"
+)
+
+// Retry says which failures of an operation may be retried.
+type Retry int
+
+const (
+	// NoRetry runs the operation once.
+	NoRetry Retry = iota
+	// RetryTransactional retries only failures the database guarantees
+	// left no trace: serialization failures and deadlocks, which roll the
+	// transaction back, and errors raised before anything was sent. Safe
+	// for any operation that runs in a single transaction.
+	RetryTransactional
+	// RetryIdempotent also retries lost connections, after which the
+	// operation may or may not have been applied. Only for reads and
+	// writes that can safely be repeated.
+	RetryIdempotent
+)
+
+// State is the circuit breaker state.
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
+
+// Executor runs operations against one dependency. Consecutive failures
+// open its breaker, after which calls are rejected with
+// apperrors.ErrUnavailable until a probe succeeds.
+type Executor struct {
+	name    string
+	cfg     config.ResilienceConfig
+	breaker *gobreaker.CircuitBreaker
+
+	openedAt   atomic.Int64 // unix nanoseconds
+	retries    atomic.Uint64
+	rejections atomic.Uint64
+}
+
+func New(name string, cfg config.ResilienceConfig) *Executor {
+	e := &Executor{name: name, cfg: cfg}
+	e.breaker = gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        name,
+		MaxRequests: 1,
+		Timeout:     cfg.BreakerOpenTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= uint32(cfg.BreakerFailures)
+		},
+		IsSuccessful: isSuccessful,
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			if to == gobreaker.StateOpen {
+				e.openedAt.Store(time.Now().UnixNano())
+			}
+			slog.Warn("Circuit breaker state changed", "breaker", name, "from", from.String(), "to", to.String())
+		},
+	})
+	return e
+}
+
+func (e *Executor) Name() string {
+	return e.name
+}
+
+func (e *Executor) State() State {
+	switch e.breaker.State() {
+	case gobreaker.StateClosed:
+		return StateClosed
+	case gobreaker.StateHalfOpen:
+		return StateHalfOpen
+	default:
+		return StateOpen
+	}
+}
+
+// Retries counts the retries made so far.
+func (e *Executor) Retries() uint64 {
+	return e.retries.Load()
+}
+
+// Rejections counts the calls refused by the open breaker so far.
+func (e *Executor) Rejections() uint64 {
+	return e.rejections.Load()
+}
+
+// Check fails while the breaker is open, for use as a readiness check.
+func (e *Executor) Check(context.Context) error {
+	if e.State() == StateOpen {
+		return fmt.Errorf("circuit breaker open, probing again in %s", e.retryAfter().Round(time.Second))
+	}
+	return nil
+}
+
+// Do runs fn, retrying failures allowed by retry with exponential backoff
+// and jitter, up to the configured number of attempts. Every attempt goes
+// through the breaker.
+func (e *Executor) Do(ctx context.Context, retry Retry, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		_, err := e.breaker.Execute(func() (any, error) {
+			return nil, fn(ctx)
+		})
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			e.rejections.Add(1)
+			return apperrors.ErrUnavailable.WithRetryAfter(e.retryAfter()).Wrap(err)
+		}
+		if err == nil || attempt >= e.cfg.RetryAttempts || !retryable(retry, err) {
+			return err
+		}
+
+		e.retries.Add(1)
+		delay := e.backoff(attempt)
+		logging.FromContext(ctx).DebugContext(ctx, "Retrying after transient error",
+			"breaker", e.name, "attempt", attempt, "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff doubles the base delay per attempt up to the maximum and picks
+// a random delay in its upper half, so retrying clients spread out.
+func (e *Executor) backoff(attempt int) time.Duration {
+	d := e.cfg.RetryMaxDelay
+	if shift := attempt - 1; shift < 32 && e.cfg.RetryBaseDelay<<shift < d {
+		d = e.cfg.RetryBaseDelay << shift
+	}
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2)
+}
+
+// retryAfter estimates when the breaker will let a probe through.
+func (e *Executor) retryAfter() time.Duration {
+	remaining := time.Until(time.Unix(0, e.openedAt.Load()).Add(e.cfg.BreakerOpenTimeout))
+	return max(remaining, time.Second)
+}
+
+// isSuccessful tells the breaker which outcomes say nothing about the
+// health of the dependency: domain errors and callers giving up.
+func isSuccessful(err error) bool {
+	var appErr *apperrors.Error
+	return err == nil ||
+		errors.As(err, &appErr) ||
+		errors.Is(err, gorm.ErrRecordNotFound) ||
+		errors.Is(err, context.Canceled)
+}
+
+func retryable(retry Retry, err error) bool {
+	if retry == NoRetry || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "40001", // serialization_failure
+			"40P01", // deadlock_detected
+			"53300", // too_many_connections
+			"57P03": // cannot_connect_now
+			return true
+		case "57P01", "57P02": // admin_shutdown, crash_shutdown
+			return retry == RetryIdempotent
+		}
+		// class 08: connection exception
+		return retry == RetryIdempotent && len(pgErr.Code) == 5 && pgErr.Code[:2] == "08"
+	}
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	// SQLite reports a database lock still held once the busy timeout has
+	// passed; the statement had no effect
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	var netErr net.Error
+	return retry == RetryIdempotent &&
+		(errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr))
+}
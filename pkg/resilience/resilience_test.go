@@ -0,0 +1,166 @@
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/resilience"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var errNotFound = apperrors.New(apperrors.CodeNotFound, http.StatusNotFound, "not found")
+
+func newExecutor(failures, attempts int) *resilience.Executor {
+	return resilience.New("test", config.ResilienceConfig{
+		BreakerFailures:    failures,
+		BreakerOpenTimeout: time.Hour,
+		RetryAttempts:      attempts,
+		RetryBaseDelay:     time.Microsecond,
+		RetryMaxDelay:      time.Millisecond,
+	})
+}
+
+// failing returns an operation that fails with err the first n times.
+func failing(n int, err error) (func(context.Context) error, *int) {
+	calls := 0
+	return func(context.Context) error {
+		calls++
+		if calls <= n {
+			return err
+		}
+		return nil
+	}, &calls
+}
+
+func TestRetries(t *testing.T) {
+	serialization := &pgconn.PgError{Code: "40001"}
+	connectionLost := &pgconn.PgError{Code: "08006"}
+	uniqueViolation := &pgconn.PgError{Code: "23505"}
+	sqliteBusy := sqlite3.Error{Code: sqlite3.ErrBusy}
+	sqliteConstraint := sqlite3.Error{Code: sqlite3.ErrConstraint}
+
+	tests := []struct {
+		name      string
+		retry     resilience.Retry
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"serialization failure retried in transaction", resilience.RetryTransactional, serialization, 3, false},
+		{"connection loss retried when idempotent", resilience.RetryIdempotent, connectionLost, 3, false},
+		{"connection loss not retried in transaction", resilience.RetryTransactional, connectionLost, 1, true},
+		{"unexpected EOF retried when idempotent", resilience.RetryIdempotent, io.ErrUnexpectedEOF, 3, false},
+		{"SQLite busy retried in transaction", resilience.RetryTransactional, sqliteBusy, 3, false},
+		{"SQLite constraint violation never retried", resilience.RetryIdempotent, sqliteConstraint, 1, true},
+		{"nothing retried without retry", resilience.NoRetry, serialization, 1, true},
+		{"constraint violation never retried", resilience.RetryIdempotent, uniqueViolation, 1, true},
+		{"domain error never retried", resilience.RetryIdempotent, errNotFound, 1, true},
+		{"deadline never retried", resilience.RetryIdempotent, context.DeadlineExceeded, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := newExecutor(100, 3)
+			fn, calls := failing(2, tt.err)
+
+			err := exec.Do(context.Background(), tt.retry, fn)
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+			assert.Equal(t, tt.wantCalls, *calls)
+			assert.Equal(t, uint64(tt.wantCalls-1), exec.Retries())
+		})
+	}
+
+	t.Run("attempts are bounded", func(t *testing.T) {
+		exec := newExecutor(100, 3)
+		fn, calls := failing(5, serialization)
+
+		err := exec.Do(context.Background(), resilience.RetryIdempotent, fn)
+		assert.ErrorIs(t, err, serialization)
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("cancellation stops retrying", func(t *testing.T) {
+		exec := resilience.New("test", config.ResilienceConfig{
+			BreakerFailures: 100, BreakerOpenTimeout: time.Hour,
+			RetryAttempts: 3, RetryBaseDelay: time.Hour, RetryMaxDelay: time.Hour,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		fn, calls := failing(5, serialization)
+
+		err := exec.Do(ctx, resilience.RetryIdempotent, fn)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 1, *calls)
+	})
+}
+
+func TestBreaker(t *testing.T) {
+	ctx := context.Background()
+	down := errors.New("connection refused")
+
+	t.Run("Consecutive failures open the breaker", func(t *testing.T) {
+		exec := newExecutor(3, 1)
+		for range 3 {
+			require.ErrorIs(t, exec.Do(ctx, resilience.RetryIdempotent, func(context.Context) error { return down }), down)
+		}
+		assert.Equal(t, resilience.StateOpen, exec.State())
+		assert.Error(t, exec.Check(ctx))
+
+		called := false
+		err := exec.Do(ctx, resilience.RetryIdempotent, func(context.Context) error {
+			called = true
+			return nil
+		})
+		assert.False(t, called, "rejected without calling the database")
+
+		var appErr *apperrors.Error
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, http.StatusServiceUnavailable, appErr.Status)
+		assert.Equal(t, apperrors.CodeUnavailable, appErr.Code)
+		assert.InDelta(t, time.Hour, appErr.RetryAfter, float64(time.Minute))
+		assert.Equal(t, uint64(1), exec.Rejections())
+	})
+
+	t.Run("Domain errors and cancellations do not count", func(t *testing.T) {
+		exec := newExecutor(2, 1)
+		for _, err := range []error{errNotFound, gorm.ErrRecordNotFound, context.Canceled} {
+			_ = exec.Do(ctx, resilience.RetryIdempotent, func(context.Context) error { return err })
+		}
+		assert.Equal(t, resilience.StateClosed, exec.State())
+		assert.NoError(t, exec.Check(ctx))
+	})
+
+	t.Run("A success resets the count", func(t *testing.T) {
+		exec := newExecutor(2, 1)
+		fail := func(context.Context) error { return down }
+		_ = exec.Do(ctx, resilience.RetryIdempotent, fail)
+		require.NoError(t, exec.Do(ctx, resilience.RetryIdempotent, func(context.Context) error { return nil }))
+		_ = exec.Do(ctx, resilience.RetryIdempotent, fail)
+		assert.Equal(t, resilience.StateClosed, exec.State())
+	})
+
+	t.Run("A successful probe closes the breaker", func(t *testing.T) {
+		exec := resilience.New("test", config.ResilienceConfig{
+			BreakerFailures: 1, BreakerOpenTimeout: 10 * time.Millisecond,
+			RetryAttempts: 1, RetryBaseDelay: time.Millisecond, RetryMaxDelay: time.Millisecond,
+		})
+		_ = exec.Do(ctx, resilience.RetryIdempotent, func(context.Context) error { return down })
+		require.Equal(t, resilience.StateOpen, exec.State())
+
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, resilience.StateHalfOpen, exec.State())
+		require.NoError(t, exec.Do(ctx, resilience.RetryIdempotent, func(context.Context) error { return nil }))
+		assert.Equal(t, resilience.StateClosed, exec.State())
+	})
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gymondo_dz/pkg/config"
+)
+
+// State tracks whether the process is alive and whether it should
+// receive traffic. Readiness drops as soon as shutdown begins, liveness
+// only once everything has been stopped.
+type State struct {
+	live  atomic.Bool
+	ready atomic.Bool
+}
+
+func (s *State) Live() bool  { return s.live.Load() }
+func (s *State) Ready() bool { return s.ready.Load() }
+
+type closer struct {
+	name string
+	fn   func() error
+}
+
+// Server runs the HTTP server and the background workers next to it and
+// tears both down in order when its context is cancelled.
+type Server struct {
+	http  *http.Server
+	cfg   config.ServerConfig
+	state *State
+
+	workers       sync.WaitGroup
+	workerCtx     context.Context
+	cancelWorkers context.CancelFunc
+	closers       []closer
+}
+
+func New(cfg config.ServerConfig, handler http.Handler) *Server {
+	workerCtx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		http: &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.Port),
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		cfg:           cfg,
+		state:         &State{},
+		workerCtx:     workerCtx,
+		cancelWorkers: cancel,
+	}
+	s.state.live.Store(true)
+	return s
+}
+
+func (s *Server) State() *State {
+	return s.state
+}
+
+// Go runs fn in the background until shutdown cancels its context.
+func (s *Server) Go(name string, fn func(ctx context.Context)) {
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		fn(s.workerCtx)
+		slog.Info("Worker stopped", "worker", name)
+	}()
+}
+
+// OnShutdown registers fn to run after requests have drained and the
+// workers have stopped. Closers run in reverse registration order.
+func (s *Server) OnShutdown(name string, fn func() error) {
+	s.closers = append(s.closers, closer{name: name, fn: fn})
+}
+
+// OnDrain registers fn to run as soon as requests start draining, to end
+// long-lived responses such as event streams, which draining would
+// otherwise wait for until the shutdown timeout.
+func (s *Server) OnDrain(fn func()) {
+	s.http.RegisterOnShutdown(fn)
+}
+
+// Run listens on the configured port and serves until ctx is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.http.Addr, err)
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve accepts connections on ln until ctx is cancelled, then shuts down
+// gracefully within the configured shutdown timeout.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.http.Serve(ln)
+	}()
+	s.state.ready.Store(true)
+	slog.Info("Server listening", "addr", ln.Addr().String())
+
+	select {
+	case err := <-serveErr:
+		// the server died on its own; still release everything else
+		s.state.ready.Store(false)
+		return errors.Join(err, s.shutdown(false))
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down server")
+	s.state.ready.Store(false)
+	if s.cfg.ShutdownDelay > 0 {
+		time.Sleep(s.cfg.ShutdownDelay)
+	}
+	return s.shutdown(true)
+}
+
+func (s *Server) shutdown(drain bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	if drain {
+		if err := s.http.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to drain requests: %w", err))
+		}
+	}
+
+	s.cancelWorkers()
+	stopped := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		errs = append(errs, errors.New("timed out waiting for workers to stop"))
+	}
+
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		c := s.closers[i]
+		if err := c.fn(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s: %w", c.name, err))
+		}
+	}
+
+	s.state.live.Store(false)
+	slog.Info("Server stopped")
+	return errors.Join(errs...)
+}
@@ -0,0 +1,136 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() config.ServerConfig {
+	cfg := config.Default().Server
+	cfg.ShutdownTimeout = 2 * time.Second
+	return cfg
+}
+
+func TestServerDrainsInFlightRequests(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_, _ = io.WriteString(w, "done")
+	})
+
+	srv := server.New(testConfig(), handler)
+
+	workerStopped := make(chan struct{})
+	srv.Go("test", func(ctx context.Context) {
+		<-ctx.Done()
+		close(workerStopped)
+	})
+
+	var closed []string
+	srv.OnShutdown("first", func() error { closed = append(closed, "first"); return nil })
+	srv.OnShutdown("second", func() error { closed = append(closed, "second"); return nil })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	assert.True(t, srv.State().Ready())
+	cancel()
+
+	assert.Eventually(t, func() bool { return !srv.State().Ready() }, time.Second, 10*time.Millisecond)
+	assert.True(t, srv.State().Live(), "the process stays live while draining")
+
+	close(release)
+	res := <-responses
+	require.NoError(t, res.err)
+	assert.Equal(t, "done", res.body)
+
+	require.NoError(t, <-served)
+	<-workerStopped
+	assert.Equal(t, []string{"second", "first"}, closed)
+	assert.False(t, srv.State().Live())
+
+	_, err = net.DialTimeout("tcp", ln.Addr().String(), 100*time.Millisecond)
+	assert.Error(t, err, "no new connections are accepted after shutdown")
+}
+
+func TestServerEndsLongLivedResponsesOnDrain(t *testing.T) {
+	started, end := make(chan struct{}), make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		close(started)
+		<-end
+	})
+
+	cfg := testConfig()
+	cfg.ShutdownTimeout = time.Minute
+	srv := server.New(cfg, handler)
+	srv.OnDrain(func() { close(end) })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	<-started
+
+	cancel()
+	select {
+	case err := <-served:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown waited for the long-lived response")
+	}
+}
+
+func TestServerReportsShutdownErrors(t *testing.T) {
+	srv := server.New(testConfig(), http.NotFoundHandler())
+	srv.OnShutdown("database", func() error { return errors.New("boom") })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = srv.Serve(ctx, ln)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to close database: boom")
+}
@@ -0,0 +1,110 @@
+// Package stream fans committed subscription events out to the
+// Server-Sent Events streams open on this instance.
+//
+// The hub is in-process and best effort: it never blocks the change that
+// published an event, so a subscriber that cannot keep up is dropped and
+// has to resume from the event store with Last-Event-ID.
+package stream
+
+import (
+	"sync"
+
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+
+	"github.com/google/uuid"
+)
+
+// Hub passes every published event on to the subscriptions interested in
+// it.
+type Hub struct {
+	buffer int
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewHub returns a hub whose subscribers may fall up to buffer events
+// behind.
+func NewHub(buffer int) *Hub {
+	return &Hub{buffer: buffer, subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives the events of one subject, or of all of them.
+type Subscription struct {
+	hub     *Hub
+	subject uuid.UUID
+	events  chan events.Event
+}
+
+// Subscribe starts receiving the events about subject, or every event if
+// subject is uuid.Nil. On a closed hub the subscription ends right away.
+func (h *Hub) Subscribe(subject uuid.UUID) *Subscription {
+	s := &Subscription{hub: h, subject: subject, events: make(chan events.Event, h.buffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(s.events)
+		return s
+	}
+	h.subs[s] = struct{}{}
+	return s
+}
+
+// Events delivers the subscription's events. It is closed when the
+// subscription ends: on Close, when the hub closes, or when the
+// subscriber fell too far behind.
+func (s *Subscription) Events() <-chan events.Event {
+	return s.events
+}
+
+// Close ends the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.drop(s)
+}
+
+// Publish passes e on to its subscribers without blocking.
+func (h *Hub) Publish(e events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if s.subject != uuid.Nil && s.subject != e.Subject {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			h.drop(s)
+		}
+	}
+}
+
+// Close ends every subscription, and any made later. Open streams end
+// with it, so it should run as soon as the server starts draining.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for s := range h.subs {
+		h.drop(s)
+	}
+}
+
+// SubscriptionChanged implements repositories.SubscriptionObserver.
+func (h *Hub) SubscriptionChanged(_ repositories.SubscriptionEvent, _ *models.Subscription, recorded events.Event) {
+	h.Publish(recorded)
+}
+
+// drop ends s if it is still open; h.mu must be held.
+func (h *Hub) drop(s *Subscription) {
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+	delete(h.subs, s)
+	close(s.events)
+}
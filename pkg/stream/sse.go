@@ -0,0 +1,36 @@
+package stream
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"gymondo_dz/pkg/events"
+)
+
+// ContentType is the media type of a Server-Sent Events stream.
+const ContentType = "text/event-stream"
+
+// WriteEvent writes e as a stream event named after its type, with its ID
+// for clients to resume from and its JSON envelope as data.
+func WriteEvent(w io.Writer, e events.Event) error {
+	msg, err := e.Encode()
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", e.Type, err)
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, msg.Payload)
+	return err
+}
+
+// WriteRetry tells the client how long to wait before reconnecting.
+func WriteRetry(w io.Writer, d time.Duration) error {
+	_, err := fmt.Fprintf(w, "retry: %d\n\n", d.Milliseconds())
+	return err
+}
+
+// WriteComment writes a line clients ignore, which keeps idle
+// connections from being closed by proxies.
+func WriteComment(w io.Writer, text string) error {
+	_, err := fmt.Fprintf(w, ": %s\n\n", text)
+	return err
+}
@@ -0,0 +1,109 @@
+package stream_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/stream"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func event(subject uuid.UUID, sequence int) events.Event {
+	return events.NewSubscriptionEvent(events.TypeSubscriptionPaused, &models.Subscription{
+		ID:      subject,
+		Status:  models.StatusPaused,
+		Version: sequence,
+	}, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// drain returns what is buffered on sub and whether it is still open.
+func drain(sub *stream.Subscription) ([]events.Event, bool) {
+	var got []events.Event
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return got, false
+			}
+			got = append(got, e)
+		default:
+			return got, true
+		}
+	}
+}
+
+func TestHubRoutesBySubject(t *testing.T) {
+	hub := stream.NewHub(10)
+	a, b := uuid.New(), uuid.New()
+	subA := hub.Subscribe(a)
+	all := hub.Subscribe(uuid.Nil)
+
+	hub.Publish(event(a, 1))
+	hub.Publish(event(b, 1))
+	hub.Publish(event(a, 2))
+
+	got, open := drain(subA)
+	assert.True(t, open)
+	require.Len(t, got, 2)
+	assert.Equal(t, 1, got[0].Sequence)
+	assert.Equal(t, 2, got[1].Sequence)
+
+	got, _ = drain(all)
+	assert.Len(t, got, 3, "the firehose sees every subject")
+
+	subA.Close()
+	subA.Close()
+	hub.Publish(event(a, 3))
+	got, open = drain(subA)
+	assert.Empty(t, got)
+	assert.False(t, open)
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	hub := stream.NewHub(2)
+	subject := uuid.New()
+	slow := hub.Subscribe(subject)
+
+	for i := 1; i <= 3; i++ {
+		hub.Publish(event(subject, i))
+	}
+
+	got, open := drain(slow)
+	assert.Len(t, got, 2, "buffered events are still delivered")
+	assert.False(t, open, "a subscriber that fell behind is dropped")
+}
+
+func TestHubClose(t *testing.T) {
+	hub := stream.NewHub(1)
+	sub := hub.Subscribe(uuid.Nil)
+
+	hub.Close()
+	_, open := drain(sub)
+	assert.False(t, open)
+
+	_, open = drain(hub.Subscribe(uuid.Nil))
+	assert.False(t, open, "subscriptions made after Close end right away")
+	hub.Publish(event(uuid.New(), 1))
+	sub.Close()
+}
+
+func TestWriteEvent(t *testing.T) {
+	e := event(uuid.MustParse("6ae3b222-af74-4c53-ac50-263d383a5a4b"), 2)
+	msg, err := e.Encode()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, stream.WriteRetry(&buf, 3*time.Second))
+	require.NoError(t, stream.WriteEvent(&buf, e))
+	require.NoError(t, stream.WriteComment(&buf, "keep-alive"))
+
+	assert.Equal(t, "retry: 3000\n\n"+
+		"id: "+e.ID.String()+"\nevent: subscription.paused\ndata: "+string(msg.Payload)+"\n\n"+
+		": keep-alive\n\n", buf.String())
+}
@@ -1,9 +1,12 @@
 package testutils
 
 import (
+	"context"
 	"time"
 
+	"gymondo_dz/pkg/events"
 	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -14,16 +17,40 @@ type MockProductRepository struct {
 	mock.Mock
 }
 
-func (m *MockProductRepository) GetProducts(page, limit int) ([]models.Product, int64, error) {
-	args := m.Called(page, limit)
+func (m *MockProductRepository) GetProducts(ctx context.Context, filter repositories.ProductFilter, page repositories.PageRequest) ([]models.Product, repositories.Pagination, error) {
+	args := m.Called(ctx, filter, page)
 	if args.Get(0) == nil {
-		return nil, 0, args.Error(2)
+		return nil, repositories.Pagination{}, args.Error(2)
 	}
-	return args.Get(0).([]models.Product), args.Get(1).(int64), args.Error(2)
+	return args.Get(0).([]models.Product), args.Get(1).(repositories.Pagination), args.Error(2)
 }
 
-func (m *MockProductRepository) GetProduct(id string) (*models.Product, error) {
-	args := m.Called(id)
+func (m *MockProductRepository) GetProduct(ctx context.Context, id string) (*models.Product, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductsByIDs(ctx context.Context, ids []string) ([]models.Product, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
+	args := m.Called(ctx, product)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) UpdateProduct(ctx context.Context, id string, changes repositories.ProductChanges) (*models.Product, error) {
+	args := m.Called(ctx, id, changes)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -35,47 +62,170 @@ type MockSubscriptionRepository struct {
 	mock.Mock
 }
 
-func (m *MockSubscriptionRepository) GetSubscription(id string) (*models.Subscription, error) {
-	args := m.Called(id)
+func (m *MockSubscriptionRepository) ListSubscriptions(ctx context.Context, filter repositories.SubscriptionFilter, page repositories.PageRequest) ([]models.Subscription, repositories.Pagination, error) {
+	args := m.Called(ctx, filter, page)
+	if args.Get(0) == nil {
+		return nil, repositories.Pagination{}, args.Error(2)
+	}
+	return args.Get(0).([]models.Subscription), args.Get(1).(repositories.Pagination), args.Error(2)
+}
+
+func (m *MockSubscriptionRepository) GetSubscription(ctx context.Context, id string) (*models.Subscription, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Subscription), args.Error(1)
 }
 
-func (m *MockSubscriptionRepository) CreateSubscription(userID string, product *models.Product) (*models.Subscription, error) {
-	args := m.Called(userID, product)
+func (m *MockSubscriptionRepository) CreateSubscription(ctx context.Context, userID string, product *models.Product) (*models.Subscription, error) {
+	args := m.Called(ctx, userID, product)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Subscription), args.Error(1)
 }
 
-func (m *MockSubscriptionRepository) PauseSubscription(id string, expectedVersion int) (*models.Subscription, error) {
-	args := m.Called(id, expectedVersion)
+func (m *MockSubscriptionRepository) PauseSubscription(ctx context.Context, id string, expectedVersion int) (*models.Subscription, error) {
+	args := m.Called(ctx, id, expectedVersion)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Subscription), args.Error(1)
 }
 
-func (m *MockSubscriptionRepository) UnpauseSubscription(id string, expectedVersion int) (*models.Subscription, error) {
-	args := m.Called(id, expectedVersion)
+func (m *MockSubscriptionRepository) UnpauseSubscription(ctx context.Context, id string, expectedVersion int) (*models.Subscription, error) {
+	args := m.Called(ctx, id, expectedVersion)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Subscription), args.Error(1)
 }
 
-func (m *MockSubscriptionRepository) CancelSubscription(id string, expectedVersion int) (*models.Subscription, error) {
-	args := m.Called(id, expectedVersion)
+func (m *MockSubscriptionRepository) CancelSubscription(ctx context.Context, id string, expectedVersion int) (*models.Subscription, error) {
+	args := m.Called(ctx, id, expectedVersion)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Subscription), args.Error(1)
 }
 
+func (m *MockSubscriptionRepository) ExpireSubscriptions(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(ctx, now)
+	return args.Int(0), args.Error(1)
+}
+
+// MockTranslationRepository implements TranslationRepository for testing
+type MockTranslationRepository struct {
+	mock.Mock
+}
+
+func (m *MockTranslationRepository) ListTranslations(ctx context.Context, productID string) ([]models.ProductTranslation, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ProductTranslation), args.Error(1)
+}
+
+func (m *MockTranslationRepository) UpsertTranslation(ctx context.Context, translation *models.ProductTranslation) (*models.ProductTranslation, error) {
+	args := m.Called(ctx, translation)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductTranslation), args.Error(1)
+}
+
+func (m *MockTranslationRepository) DeleteTranslation(ctx context.Context, productID, locale string) error {
+	args := m.Called(ctx, productID, locale)
+	return args.Error(0)
+}
+
+func (m *MockTranslationRepository) Localize(ctx context.Context, locales []string, products ...*models.Product) error {
+	args := m.Called(ctx, locales, products)
+	return args.Error(0)
+}
+
+// MockWebhookRepository implements WebhookRepository for testing
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) (*models.WebhookEndpoint, error) {
+	args := m.Called(ctx, endpoint)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ListEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockWebhookRepository) UpdateEndpoint(ctx context.Context, id string, changes repositories.WebhookEndpointChanges) (*models.WebhookEndpoint, error) {
+	args := m.Called(ctx, id, changes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockWebhookRepository) DeleteEndpoint(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) ListDeliveries(ctx context.Context, endpointID string, filter repositories.WebhookDeliveryFilter, page repositories.PageRequest) ([]models.WebhookDelivery, repositories.Pagination, error) {
+	args := m.Called(ctx, endpointID, filter, page)
+	if args.Get(0) == nil {
+		return nil, repositories.Pagination{}, args.Error(2)
+	}
+	return args.Get(0).([]models.WebhookDelivery), args.Get(1).(repositories.Pagination), args.Error(2)
+}
+
+func (m *MockWebhookRepository) GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) Redeliver(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
 // Helper functions for testing
+// MockEventRepository implements EventRepository for testing
+type MockEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockEventRepository) EventsAfter(ctx context.Context, subject uuid.UUID, after string, limit int) ([]events.Event, error) {
+	args := m.Called(ctx, subject, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]events.Event), args.Error(1)
+}
+
 func NewMockProduct() *models.Product {
 	return &models.Product{
 		ID:        uuid.New(),
@@ -1,6 +1,7 @@
 package testutils
 
 import (
+	"context"
 	"gymondo_dz/pkg/models"
 	"gymondo_dz/pkg/repositories"
 	"testing"
@@ -82,9 +83,9 @@ func TestProductRepositorySuite(t *testing.T) {
 }
 
 func (s *ProductRepositoryTestSuite) TestGetProducts() {
-	products, total, err := s.repo.GetProducts(1, 10)
+	products, page, err := s.repo.GetProducts(context.Background(), repositories.ProductFilter{}, repositories.PageRequest{Page: 1, Limit: 10})
 	s.NoError(err)
-	s.Equal(int64(3), total)
+	s.Equal(int64(3), *page.Total)
 	s.Len(products, 3)
 
 	// Verify order is correct (oldest first)
@@ -121,7 +122,7 @@ func (s *ProductRepositoryTestSuite) TestGetProduct() {
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			product, err := s.repo.GetProduct(tt.id)
+			product, err := s.repo.GetProduct(context.Background(), tt.id)
 
 			if tt.expectError {
 				s.Error(err)
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	instrumentation = "gymondo_dz/pkg/tracing"
+	spanKey         = "tracing:span"
+)
+
+// GormPlugin starts a client span for every statement, as a child of the
+// span carried by the statement's context (see gorm.DB.WithContext).
+type GormPlugin struct {
+	tracer trace.Tracer
+}
+
+// NewGormPlugin traces with tp, or the global provider when tp is nil.
+func NewGormPlugin(tp trace.TracerProvider) *GormPlugin {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &GormPlugin{tracer: tp.Tracer(instrumentation)}
+}
+
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", p.start("create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", p.end),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", p.start("query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", p.end),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", p.start("update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", p.end),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", p.start("delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", p.end),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", p.start("row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", p.end),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", p.start("raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", p.end),
+	)
+}
+
+func (p *GormPlugin) start(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		name := "gorm." + op
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		ctx, span := p.tracer.Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(db.Dialector.Name()),
+				semconv.DBOperationName(op),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+func (p *GormPlugin) end(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := v.(trace.Span)
+	defer span.End()
+
+	// SQL is kept with placeholders so bound values never reach the backend
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,122 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/database"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/repositories"
+	"gymondo_dz/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func tracedDB(t *testing.T, tp trace.TracerProvider) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(context.Background(), db))
+	require.NoError(t, db.Use(tracing.NewGormPlugin(tp)))
+	return db
+}
+
+func TestRequestSpansPropagateToQueries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	db := tracedDB(t, tp)
+	product := &models.Product{Name: "Monthly", Duration: models.DurationMonth, Price: 9.99}
+	require.NoError(t, db.Create(product).Error)
+	recorder.Reset()
+
+	repo := repositories.NewProductRepository(db)
+	router := gin.New()
+	router.Use(otelgin.Middleware("gymondo",
+		otelgin.WithTracerProvider(tp),
+		otelgin.WithPropagators(propagation.TraceContext{}),
+	))
+	router.GET("/products/:id", func(c *gin.Context) {
+		if _, err := repo.GetProduct(c.Request.Context(), c.Param("id")); err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/products/"+product.ID.String(), nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	query, server := spans[0], spans[1]
+
+	assert.Equal(t, "/products/:id", server.Name())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+
+	assert.Equal(t, "gorm.query products", query.Name())
+	assert.Equal(t, trace.SpanKindClient, query.SpanKind())
+	assert.Equal(t, server.SpanContext().SpanID(), query.Parent().SpanID())
+
+	attrs := map[string]string{}
+	for _, kv := range query.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	assert.Equal(t, "sqlite", attrs["db.system"])
+	assert.Equal(t, "products", attrs["db.collection.name"])
+	assert.Contains(t, attrs["db.query.text"], "FROM `products`")
+	assert.NotContains(t, attrs["db.query.text"], product.ID.String(), "bound values stay out of spans")
+}
+
+func TestQueryErrorsMarkSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	db := tracedDB(t, tp)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	err := db.WithContext(ctx).Exec("SELECT * FROM missing_table").Error
+	parent.End()
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "gorm.raw", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+
+	// a miss is an expected outcome, not a failed query
+	recorder.Reset()
+	var product models.Product
+	assert.ErrorIs(t, db.First(&product, "name = ?", "nope").Error, gorm.ErrRecordNotFound)
+	require.Len(t, recorder.Ended(), 1)
+	assert.Equal(t, codes.Unset, recorder.Ended()[0].Status().Code)
+}
+
+func TestSetup(t *testing.T) {
+	cfg := config.Default().Tracing
+	for _, exporter := range []string{"none", "stdout", "otlp"} {
+		cfg.Exporter = exporter
+		shutdown, err := tracing.Setup(context.Background(), cfg)
+		require.NoError(t, err, exporter)
+		require.NoError(t, shutdown(context.Background()), exporter)
+	}
+}
@@ -0,0 +1,329 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/i18n"
+	"gymondo_dz/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+var ErrValidation = apperrors.New(apperrors.CodeValidation, http.StatusBadRequest, "request validation failed")
+
+// SupportedCurrencies lists the ISO 4217 codes products can be priced in.
+var SupportedCurrencies = []string{"EUR", "USD", "GBP", "CHF"}
+
+var registerOnce sync.Once
+
+// Register installs the custom validators and JSON/form/uri field naming
+// on gin's validator engine. It is safe to call more than once.
+func Register() {
+	registerOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+
+		v.RegisterTagNameFunc(fieldName)
+		_ = v.RegisterValidation("resource_id", validateResourceID)
+		_ = v.RegisterValidation("subscription_duration", validateDuration)
+		_ = v.RegisterValidation("subscription_status", validateStatus)
+		_ = v.RegisterValidation("currency", validateCurrency)
+		_ = v.RegisterValidation("locale", validateLocale)
+		_ = v.RegisterValidation("event_type", validateEventType)
+		_ = v.RegisterValidation("webhook_url", validateWebhookURL)
+		_ = v.RegisterValidation("delivery_status", validateDeliveryStatus)
+	})
+}
+
+// BindQuery binds and validates query parameters into obj.
+func BindQuery(c *gin.Context, obj any) error {
+	Register()
+	if err := c.ShouldBindQuery(obj); err != nil {
+		return translate(err, obj, "form", c.Request.URL.Query())
+	}
+	return nil
+}
+
+// BindURI binds and validates path parameters into obj.
+func BindURI(c *gin.Context, obj any) error {
+	Register()
+	params := make(url.Values, len(c.Params))
+	for _, p := range c.Params {
+		params.Add(p.Key, p.Value)
+	}
+	if err := c.ShouldBindUri(obj); err != nil {
+		return translate(err, obj, "uri", params)
+	}
+	return nil
+}
+
+// BindJSON binds and validates a JSON request body into obj.
+func BindJSON(c *gin.Context, obj any) error {
+	Register()
+	if err := c.ShouldBindJSON(obj); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return ErrValidation.WithFields(apperrors.FieldError{
+				Field:   typeErr.Field,
+				Message: "must be " + jsonType(typeErr.Type),
+			})
+		}
+		return translate(err, obj, "json", nil)
+	}
+	return nil
+}
+
+// jsonType names t the way the API documents it, rather than by its Go
+// type.
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case reflect.TypeOf(uuid.UUID{}):
+		return "a UUID"
+	case reflect.TypeOf(time.Time{}):
+		return "a string"
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+	return "of another type"
+}
+
+// Struct validates obj, filled in by a transport other than gin such as
+// gRPC, by the same rules and with the same messages as the Bind
+// functions.
+func Struct(obj any) error {
+	Register()
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return translate(err, obj, "", nil)
+	}
+	return nil
+}
+
+func translate(err error, obj any, tag string, values url.Values) error {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		fields := make([]apperrors.FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			fields = append(fields, apperrors.FieldError{Field: fe.Field(), Message: message(fe)})
+		}
+		return ErrValidation.WithFields(fields...)
+	}
+
+	if fields := typeErrors(obj, tag, values); len(fields) > 0 {
+		return ErrValidation.WithFields(fields...)
+	}
+
+	return ErrValidation.WithDetail("malformed request").Wrap(err)
+}
+
+// typeErrors finds the parameters that could not be converted to their
+// field's type; gin reports those as bare strconv errors without a name.
+func typeErrors(obj any, tag string, values url.Values) []apperrors.FieldError {
+	if tag == "" || values == nil {
+		return nil
+	}
+
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return structTypeErrors(t, tag, values)
+}
+
+func structTypeErrors(t reflect.Type, tag string, values url.Values) []apperrors.FieldError {
+	var fields []apperrors.FieldError
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, structTypeErrors(f.Type, tag, values)...)
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		raw := values.Get(name)
+		if name == "" || name == "-" || raw == "" {
+			continue
+		}
+
+		kind := f.Type.Kind()
+		if kind == reflect.Ptr {
+			kind = f.Type.Elem().Kind()
+		}
+
+		var msg string
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+				msg = "must be an integer"
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if _, err := strconv.ParseUint(raw, 10, 64); err != nil {
+				msg = "must be a non-negative integer"
+			}
+		case reflect.Float32, reflect.Float64:
+			if _, err := strconv.ParseFloat(raw, 64); err != nil {
+				msg = "must be a number"
+			}
+		case reflect.Bool:
+			if _, err := strconv.ParseBool(raw); err != nil {
+				msg = "must be a boolean"
+			}
+		}
+		if msg != "" {
+			fields = append(fields, apperrors.FieldError{Field: name, Message: msg})
+		}
+	}
+	return fields
+}
+
+func message(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	isList := fe.Kind() == reflect.Slice || fe.Kind() == reflect.Array
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		if isList {
+			return fmt.Sprintf("must have at least %s items", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if isString {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		if isList {
+			return fmt.Sprintf("must have at most %s items", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "lte":
+		return "must be less than or equal to " + fe.Param()
+	case "excluded_with":
+		return "cannot be combined with " + strings.ToLower(fe.Param())
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "unique":
+		return "must not contain duplicates"
+	case "resource_id":
+		return "must be a valid UUID"
+	case "subscription_duration":
+		return fmt.Sprintf("must be one of: %d, %d, %d", models.DurationMonth, models.DurationYear, models.DurationLifetime)
+	case "subscription_status":
+		return fmt.Sprintf("must be one of: %s, %s, %s, %s",
+			models.StatusActive, models.StatusPaused, models.StatusCancelled, models.StatusExpired)
+	case "locale":
+		var locales []string
+		for _, l := range i18n.SupportedLocales {
+			if i18n.IsTranslatable(l) {
+				locales = append(locales, l)
+			}
+		}
+		return "must be one of: " + strings.Join(locales, ", ")
+	case "currency":
+		return "must be one of: " + strings.Join(SupportedCurrencies, ", ")
+	case "event_type":
+		return "must be one of: " + strings.Join(events.Types, ", ")
+	case "webhook_url":
+		return "must be an absolute http or https URL"
+	case "delivery_status":
+		return fmt.Sprintf("must be one of: %s, %s, %s", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed)
+	default:
+		return fmt.Sprintf("failed on the '%s' rule", fe.Tag())
+	}
+}
+
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+func validateResourceID(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	if len(value) != 36 {
+		return false
+	}
+	_, err := uuid.Parse(value)
+	return err == nil
+}
+
+func validateDuration(fl validator.FieldLevel) bool {
+	return models.SubscriptionDuration(fl.Field().Int()).IsValid()
+}
+
+func validateStatus(fl validator.FieldLevel) bool {
+	return models.SubscriptionStatus(fl.Field().String()).IsValid()
+}
+
+func validateCurrency(fl validator.FieldLevel) bool {
+	return slices.Contains(SupportedCurrencies, fl.Field().String())
+}
+
+func validateLocale(fl validator.FieldLevel) bool {
+	return i18n.IsTranslatable(fl.Field().String())
+}
+
+func validateEventType(fl validator.FieldLevel) bool {
+	return slices.Contains(events.Types, fl.Field().String())
+}
+
+func validateWebhookURL(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil
+}
+
+func validateDeliveryStatus(fl validator.FieldLevel) bool {
+	return models.WebhookDeliveryStatus(fl.Field().String()).IsValid()
+}
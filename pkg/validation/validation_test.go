@@ -0,0 +1,127 @@
+package validation_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/apperrors"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type testBody struct {
+	ProductID string                      `json:"product_id" binding:"required,resource_id"`
+	Duration  models.SubscriptionDuration `json:"duration" binding:"required,subscription_duration"`
+	Status    models.SubscriptionStatus   `json:"status" binding:"omitempty,subscription_status"`
+	Currency  string                      `json:"currency" binding:"omitempty,currency"`
+	Price     float64                     `json:"price"`
+	Trial     *bool                       `json:"trial"`
+	Tags      []string                    `json:"tags"`
+	Reference uuid.UUID                   `json:"reference"`
+	Starts    time.Time                   `json:"starts"`
+}
+
+func bindBody(t *testing.T, body string) error {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	var req testBody
+	return validation.BindJSON(c, &req)
+}
+
+func TestBindJSON(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedFields []apperrors.FieldError
+	}{
+		{
+			name: "Valid body",
+			body: `{"product_id":"465DC700-666C-4B7A-80E2-D9E2967F4442","duration":30,"status":"paused","currency":"EUR"}`,
+		},
+		{
+			name: "Invalid custom rules",
+			body: `{"product_id":"465dc700","duration":31,"status":"frozen","currency":"XYZ"}`,
+			expectedFields: []apperrors.FieldError{
+				{Field: "product_id", Message: "must be a valid UUID"},
+				{Field: "duration", Message: "must be one of: 30, 365, 36500"},
+				{Field: "status", Message: "must be one of: active, paused, cancelled, expired"},
+				{Field: "currency", Message: "must be one of: EUR, USD, GBP, CHF"},
+			},
+		},
+		{
+			name: "Missing required fields",
+			body: `{}`,
+			expectedFields: []apperrors.FieldError{
+				{Field: "product_id", Message: "is required"},
+				{Field: "duration", Message: "is required"},
+			},
+		},
+		{
+			name: "Wrong JSON type",
+			body: `{"product_id":"465dc700-666c-4b7a-80e2-d9e2967f4442","duration":"monthly"}`,
+			expectedFields: []apperrors.FieldError{
+				{Field: "duration", Message: "must be an integer"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bindBody(t, tt.body)
+
+			if tt.expectedFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, validation.ErrValidation)
+			assert.Equal(t, tt.expectedFields, apperrors.From(err).Fields)
+		})
+	}
+}
+
+func TestBindJSONNamesTypesInAPITerms(t *testing.T) {
+	tests := []struct {
+		field, value, message string
+	}{
+		{"duration", `"monthly"`, "must be an integer"},
+		{"price", `"9.99"`, "must be a number"},
+		{"currency", `978`, "must be a string"},
+		{"trial", `"yes"`, "must be a boolean"},
+		{"tags", `"yoga"`, "must be an array"},
+		{"reference", `42`, "must be a UUID"},
+		{"starts", `1735689600`, "must be a string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			err := bindBody(t, `{"product_id":"465dc700-666c-4b7a-80e2-d9e2967f4442","duration":30,"`+tt.field+`":`+tt.value+`}`)
+
+			appErr := apperrors.From(err)
+			assert.Equal(t, []apperrors.FieldError{{Field: tt.field, Message: tt.message}}, appErr.Fields)
+		})
+	}
+}
+
+func TestStruct(t *testing.T) {
+	assert.NoError(t, validation.Struct(&testBody{ProductID: "465dc700-666c-4b7a-80e2-d9e2967f4442", Duration: models.DurationMonth}))
+
+	err := validation.Struct(&testBody{ProductID: "465dc700", Duration: models.DurationMonth, Currency: "XYZ"})
+	assert.ErrorIs(t, err, validation.ErrValidation)
+	assert.Equal(t, []apperrors.FieldError{
+		{Field: "product_id", Message: "must be a valid UUID"},
+		{Field: "currency", Message: "must be one of: EUR, USD, GBP, CHF"},
+	}, apperrors.From(err).Fields)
+}
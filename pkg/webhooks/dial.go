@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// nonPublic are the ranges outside the private, loopback, link-local and
+// multicast ones that still do not lead to the public internet.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // this network
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT, also used for cloud metadata
+	netip.MustParsePrefix("192.0.0.0/24"),   // protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, which can reach any IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+}
+
+// blockedAddressError fails a delivery to an address that is
+// not publicly routable.
+type blockedAddressError struct {
+	Addr netip.Addr
+}
+
+func (e *blockedAddressError) Error() string {
+	return fmt.Sprintf("address %s is not publicly routable", e.Addr)
+}
+
+// isPublic reports whether addr is on the public internet. Loopback,
+// private, link-local (which holds the cloud metadata endpoint),
+// unspecified and multicast addresses are not.
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublic {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// refuseNonPublic is a net.Dialer Control function that stops connections
+// to addresses that are not public. It runs on the address actually
+// dialed, after DNS resolution, so a host name cannot be pointed at an
+// internal address once its endpoint is registered.
+func refuseNonPublic(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublic(addr) {
+		return &blockedAddressError{Addr: addr.WithZone("")}
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"gymondo_dz/pkg/buildinfo"
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/logging"
+	"gymondo_dz/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxDrainedBody bounds how much of an endpoint's answer is read, and
+// thrown away, so that the connection can be reused.
+const maxDrainedBody = 4 << 10
+
+// Dispatcher sends queued deliveries to their endpoints.
+//
+// A delivery succeeds when the endpoint answers 2xx within the timeout;
+// anything else, redirects included, is retried with exponential backoff
+// until the attempts run out. Every attempt is logged with the status code
+// of the response; the body is not kept. Connections are only made to
+// public addresses unless AllowPrivateNetworks is set, and never through
+// a proxy, which would hide the address dialed.
+// An endpoint that keeps failing without a single success for
+// DisableAfter is disabled, and its pending deliveries wait until it is
+// enabled again. Deliveries are claimed with a lease under SKIP LOCKED,
+// so every instance can run a dispatcher; they may reach an endpoint out
+// of order, and receivers order them by the event sequence.
+type Dispatcher struct {
+	db     *gorm.DB
+	client *http.Client
+	cfg    config.WebhooksConfig
+}
+
+func NewDispatcher(db *gorm.DB, cfg config.WebhooksConfig) *Dispatcher {
+	dialer := &net.Dialer{Timeout: cfg.Timeout, KeepAlive: 30 * time.Second}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = refuseNonPublic
+	}
+	return &Dispatcher{
+		db: db,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext:           dialer.DialContext,
+				ForceAttemptHTTP2:     true,
+				MaxIdleConns:          100,
+				IdleConnTimeout:       90 * time.Second,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ExpectContinueTimeout: time.Second,
+			},
+			Timeout:       cfg.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		cfg: cfg,
+	}
+}
+
+// Run dispatches every PollInterval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		// keep going while there is a backlog
+		for {
+			attempted, err := d.DeliverPending(ctx)
+			if err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "Failed to deliver webhooks", "error", err)
+			}
+			if err != nil || attempted < d.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverPending attempts up to BatchSize due deliveries and removes
+// finished deliveries older than Retention. It returns how many
+// deliveries it attempted.
+func (d *Dispatcher) DeliverPending(ctx context.Context) (int, error) {
+	db := d.db.WithContext(ctx)
+	now := time.Now()
+	if err := db.Where("status <> ? AND updated_at < ?", models.DeliveryPending, now.Add(-d.cfg.Retention)).
+		Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return 0, err
+	}
+
+	deliveries, err := d.claim(db, now)
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+
+	endpointIDs := make([]uuid.UUID, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		endpointIDs = append(endpointIDs, delivery.EndpointID)
+	}
+	var endpoints []models.WebhookEndpoint
+	if err := db.Where("id IN ?", endpointIDs).Find(&endpoints).Error; err != nil {
+		return 0, err
+	}
+	byID := make(map[uuid.UUID]*models.WebhookEndpoint, len(endpoints))
+	for i := range endpoints {
+		byID[endpoints[i].ID] = &endpoints[i]
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	slots := make(chan struct{}, d.cfg.Concurrency)
+	for i := range deliveries {
+		endpoint := byID[deliveries[i].EndpointID]
+		if endpoint == nil {
+			continue // deleted since the claim, and its deliveries with it
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(delivery *models.WebhookDelivery) {
+			defer func() { <-slots; wg.Done() }()
+			if err := d.deliver(ctx, endpoint, delivery); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(&deliveries[i])
+	}
+	wg.Wait()
+	return len(deliveries), errors.Join(errs...)
+}
+
+// claim picks the due deliveries of enabled endpoints and leases them
+// long enough to be sent, so other dispatchers skip them meanwhile.
+func (d *Dispatcher) claim(db *gorm.DB, now time.Time) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+			Where("endpoint_id IN (?)", tx.Model(&models.WebhookEndpoint{}).Select("id").Where("enabled = ?", true)).
+			Order("next_attempt_at").
+			Limit(d.cfg.BatchSize).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+		rounds := (len(deliveries) + d.cfg.Concurrency - 1) / d.cfg.Concurrency
+		lease := now.Add(time.Duration(rounds+1) * d.cfg.Timeout)
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", lease).Error
+	})
+	return deliveries, err
+}
+
+// attempt is the outcome of sending a delivery once.
+type attempt struct {
+	at       time.Time
+	code     *int
+	err      error
+	duration time.Duration
+}
+
+func (a attempt) succeeded() bool {
+	return a.err == nil && *a.code >= 200 && *a.code < 300
+}
+
+// errorMessage describes a failed attempt for the delivery log.
+func (a attempt) errorMessage() string {
+	if a.err != nil {
+		return a.err.Error()
+	}
+	if a.succeeded() {
+		return ""
+	}
+	return fmt.Sprintf("endpoint answered %d", *a.code)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) error {
+	a := d.send(ctx, endpoint, delivery)
+	if ctx.Err() != nil && !a.succeeded() {
+		// cut short by shutdown; the lease runs out and it is sent again
+		return nil
+	}
+	return d.record(ctx, endpoint, delivery, a)
+}
+
+func (d *Dispatcher) send(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (a attempt) {
+	a.at = time.Now()
+	defer func() { a.duration = time.Since(a.at) }()
+
+	body := []byte(delivery.Payload)
+	signature, err := Sign(endpoint.Secret, delivery.EventID.String(), a.at, body)
+	if err != nil {
+		a.err = err
+		return a
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		a.err = err
+		return a
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gymondo-webhooks/"+buildinfo.Get().Version)
+	req.Header.Set(HeaderID, delivery.EventID.String())
+	req.Header.Set(HeaderTimestamp, fmt.Sprint(a.at.Unix()))
+	req.Header.Set(HeaderSignature, signature)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		a.err = err
+		return a
+	}
+	defer resp.Body.Close()
+	a.code = &resp.StatusCode
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedBody))
+	return a
+}
+
+// record logs an attempt and moves the delivery and its endpoint on. It
+// does so even if ctx is done, so a delivered event is not sent again
+// needlessly.
+func (d *Dispatcher) record(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery, a attempt) error {
+	ctx = context.WithoutCancel(ctx)
+	log := logging.FromContext(ctx)
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+	message := a.errorMessage()
+
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&models.WebhookAttempt{
+			DeliveryID:   delivery.ID,
+			AttemptedAt:  a.at,
+			ResponseCode: a.code,
+			Error:        message,
+			DurationMS:   a.duration.Milliseconds(),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		changes := map[string]interface{}{
+			"attempts":           attempts,
+			"last_attempt_at":    a.at,
+			"last_response_code": a.code,
+			"last_error":         nil,
+		}
+		if a.succeeded() {
+			changes["status"] = models.DeliverySucceeded
+			changes["delivered_at"] = now
+			if err := tx.Model(delivery).Updates(changes).Error; err != nil {
+				return err
+			}
+			return tx.Model(&models.WebhookEndpoint{}).Where("id = ? AND failing_since IS NOT NULL", endpoint.ID).
+				Update("failing_since", nil).Error
+		}
+
+		changes["last_error"] = message
+		if attempts >= d.cfg.MaxAttempts {
+			changes["status"] = models.DeliveryFailed
+			log.WarnContext(ctx, "Giving up on webhook delivery",
+				"delivery_id", delivery.ID, "endpoint_id", endpoint.ID, "attempts", attempts, "error", message)
+		} else {
+			changes["next_attempt_at"] = now.Add(d.backoff(attempts))
+		}
+		if err := tx.Model(delivery).Updates(changes).Error; err != nil {
+			return err
+		}
+		return d.recordFailure(ctx, tx, endpoint.ID, now)
+	})
+}
+
+// recordFailure starts the endpoint's run of failures, or disables it if
+// the run has lasted DisableAfter.
+func (d *Dispatcher) recordFailure(ctx context.Context, tx *gorm.DB, endpointID uuid.UUID, now time.Time) error {
+	var endpoint models.WebhookEndpoint
+	if err := tx.First(&endpoint, "id = ?", endpointID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if endpoint.FailingSince == nil {
+		return tx.Model(&endpoint).Update("failing_since", now).Error
+	}
+	if !endpoint.Enabled || now.Sub(*endpoint.FailingSince) < d.cfg.DisableAfter {
+		return nil
+	}
+
+	logging.FromContext(ctx).WarnContext(ctx, "Disabling failing webhook endpoint",
+		"endpoint_id", endpoint.ID, "failing_since", endpoint.FailingSince)
+	return tx.Model(&endpoint).Updates(map[string]interface{}{
+		"enabled":         false,
+		"disabled_at":     now,
+		"disabled_reason": fmt.Sprintf("deliveries failed for %s without a success", d.cfg.DisableAfter),
+	}).Error
+}
+
+// backoff doubles the base delay per failed attempt up to the maximum and
+// picks a random delay in its upper half.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.RetryMaxDelay
+	if shift := attempts - 1; shift < 32 && d.cfg.RetryBaseDelay<<shift < delay {
+		delay = d.cfg.RetryBaseDelay << shift
+	}
+	if delay <= 1 {
+		return delay
+	}
+	return delay/2 + rand.N(delay/2)
+}
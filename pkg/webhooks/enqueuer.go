@@ -0,0 +1,61 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Enqueuer is the events.Publisher that hands events to webhooks: it
+// queues a delivery of each event for every enabled endpoint subscribed
+// to its type and scoped to the product subscribed to, for the Dispatcher
+// to send. An event published again is not queued twice.
+type Enqueuer struct {
+	db *gorm.DB
+}
+
+func NewEnqueuer(db *gorm.DB) *Enqueuer {
+	return &Enqueuer{db: db}
+}
+
+func (q *Enqueuer) Publish(ctx context.Context, msg events.Message) error {
+	var event events.Event
+	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+		return fmt.Errorf("decoding event %s: %w", msg.ID, err)
+	}
+
+	db := q.db.WithContext(ctx)
+	var endpoints []models.WebhookEndpoint
+	if err := db.Where("enabled = ?", true).Find(&endpoints).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []models.WebhookDelivery
+	for _, endpoint := range endpoints {
+		if !endpoint.EventTypes.Includes(msg.Type) || !endpoint.ProductIDs.Includes(event.Data.Product.ID) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventID:       msg.ID,
+			EventType:     msg.Type,
+			Payload:       string(msg.Payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+func (q *Enqueuer) Close() error { return nil }
@@ -0,0 +1,110 @@
+// Package webhooks delivers subscription events to the HTTP endpoints
+// partners register.
+//
+// Requests follow the Standard Webhooks specification
+// (https://www.standardwebhooks.com): the body is the event as published
+// to every other consumer, and three headers let the receiver check it.
+// webhook-id is the event ID, the same on every retry, so receivers can
+// drop duplicates. webhook-timestamp is the Unix time of the attempt,
+// which receivers should reject if it is too far from their clock.
+// webhook-signature is "v1," followed by the base64 HMAC-SHA256 of
+// "<id>.<timestamp>.<body>", keyed with the endpoint secret.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers every delivery carries.
+const (
+	HeaderID        = "webhook-id"
+	HeaderTimestamp = "webhook-timestamp"
+	HeaderSignature = "webhook-signature"
+)
+
+// secretPrefix marks endpoint secrets; the rest is the base64 key.
+const secretPrefix = "whsec_"
+
+var (
+	ErrInvalidSecret    = errors.New("invalid webhook secret")
+	ErrMissingHeaders   = errors.New("missing webhook headers")
+	ErrInvalidTimestamp = errors.New("webhook timestamp is invalid or out of tolerance")
+	ErrInvalidSignature = errors.New("no matching webhook signature")
+)
+
+// NewSecret returns a random endpoint secret.
+func NewSecret() (string, error) {
+	key := make([]byte, 24)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Sign returns the webhook-signature header value for a delivery of body
+// with the given id at timestamp.
+func Sign(secret, id string, timestamp time.Time, body []byte) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return "v1," + signature(key, id, timestamp.Unix(), body), nil
+}
+
+// Verify checks the headers of a delivery of body the way a receiver
+// should: the timestamp is within tolerance of now and one of the
+// space-separated signatures matches.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return err
+	}
+	id, ts, signatures := header.Get(HeaderID), header.Get(HeaderTimestamp), header.Get(HeaderSignature)
+	if id == "" || ts == "" || signatures == "" {
+		return ErrMissingHeaders
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	if skew := now.Sub(time.Unix(unix, 0)); skew > tolerance || skew < -tolerance {
+		return ErrInvalidTimestamp
+	}
+
+	expected := signature(key, id, unix, body)
+	for _, s := range strings.Fields(signatures) {
+		version, sig, _ := strings.Cut(s, ",")
+		if version == "v1" && hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func signature(key []byte, id string, unix int64, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s.%d.", id, unix)
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(secret, secretPrefix)
+	if !ok {
+		return nil, ErrInvalidSecret
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return key, nil
+}
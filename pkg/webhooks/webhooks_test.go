@@ -0,0 +1,440 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gymondo_dz/pkg/config"
+	"gymondo_dz/pkg/database"
+	"gymondo_dz/pkg/events"
+	"gymondo_dz/pkg/models"
+	"gymondo_dz/pkg/webhooks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+var dispatcherConfig = config.WebhooksConfig{
+	Enabled:        true,
+	Timeout:        time.Second,
+	MaxAttempts:    3,
+	RetryBaseDelay: time.Minute,
+	RetryMaxDelay:  time.Hour,
+	DisableAfter:   time.Hour,
+	PollInterval:   10 * time.Millisecond,
+	BatchSize:      10,
+	Concurrency:    2,
+	Retention:      24 * time.Hour,
+	// the receivers listen on loopback
+	AllowPrivateNetworks: true,
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	db, err := database.NewSQLiteConnection(config.SQLiteConfig{
+		Path:        filepath.Join(t.TempDir(), "test.db"),
+		BusyTimeout: 5 * time.Second,
+		JournalMode: "wal",
+	}, gormlogger.Discard)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, database.Migrate(context.Background(), db))
+	return db
+}
+
+// receiver is a partner endpoint that verifies every request and answers
+// with the next of its status codes, repeating the last one.
+type receiver struct {
+	*httptest.Server
+	secret string
+
+	mu       sync.Mutex
+	statuses []int
+	received []http.Header
+	invalid  []error
+}
+
+func newReceiver(t *testing.T, secret string, statuses ...int) *receiver {
+	r := &receiver{secret: secret, statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if err := webhooks.Verify(r.secret, req.Header, body, 5*time.Minute, time.Now()); err != nil {
+			r.invalid = append(r.invalid, err)
+		}
+		r.received = append(r.received, req.Header.Clone())
+		status := r.statuses[0]
+		if len(r.statuses) > 1 {
+			r.statuses = r.statuses[1:]
+		}
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) requests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.received)
+}
+
+// partnerProduct is the product the endpoints of most tests are scoped to.
+var partnerProduct = uuid.New()
+
+func createEndpoint(t *testing.T, db *gorm.DB, url string, eventTypes ...string) *models.WebhookEndpoint {
+	return createScopedEndpoint(t, db, url, models.ProductIDs{partnerProduct}, eventTypes...)
+}
+
+func createScopedEndpoint(t *testing.T, db *gorm.DB, url string, productIDs models.ProductIDs, eventTypes ...string) *models.WebhookEndpoint {
+	secret, err := webhooks.NewSecret()
+	require.NoError(t, err)
+	endpoint := &models.WebhookEndpoint{URL: url, Secret: secret, EventTypes: eventTypes, ProductIDs: productIDs, Enabled: true}
+	require.NoError(t, db.Create(endpoint).Error)
+	return endpoint
+}
+
+func publish(t *testing.T, db *gorm.DB, eventType string) events.Message {
+	return publishAbout(t, db, partnerProduct, eventType)
+}
+
+// publishAbout publishes an event about a new subscription to productID.
+func publishAbout(t *testing.T, db *gorm.DB, productID uuid.UUID, eventType string) events.Message {
+	s := &models.Subscription{ID: uuid.New(), ProductID: productID, Version: 1}
+	msg, err := events.NewSubscriptionEvent(eventType, s, time.Now()).Encode()
+	require.NoError(t, err)
+	require.NoError(t, webhooks.NewEnqueuer(db).Publish(context.Background(), msg))
+	return msg
+}
+
+// reload reads endpoint back from db.
+func reload(t *testing.T, db *gorm.DB, endpoint *models.WebhookEndpoint) *models.WebhookEndpoint {
+	var stored models.WebhookEndpoint
+	require.NoError(t, db.First(&stored, "id = ?", endpoint.ID).Error)
+	return &stored
+}
+
+// makeDue lets the retries of every pending delivery run now.
+func makeDue(t *testing.T, db *gorm.DB) {
+	require.NoError(t, db.Model(&models.WebhookDelivery{}).Where("status = ?", models.DeliveryPending).
+		Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	secret, err := webhooks.NewSecret()
+	require.NoError(t, err)
+	at := time.Unix(1735689600, 0)
+	body := []byte(`{"type":"subscription.paused"}`)
+
+	signature, err := webhooks.Sign(secret, "msg_1", at, body)
+	require.NoError(t, err)
+	header := http.Header{}
+	header.Set(webhooks.HeaderID, "msg_1")
+	header.Set(webhooks.HeaderTimestamp, "1735689600")
+	header.Set(webhooks.HeaderSignature, "v1,bm90IGl0 "+signature)
+	assert.NoError(t, webhooks.Verify(secret, header, body, time.Minute, at.Add(30*time.Second)), "any listed signature may match")
+
+	assert.ErrorIs(t, webhooks.Verify(secret, header, []byte(`{}`), time.Minute, at), webhooks.ErrInvalidSignature)
+	assert.ErrorIs(t, webhooks.Verify(secret, header, body, time.Minute, at.Add(2*time.Minute)), webhooks.ErrInvalidTimestamp)
+	other, err := webhooks.NewSecret()
+	require.NoError(t, err)
+	assert.ErrorIs(t, webhooks.Verify(other, header, body, time.Minute, at), webhooks.ErrInvalidSignature)
+	assert.ErrorIs(t, webhooks.Verify(secret, http.Header{}, body, time.Minute, at), webhooks.ErrMissingHeaders)
+	_, err = webhooks.Sign("secret", "msg_1", at, body)
+	assert.ErrorIs(t, err, webhooks.ErrInvalidSecret)
+}
+
+// TestSignMatchesStandardWebhooks checks against the example of the
+// Standard Webhooks reference implementation.
+func TestSignMatchesStandardWebhooks(t *testing.T) {
+	signature, err := webhooks.Sign("whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw", "msg_p5jXN8AQM9LWM0D4loKWxJek",
+		time.Unix(1614265330, 0), []byte(`{"test": 2432232314}`))
+	require.NoError(t, err)
+	assert.Equal(t, "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE=", signature)
+}
+
+func TestEnqueuer(t *testing.T) {
+	db := openDB(t)
+	all := createEndpoint(t, db, "http://partner-a.test/hooks")
+	paused := createEndpoint(t, db, "http://partner-b.test/hooks", events.TypeSubscriptionPaused)
+	disabled := createEndpoint(t, db, "http://partner-c.test/hooks")
+	require.NoError(t, db.Model(disabled).Update("enabled", false).Error)
+
+	msg := publish(t, db, events.TypeSubscriptionPaused)
+	publish(t, db, events.TypeSubscriptionCreated)
+	// the relay publishes an event again if it could not record it
+	require.NoError(t, webhooks.NewEnqueuer(db).Publish(context.Background(), msg))
+
+	count := func(endpoint *models.WebhookEndpoint) int64 {
+		var n int64
+		require.NoError(t, db.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpoint.ID).Count(&n).Error)
+		return n
+	}
+	assert.EqualValues(t, 2, count(all))
+	assert.EqualValues(t, 1, count(paused))
+	assert.Zero(t, count(disabled))
+
+	var delivery models.WebhookDelivery
+	require.NoError(t, db.First(&delivery, "endpoint_id = ?", paused.ID).Error)
+	assert.Equal(t, msg.ID, delivery.EventID)
+	assert.Equal(t, string(msg.Payload), delivery.Payload)
+	assert.Equal(t, models.DeliveryPending, delivery.Status)
+}
+
+func TestEnqueuerScopesToPartnerProducts(t *testing.T) {
+	db := openDB(t)
+	productA, productB, shared := uuid.New(), uuid.New(), uuid.New()
+	partnerA := createScopedEndpoint(t, db, "http://partner-a.test/hooks", models.ProductIDs{productA, shared})
+	partnerB := createScopedEndpoint(t, db, "http://partner-b.test/hooks", models.ProductIDs{productB, shared})
+	unscoped := createScopedEndpoint(t, db, "http://partner-c.test/hooks", nil)
+
+	for _, eventType := range events.Types {
+		publishAbout(t, db, productB, eventType)
+	}
+	publishAbout(t, db, shared, events.TypeSubscriptionCreated)
+	publishAbout(t, db, uuid.New(), events.TypeSubscriptionCreated)
+
+	var deliveries []models.WebhookDelivery
+	require.NoError(t, db.Find(&deliveries, "endpoint_id = ?", partnerA.ID).Error)
+	require.Len(t, deliveries, 1, "partner A only hears about the shared product")
+	var event events.Event
+	require.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &event))
+	assert.Equal(t, shared, event.Data.Product.ID)
+
+	var n int64
+	require.NoError(t, db.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", partnerB.ID).Count(&n).Error)
+	assert.EqualValues(t, len(events.Types)+1, n)
+	require.NoError(t, db.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", unscoped.ID).Count(&n).Error)
+	assert.Zero(t, n, "an endpoint without products receives nothing")
+}
+
+func TestDispatcherDeliversSignedEvents(t *testing.T) {
+	db := openDB(t)
+	partner := newReceiver(t, "", http.StatusNoContent)
+	endpoint := createEndpoint(t, db, partner.URL)
+	partner.secret = endpoint.Secret
+	msg := publish(t, db, events.TypeSubscriptionCreated)
+
+	n, err := webhooks.NewDispatcher(db, dispatcherConfig).DeliverPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.Equal(t, 1, partner.requests())
+	assert.Empty(t, partner.invalid)
+	assert.Equal(t, msg.ID.String(), partner.received[0].Get(webhooks.HeaderID))
+	assert.Equal(t, "application/json", partner.received[0].Get("Content-Type"))
+
+	var delivery models.WebhookDelivery
+	require.NoError(t, db.Preload("AttemptLog").First(&delivery).Error)
+	assert.Equal(t, models.DeliverySucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, *delivery.LastResponseCode)
+	assert.NotNil(t, delivery.DeliveredAt)
+	require.Len(t, delivery.AttemptLog, 1)
+	assert.Equal(t, http.StatusNoContent, *delivery.AttemptLog[0].ResponseCode)
+	assert.Empty(t, delivery.AttemptLog[0].Error)
+
+	n, err = webhooks.NewDispatcher(db, dispatcherConfig).DeliverPending(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n, "delivered events are not sent again")
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	db := openDB(t)
+	partner := newReceiver(t, "", http.StatusInternalServerError, http.StatusOK)
+	endpoint := createEndpoint(t, db, partner.URL)
+	partner.secret = endpoint.Secret
+	publish(t, db, events.TypeSubscriptionCancelled)
+	dispatcher := webhooks.NewDispatcher(db, dispatcherConfig)
+
+	_, err := dispatcher.DeliverPending(context.Background())
+	require.NoError(t, err)
+	var delivery models.WebhookDelivery
+	require.NoError(t, db.First(&delivery).Error)
+	assert.Equal(t, models.DeliveryPending, delivery.Status)
+	assert.Equal(t, http.StatusInternalServerError, *delivery.LastResponseCode)
+	assert.Equal(t, "endpoint answered 500", *delivery.LastError)
+	assert.WithinRange(t, delivery.NextAttemptAt, time.Now().Add(29*time.Second), time.Now().Add(time.Minute))
+	assert.NotNil(t, reload(t, db, endpoint).FailingSince)
+
+	n, err := dispatcher.DeliverPending(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n, "the retry is not due yet")
+
+	makeDue(t, db)
+	_, err = dispatcher.DeliverPending(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, db.Preload("AttemptLog").First(&delivery).Error)
+	assert.Equal(t, models.DeliverySucceeded, delivery.Status)
+	assert.Nil(t, delivery.LastError)
+	require.Len(t, delivery.AttemptLog, 2)
+	assert.Equal(t, http.StatusInternalServerError, *delivery.AttemptLog[0].ResponseCode)
+	assert.Equal(t, 2, partner.requests())
+	assert.Empty(t, partner.invalid)
+
+	assert.Nil(t, reload(t, db, endpoint).FailingSince, "a success ends the run of failures")
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	db := openDB(t)
+	partner := newReceiver(t, "", http.StatusMovedPermanently)
+	createEndpoint(t, db, partner.URL)
+	publish(t, db, events.TypeSubscriptionExpired)
+	dispatcher := webhooks.NewDispatcher(db, dispatcherConfig)
+
+	for range dispatcherConfig.MaxAttempts {
+		makeDue(t, db)
+		_, err := dispatcher.DeliverPending(context.Background())
+		require.NoError(t, err)
+	}
+
+	var delivery models.WebhookDelivery
+	require.NoError(t, db.First(&delivery).Error)
+	assert.Equal(t, models.DeliveryFailed, delivery.Status)
+	assert.Equal(t, dispatcherConfig.MaxAttempts, delivery.Attempts)
+	assert.Equal(t, http.StatusMovedPermanently, *delivery.LastResponseCode, "redirects are not followed")
+
+	makeDue(t, db)
+	n, err := dispatcher.DeliverPending(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, dispatcherConfig.MaxAttempts, partner.requests())
+}
+
+func TestDispatcherDisablesFailingEndpoint(t *testing.T) {
+	db := openDB(t)
+	partner := newReceiver(t, "", http.StatusServiceUnavailable)
+	endpoint := createEndpoint(t, db, partner.URL)
+	publish(t, db, events.TypeSubscriptionPaused)
+	dispatcher := webhooks.NewDispatcher(db, dispatcherConfig)
+
+	_, err := dispatcher.DeliverPending(context.Background())
+	require.NoError(t, err)
+
+	// failing for longer than DisableAfter
+	require.NoError(t, db.Model(endpoint).Update("failing_since", time.Now().Add(-2*time.Hour)).Error)
+	makeDue(t, db)
+	_, err = dispatcher.DeliverPending(context.Background())
+	require.NoError(t, err)
+
+	endpoint = reload(t, db, endpoint)
+	assert.False(t, endpoint.Enabled)
+	assert.NotNil(t, endpoint.DisabledAt)
+	assert.Equal(t, "deliveries failed for 1h0m0s without a success", endpoint.DisabledReason)
+
+	// deliveries of a disabled endpoint wait for it to be enabled again
+	makeDue(t, db)
+	n, err := dispatcher.DeliverPending(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, 2, partner.requests())
+	var delivery models.WebhookDelivery
+	require.NoError(t, db.First(&delivery).Error)
+	assert.Equal(t, models.DeliveryPending, delivery.Status)
+}
+
+func TestDispatcherLogsUnreachableEndpoint(t *testing.T) {
+	db := openDB(t)
+	partner := newReceiver(t, "", http.StatusOK)
+	partner.Close()
+	createEndpoint(t, db, partner.URL)
+	publish(t, db, events.TypeSubscriptionCreated)
+
+	_, err := webhooks.NewDispatcher(db, dispatcherConfig).DeliverPending(context.Background())
+	require.NoError(t, err)
+
+	var attempt models.WebhookAttempt
+	require.NoError(t, db.First(&attempt).Error)
+	assert.Nil(t, attempt.ResponseCode)
+	assert.Contains(t, attempt.Error, "connection refused")
+}
+
+func TestDispatcherRefusesNonPublicAddresses(t *testing.T) {
+	partner := newReceiver(t, "", http.StatusOK)
+	port := partner.Listener.Addr().(*net.TCPAddr).Port
+	cfg := dispatcherConfig
+	cfg.AllowPrivateNetworks = false
+
+	urls := []string{
+		partner.URL,
+		fmt.Sprintf("http://localhost:%d/", port),
+		fmt.Sprintf("http://[::ffff:127.0.0.1]:%d/", port),
+		fmt.Sprintf("http://0.0.0.0:%d/", port),
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/",
+		"http://[fd00:ec2::254]/",
+	}
+	for _, url := range urls {
+		t.Run(url, func(t *testing.T) {
+			db := openDB(t)
+			createEndpoint(t, db, url)
+			publish(t, db, events.TypeSubscriptionCreated)
+
+			_, err := webhooks.NewDispatcher(db, cfg).DeliverPending(context.Background())
+			require.NoError(t, err)
+
+			var attempt models.WebhookAttempt
+			require.NoError(t, db.First(&attempt).Error)
+			assert.Nil(t, attempt.ResponseCode)
+			assert.Contains(t, attempt.Error, "is not publicly routable")
+		})
+	}
+	assert.Zero(t, partner.requests())
+}
+
+func TestDispatcherDeletesExpiredDeliveries(t *testing.T) {
+	db := openDB(t)
+	partner := newReceiver(t, "", http.StatusOK)
+	createEndpoint(t, db, partner.URL)
+	publish(t, db, events.TypeSubscriptionCreated)
+	publish(t, db, events.TypeSubscriptionCreated)
+	dispatcher := webhooks.NewDispatcher(db, dispatcherConfig)
+	_, err := dispatcher.DeliverPending(context.Background())
+	require.NoError(t, err)
+
+	var old models.WebhookDelivery
+	require.NoError(t, db.First(&old).Error)
+	require.NoError(t, db.Model(&old).UpdateColumn("updated_at", time.Now().Add(-25*time.Hour)).Error)
+	_, err = dispatcher.DeliverPending(context.Background())
+	require.NoError(t, err)
+
+	var remaining, attempts int64
+	require.NoError(t, db.Model(&models.WebhookDelivery{}).Count(&remaining).Error)
+	require.NoError(t, db.Model(&models.WebhookAttempt{}).Count(&attempts).Error)
+	assert.EqualValues(t, 1, remaining)
+	assert.EqualValues(t, 1, attempts, "the attempt log goes with its delivery")
+}
+
+func TestDispatcherRun(t *testing.T) {
+	db := openDB(t)
+	partner := newReceiver(t, "", http.StatusOK)
+	createEndpoint(t, db, partner.URL)
+	cfg := dispatcherConfig
+	cfg.BatchSize = 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		webhooks.NewDispatcher(db, cfg).Run(ctx)
+		close(done)
+	}()
+
+	for range 5 {
+		publish(t, db, events.TypeSubscriptionCreated)
+	}
+	assert.Eventually(t, func() bool { return partner.requests() == 5 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+}